@@ -0,0 +1,1181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/metadata"
+	"github.com/linus4/csgoverview/network"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/export"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/query"
+	"github.com/linus4/csgoverview/pkg/stats"
+	"github.com/linus4/csgoverview/server"
+	"github.com/linus4/csgoverview/steam"
+	"github.com/linus4/csgoverview/viewer"
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	winWidth             int32 = 1624
+	winHeight            int32 = 1024
+	nameMapFontSize      int   = 14
+	mapOverviewWidth     int32 = 1024
+	mapOverviewHeight    int32 = 1024
+	mapXOffset           int32 = 300
+	mapYOffset           int32 = 0
+	infobarElementHeight int32 = 100
+	avatarSize           int32 = 16
+)
+
+var (
+	paused         bool
+	curFrame       int
+	afterplantSite string
+	awpOverlay     bool
+	avatars        = make(map[uint64]*sdl.Texture)
+	serverInfo     bool
+	// povPanel shows the stats of the selected player in a panel on the map.
+	povPanel       bool
+	playbackSpeed  float64 = 1
+	helpOverlay    bool
+	roundStrip     = true
+	infernoExtents bool
+	// altitudeShading draws players and grenades larger the higher they are.
+	altitudeShading bool
+	// soundOverlay draws circles around the sounds of the players in which
+	// enemies could hear them.
+	soundOverlay bool
+	// entryPaths draws the paths the terrorists took to the sites as arrows.
+	entryPaths bool
+	// smokeKills draws all kills through smokes of the match.
+	smokeKills   bool
+	hiddenLayers = make(common.LayerFilter)
+	// ghost is the marker the reviewer placed with the right mouse button,
+	// e.g. to show where a player should have been. It is nil if there is
+	// none.
+	ghost *common.Point
+	// measuring is true while clicks on the map set the positions of
+	// measurement instead of selecting players.
+	measuring   bool
+	measurement []common.Point
+	// spawnTiming is true while clicks on the map set spawnTimingPosition,
+	// which is labeled with the times the teams need to run there.
+	spawnTiming         bool
+	spawnTimingPosition *common.Point
+	// lineOfSight shades the area the selected players can see.
+	lineOfSight bool
+	// crossfire shades what the selected counter-terrorists see together
+	// and marks the entries to the sites none of them watches.
+	crossfire bool
+	// zones outlines and labels the bombsites and hostage zones.
+	zones bool
+	// mapViewer draws the overview of the current demo.
+	mapViewer *viewer.Viewer
+	// replaySeconds is the number of seconds the instant replay jumps back.
+	replaySeconds = defaultReplaySeconds
+	// replayEndFrame is the frame at which the instant replay was started.
+	// Until it is reached again, the playback is slowed down. It is -1 if no
+	// replay is running.
+	replayEndFrame = -1
+)
+
+// Config contains information the application requires in order to run
+type Config struct {
+	// Path to font file (.ttf), the built-in font is used if it is empty
+	FontPath string
+
+	// Path to overview directory
+	OverviewDir string
+
+	// Fallback GOTV Framerate
+	FrameRate float64
+
+	// Fallback Gameserver Tickrate
+	TickRate float64
+
+	// Print the analysis of the demo instead of opening the viewer
+	Stats bool
+
+	// Directory to export CSV files and a JSON report to instead of opening
+	// the viewer
+	ExportDir string
+
+	// Take knife rounds into account in the analysis
+	IncludeKnifeRounds bool
+
+	// File to write a HLAE campath of CampathPlayer in CampathRound to
+	CampathFile string
+
+	// SteamID of the player whose view is exported as campath
+	CampathPlayer uint64
+
+	// Number of the round that is exported as campath
+	CampathRound int
+
+	// Steam Web API key that is used to fetch the profiles of the players
+	SteamAPIKey string
+
+	// Liquipedia API key that is used to look up the event of the match
+	LiquipediaAPIKey string
+
+	// Interpolate the positions of the players across short gaps of the
+	// demo, e.g. when the GOTV server lagged
+	SmoothGaps bool
+
+	// Only parse the events of the demo for the analysis and exports, which
+	// is faster but leaves out the parts that need the positions of players
+	EventsOnly bool
+
+	// Directory whose demos are listed if no demo is given, defaults to the
+	// directory of the last opened demo
+	DemoDir string
+
+	// Language of the user interface, e.g. pt_BR, defaults to the language
+	// of the environment
+	Language string
+
+	// Directory to write CPU and heap profiles of the application to
+	ProfileDir string
+
+	// Address to serve the states of the demo over WebSockets on while it is
+	// being recorded, instead of opening the viewer
+	ServeAddr string
+
+	// Address to serve the states of a GOTV broadcast over WebSockets on,
+	// the URL of the broadcast is given instead of a demo
+	ServeBroadcastAddr string
+
+	// URL that the events of a match served with ServeAddr or
+	// ServeBroadcastAddr are posted to, e.g. a Discord webhook
+	WebhookURL string
+
+	// Comma separated events that are posted to WebhookURL: round_end,
+	// bomb_plant and match_end, defaults to all
+	WebhookEvents string
+
+	// Comma separated origins of pages besides the server itself that may
+	// connect to the WebSocket of ServeAddr or ServeBroadcastAddr, e.g. an
+	// overlay on another host
+	AllowedOrigins string
+
+	// Directory whose recorded demos are parsed on request and whose reports
+	// are served with ServeAddr under /demos/
+	ServeDemoDir string
+
+	// Memory in MB that the parsed demos of ServeDemoDir may take up before
+	// the least recently used ones are evicted
+	CacheSize int
+
+	// File to record the playback actions of the review session to
+	RecordSession string
+
+	// Session file whose playback actions are replayed
+	PlaySession string
+
+	// Proxy for all network features, e.g. socks5://localhost:1080, defaults
+	// to the proxy environment variables
+	Proxy string
+
+	// JSON file with the overview positions of maps the parser does not
+	// know, defaults to maps.json in the overview directory
+	MapConfig string
+
+	// Query whose events are exported to search.csv, e.g.
+	// "kill weapon:awp player:s1mple area:A"
+	Search string
+
+	// Query whose grenade throws are exported to the practice config
+	// lineups.cfg and to lineups.json, e.g. "weapon:smokegrenade team:T"
+	Lineups string
+
+	// Directory to save a PNG of the map at every kill to instead of opening
+	// the viewer
+	KillShotsDir string
+
+	// Number of seconds before each kill at which the map is saved to
+	// KillShotsDir
+	KillShotsBefore float64
+
+	// Frames drawn per second, defaults to the refresh rate of the display
+	// the window is on
+	FPS int
+
+	// Draw with the CPU even if the GPU could be used, e.g. for graphics
+	// drivers that draw the overview incorrectly
+	SoftwareRenderer bool
+
+	// Store the positions of the players rounded to whole units to reduce
+	// the memory of long demos and of the demos cached with ServeDemoDir
+	Quantize bool
+
+	// Memory in MB that the next demo of the playlist or the demo selected
+	// in the picker, parsed in the background, may take up before it is
+	// dropped, 0 disables parsing ahead
+	PrefetchSize int
+
+	// Register csgoverview as the program that opens demos for the current
+	// user instead of opening the viewer
+	Associate bool
+}
+
+// DefaultConfig contains standard parameters for the application.
+var DefaultConfig = Config{
+	FrameRate:    -1,
+	TickRate:     -1,
+	CampathRound: 1,
+	CacheSize:    2048,
+	PrefetchSize: 1024,
+}
+
+func run(c *Config) error {
+	// the parser discards its warnings unless it is given a logger
+	match.SetLogger(match.NewTextLogger(os.Stderr, match.LevelInfo))
+
+	if c.Associate {
+		err := associateDemos()
+		if err != nil {
+			return fmt.Errorf("trying to associate demos: %v", err)
+		}
+		fmt.Println("csgoverview now opens .dem files")
+		return nil
+	}
+
+	var session *sessionPlayer
+	if c.PlaySession != "" {
+		var err error
+		session, err = loadSession(c.PlaySession)
+		if err != nil {
+			return fmt.Errorf("trying to load session: %v", err)
+		}
+	}
+
+	var demoFileName string
+	if len(flag.Args()) < 1 && session != nil {
+		demoFileName = session.file.Demo
+	} else if len(flag.Args()) < 1 {
+		var err error
+		demoFileName, err = openFileDialog()
+		if err != nil {
+			log.Println("trying to open file dialog:", err)
+		}
+	} else {
+		demos, err := expandPlaylist(flag.Args())
+		if err != nil {
+			return fmt.Errorf("trying to read playlist: %v", err)
+		}
+		if len(demos) == 0 {
+			return errors.New("trying to read playlist: no demos listed")
+		}
+		demoFileName = demos[0]
+		playlist = demos
+	}
+
+	headless := c.ServeAddr != "" || c.ServeBroadcastAddr != "" || c.Stats || c.ExportDir != "" || c.CampathFile != "" || c.KillShotsDir != ""
+	if demoFileName == "" && headless {
+		fmt.Println("Usage: ./csgoverview [path to demo]")
+		return errors.New("no demo file given")
+	}
+
+	if c.ProfileDir != "" {
+		stopProfiling, err := startProfiling(c.ProfileDir)
+		if err != nil {
+			return fmt.Errorf("trying to start profiling: %v", err)
+		}
+		defer stopProfiling()
+	}
+
+	if c.SteamAPIKey == "" {
+		c.SteamAPIKey = os.Getenv("STEAM_API_KEY")
+	}
+	if c.LiquipediaAPIKey == "" {
+		c.LiquipediaAPIKey = os.Getenv("LIQUIPEDIA_API_KEY")
+	}
+	err := network.SetProxy(c.Proxy)
+	if err != nil {
+		return err
+	}
+	err = loadMapConfigs(c)
+	if err != nil {
+		return fmt.Errorf("trying to load map config: %v", err)
+	}
+
+	if c.ServeAddr != "" || c.ServeBroadcastAddr != "" {
+		var webhook *server.Webhook
+		if c.WebhookURL != "" {
+			webhook, err = server.NewWebhook(c.WebhookURL, c.WebhookEvents)
+			if err != nil {
+				return err
+			}
+		}
+		var demos *server.DemoLibrary
+		if c.ServeDemoDir != "" {
+			demos = server.NewDemoLibrary(c.ServeDemoDir, int64(c.CacheSize)<<20, c.FrameRate, c.TickRate)
+			demos.Quantize = c.Quantize
+			demos.Options = stats.Options{IncludeKnifeRounds: c.IncludeKnifeRounds}
+		}
+		var allowedOrigins []string
+		if c.AllowedOrigins != "" {
+			allowedOrigins = strings.Split(c.AllowedOrigins, ",")
+		}
+		if c.ServeBroadcastAddr != "" {
+			return server.ServeBroadcast(c.ServeBroadcastAddr, demoFileName, c.FrameRate, c.TickRate, webhook, demos, allowedOrigins)
+		}
+		return server.ServeRecording(c.ServeAddr, demoFileName, c.FrameRate, c.TickRate, webhook, demos, allowedOrigins)
+	}
+
+	if c.KillShotsDir != "" {
+		if c.EventsOnly {
+			return errors.New("the kill shots need the positions of the players and cannot be used with -events-only")
+		}
+		err = exportKillShots(c, demoFileName)
+		if err != nil {
+			return fmt.Errorf("trying to export kill shots: %v", err)
+		}
+		if !c.Stats && c.ExportDir == "" && c.CampathFile == "" {
+			return nil
+		}
+	}
+
+	if c.Stats || c.ExportDir != "" || c.CampathFile != "" {
+		if c.EventsOnly && c.CampathFile != "" {
+			return errors.New("the campath export needs the positions of the players and cannot be used with -events-only")
+		}
+		parse := match.NewMatch
+		if c.EventsOnly {
+			parse = match.ParseEvents
+		}
+		match, err := parse(demoFileName, c.FrameRate, c.TickRate)
+		if err != nil {
+			return err
+		}
+		if c.Quantize {
+			match.Quantize()
+		}
+		enrichProfiles(match, c.SteamAPIKey)
+		attachEvent(match, demoFileName, c.LiquipediaAPIKey)
+		match.Freeze()
+		opts := stats.Options{IncludeKnifeRounds: c.IncludeKnifeRounds}
+		if c.ExportDir != "" {
+			err = export.CSV(c.ExportDir, match, opts)
+			if err != nil {
+				return err
+			}
+			err = loadReview(demoFileName)
+			if err != nil {
+				log.Println("trying to load review file:", err)
+			}
+			err = export.JSON(c.ExportDir, match, reportNotes(match), opts)
+			if err != nil {
+				return fmt.Errorf("trying to export report: %v", err)
+			}
+			screenshots, err := screenshotFiles(demoFileName)
+			if err != nil {
+				return fmt.Errorf("trying to find screenshots: %v", err)
+			}
+			err = export.HTML(c.ExportDir, match, reportNotes(match), findOverview(c.OverviewDir, match.MapName), screenshots, opts)
+			if err != nil {
+				return fmt.Errorf("trying to export HTML report: %v", err)
+			}
+			err = export.EntryPaths(c.ExportDir, match, findOverview(c.OverviewDir, match.MapName), opts)
+			if err != nil {
+				return fmt.Errorf("trying to export entry paths: %v", err)
+			}
+			err = export.HealthGraphs(c.ExportDir, match, opts)
+			if err != nil {
+				return fmt.Errorf("trying to export health graphs: %v", err)
+			}
+			if c.Search != "" {
+				q, err := query.Parse(c.Search)
+				if err != nil {
+					return fmt.Errorf("trying to parse search query: %v", err)
+				}
+				err = export.Search(c.ExportDir, match, q)
+				if err != nil {
+					return fmt.Errorf("trying to export search results: %v", err)
+				}
+			}
+			if c.Lineups != "" {
+				q, err := query.Parse(c.Lineups)
+				if err != nil {
+					return fmt.Errorf("trying to parse lineup query: %v", err)
+				}
+				err = export.Lineups(c.ExportDir, match, q)
+				if err != nil {
+					return fmt.Errorf("trying to export lineups: %v", err)
+				}
+			}
+		}
+		if c.CampathFile != "" {
+			err = export.CampathFile(c.CampathFile, match, c.CampathPlayer, c.CampathRound)
+			if err != nil {
+				return fmt.Errorf("trying to export campath: %v", err)
+			}
+		}
+		if c.Stats {
+			return stats.WriteReport(os.Stdout, match, opts)
+		}
+		return nil
+	}
+
+	language := c.Language
+	if language == "" {
+		language = locale.Detect()
+	}
+	err = locale.Load(language, filepath.Join(c.OverviewDir, "locales"), "locales")
+	if err != nil {
+		log.Println("trying to load translation:", err)
+	}
+
+	userSettings, err := loadSettings()
+	if err != nil {
+		log.Println("trying to load settings:", err)
+	}
+	applySettings(userSettings)
+	lastDirectory := userSettings.LastDirectory
+
+	err = sdl.Init(sdl.INIT_VIDEO | sdl.INIT_EVENTS)
+	if err != nil {
+		errorString := locale.Sprintf("error.sdl_init", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, nil)
+		return err
+	}
+	defer sdl.Quit()
+
+	err = ttf.Init()
+	if err != nil {
+		errorString := locale.Sprintf("error.ttf_init", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, nil)
+		return err
+	}
+	defer ttf.Quit()
+
+	font, err := openFont(c.FontPath, nameMapFontSize)
+	if err != nil && c.FontPath != "" {
+		errorString := locale.Sprintf("error.font_file", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, nil)
+		font, err = openFont("", nameMapFontSize)
+	}
+	if err != nil {
+		errorString := locale.Sprintf("error.font_builtin", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, nil)
+		return err
+	}
+	defer font.Close()
+	font.SetStyle(ttf.STYLE_BOLD)
+
+	window, err := sdl.CreateWindow("csgoverview", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		userSettings.WindowWidth, userSettings.WindowHeight, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
+	if err != nil {
+		errorString := locale.Sprintf("error.create_window", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, nil)
+		return err
+	}
+	defer window.Destroy()
+	defer func() {
+		err := currentSettings(window, lastDirectory).save()
+		if err != nil {
+			log.Println("trying to save settings:", err)
+		}
+	}()
+
+	// the overview, names and icons are textures that are drawn faster by
+	// the GPU, the software renderer is only used if there is none
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil || c.SoftwareRenderer {
+		if renderer != nil {
+			renderer.Destroy()
+		}
+		renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_SOFTWARE)
+	}
+	if err != nil {
+		errorString := locale.Sprintf("error.create_renderer", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+		return err
+	}
+	defer renderer.Destroy()
+	defer viewer.ClearTextCache()
+	renderer.SetLogicalSize(mapOverviewWidth+2*mapXOffset, mapOverviewHeight+mapYOffset)
+
+	sdl.EventState(sdl.DROPFILE, sdl.ENABLE)
+	defer cancelPrefetch()
+
+	if demoFileName == "" {
+		demoDir := c.DemoDir
+		if demoDir == "" {
+			demoDir = lastDirectory
+		}
+		if demoDir == "" {
+			demoDir, err = os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("trying to get user home directory: %v", err)
+			}
+		}
+		demoFileName, err = pickDemo(renderer, window, font, demoDir, c)
+		if err != nil {
+			errorString := locale.Sprintf("error.select_demo", err)
+			sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+			return err
+		}
+		if demoFileName == "" {
+			return nil
+		}
+	}
+
+	if len(playlist) == 0 {
+		playlist = []string{demoFileName}
+	}
+
+	match, firstViewer, err := loadDemo(demoFileName, c, renderer, window, font)
+	if err != nil {
+		return err
+	}
+	mapViewer = firstViewer
+	lastDirectory = filepath.Dir(demoFileName)
+	defer func() {
+		mapViewer.Destroy()
+		destroyAvatars()
+	}()
+	prefetchNext(c)
+
+	// openDemo replaces the current demo, reusing the window and the renderer.
+	openDemo := func(demoFileName string) error {
+		newMatch, newViewer, err := loadDemo(demoFileName, c, renderer, window, font)
+		if err != nil {
+			return err
+		}
+		mapViewer.Destroy()
+		match, mapViewer = newMatch, newViewer
+		lastDirectory = filepath.Dir(demoFileName)
+		resetPlayback()
+		return nil
+	}
+
+	var recorder *sessionRecorder
+	if c.RecordSession != "" {
+		recorder = newSessionRecorder(demoFileName)
+		defer func() {
+			err := recorder.save(c.RecordSession)
+			if err != nil {
+				log.Println("trying to save session:", err)
+			}
+		}()
+	}
+	if session != nil {
+		// the session starts when the demo is shown
+		session.start = time.Now()
+	}
+
+	updateDrawInterval(window, c.FPS)
+	lastFrameStart := time.Now()
+
+	// MAIN GAME LOOP
+	for {
+		frameStart := time.Now()
+		elapsed := frameStart.Sub(lastFrameStart)
+		lastFrameStart = frameStart
+
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch eventT := event.(type) {
+			case *sdl.QuitEvent:
+				return err
+
+			case *sdl.KeyboardEvent:
+				if editingNote {
+					handleNoteKeyboardEvents(eventT)
+				} else if editingSearch {
+					handleSearchKeyboardEvents(eventT, match)
+				} else if calibrating {
+					handleCalibrationKeyboardEvents(eventT, match)
+				} else {
+					handleKeyboardEvents(eventT, window, match)
+				}
+
+			case *sdl.TextInputEvent:
+				if editingNote {
+					noteText += eventT.GetText()
+				} else if editingSearch {
+					searchText += eventT.GetText()
+				}
+
+			case *sdl.DropEvent:
+				if eventT.Type != sdl.DROPFILE {
+					break
+				}
+				err := openDemo(eventT.File)
+				if err != nil {
+					log.Println("trying to open dropped demo:", err)
+					break
+				}
+				// the dropped demo is added to the end of the playlist
+				playlist = append(playlist, eventT.File)
+				playlistIndex = len(playlist) - 1
+
+			case *sdl.WindowEvent:
+				// the window may have been moved to a display with another
+				// refresh rate
+				if eventT.Event == sdl.WINDOWEVENT_MOVED {
+					updateDrawInterval(window, c.FPS)
+				}
+
+			case *sdl.MouseMotionEvent:
+				mapViewer.SetMouse(eventT.X, eventT.Y)
+				if eventT.State&sdl.ButtonRMask() != 0 {
+					placeGhost(eventT.X, eventT.Y)
+				}
+
+			case *sdl.MouseButtonEvent:
+				if eventT.Type == sdl.MOUSEBUTTONDOWN && eventT.Button == sdl.BUTTON_RIGHT {
+					placeGhost(eventT.X, eventT.Y)
+					break
+				}
+				if eventT.Type != sdl.MOUSEBUTTONDOWN || eventT.Button != sdl.BUTTON_LEFT {
+					break
+				}
+				if steamID, ok := economyPlayerAt(match, eventT.X, eventT.Y); economyPanel && ok {
+					cycleEconomyBuy(steamID)
+					break
+				}
+				if i := roundAtStripPosition(match, eventT.X, eventT.Y); roundStrip && i >= 0 {
+					curFrame = match.Rounds[i].StartFrame
+					break
+				}
+				if frame := frameAtProgressPosition(match, eventT.X, eventT.Y); roundStrip && frame >= 0 {
+					seek(match, frame)
+					break
+				}
+				if measuring {
+					addMeasurementPoint(eventT.X, eventT.Y)
+					break
+				}
+				if spawnTiming {
+					if position, ok := mapViewer.WorldAt(eventT.X, eventT.Y); ok {
+						spawnTimingPosition = &position
+					}
+					break
+				}
+				mapViewer.SelectAt(eventT.X, eventT.Y, sdl.GetModState()&sdl.KMOD_SHIFT != 0)
+
+			case *sdl.MouseWheelEvent:
+				// back
+				if eventT.Type == sdl.MOUSEWHEEL {
+					if eventT.Y > 0 {
+						if curFrame < match.FrameRateRounded*1 {
+							curFrame = 0
+						} else {
+							curFrame -= match.FrameRateRounded * 1
+						}
+					}
+					if eventT.Y < 0 {
+						// forward
+						if curFrame+match.FrameRateRounded*1 > match.LastFrame() {
+							curFrame = match.LastFrame()
+						} else {
+							curFrame += match.FrameRateRounded * 1
+						}
+					}
+				}
+			}
+
+		}
+
+		if playlistStep != 0 {
+			i := playlistIndex + playlistStep
+			playlistStep = 0
+			if i >= 0 && i < len(playlist) {
+				err := openDemo(playlist[i])
+				if err != nil {
+					log.Println("trying to open demo of playlist:", err)
+				} else {
+					playlistIndex = i
+					prefetchNext(c)
+				}
+			}
+		}
+
+		if session != nil {
+			session.apply(match)
+		}
+		if recorder != nil {
+			recorder.observe()
+		}
+
+		if paused {
+			playbackElapsed = 0
+			sdl.Delay(32)
+			updateGraphics(renderer, match, font)
+			updateWindowTitle(window, match)
+			continue
+		}
+
+		updateGraphics(renderer, match, font)
+		updateWindowTitle(window, match)
+
+		speed := playbackSpeed
+		keyboardState := sdl.GetKeyboardState()
+		if isBindingHeld(keyboardState, "speed_up") && !editingNote && !editingSearch && !calibrating {
+			speed *= 5
+		}
+		if isBindingHeld(keyboardState, "slow_down") && !editingNote && !editingSearch && !calibrating {
+			speed *= 0.5
+		}
+		if curFrame < replayEndFrame {
+			speed *= replaySpeed
+		} else {
+			replayEndFrame = -1
+		}
+		advancePlayback(match, elapsed, speed)
+		applyLoop(match)
+		if recorder != nil {
+			recorder.advance()
+		}
+		if delay := drawInterval - time.Since(frameStart); delay > 0 {
+			sdl.Delay(uint32(delay / time.Millisecond))
+		}
+	}
+
+}
+
+// loadDemo parses the demo, unless it was parsed in the background, and
+// creates the viewer with the overview image of its map. Errors are shown in
+// a message box.
+func loadDemo(demoFileName string, c *Config, renderer *sdl.Renderer, window *sdl.Window, font *ttf.Font) (*match.Match, *viewer.Viewer, error) {
+	match, err := takePrefetched(demoFileName)
+	if match == nil && err == nil {
+		match, err = parseDemo(context.Background(), c, demoFileName)
+	}
+	if err != nil {
+		errorString := locale.Sprintf("error.parse_demo", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+		return nil, nil, err
+	}
+
+	mapSurface, err := img.Load(filepath.Join(c.OverviewDir, fmt.Sprintf("%v.jpg", match.MapName)))
+	if err != nil {
+		errorString := locale.Sprintf("error.overview_dir", c.OverviewDir, err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+		mapSurface, err = img.Load(fmt.Sprintf("%v.jpg", match.MapName))
+		if err != nil {
+			errorString := locale.Sprintf("error.overview_current", err, c.OverviewDir)
+			sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+			return nil, nil, err
+		}
+	}
+	defer mapSurface.Free()
+
+	mapTexture, err := renderer.CreateTextureFromSurface(mapSurface)
+	if err != nil {
+		errorString := locale.Sprintf("error.create_map_texture", err)
+		sdl.ShowSimpleMessageBox(sdl.MESSAGEBOX_ERROR, locale.T("error.title"), errorString, window)
+		return nil, nil, err
+	}
+
+	matchViewer := viewer.New(match, renderer)
+	matchViewer.X, matchViewer.Y = mapXOffset, mapYOffset
+	matchViewer.SetFont(font)
+	matchViewer.SetOverview(mapTexture)
+	matchViewer.LoadSectionOverviews(c.OverviewDir)
+
+	err = loadReview(demoFileName)
+	if err != nil {
+		log.Println("trying to load review file:", err)
+	}
+	screenshotDir = screenshotDirectory(demoFileName)
+
+	attachEvent(match, demoFileName, c.LiquipediaAPIKey)
+	destroyAvatars()
+	steamClient := enrichProfiles(match, c.SteamAPIKey)
+	if steamClient != nil {
+		loadAvatars(renderer, steamClient, match)
+	}
+
+	return match, matchViewer, nil
+}
+
+// resetPlayback resets the state of the playback when a new demo is opened.
+func resetPlayback() {
+	curFrame = 0
+	paused = false
+	afterplantSite = ""
+	replayEndFrame = -1
+	calibrating = false
+	roundLoop = false
+	clearLoop()
+	mapViewer.ClearSelection()
+	clearSearch()
+	ghost = nil
+	measurement = nil
+	spawnTimingPosition = nil
+	economyRound = -1
+	roundHealthIndex = -1
+	playbackElapsed = 0
+}
+
+// toggleMeasuring starts or ends the measuring mode. The measurement is
+// removed when it ends.
+func toggleMeasuring() {
+	measuring = !measuring
+	measurement = nil
+	spawnTiming = false
+	spawnTimingPosition = nil
+}
+
+// toggleSpawnTiming starts or ends the mode in which a click on the map shows
+// the times the teams need to run there from their spawns.
+func toggleSpawnTiming() {
+	spawnTiming = !spawnTiming
+	spawnTimingPosition = nil
+	measuring = false
+	measurement = nil
+}
+
+// addMeasurementPoint adds the position at x, y in renderer coordinates to
+// the measurement. After two positions the next click starts a new
+// measurement.
+func addMeasurementPoint(x, y int32) {
+	position, ok := mapViewer.WorldAt(x, y)
+	if !ok {
+		return
+	}
+	if len(measurement) == 2 {
+		measurement = nil
+	}
+	measurement = append(measurement, position)
+}
+
+// placeGhost moves the ghost to x, y in renderer coordinates if it is on the
+// overview.
+func placeGhost(x, y int32) {
+	position, ok := mapViewer.WorldAt(x, y)
+	if ok {
+		ghost = &position
+	}
+}
+
+// loadMapConfigs registers the maps of the map config. The default map config
+// in the overview directory is optional.
+func loadMapConfigs(c *Config) error {
+	if c.MapConfig != "" {
+		mapConfigFile = c.MapConfig
+		return match.LoadMapConfigs(c.MapConfig)
+	}
+	// calibrated maps are saved to the default map config
+	mapConfigFile = filepath.Join(c.OverviewDir, "maps.json")
+	_, err := os.Stat(mapConfigFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return match.LoadMapConfigs(mapConfigFile)
+}
+
+// findOverview returns the overview image of the map in the same places that
+// loadDemo looks for it or an empty string if there is none.
+func findOverview(overviewDir, mapName string) string {
+	for _, fileName := range []string{filepath.Join(overviewDir, mapName+".jpg"), mapName + ".jpg"} {
+		_, err := os.Stat(fileName)
+		if err == nil {
+			return fileName
+		}
+	}
+	return ""
+}
+
+// openFont opens the font file at fontPath or, if it is empty, the font that
+// is built into csgoverview.
+func openFont(fontPath string, size int) (*ttf.Font, error) {
+	if fontPath != "" {
+		return ttf.OpenFont(fontPath, size)
+	}
+	rw, err := sdl.RWFromMem(defaultFont)
+	if err != nil {
+		return nil, err
+	}
+	return ttf.OpenFontRW(rw, 1, size)
+}
+
+func handleKeyboardEvents(eventT *sdl.KeyboardEvent, window *sdl.Window, match *match.Match) {
+	if eventT.Type != sdl.KEYDOWN {
+		return
+	}
+	binding, ok := bindingFor(eventT.Keysym.Sym, isShiftPressed(eventT))
+	if ok && binding.run != nil {
+		binding.run(match)
+	}
+}
+
+// previousStart returns the latest frame in starts before curFrame. If
+// curFrame is shortly after a start, the start before that one is returned so
+// that pressing the key repeatedly keeps going backwards.
+func previousStart(starts []int, match *match.Match) int {
+	if len(starts) == 0 {
+		return curFrame
+	}
+	for i, frame := range starts {
+		if curFrame < frame {
+			if i > 1 && curFrame < starts[i-1]+match.FrameRateRounded/2 {
+				return starts[i-2]
+			}
+			if i-1 < 0 {
+				return 0
+			}
+			return starts[i-1]
+		}
+	}
+	// not found -> last start of match
+	if len(starts) > 1 && curFrame < starts[len(starts)-1]+match.FrameRateRounded/2 {
+		return starts[len(starts)-2]
+	}
+	return starts[len(starts)-1]
+}
+
+// nextStart returns the first frame in starts after curFrame.
+func nextStart(starts []int) int {
+	for _, frame := range starts {
+		if curFrame < frame {
+			return frame
+		}
+	}
+	return curFrame
+}
+
+func updateWindowTitle(window *sdl.Window, match *match.Match) {
+	cts := match.StateAtFrame(curFrame).TeamCounterTerrorists
+	ts := match.StateAtFrame(curFrame).TeamTerrorists
+	clanNameCTs := cts.ClanName
+	if clanNameCTs == "" {
+		clanNameCTs = locale.T("title.counter_terrorists")
+	}
+	clanNameTs := ts.ClanName
+	if clanNameTs == "" {
+		clanNameTs = locale.T("title.terrorists")
+	}
+	windowTitle := fmt.Sprintf("%s  [%d:%d]  %s - %s", clanNameCTs, cts.Score, ts.Score, clanNameTs,
+		locale.Sprintf("title.round", cts.Score+ts.Score+1))
+	if afterplantSite != "" {
+		windowTitle += " - " + locale.Sprintf("title.afterplants", afterplantSite)
+	}
+	if playbackSpeed != 1 {
+		windowTitle += fmt.Sprintf(" - %gx", playbackSpeed)
+	}
+	if replayEndFrame >= 0 {
+		windowTitle += " - " + locale.T("title.replay")
+	}
+	if hasCustomLoop() {
+		windowTitle += " - " + locale.Sprintf("title.custom_loop", (match.TimeAt(loopOut)-match.TimeAt(loopIn)).Seconds())
+	} else if roundLoop {
+		windowTitle += " - " + locale.T("title.round_loop")
+	}
+	if len(playlist) > 1 {
+		windowTitle += " - " + locale.Sprintf("title.playlist", playlistIndex+1, len(playlist))
+	}
+	if event := match.Summary.Event; event != nil {
+		windowTitle += fmt.Sprintf(" - %s %s", event.Name, event.Stage)
+	}
+	// expensive?
+	window.SetTitle(windowTitle)
+}
+
+// startProfiling writes a CPU profile to dir until the returned function is
+// called, which also writes a heap profile.
+func startProfiling(dir string) (func(), error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	cpuFile, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, err
+	}
+	err = pprof.StartCPUProfile(cpuFile)
+	if err != nil {
+		cpuFile.Close()
+		return nil, err
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		cpuFile.Close()
+
+		heapFile, err := os.Create(filepath.Join(dir, "heap.pprof"))
+		if err != nil {
+			log.Println("trying to create heap profile:", err)
+			return
+		}
+		defer heapFile.Close()
+		runtime.GC()
+		err = pprof.WriteHeapProfile(heapFile)
+		if err != nil {
+			log.Println("trying to write heap profile:", err)
+		}
+	}, nil
+}
+
+// enrichProfiles fetches the Steam profiles of the players if an API key is
+// set. Failing to fetch them is not fatal, the profiles are just left out.
+func enrichProfiles(match *match.Match, key string) *steam.Client {
+	if key == "" {
+		return nil
+	}
+	client, err := steam.NewClient(key)
+	if err != nil {
+		log.Println("trying to create Steam client:", err)
+		return nil
+	}
+	err = steam.Enrich(match, client)
+	if err != nil {
+		log.Println("trying to fetch Steam profiles:", err)
+		return nil
+	}
+	return client
+}
+
+// attachEvent looks up the event of the match if an API key is set. The
+// modification time of the demo file is used as date of the match.
+func attachEvent(match *match.Match, demoFileName, key string) {
+	if key == "" {
+		return
+	}
+	info, err := os.Stat(demoFileName)
+	if err != nil {
+		log.Println("trying to get date of demo file:", err)
+		return
+	}
+	err = metadata.Attach(match, metadata.NewLiquipedia(key), info.ModTime())
+	if err != nil {
+		log.Println("trying to look up event:", err)
+	}
+}
+
+func loadAvatars(renderer *sdl.Renderer, client *steam.Client, match *match.Match) {
+	for _, player := range match.Players() {
+		id := player.SteamID64
+		profile, ok := match.Profile(id)
+		if !ok {
+			continue
+		}
+		data, err := client.Avatar(profile)
+		if err != nil {
+			log.Println("trying to fetch avatar:", err)
+			continue
+		}
+		rw, err := sdl.RWFromMem(data)
+		if err != nil {
+			log.Println("trying to read avatar:", err)
+			continue
+		}
+		surface, err := img.LoadRW(rw, true)
+		if err != nil {
+			log.Println("trying to load avatar:", err)
+			continue
+		}
+		texture, err := renderer.CreateTextureFromSurface(surface)
+		surface.Free()
+		if err != nil {
+			log.Println("trying to create avatar texture:", err)
+			continue
+		}
+		avatars[id] = texture
+	}
+}
+
+func destroyAvatars() {
+	for id, texture := range avatars {
+		texture.Destroy()
+		delete(avatars, id)
+	}
+}
+
+func updateGraphics(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	renderer.SetDrawColor(10, 10, 10, 255)
+	renderer.Clear()
+
+	drawInfobars(renderer, match, font)
+	syncViewer()
+	mapViewer.Draw()
+
+	drawScoreHeader(renderer, match, font)
+
+	if roundStrip {
+		drawRoundStrip(renderer, match, font)
+		drawProgressBar(renderer, match)
+	}
+
+	if serverInfo {
+		drawServerInfo(renderer, font, match)
+	}
+
+	if economyPanel {
+		drawEconomy(renderer, match, font)
+	}
+
+	if povPanel {
+		drawPOVPanel(renderer, match, font)
+	}
+
+	if healthGraphs {
+		drawHealthPanel(renderer, match, font)
+	}
+
+	drawNotes(renderer, match, font)
+
+	drawSearch(renderer, font)
+
+	if calibrating {
+		drawCalibration(renderer, match, font)
+	}
+
+	if helpOverlay {
+		drawHelp(renderer, font)
+	}
+
+	if screenshotRequested {
+		screenshotRequested = false
+		err := saveScreenshot(renderer, match)
+		if err != nil {
+			log.Println("trying to save screenshot:", err)
+		}
+	}
+
+	renderer.Present()
+}
+
+// syncViewer passes the playback state and the toggles of the application to
+// the viewer, which draws the overview.
+func syncViewer() {
+	mapViewer.Frame = curFrame
+	mapViewer.HiddenLayers = hiddenLayers
+	mapViewer.AWPOverlay = awpOverlay
+	mapViewer.InfernoExtents = infernoExtents
+	mapViewer.AltitudeShading = altitudeShading
+	mapViewer.SoundOverlay = soundOverlay
+	mapViewer.EntryPaths = entryPaths
+	mapViewer.SmokeKills = smokeKills
+	mapViewer.Ghost = ghost
+	mapViewer.Measurement = measurement
+	mapViewer.SpawnTiming = spawnTimingPosition
+	mapViewer.LineOfSight = lineOfSight
+	mapViewer.Crossfire = crossfire
+	mapViewer.Zones = zones
+	mapViewer.Site = afterplantSite
+}
+
+func isShiftPressed(event *sdl.KeyboardEvent) bool {
+	pressed := event.Keysym.Mod & sdl.KMOD_SHIFT
+
+	if pressed > 0 {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// maxReviewHistory is the number of changes that can be undone.
+const maxReviewHistory = 100
+
+// bookmark marks a frame of the demo that the reviewer wants to come back to.
+type bookmark struct {
+	Frame int
+	// Note is a text of any number of lines that is shown in the notes panel.
+	Note string `json:",omitempty"`
+}
+
+// reviewNotes contains everything the reviewer added to a demo.
+type reviewNotes struct {
+	Bookmarks []bookmark
+	// RoundNotes maps the number of a round to its note.
+	RoundNotes map[int]string `json:",omitempty"`
+}
+
+// copy returns a deep copy of the notes.
+func (n reviewNotes) copy() reviewNotes {
+	c := reviewNotes{Bookmarks: append([]bookmark{}, n.Bookmarks...)}
+	if len(n.RoundNotes) > 0 {
+		c.RoundNotes = make(map[int]string, len(n.RoundNotes))
+		for round, note := range n.RoundNotes {
+			c.RoundNotes[round] = note
+		}
+	}
+	return c
+}
+
+// reviewFile contains the notes of a demo and their history. It is saved next
+// to the demo after every change, so that the changes can be undone even after
+// the viewer was restarted.
+type reviewFile struct {
+	reviewNotes
+	// Undo contains the previous versions of the notes and Redo the undone
+	// versions, the most recent last.
+	Undo []reviewNotes `json:",omitempty"`
+	Redo []reviewNotes `json:",omitempty"`
+}
+
+var (
+	review         reviewFile
+	reviewFileName string
+)
+
+// loadReview reads the review file of the demo. A missing file is not an
+// error.
+func loadReview(demoFileName string) error {
+	review = reviewFile{}
+	reviewFileName = demoFileName + ".review.json"
+	data, err := ioutil.ReadFile(reviewFileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &review)
+}
+
+func saveReview() {
+	data, err := json.MarshalIndent(review, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(reviewFileName, data, 0644)
+	}
+	if err != nil {
+		log.Println("trying to save review file:", err)
+	}
+}
+
+// changeReview applies change to the notes and records the previous notes so
+// that the change can be undone.
+func changeReview(change func(notes *reviewNotes)) {
+	review.Undo = appendHistory(review.Undo, review.reviewNotes.copy())
+	review.Redo = nil
+	change(&review.reviewNotes)
+	sort.Slice(review.Bookmarks, func(i, j int) bool { return review.Bookmarks[i].Frame < review.Bookmarks[j].Frame })
+	saveReview()
+}
+
+func appendHistory(history []reviewNotes, notes reviewNotes) []reviewNotes {
+	history = append(history, notes)
+	if len(history) > maxReviewHistory {
+		history = history[len(history)-maxReviewHistory:]
+	}
+	return history
+}
+
+// undoReview restores the notes before the last change.
+func undoReview() {
+	if len(review.Undo) == 0 {
+		return
+	}
+	review.Redo = appendHistory(review.Redo, review.reviewNotes)
+	review.reviewNotes = review.Undo[len(review.Undo)-1]
+	review.Undo = review.Undo[:len(review.Undo)-1]
+	saveReview()
+}
+
+// redoReview restores the notes before the last undo.
+func redoReview() {
+	if len(review.Redo) == 0 {
+		return
+	}
+	review.Undo = appendHistory(review.Undo, review.reviewNotes)
+	review.reviewNotes = review.Redo[len(review.Redo)-1]
+	review.Redo = review.Redo[:len(review.Redo)-1]
+	saveReview()
+}
+
+// toggleBookmark adds a bookmark at the current frame or removes it if there
+// already is one.
+func toggleBookmark() {
+	changeReview(func(notes *reviewNotes) {
+		for i, b := range notes.Bookmarks {
+			if b.Frame == curFrame {
+				notes.Bookmarks = append(notes.Bookmarks[:i], notes.Bookmarks[i+1:]...)
+				return
+			}
+		}
+		notes.Bookmarks = append(notes.Bookmarks, bookmark{Frame: curFrame})
+	})
+}
+
+func nextBookmark(match *match.Match) {
+	for _, b := range review.Bookmarks {
+		if b.Frame > curFrame {
+			seek(match, b.Frame)
+			break
+		}
+	}
+}
+
+func previousBookmark(match *match.Match) {
+	for i := len(review.Bookmarks) - 1; i >= 0; i-- {
+		if review.Bookmarks[i].Frame < curFrame {
+			seek(match, review.Bookmarks[i].Frame)
+			break
+		}
+	}
+}
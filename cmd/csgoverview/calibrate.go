@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/linus4/csgoverview/locale"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/viewer"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	// calibrationStep and calibrationStepLarge are the distances in pixels
+	// the positions are moved by, calibrationZoom and calibrationZoomLarge the
+	// factors the scale changes by.
+	calibrationStep      float32 = 1
+	calibrationStepLarge float32 = 10
+	calibrationZoom      float32 = 1.002
+	calibrationZoomLarge float32 = 1.02
+)
+
+var (
+	// calibrating is true while the position and scale of the overview are
+	// adjusted. Key bindings are disabled in the meantime.
+	calibrating bool
+	// calibrationPZero and calibrationScale are the values before the
+	// calibration, they are restored if it is canceled.
+	calibrationPZero common.Point
+	calibrationScale float32
+	// mapConfigFile is the file the calibrated maps are saved to.
+	mapConfigFile string
+)
+
+func startCalibration(match *match.Match) {
+	calibrating = true
+	calibrationPZero = match.MapPZero
+	calibrationScale = match.MapScale
+}
+
+// handleCalibrationKeyboardEvents moves the positions with the arrow keys and
+// scales them around the center of the overview with page up and page down.
+// Return saves the calibration to the map config and escape restores the
+// previous values.
+func handleCalibrationKeyboardEvents(eventT *sdl.KeyboardEvent, match *match.Match) {
+	if eventT.Type != sdl.KEYDOWN {
+		return
+	}
+	step, zoom := calibrationStep, calibrationZoom
+	if isShiftPressed(eventT) {
+		step, zoom = calibrationStepLarge, calibrationZoomLarge
+	}
+	switch eventT.Keysym.Sym {
+	case sdl.K_LEFT:
+		moveOverview(match, -step, 0)
+	case sdl.K_RIGHT:
+		moveOverview(match, step, 0)
+	case sdl.K_UP:
+		moveOverview(match, 0, -step)
+	case sdl.K_DOWN:
+		moveOverview(match, 0, step)
+	case sdl.K_PAGEUP:
+		scaleAroundCenter(match, match.MapScale/zoom)
+	case sdl.K_PAGEDOWN:
+		scaleAroundCenter(match, match.MapScale*zoom)
+	case sdl.K_RETURN:
+		err := saveCalibration(match)
+		if err != nil {
+			log.Println("trying to save map config:", err)
+			break
+		}
+		calibrating = false
+	case sdl.K_ESCAPE:
+		match.MapPZero = calibrationPZero
+		match.MapScale = calibrationScale
+		calibrating = false
+	}
+}
+
+// moveOverview moves the positions on the screen by the given number of
+// pixels. The direction is transformed back if the overview is rotated or
+// mirrored.
+func moveOverview(match *match.Match, dx, dy float32) {
+	x0, y0 := match.MapTransform.Invert(0, 0)
+	x1, y1 := match.MapTransform.Invert(dx, dy)
+	match.MapPZero.X -= (x1 - x0) * match.MapScale
+	match.MapPZero.Y += (y1 - y0) * match.MapScale
+}
+
+// scaleAroundCenter changes the scale so that the point in the center of the
+// overview stays where it is.
+func scaleAroundCenter(match *match.Match, scale float32) {
+	centerX := match.MapPZero.X + float32(mapOverviewWidth)/2*match.MapScale
+	centerY := match.MapPZero.Y - float32(mapOverviewHeight)/2*match.MapScale
+	match.MapScale = scale
+	match.MapPZero.X = centerX - float32(mapOverviewWidth)/2*scale
+	match.MapPZero.Y = centerY + float32(mapOverviewHeight)/2*scale
+}
+
+func saveCalibration(m *match.Match) error {
+	config := match.MapConfig{
+		PosX:   float64(m.MapPZero.X),
+		PosY:   float64(m.MapPZero.Y),
+		Scale:  float64(m.MapScale),
+		Rotate: m.MapTransform.Rotate,
+		FlipX:  m.MapTransform.FlipX,
+		FlipY:  m.MapTransform.FlipY,
+	}
+	for _, section := range m.MapSections {
+		config.Sections = append(config.Sections, match.MapSectionConfig{
+			Name:        section.Name,
+			AltitudeMin: float64(section.AltitudeMin),
+			AltitudeMax: float64(section.AltitudeMax),
+		})
+	}
+	return match.SaveMapConfig(mapConfigFile, m.MapName, config)
+}
+
+// drawCalibration draws the current position and scale of the overview and
+// the keys to change them.
+func drawCalibration(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	lines := []string{
+		locale.Sprintf("calibration.title", match.MapName),
+		fmt.Sprintf("pos_x %.1f, pos_y %.1f, scale %.3f", match.MapPZero.X, match.MapPZero.Y, match.MapScale),
+		locale.T("calibration.move"),
+		locale.T("calibration.scale"),
+		locale.Sprintf("calibration.save", mapConfigFile),
+	}
+	x := mapXOffset + 10
+	y := mapYOffset + 10
+	gfx.BoxColor(renderer, x-5, y-5, x+500, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, line, colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
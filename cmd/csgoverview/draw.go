@@ -0,0 +1,631 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/locale"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	"github.com/linus4/csgoverview/viewer"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	killfeedHeight       int32 = 15
+	serverInfoLineHeight int32 = 18
+	timerBarWidth        int32 = 200
+	timerBarHeight       int32 = 8
+	roundStripHeight     int32 = 30
+	roundStripCellWidth  int32 = 32
+	progressBarHeight    int32 = 4
+	winTypeIconRadius    int32 = 5
+	notesPanelY          int32 = 680
+	sparklineWidth       int32 = 255
+	sparklineHeight      int32 = 24
+	sparklineMaxDiff     int32 = 5
+	notesLineLength      int   = 40
+)
+
+var (
+	colorTerror            = sdl.Color{252, 176, 12, 255}
+	colorCounter           = sdl.Color{89, 206, 200, 255}
+	colorMoney             = sdl.Color{45, 135, 45, 255}
+	colorBomb              = sdl.Color{255, 0, 0, 255}
+	colorEqDecoy           = sdl.Color{102, 34, 0, 255}
+	colorEqMolotov         = sdl.Color{255, 153, 0, 255}
+	colorEqIncendiary      = sdl.Color{255, 153, 0, 255}
+	colorBookmark          = sdl.Color{240, 90, 200, 255}
+	colorEqFlash           = sdl.Color{128, 170, 255, 255}
+	colorEqSmoke           = sdl.Color{153, 153, 153, 255}
+	colorEqHE              = sdl.Color{85, 150, 0, 255}
+	colorDarkWhite         = sdl.Color{200, 200, 200, 255}
+	colorVACBanned         = sdl.Color{255, 0, 0, 255}
+	colorOverlayBackground = sdl.Color{10, 10, 10, 200}
+	colorDefuseInTime      = sdl.Color{0, 200, 0, 255}
+	colorRoundUndecided    = sdl.Color{80, 80, 80, 255}
+	colorMultiKill         = sdl.Color{230, 230, 230, 255}
+	colorAce               = sdl.Color{255, 215, 0, 255}
+	colorTeamKill          = sdl.Color{230, 40, 40, 255}
+	colorTechnicalPause    = sdl.Color{150, 110, 255, 255}
+)
+
+// drawStringRight draws text so that it ends at x.
+func drawStringRight(renderer *sdl.Renderer, text string, color sdl.Color, x, y int32, font *ttf.Font) {
+	width, _, err := font.SizeUTF8(text)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	viewer.DrawString(renderer, text, color, x-int32(width), y, font)
+}
+
+func drawInfobars(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	var cts, ts []common.Player
+	for _, player := range match.StateAtFrame(curFrame).Players {
+		if player.Team == demoinfo.TeamCounterTerrorists {
+			cts = append(cts, player)
+
+		} else {
+			ts = append(ts, player)
+		}
+	}
+	sort.Slice(cts, func(i, j int) bool { return cts[i].SteamID64 < cts[j].SteamID64 })
+	sort.Slice(ts, func(i, j int) bool { return ts[i].SteamID64 < ts[j].SteamID64 })
+	drawInfobar(renderer, cts, match, 0, mapYOffset, colorCounter, font)
+	drawInfobar(renderer, ts, match, mapXOffset+mapOverviewWidth, mapYOffset, colorTerror, font)
+	if hiddenLayers.Shows(common.LayerKillfeed) {
+		drawKillfeed(renderer, match.KillfeedAt(curFrame), mapXOffset+mapOverviewWidth, mapYOffset+600, font)
+	}
+	drawTimer(renderer, match.TimerAt(curFrame), 0, mapYOffset+600, font)
+	drawAliveSparkline(renderer, match, 5, mapYOffset+600+3*killfeedHeight+5)
+}
+
+// drawAliveSparkline draws how many more players the counter-terrorists than
+// the terrorists had alive from the end of the freezetime until the current
+// frame, above the center line in the color of the counter-terrorists and
+// below in the color of the terrorists. The width spans the whole round and
+// a differential of sparklineMaxDiff half of the height.
+func drawAliveSparkline(renderer *sdl.Renderer, match *match.Match, x, y int32) {
+	i := match.RoundIndex(curFrame)
+	if i < 0 || i >= len(match.Rounds) {
+		return
+	}
+	counts := stats.RoundAliveCounts(match, i)
+	start := counts[0].Frame
+	end := match.Rounds[i].EndFrame
+	if end < 0 {
+		end = match.LastFrame()
+	}
+	if end <= start || curFrame < start {
+		return
+	}
+	center := y + sparklineHeight/2
+	gfx.HlineColor(renderer, x, x+sparklineWidth, center, colorRoundUndecided)
+	for j, count := range counts {
+		if count.Frame > curFrame {
+			break
+		}
+		next := curFrame
+		if j+1 < len(counts) && counts[j+1].Frame < next {
+			next = counts[j+1].Frame
+		}
+		if next > end {
+			next = end
+		}
+		x1 := x + int32(int64(count.Frame-start)*int64(sparklineWidth)/int64(end-start))
+		x2 := x + int32(int64(next-start)*int64(sparklineWidth)/int64(end-start))
+		level := center - int32(count.Differential())*sparklineHeight/(2*sparklineMaxDiff)
+		color := colorCounter
+		if count.Differential() < 0 {
+			color = colorTerror
+		}
+		if count.Differential() != 0 {
+			gfx.BoxColor(renderer, x1, center, x2, level, color)
+		}
+	}
+}
+
+func drawInfobar(renderer *sdl.Renderer, players []common.Player, match *match.Match, x, y int32, color sdl.Color, font *ttf.Font) {
+	var yOffset int32
+	for _, player := range players {
+		if player.IsAlive {
+			gfx.BoxColor(renderer, x+int32(player.Health)*(mapXOffset/infobarElementHeight), yOffset, x, yOffset+5, color)
+		}
+		if !player.IsAlive {
+			color.A = 150
+		}
+		viewer.DrawString(renderer, cropStringToN(player.Name, 20), color, x+85, yOffset+10, font)
+		color.A = 255
+		if avatar, ok := avatars[player.SteamID64]; ok {
+			renderer.Copy(avatar, nil, &sdl.Rect{X: x + 65, Y: yOffset + 10, W: avatarSize, H: avatarSize})
+		}
+		if profile, _ := match.Profile(player.SteamID64); profile.VACBanned {
+			viewer.DrawString(renderer, locale.T("infobar.vac"), colorVACBanned, x+250, yOffset+10, font)
+		}
+		hud := player.HUD
+		viewer.DrawString(renderer, fmt.Sprintf("%v", hud.Health), color, x+5, yOffset+10, font)
+		switch hud.Armor {
+		case common.ArmorKevlarHelmet:
+			viewer.DrawString(renderer, locale.T("infobar.helmet"), color, x+35, yOffset+10, font)
+		case common.ArmorKevlar:
+			viewer.DrawString(renderer, locale.T("infobar.armor"), color, x+35, yOffset+10, font)
+		}
+		if hud.HasDefuseKit {
+			viewer.DrawString(renderer, locale.T("infobar.defuser"), color, x+50, yOffset+10, font)
+		}
+		if hud.HasBomb {
+			gfx.BoxColor(renderer, x+50, yOffset+12, x+45+12, yOffset+12+9, colorBomb)
+		}
+		viewer.DrawString(renderer, fmt.Sprintf("%v $", hud.Money), colorMoney, x+5, yOffset+25, font)
+		if hud.Primary != demoinfo.EqUnknown {
+			viewer.DrawString(renderer, hud.Primary.String(), color, x+150, yOffset+25, font)
+		}
+		if hud.Secondary != demoinfo.EqUnknown {
+			viewer.DrawString(renderer, hud.Secondary.String(), color, x+150, yOffset+40, font)
+		}
+		var nadeCounter int32
+		for _, nade := range hud.Grenades {
+			var nadeColor sdl.Color
+			switch nade.Type {
+			case demoinfo.EqDecoy:
+				nadeColor = colorEqDecoy
+			case demoinfo.EqMolotov:
+				nadeColor = colorEqMolotov
+			case demoinfo.EqIncendiary:
+				nadeColor = colorEqIncendiary
+			case demoinfo.EqFlash:
+				nadeColor = colorEqFlash
+			case demoinfo.EqSmoke:
+				nadeColor = colorEqSmoke
+			case demoinfo.EqHE:
+				nadeColor = colorEqHE
+			}
+			// the grenade icons are drawn in the middle of their icon, so they
+			// are moved to the left to start at x+150
+			for i := 0; i < nade.Count; i++ {
+				viewer.DrawIcon(renderer, nade.Type, nadeColor, x+144+nadeCounter*12, yOffset+60, 1)
+				nadeCounter++
+			}
+		}
+		kdaInfo := fmt.Sprintf("%v / %v / %v", player.Kills, player.Assists, player.Deaths)
+		viewer.DrawString(renderer, kdaInfo, color, x+5, yOffset+40, font)
+
+		yOffset += infobarElementHeight
+	}
+}
+
+func drawKillfeed(renderer *sdl.Renderer, killfeed []common.Kill, x, y int32, font *ttf.Font) {
+	var yOffset int32
+	for _, kill := range killfeed {
+		var colorKiller, colorVictim sdl.Color
+		if kill.KillerTeam == demoinfo.TeamCounterTerrorists {
+			colorKiller = colorCounter
+		} else if kill.KillerTeam == demoinfo.TeamTerrorists {
+			colorKiller = colorTerror
+		} else {
+			colorKiller = colorDarkWhite
+		}
+		if kill.VictimTeam == demoinfo.TeamCounterTerrorists {
+			colorVictim = colorCounter
+		} else {
+			colorVictim = colorTerror
+		}
+		killerName := cropStringToN(kill.KillerName, 10)
+		victimName := cropStringToN(kill.VictimName, 10)
+		weaponName := cropStringToN(kill.Weapon.String(), 10)
+		colorWeapon := colorDarkWhite
+		// suicides and fall damage show no killer, team kills a red weapon
+		switch kill.Type {
+		case common.KillSuicide:
+			killerName = ""
+			weaponName = locale.T("killfeed.suicide")
+		case common.KillFallDamage:
+			killerName = ""
+			weaponName = locale.T("killfeed.fall_damage")
+		case common.KillTeam:
+			colorWeapon = colorTeamKill
+		}
+		viewer.DrawString(renderer, killerName, colorKiller, x+5, y+yOffset, font)
+		viewer.DrawString(renderer, weaponName, colorWeapon, x+110, y+yOffset, font)
+		// a flashed killer is marked with a white dot and a no-scope with a
+		// crossed out circle in front of the weapon
+		iconY := y + yOffset + killfeedHeight/2
+		if kill.KillerBlind {
+			gfx.FilledCircleColor(renderer, x+92, iconY, 3, colorDarkWhite)
+		}
+		if kill.IsNoScope {
+			gfx.AACircleColor(renderer, x+102, iconY, 4, colorDarkWhite)
+			gfx.AALineColor(renderer, x+99, iconY+3, x+105, iconY-3, colorDarkWhite)
+		}
+		viewer.DrawString(renderer, victimName, colorVictim, x+200, y+yOffset, font)
+		yOffset += killfeedHeight
+	}
+}
+
+func drawTimer(renderer *sdl.Renderer, timer common.Timer, x, y int32, font *ttf.Font) {
+	if timer.Phase == common.PhaseWarmup {
+		viewer.DrawString(renderer, locale.T("timer.warmup"), colorDarkWhite, x+5, y, font)
+	} else {
+		minutes := int(timer.TimeRemaining.Minutes())
+		seconds := int(timer.TimeRemaining.Seconds()) - 60*minutes
+		timeString := fmt.Sprintf("%d:%2d", minutes, seconds)
+		var color sdl.Color
+		if timer.Phase == common.PhasePlanted {
+			color = colorBomb
+			if timer.IsDefusing && timer.DefuseInTime {
+				color = colorDefuseInTime
+			}
+		} else if timer.Phase == common.PhaseRestart || timer.Phase == common.PhaseHalftime {
+			color = colorEqHE
+		} else {
+			color = colorDarkWhite
+		}
+		viewer.DrawString(renderer, timeString, color, x+5, y, font)
+		if timer.Phase == common.PhasePlanted && timer.Duration > 0 {
+			drawTimerBar(renderer, timer.TimeRemaining, timer.Duration, x+60, y+5, color)
+			if timer.IsDefusing {
+				defuseString := locale.Sprintf("timer.defuse", timer.DefuseRemaining.Seconds())
+				viewer.DrawString(renderer, defuseString, color, x+5, y+2*killfeedHeight, font)
+			}
+		}
+	}
+	if timer.IsPaused {
+		viewer.DrawString(renderer, locale.T("timer.paused"), colorDarkWhite, x+5, y+killfeedHeight, font)
+	}
+}
+
+// drawTimerBar draws a bar whose length is proportional to the remaining time.
+func drawTimerBar(renderer *sdl.Renderer, remaining, duration time.Duration, x, y int32, color sdl.Color) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	width := int32(float64(timerBarWidth) * float64(remaining) / float64(duration))
+	gfx.RectangleColor(renderer, x, y, x+timerBarWidth, y+timerBarHeight, colorDarkWhite)
+	gfx.BoxColor(renderer, x, y, x+width, y+timerBarHeight, color)
+}
+
+func drawServerInfo(renderer *sdl.Renderer, font *ttf.Font, match *match.Match) {
+	lines := []string{
+		match.Server.Name,
+		locale.Sprintf("serverinfo.protocol", match.Server.NetworkProtocol, match.Server.TickRate),
+	}
+	for _, name := range match.ConVarNames() {
+		if strings.HasPrefix(name, "mp_") {
+			value, _ := match.ConVar(name)
+			lines = append(lines, fmt.Sprintf("%s %s", name, value))
+		}
+	}
+
+	x := mapXOffset + 10
+	y := mapYOffset + 10
+	gfx.BoxColor(renderer, x-5, y-5, x+400, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, line, colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
+
+// roundStripRect returns the area of the round strip at the bottom of the map
+// and the width of a single round in it.
+func roundStripRect(match *match.Match) (sdl.Rect, int32) {
+	cellWidth := roundStripCellWidth
+	if len(match.Rounds) > 0 && int32(len(match.Rounds))*cellWidth > mapOverviewWidth {
+		cellWidth = mapOverviewWidth / int32(len(match.Rounds))
+	}
+	width := cellWidth * int32(len(match.Rounds))
+	return sdl.Rect{
+		X: mapXOffset + (mapOverviewWidth-width)/2,
+		Y: mapYOffset + mapOverviewHeight - progressBarHeight - roundStripHeight - 5,
+		W: width,
+		H: roundStripHeight,
+	}, cellWidth
+}
+
+// roundAtStripPosition returns the index of the round in the round strip at
+// the given position or -1 if there is none.
+func roundAtStripPosition(match *match.Match, x, y int32) int {
+	rect, cellWidth := roundStripRect(match)
+	if cellWidth == 0 || x < rect.X || x >= rect.X+rect.W || y < rect.Y || y >= rect.Y+rect.H {
+		return -1
+	}
+	return int((x - rect.X) / cellWidth)
+}
+
+// roundStripX returns the horizontal position of frame in the round strip.
+// Within the cell of a round, the position is proportional to the time since
+// the start of the round.
+func roundStripX(match *match.Match, rect sdl.Rect, cellWidth int32, frame int) (int32, bool) {
+	i := match.RoundIndex(frame)
+	if i < 0 || i >= len(match.Rounds) {
+		return 0, false
+	}
+	end := match.TotalFrames()
+	if i+1 < len(match.Rounds) {
+		end = match.Rounds[i+1].StartFrame
+	}
+	start := match.Rounds[i].StartFrame
+	x := rect.X + int32(i)*cellWidth
+	if end > start {
+		x += int32(int64(cellWidth) * int64(frame-start) / int64(end-start))
+	}
+	return x, true
+}
+
+// pauseColor returns the color that a pause is marked with: the color of the
+// team for timeouts and colorTechnicalPause for technical pauses.
+func pauseColor(kind common.PauseKind) sdl.Color {
+	switch kind {
+	case common.PauseTimeoutTerrorists:
+		return colorTerror
+	case common.PauseTimeoutCounterTerrorists:
+		return colorCounter
+	default:
+		return colorTechnicalPause
+	}
+}
+
+// pauseEndFrame returns the frame at which p ended. Pauses that did not end
+// before the end of the demo last until its last frame.
+func pauseEndFrame(match *match.Match, p common.Pause) int {
+	if p.EndFrame < 0 {
+		return match.LastFrame()
+	}
+	return p.EndFrame
+}
+
+// drawRoundStrip draws a cell for every round in the color of its winner
+// with the number of kills and marks for bomb plants and multi-kills. Pauses
+// and timeouts are marked along the top of the cells. The current round is
+// outlined.
+func drawRoundStrip(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	rect, cellWidth := roundStripRect(match)
+	if cellWidth == 0 {
+		return
+	}
+	gfx.BoxColor(renderer, rect.X-3, rect.Y-3, rect.X+rect.W+3, rect.Y+rect.H+3, colorOverlayBackground)
+	current := match.RoundIndex(curFrame)
+	for i, round := range match.Rounds {
+		x := rect.X + int32(i)*cellWidth
+		var color sdl.Color
+		switch {
+		case round.IsKnifeRound || round.Winner == demoinfo.TeamUnassigned:
+			color = colorRoundUndecided
+		case round.Winner == demoinfo.TeamCounterTerrorists:
+			color = colorCounter
+		default:
+			color = colorTerror
+		}
+		color.A = 180
+		gfx.BoxColor(renderer, x+1, rect.Y, x+cellWidth-1, rect.Y+rect.H, color)
+		if round.BombPlanted {
+			gfx.BoxColor(renderer, x+2, rect.Y+2, x+6, rect.Y+6, colorBomb)
+		}
+		if cellWidth >= 16 {
+			viewer.DrawString(renderer, fmt.Sprintf("%d", round.Kills), colorDarkWhite, x+8, rect.Y, font)
+		}
+		drawWinTypeIcon(renderer, round.WinType, x+cellWidth/2, rect.Y+rect.H-winTypeIconRadius-2, colorDarkWhite)
+		if i == current {
+			gfx.RectangleColor(renderer, x, rect.Y-2, x+cellWidth, rect.Y+rect.H+2, colorDarkWhite)
+		}
+		// multi-kills are marked in the lower right corner of the cell, one
+		// dot per 3k or 4k and a larger golden dot for an ace
+		for j, k := range round.MultiKills {
+			markerX := x + cellWidth - 5 - int32(j)*7
+			if k.IsAce() {
+				gfx.FilledCircleColor(renderer, markerX, rect.Y+rect.H-5, 3, colorAce)
+			} else {
+				gfx.FilledCircleColor(renderer, markerX, rect.Y+rect.H-5, 2, colorMultiKill)
+			}
+		}
+	}
+
+	// pauses are marked along the top of the cells from the time they
+	// started to the time they ended within their rounds, a pause during the
+	// freezetime at the start of its cell
+	for _, p := range match.Pauses {
+		x1, ok := roundStripX(match, rect, cellWidth, p.StartFrame)
+		if !ok {
+			continue
+		}
+		x2, ok := roundStripX(match, rect, cellWidth, pauseEndFrame(match, p))
+		if !ok || x2 < x1+2 {
+			x2 = x1 + 2
+		}
+		gfx.BoxColor(renderer, x1, rect.Y, x2, rect.Y+3, pauseColor(p.Kind))
+	}
+
+	// bookmarks are marked above the cell of their round at the time they
+	// were set within the round
+	for _, b := range review.Bookmarks {
+		x, ok := roundStripX(match, rect, cellWidth, b.Frame)
+		if !ok {
+			continue
+		}
+		gfx.FilledTrigonColor(renderer, x-3, rect.Y-9, x+3, rect.Y-9, x, rect.Y-3, colorBookmark)
+	}
+}
+
+// progressBarRect returns the area of the progress bar of the demo at the
+// bottom of the map.
+func progressBarRect() sdl.Rect {
+	return sdl.Rect{
+		X: mapXOffset,
+		Y: mapYOffset + mapOverviewHeight - progressBarHeight,
+		W: mapOverviewWidth,
+		H: progressBarHeight,
+	}
+}
+
+// frameAtProgressPosition returns the frame at the given position of the
+// progress bar or -1 if it is outside of the bar.
+func frameAtProgressPosition(match *match.Match, x, y int32) int {
+	rect := progressBarRect()
+	if x < rect.X || x >= rect.X+rect.W || y < rect.Y || y >= rect.Y+rect.H {
+		return -1
+	}
+	return int(int64(x-rect.X) * int64(match.LastFrame()) / int64(rect.W))
+}
+
+// drawProgressBar draws the playback position in the whole demo with the
+// pauses and timeouts of the match in their colors.
+func drawProgressBar(renderer *sdl.Renderer, match *match.Match) {
+	last := match.LastFrame()
+	if last <= 0 {
+		return
+	}
+	rect := progressBarRect()
+	frameX := func(frame int) int32 {
+		return rect.X + int32(int64(rect.W)*int64(frame)/int64(last))
+	}
+	gfx.BoxColor(renderer, rect.X, rect.Y, rect.X+rect.W, rect.Y+rect.H, colorOverlayBackground)
+	gfx.BoxColor(renderer, rect.X, rect.Y+1, frameX(curFrame), rect.Y+rect.H-1, colorDarkWhite)
+	for _, p := range match.Pauses {
+		x1 := frameX(p.StartFrame)
+		x2 := frameX(pauseEndFrame(match, p))
+		if x2 < x1+1 {
+			x2 = x1 + 1
+		}
+		gfx.BoxColor(renderer, x1, rect.Y, x2, rect.Y+rect.H, pauseColor(p.Kind))
+	}
+}
+
+// drawScoreHeader draws the clan names and scores at the top of the map and
+// the way the last round was won next to the score of its winner.
+func drawScoreHeader(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	state := match.StateAtFrame(curFrame)
+	clanNameCTs := cropStringToN(state.TeamCounterTerrorists.ClanName, 20)
+	if clanNameCTs == "" {
+		clanNameCTs = locale.T("title.counter_terrorists")
+	}
+	clanNameTs := cropStringToN(state.TeamTerrorists.ClanName, 20)
+	if clanNameTs == "" {
+		clanNameTs = locale.T("title.terrorists")
+	}
+
+	centerX := mapXOffset + mapOverviewWidth/2
+	y := mapYOffset + 5
+	gfx.BoxColor(renderer, centerX-220, y-3, centerX+220, y+20, colorOverlayBackground)
+	drawStringRight(renderer, clanNameCTs, colorCounter, centerX-50, y, font)
+	drawStringRight(renderer, fmt.Sprintf("%d", state.TeamCounterTerrorists.Score), colorCounter, centerX-10, y, font)
+	viewer.DrawString(renderer, ":", colorDarkWhite, centerX-2, y, font)
+	viewer.DrawString(renderer, fmt.Sprintf("%d", state.TeamTerrorists.Score), colorTerror, centerX+10, y, font)
+	viewer.DrawString(renderer, clanNameTs, colorTerror, centerX+50, y, font)
+
+	round, ok := match.LastEndedRound(curFrame)
+	if !ok || round.IsKnifeRound {
+		return
+	}
+	switch round.Winner {
+	case demoinfo.TeamCounterTerrorists:
+		drawWinTypeIcon(renderer, round.WinType, centerX-35, y+8, colorCounter)
+	case demoinfo.TeamTerrorists:
+		drawWinTypeIcon(renderer, round.WinType, centerX+35, y+8, colorTerror)
+	}
+}
+
+// drawWinTypeIcon draws a small icon for the way a round was won centered at
+// x, y: a cross for elimination, the bomb for an explosion, a cut wire for a
+// defuse, a clock for time and a flag for a surrender.
+func drawWinTypeIcon(renderer *sdl.Renderer, winType common.RoundWinType, x, y int32, color sdl.Color) {
+	r := winTypeIconRadius
+	switch winType {
+	case common.RoundWinTypeElimination:
+		gfx.ThickLineColor(renderer, x-r, y-r, x+r, y+r, 2, color)
+		gfx.ThickLineColor(renderer, x-r, y+r, x+r, y-r, 2, color)
+	case common.RoundWinTypeBombExploded:
+		gfx.FilledCircleColor(renderer, x, y, r, colorBomb)
+	case common.RoundWinTypeBombDefused:
+		gfx.BoxColor(renderer, x-r, y-r/2, x+r, y+r/2, colorBomb)
+		gfx.ThickLineColor(renderer, x-r, y+r, x+r, y-r, 2, colorDefuseInTime)
+	case common.RoundWinTypeTime:
+		gfx.AACircleColor(renderer, x, y, r, color)
+		gfx.LineColor(renderer, x, y, x, y-r+1, color)
+		gfx.LineColor(renderer, x, y, x+r-2, y, color)
+	case common.RoundWinTypeSurrender:
+		gfx.LineColor(renderer, x-r+1, y-r, x-r+1, y+r, color)
+		gfx.BoxColor(renderer, x-r+1, y-r, x+r, y, colorDarkWhite)
+	}
+}
+
+// drawHelp draws the key bindings on top of the map.
+func drawHelp(renderer *sdl.Renderer, font *ttf.Font) {
+	lines := helpLines()
+	x := mapXOffset + mapOverviewWidth - 410
+	y := mapYOffset + 10
+	gfx.BoxColor(renderer, x-5, y-5, x+400, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, line, colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
+
+// drawNotes draws the panel with the note of the current round and the notes
+// of its bookmarks below the timer. While a note is edited, the panel shows the
+// note with a cursor instead.
+func drawNotes(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	var lines []string
+	if editingNote {
+		if noteRound > 0 {
+			lines = append(lines, locale.Sprintf("notes.edit_round", noteRound))
+		} else {
+			lines = append(lines, locale.T("notes.edit_bookmark"))
+		}
+		lines = append(lines, locale.T("notes.edit_hint"))
+		lines = append(lines, strings.Split(noteText+"_", "\n")...)
+	} else {
+		i := match.RoundIndex(curFrame)
+		if i < 0 || i >= len(match.Rounds) {
+			return
+		}
+		round := match.Rounds[i]
+		if note, ok := review.RoundNotes[round.Number]; ok {
+			lines = append(lines, locale.Sprintf("title.round", round.Number))
+			lines = append(lines, strings.Split(note, "\n")...)
+		}
+		end := match.TotalFrames()
+		if i+1 < len(match.Rounds) {
+			end = match.Rounds[i+1].StartFrame
+		}
+		for _, b := range review.Bookmarks {
+			if b.Note == "" || b.Frame < round.StartFrame || b.Frame >= end {
+				continue
+			}
+			elapsed := match.TimeAt(b.Frame) - match.TimeAt(round.StartFrame)
+			minutes := int(elapsed.Minutes())
+			seconds := int(elapsed.Seconds()) - 60*minutes
+			lines = append(lines, fmt.Sprintf("%d:%02d", minutes, seconds))
+			lines = append(lines, strings.Split(b.Note, "\n")...)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	x := int32(5)
+	y := mapYOffset + notesPanelY
+	maxLines := int((mapYOffset + mapOverviewHeight - y) / serverInfoLineHeight)
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	gfx.BoxColor(renderer, x-5, y-5, mapXOffset-5, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, cropStringToN(line, notesLineLength), colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
+
+func cropStringToN(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
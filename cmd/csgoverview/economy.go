@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	"github.com/linus4/csgoverview/viewer"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const economyPanelWidth int32 = 520
+
+var (
+	// economyPanel shows the money of the players in the current round and
+	// what the teams would have in the next round with hypothetical buys.
+	economyPanel bool
+	// economyBuys contains the hypothetical buys of the players in the round
+	// with the index economyRound. They are discarded when the round changes.
+	economyBuys  = make(map[uint64]stats.Buy)
+	economyRound = -1
+)
+
+// economyLine is a line of the economy panel. Clicking a line with a
+// SteamID64 cycles the buy of the player.
+type economyLine struct {
+	text      string
+	color     sdl.Color
+	steamID64 uint64
+}
+
+// economyLines returns the lines of the economy panel for the current round.
+func economyLines(match *match.Match) []economyLine {
+	i := match.RoundIndex(curFrame)
+	if i < 0 || i >= len(match.Rounds) {
+		return []economyLine{{text: locale.T("economy.no_round"), color: colorDarkWhite}}
+	}
+	if i != economyRound {
+		economyBuys = make(map[uint64]stats.Buy)
+		economyRound = i
+	}
+
+	lines := make([]economyLine, 0)
+	for _, team := range []demoinfo.Team{demoinfo.TeamCounterTerrorists, demoinfo.TeamTerrorists} {
+		plan := stats.NewEconomyPlan(match, match.Rounds[i], team, curFrame)
+		color, name := colorCounter, locale.T("title.counter_terrorists")
+		if team == demoinfo.TeamTerrorists {
+			color, name = colorTerror, locale.T("title.terrorists")
+		}
+		lines = append(lines, economyLine{
+			text:  locale.Sprintf("economy.team", name, plan.LossStreak, plan.LossBonus()),
+			color: color,
+		})
+		for j := range plan.Players {
+			player := &plan.Players[j]
+			player.Buy = economyBuys[player.SteamID64]
+			cost := player.Cost(team)
+			lines = append(lines, economyLine{
+				text: fmt.Sprintf("  %-16.16s %5d $  %-6s -%5d $  = %5d $",
+					player.Name, player.Money, player.Buy, cost, player.Money-cost),
+				color:     colorDarkWhite,
+				steamID64: player.SteamID64,
+			})
+		}
+		win := plan.Project(true, false)
+		loss := plan.Project(false, false)
+		lines = append(lines,
+			economyLine{text: locale.Sprintf("economy.win", win.Total, win.FullBuys, len(plan.Players)), color: colorDarkWhite},
+			economyLine{text: locale.Sprintf("economy.loss", loss.Total, loss.FullBuys, len(plan.Players),
+				loss.LossBonus), color: colorDarkWhite},
+		)
+		if team == demoinfo.TeamTerrorists {
+			plant := plan.Project(false, true)
+			lines = append(lines, economyLine{
+				text:  locale.Sprintf("economy.plant_loss", plant.Total, plant.FullBuys, len(plan.Players)),
+				color: colorDarkWhite,
+			})
+		}
+	}
+	return append(lines, economyLine{text: locale.T("economy.hint"), color: colorDarkWhite})
+}
+
+// economyPlayerAt returns the SteamID of the player whose line of the
+// economy panel is at the given position.
+func economyPlayerAt(match *match.Match, x, y int32) (uint64, bool) {
+	left := mapXOffset + 10
+	top := mapYOffset + 10
+	if x < left || x >= left+economyPanelWidth || y < top {
+		return 0, false
+	}
+	lines := economyLines(match)
+	i := int((y - top) / serverInfoLineHeight)
+	if i >= len(lines) || lines[i].steamID64 == 0 {
+		return 0, false
+	}
+	return lines[i].steamID64, true
+}
+
+// cycleEconomyBuy switches the player to the next hypothetical buy.
+func cycleEconomyBuy(steamID64 uint64) {
+	economyBuys[steamID64] = economyBuys[steamID64].Next()
+}
+
+func drawEconomy(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	lines := economyLines(match)
+	x := mapXOffset + 10
+	y := mapYOffset + 10
+	gfx.BoxColor(renderer, x-5, y-5, x+economyPanelWidth, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, line.text, line.color, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	// embed is needed for the font that is compiled into csgoverview
+	_ "embed"
+)
+
+// defaultFont is the TrueType file of DejaVu Sans, the font that is used if
+// no other font is configured. It is embedded in the app and not in the pkg
+// module, so that users of the parser do not compile it in.
+//
+//go:embed fonts/DejaVuSans.ttf
+var defaultFont []byte
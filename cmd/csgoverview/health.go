@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	"github.com/linus4/csgoverview/viewer"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	healthPanelWidth  int32 = 600
+	healthPanelHeight int32 = 120
+)
+
+var (
+	// healthGraphs shows the health of all players over the current round.
+	healthGraphs bool
+	// roundHealth caches the health series of the round with the index
+	// roundHealthIndex, it is -1 if nothing is cached.
+	roundHealth      stats.RoundHealth
+	roundHealthIndex = -1
+	colorCurrentTime = sdl.Color{230, 230, 230, 120}
+)
+
+// currentRoundHealth returns the health series of the current round.
+func currentRoundHealth(match *match.Match) (stats.RoundHealth, bool) {
+	i := match.RoundIndex(curFrame)
+	if i < 0 || i >= len(match.Rounds) || match.LastFrame() < 0 {
+		return stats.RoundHealth{}, false
+	}
+	if i != roundHealthIndex {
+		roundHealth = stats.RoundHealthSeries(match, i)
+		roundHealthIndex = i
+	}
+	return roundHealth, true
+}
+
+// drawHealthPanel draws the health of all players over the current round
+// above the round strip, the lines of selected players thicker.
+func drawHealthPanel(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	health, ok := currentRoundHealth(match)
+	if !ok {
+		return
+	}
+	x := mapXOffset + (mapOverviewWidth-healthPanelWidth)/2
+	y := mapYOffset + mapOverviewHeight - roundStripHeight - healthPanelHeight - 25
+	gfx.BoxColor(renderer, x-5, y-5, x+healthPanelWidth+5, y+healthPanelHeight+serverInfoLineHeight+5, colorOverlayBackground)
+	viewer.DrawString(renderer, locale.Sprintf("health.title", health.Round), colorDarkWhite, x, y, font)
+	y += serverInfoLineHeight
+	gfx.RectangleColor(renderer, x, y, x+healthPanelWidth, y+healthPanelHeight, colorRoundUndecided)
+
+	for _, player := range health.Players {
+		color := colorCounter
+		if player.Team == demoinfo.TeamTerrorists {
+			color = colorTerror
+		}
+		thickness := int32(1)
+		if mapViewer.Selected[player.ID] {
+			thickness = 3
+		} else if len(mapViewer.Selected) > 0 {
+			color.A = 100
+		}
+		drawHealthLine(renderer, player.Health, len(player.Health)-1, x, y, healthPanelWidth, healthPanelHeight, thickness, color)
+	}
+
+	if current := health.SampleAt(curFrame); current >= 0 && health.Samples() > 1 {
+		currentX := x + int32(current)*healthPanelWidth/int32(health.Samples()-1)
+		gfx.LineColor(renderer, currentX, y, currentX, y+healthPanelHeight, colorCurrentTime)
+	}
+}
+
+// drawHealthLine draws the samples of a health series up to the index last
+// into the area at x, y with all samples spanning the width. Thin lines are
+// drawn with a single call, as the panel has hundreds of samples per player.
+func drawHealthLine(renderer *sdl.Renderer, samples []uint8, last int, x, y, width, height, thickness int32, color sdl.Color) {
+	if len(samples) < 2 {
+		return
+	}
+	if last >= len(samples) {
+		last = len(samples) - 1
+	}
+	points := make([]sdl.Point, 0, last+1)
+	for i := 0; i <= last; i++ {
+		points = append(points, sdl.Point{
+			X: x + int32(i)*width/int32(len(samples)-1),
+			Y: y + height - int32(samples[i])*height/100,
+		})
+	}
+	if thickness == 1 {
+		if len(points) > 1 {
+			renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+			renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+			renderer.DrawLines(points)
+		}
+		return
+	}
+	for i := 1; i < len(points); i++ {
+		gfx.ThickLineColor(renderer, points[i-1].X, points[i-1].Y, points[i].X, points[i].Y, thickness, color)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/linus4/csgoverview/locale"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const shiftPrefix string = "Shift+"
+
+// Step sizes in seconds of the step_backward and step_forward bindings when
+// the modifier key is held.
+const (
+	stepSizeShift float64 = 0.25
+	stepSizeCtrl  float64 = 1
+	stepSizeAlt   float64 = 5
+)
+
+// keyBinding binds an action of the viewer to a key. The help overlay is
+// generated from the bindings, the description of a binding is the
+// translation of "help.<name>".
+type keyBinding struct {
+	// name identifies the binding in the settings file.
+	name  string
+	key   sdl.Keycode
+	shift bool
+	// run is called when the key is pressed. It is nil for bindings whose
+	// key is held down, which are polled in the main loop.
+	run func(match *match.Match)
+}
+
+// keyBindings contains all bindings in the order in which they are shown in
+// the help overlay.
+var keyBindings = []keyBinding{
+	{name: "pause", key: sdl.K_SPACE, run: func(*match.Match) { paused = !paused }},
+	{name: "backward_5s", key: sdl.K_a, run: func(m *match.Match) { skipSeconds(m, -5) }},
+	{name: "forward_5s", key: sdl.K_d, run: func(m *match.Match) { skipSeconds(m, 5) }},
+	{name: "backward_10s", key: sdl.K_a, shift: true, run: func(m *match.Match) { skipSeconds(m, -10) }},
+	{name: "forward_10s", key: sdl.K_d, shift: true, run: func(m *match.Match) { skipSeconds(m, 10) }},
+	{name: "step_backward", key: sdl.K_COMMA, run: func(m *match.Match) { step(m, -1) }},
+	{name: "step_forward", key: sdl.K_PERIOD, run: func(m *match.Match) { step(m, 1) }},
+	{name: "instant_replay", key: sdl.K_b, run: instantReplay},
+	{name: "speed_up", key: sdl.K_w},
+	{name: "slow_down", key: sdl.K_s},
+	{name: "faster", key: sdl.K_EQUALS, run: func(*match.Match) { changePlaybackSpeed(2) }},
+	{name: "slower", key: sdl.K_MINUS, run: func(*match.Match) { changePlaybackSpeed(0.5) }},
+	{name: "previous_round", key: sdl.K_q, run: func(m *match.Match) { curFrame = previousStart(m.RoundStarts, m) }},
+	{name: "next_round", key: sdl.K_e, run: func(m *match.Match) { curFrame = nextStart(m.RoundStarts) }},
+	{name: "previous_half", key: sdl.K_q, shift: true, run: func(m *match.Match) { curFrame = previousStart(m.HalfStartFrames(), m) }},
+	{name: "next_half", key: sdl.K_e, shift: true, run: func(m *match.Match) { curFrame = nextStart(m.HalfStartFrames()) }},
+	{name: "round_loop", key: sdl.K_l, run: func(*match.Match) { roundLoop = !roundLoop }},
+	{name: "loop_in", key: sdl.K_LEFTBRACKET, run: func(*match.Match) { setLoopIn() }},
+	{name: "loop_out", key: sdl.K_RIGHTBRACKET, run: func(*match.Match) { setLoopOut() }},
+	{name: "clear_loop", key: sdl.K_BACKSLASH, run: func(*match.Match) { clearLoop() }},
+	{name: "next_demo", key: sdl.K_n, run: func(*match.Match) { playlistStep = 1 }},
+	{name: "previous_demo", key: sdl.K_n, shift: true, run: func(*match.Match) { playlistStep = -1 }},
+	{name: "toggle_bookmark", key: sdl.K_k, run: func(*match.Match) { toggleBookmark() }},
+	{name: "next_bookmark", key: sdl.K_g, run: nextBookmark},
+	{name: "previous_bookmark", key: sdl.K_g, shift: true, run: previousBookmark},
+	{name: "bookmark_note", key: sdl.K_m, run: editBookmarkNote},
+	{name: "round_note", key: sdl.K_m, shift: true, run: editRoundNote},
+	{name: "undo", key: sdl.K_z, run: func(*match.Match) { undoReview() }},
+	{name: "redo", key: sdl.K_z, shift: true, run: func(*match.Match) { redoReview() }},
+	{name: "next_plant", key: sdl.K_p, run: nextBombPlant},
+	{name: "previous_plant", key: sdl.K_p, shift: true, run: previousBombPlant},
+	{name: "afterplant_site", key: sdl.K_o, run: func(*match.Match) { cycleAfterplantSite() }},
+	{name: "zones", key: sdl.K_o, shift: true, run: func(*match.Match) { zones = !zones }},
+	{name: "awp_overlay", key: sdl.K_x, run: func(*match.Match) { awpOverlay = !awpOverlay }},
+	{name: "crossfire", key: sdl.K_x, shift: true, run: func(*match.Match) { crossfire = !crossfire }},
+	{name: "economy", key: sdl.K_b, shift: true, run: func(*match.Match) { economyPanel = !economyPanel }},
+	{name: "server_info", key: sdl.K_i, run: func(*match.Match) { serverInfo = !serverInfo }},
+	{name: "health_graphs", key: sdl.K_h, shift: true, run: func(*match.Match) { healthGraphs = !healthGraphs }},
+	{name: "pov_panel", key: sdl.K_i, shift: true, run: func(*match.Match) { povPanel = !povPanel }},
+	{name: "inferno_extents", key: sdl.K_f, run: func(*match.Match) { infernoExtents = !infernoExtents }},
+	{name: "altitude_shading", key: sdl.K_y, run: func(*match.Match) { altitudeShading = !altitudeShading }},
+	{name: "sound_overlay", key: sdl.K_u, run: func(*match.Match) { soundOverlay = !soundOverlay }},
+	{name: "entry_paths", key: sdl.K_t, run: func(*match.Match) { entryPaths = !entryPaths }},
+	{name: "smoke_kills", key: sdl.K_SEMICOLON, run: func(*match.Match) { smokeKills = !smokeKills }},
+	{name: "round_strip", key: sdl.K_r, run: func(*match.Match) { roundStrip = !roundStrip }},
+	{name: "clear_selection", key: sdl.K_c, run: func(*match.Match) { mapViewer.ClearSelection() }},
+	{name: "clear_ghost", key: sdl.K_c, shift: true, run: func(*match.Match) { ghost = nil }},
+	{name: "measure", key: sdl.K_F10, run: func(*match.Match) { toggleMeasuring() }},
+	{name: "spawn_timing", key: sdl.K_F11, run: func(*match.Match) { toggleSpawnTiming() }},
+	{name: "line_of_sight", key: sdl.K_v, shift: true, run: func(*match.Match) { lineOfSight = !lineOfSight }},
+	{name: "map_section", key: sdl.K_v, run: func(*match.Match) { mapViewer.CycleSection() }},
+	{name: "calibrate", key: sdl.K_F9, run: startCalibration},
+	{name: "search", key: sdl.K_SLASH, run: startSearch},
+	{name: "next_result", key: sdl.K_j, run: nextSearchResult},
+	{name: "previous_result", key: sdl.K_j, shift: true, run: previousSearchResult},
+	{name: "screenshot", key: sdl.K_F12, run: func(*match.Match) { screenshotRequested = true }},
+	{name: "help", key: sdl.K_h, run: func(*match.Match) { helpOverlay = !helpOverlay }},
+	layerBinding(common.LayerShots, sdl.K_F1),
+	layerBinding(common.LayerGrenadeEffects, sdl.K_F2),
+	layerBinding(common.LayerKillfeed, sdl.K_F3),
+	layerBinding(common.LayerGrenades, sdl.K_F4),
+	layerBinding(common.LayerInfernos, sdl.K_F5),
+	layerBinding(common.LayerBomb, sdl.K_F6),
+	layerBinding(common.LayerDeadPlayers, sdl.K_F7),
+	layerBinding(common.LayerDroppedWeapons, sdl.K_F8),
+}
+
+// layerBinding returns a binding named "layer_<layer>" that shows or hides
+// the layer.
+func layerBinding(layer common.Layer, key sdl.Keycode) keyBinding {
+	return keyBinding{
+		name: "layer_" + layer.String(),
+		key:  key,
+		run:  func(*match.Match) { hiddenLayers.Toggle(layer) },
+	}
+}
+
+// bindingFor returns the binding for the pressed key. A binding without shift
+// also matches if shift is pressed, unless the key has its own binding with
+// shift.
+func bindingFor(key sdl.Keycode, shift bool) (keyBinding, bool) {
+	var fallback keyBinding
+	var found bool
+	for _, binding := range keyBindings {
+		if binding.key != key {
+			continue
+		}
+		if binding.shift == shift {
+			return binding, true
+		}
+		if !binding.shift {
+			fallback, found = binding, true
+		}
+	}
+	return fallback, found
+}
+
+// isBindingHeld reports whether the key of the binding with the given name is
+// held down.
+func isBindingHeld(keyboardState []uint8, name string) bool {
+	for _, binding := range keyBindings {
+		if binding.name != name {
+			continue
+		}
+		if binding.shift && sdl.GetModState()&sdl.KMOD_SHIFT == 0 {
+			return false
+		}
+		return keyboardState[sdl.GetScancodeFromKey(binding.key)] != 0
+	}
+	return false
+}
+
+// applyKeyBindings overrides the default bindings with the custom bindings
+// from the settings file, which map the name of a binding to a key name like
+// "F5" or "Shift+P".
+func applyKeyBindings(custom map[string]string) {
+	for name, keyName := range custom {
+		key, shift, err := parseKeyName(keyName)
+		if err != nil {
+			log.Printf("trying to bind %v: %v", name, err)
+			continue
+		}
+		found := false
+		for i := range keyBindings {
+			if keyBindings[i].name == name {
+				keyBindings[i].key = key
+				keyBindings[i].shift = shift
+				found = true
+			}
+		}
+		if !found {
+			log.Printf("trying to bind %v: unknown action", name)
+		}
+	}
+}
+
+func parseKeyName(keyName string) (sdl.Keycode, bool, error) {
+	shift := strings.HasPrefix(keyName, shiftPrefix)
+	key := sdl.GetKeyFromName(strings.TrimPrefix(keyName, shiftPrefix))
+	if key == sdl.K_UNKNOWN {
+		return key, shift, fmt.Errorf("unknown key %q", keyName)
+	}
+	return key, shift, nil
+}
+
+// keyName returns the name of the key of the binding as it is shown in the
+// help overlay and accepted in the settings file.
+func (b keyBinding) keyName() string {
+	name := sdl.GetKeyName(b.key)
+	if b.shift {
+		return shiftPrefix + name
+	}
+	return name
+}
+
+// helpLines returns the lines of the help overlay.
+func helpLines() []string {
+	lines := make([]string, 0, len(keyBindings)+1)
+	for _, binding := range keyBindings {
+		lines = append(lines, fmt.Sprintf("%-10s %s", binding.keyName(), locale.T("help."+binding.name)))
+	}
+	lines = append(lines, fmt.Sprintf("%-10s %s", locale.T("help.mouse_wheel_key"), locale.T("help.mouse_wheel")))
+	return lines
+}
+
+func skipSeconds(match *match.Match, seconds int) {
+	seek(match, curFrame+match.FrameRateRounded*seconds)
+}
+
+// seek sets the current frame, clamped to the frames of the demo.
+func seek(match *match.Match, frame int) {
+	if frame > match.LastFrame() {
+		frame = match.LastFrame()
+	}
+	if frame < 0 {
+		frame = 0
+	}
+	curFrame = frame
+}
+
+// step pauses the playback and moves it by a step in the given direction. The
+// size of the step depends on the held modifier keys: a single frame without
+// modifier, stepSizeShift with shift, stepSizeCtrl with ctrl and stepSizeAlt
+// with alt.
+func step(match *match.Match, direction int) {
+	paused = true
+	mod := sdl.GetModState()
+	var seconds float64
+	switch {
+	case mod&sdl.KMOD_ALT != 0:
+		seconds = stepSizeAlt
+	case mod&sdl.KMOD_CTRL != 0:
+		seconds = stepSizeCtrl
+	case mod&sdl.KMOD_SHIFT != 0:
+		seconds = stepSizeShift
+	default:
+		seek(match, curFrame+direction)
+		return
+	}
+	frames := int(math.Round(seconds * match.FrameRate))
+	if frames < 1 {
+		frames = 1
+	}
+	seek(match, curFrame+direction*frames)
+}
+
+// instantReplay jumps back replaySeconds and plays the part up to the current
+// frame again at replaySpeed.
+func instantReplay(match *match.Match) {
+	end := curFrame
+	if replayEndFrame > end {
+		// keep slowing down until the end of the running replay
+		end = replayEndFrame
+	}
+	skipSeconds(match, -replaySeconds)
+	replayEndFrame = end
+	paused = false
+}
+
+func changePlaybackSpeed(factor float64) {
+	speed := playbackSpeed * factor
+	if speed >= minPlaybackSpeed && speed <= maxPlaybackSpeed {
+		playbackSpeed = speed
+	}
+}
+
+func cycleAfterplantSite() {
+	switch afterplantSite {
+	case "":
+		afterplantSite = "A"
+	case "A":
+		afterplantSite = "B"
+	default:
+		afterplantSite = ""
+	}
+}
+
+func nextBombPlant(match *match.Match) {
+	for _, plant := range match.BombPlants {
+		if plant.Frame > curFrame && (afterplantSite == "" || plant.Site == afterplantSite) {
+			curFrame = plant.Frame
+			break
+		}
+	}
+}
+
+func previousBombPlant(match *match.Match) {
+	for i := len(match.BombPlants) - 1; i >= 0; i-- {
+		plant := match.BombPlants[i]
+		if plant.Frame < curFrame && (afterplantSite == "" || plant.Site == afterplantSite) {
+			curFrame = plant.Frame
+			break
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/viewer"
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// exportKillShots draws the map at every kill of the demo, or c.KillShotsBefore
+// seconds before it, and saves it as PNG file in c.KillShotsDir. No window is
+// opened, the overview is drawn into a surface by a software renderer.
+func exportKillShots(c *Config, demoFileName string) error {
+	m, err := match.NewMatch(demoFileName, c.FrameRate, c.TickRate)
+	if err != nil {
+		return err
+	}
+	if c.SmoothGaps {
+		m.SmoothGaps()
+	}
+	if c.Quantize {
+		m.Quantize()
+	}
+	err = os.MkdirAll(c.KillShotsDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, viewer.OverviewSize, viewer.OverviewSize, 32, sdl.PIXELFORMAT_ARGB8888)
+	if err != nil {
+		return fmt.Errorf("trying to create surface: %v", err)
+	}
+	defer surface.Free()
+	renderer, err := sdl.CreateSoftwareRenderer(surface)
+	if err != nil {
+		return fmt.Errorf("trying to create renderer: %v", err)
+	}
+	defer renderer.Destroy()
+	defer viewer.ClearTextCache()
+
+	killViewer := viewer.New(m, renderer)
+	defer killViewer.Destroy()
+	err = killViewer.LoadOverview(c.OverviewDir)
+	if err != nil {
+		return err
+	}
+	// without a font the names of the players are left out
+	err = ttf.Init()
+	if err != nil {
+		log.Println("trying to initialize fonts:", err)
+	} else {
+		defer ttf.Quit()
+		font, err := openFont(c.FontPath, nameMapFontSize)
+		if err != nil && c.FontPath != "" {
+			font, err = openFont("", nameMapFontSize)
+		}
+		if err != nil {
+			log.Println("trying to open font:", err)
+		} else {
+			defer font.Close()
+			font.SetStyle(ttf.STYLE_BOLD)
+			killViewer.SetFont(font)
+		}
+	}
+
+	before := int(c.KillShotsBefore * m.FrameRate)
+	for _, kill := range m.Kills {
+		frame := kill.Frame - before
+		if frame < 0 {
+			frame = 0
+		}
+		if frame > m.LastFrame() {
+			frame = m.LastFrame()
+		}
+		killViewer.Frame = frame
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		killViewer.Draw()
+
+		round := m.RoundIndex(kill.Frame)
+		var roundTime float64
+		if round >= 0 && round < len(m.Rounds) {
+			roundTime = (m.TimeAt(kill.Frame) - m.TimeAt(m.Rounds[round].StartFrame)).Seconds()
+		}
+		killer := "world"
+		if kill.HasKiller() {
+			killer = kill.KillerName
+		}
+		fileName := fmt.Sprintf("round%02d_%dm%02ds_%s_%s.png", round+1, int(roundTime)/60, int(roundTime)%60,
+			fileNamePart(killer), fileNamePart(kill.VictimName))
+		err = img.SavePNG(surface, filepath.Join(c.KillShotsDir, fileName))
+		if err != nil {
+			return fmt.Errorf("trying to save kill shot %v: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+// fileNamePart replaces all characters of a player name that are not letters
+// or digits with underscores so that it can be used in file names.
+func fileNamePart(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+var (
+	// roundLoop is true if the current round is played in a loop from the end
+	// of its freezetime until it is decided.
+	roundLoop bool
+	// loopIn and loopOut are the frames set by the user between which the
+	// playback loops, or -1 if they are not set.
+	loopIn  = -1
+	loopOut = -1
+)
+
+// loopStartEnd returns the frames between which the playback loops. A loop
+// between custom markers takes precedence over the round loop. ok is false if
+// no loop is active at the current frame.
+func loopStartEnd(match *match.Match) (start, end int, ok bool) {
+	if hasCustomLoop() {
+		return loopIn, loopOut, true
+	}
+	if !roundLoop {
+		return 0, 0, false
+	}
+	i := match.RoundIndex(curFrame)
+	if i < 0 || i >= len(match.Rounds) || match.Rounds[i].EndFrame < 0 {
+		return 0, 0, false
+	}
+	round := match.Rounds[i]
+	start = round.FreezetimeEndFrame
+	if start < 0 {
+		start = round.StartFrame
+	}
+	return start, round.EndFrame, true
+}
+
+// applyLoop moves the playback back to the start of the loop once it reached
+// the end of the loop.
+func applyLoop(match *match.Match) {
+	start, end, ok := loopStartEnd(match)
+	if ok && curFrame >= end {
+		curFrame = start
+	}
+}
+
+// hasCustomLoop reports whether both markers are set and the out point is
+// after the in point.
+func hasCustomLoop() bool {
+	return loopIn >= 0 && loopOut > loopIn
+}
+
+// setLoopIn sets the in point to the current frame. An out point before the
+// new in point is removed.
+func setLoopIn() {
+	loopIn = curFrame
+	if loopOut <= loopIn {
+		loopOut = -1
+	}
+}
+
+// setLoopOut sets the out point to the current frame. An in point after the
+// new out point is removed.
+func setLoopOut() {
+	loopOut = curFrame
+	if loopIn >= loopOut {
+		loopIn = -1
+	}
+}
+
+func clearLoop() {
+	loopIn, loopOut = -1, -1
+}
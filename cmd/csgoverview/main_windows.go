@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	conf := DefaultConfig
+	flag.Float64Var(&conf.FrameRate, "framerate", conf.FrameRate, "Fallback GOTV Framerate")
+	flag.Float64Var(&conf.TickRate, "tickrate", conf.TickRate, "Fallback Gameserver Tickrate")
+	flag.BoolVar(&conf.Stats, "stats", conf.Stats, "Print the analysis of the demo instead of opening the viewer")
+	flag.StringVar(&conf.ExportDir, "export", conf.ExportDir, "Export CSV files and a JSON report to this directory instead of opening the viewer")
+	flag.StringVar(&conf.CampathFile, "campath", conf.CampathFile, "Export the view of -campath-player in -campath-round as HLAE campath to this file")
+	flag.Uint64Var(&conf.CampathPlayer, "campath-player", conf.CampathPlayer, "SteamID64 of the player whose view is exported as campath")
+	flag.IntVar(&conf.CampathRound, "campath-round", conf.CampathRound, "Number of the round that is exported as campath")
+	flag.StringVar(&conf.SteamAPIKey, "steam-key", conf.SteamAPIKey, "Steam Web API key to fetch avatars, names and VAC status of the players (falls back to $STEAM_API_KEY)")
+	flag.StringVar(&conf.LiquipediaAPIKey, "liquipedia-key", conf.LiquipediaAPIKey, "Liquipedia API key to look up the event and bracket stage of the match (falls back to $LIQUIPEDIA_API_KEY)")
+	flag.BoolVar(&conf.SmoothGaps, "smooth-gaps", conf.SmoothGaps, "Interpolate the positions of the players across short gaps of the demo, e.g. when the GOTV server lagged")
+	flag.BoolVar(&conf.EventsOnly, "events-only", conf.EventsOnly, "Only parse the events of the demo for -stats and -export, which is faster but leaves out positional analyses")
+	flag.StringVar(&conf.ProfileDir, "profile", conf.ProfileDir, "Write CPU and heap profiles (pprof) to this directory")
+	flag.StringVar(&conf.ServeAddr, "serve-recording", conf.ServeAddr, "Follow the demo file while it is being recorded (tv_record) and serve its states as JSON or Protobuf over WebSockets on this address")
+	flag.StringVar(&conf.ServeBroadcastAddr, "serve-broadcast", conf.ServeBroadcastAddr, "Join the GOTV broadcast (tv_broadcast_url) at the URL given instead of a demo and serve its states as JSON or Protobuf over WebSockets on this address")
+	flag.StringVar(&conf.WebhookURL, "webhook", conf.WebhookURL, "Post the events of the match served with -serve-recording or -serve-broadcast as JSON to this URL, e.g. a Discord webhook")
+	flag.StringVar(&conf.WebhookEvents, "webhook-events", conf.WebhookEvents, "Comma separated events that are posted to -webhook: round_end, bomb_plant and match_end (default all)")
+	flag.StringVar(&conf.AllowedOrigins, "allowed-origins", conf.AllowedOrigins, "Comma separated origins of pages on other hosts that may connect to the WebSocket of -serve-recording or -serve-broadcast, e.g. http://overlay:3000, or * for all")
+	flag.StringVar(&conf.ServeDemoDir, "serve-demos", conf.ServeDemoDir, "Parse the recorded demos in this directory on request and serve their reports with -serve-recording or -serve-broadcast under /demos/")
+	flag.IntVar(&conf.CacheSize, "cache-size", conf.CacheSize, "Memory in MB that the demos parsed for -serve-demos may take up before the least recently used ones are evicted")
+	flag.BoolVar(&conf.IncludeKnifeRounds, "knife-rounds", conf.IncludeKnifeRounds, "Take knife rounds into account in the analysis")
+	flag.StringVar(&conf.RecordSession, "record-session", conf.RecordSession, "Record seeks, pauses, speed changes and bookmarks of the review to this file")
+	flag.StringVar(&conf.PlaySession, "play-session", conf.PlaySession, "Replay the review session recorded with -record-session (opens its demo if none is given)")
+	flag.StringVar(&conf.Proxy, "proxy", conf.Proxy, "Proxy for Steam, Liquipedia and HTTP demo streams, e.g. socks5://localhost:1080 (defaults to $HTTPS_PROXY and $HTTP_PROXY)")
+	flag.StringVar(&conf.MapConfig, "map-config", conf.MapConfig, "JSON file with the overview positions of additional maps (defaults to maps.json in the overview directory)")
+	flag.StringVar(&conf.Search, "search", conf.Search, "Export the events that match this query to search.csv, e.g. \"kill weapon:awp player:s1mple area:A\" (with -export)")
+	flag.StringVar(&conf.Lineups, "lineups", conf.Lineups, "Export the grenade throws that match this query as practice config lineups.cfg and lineups.json, e.g. \"weapon:smokegrenade team:T\" (with -export)")
+	flag.StringVar(&conf.KillShotsDir, "kill-shots", conf.KillShotsDir, "Save a PNG of the map at every kill to this directory instead of opening the viewer")
+	flag.Float64Var(&conf.KillShotsBefore, "kill-shots-before", conf.KillShotsBefore, "Number of seconds before each kill at which the map is saved with -kill-shots")
+	flag.IntVar(&conf.FPS, "fps", conf.FPS, "Frames drawn per second (defaults to the refresh rate of the display)")
+	flag.BoolVar(&conf.SoftwareRenderer, "software-renderer", conf.SoftwareRenderer, "Draw with the CPU instead of the GPU, e.g. if the overview is drawn incorrectly")
+	flag.BoolVar(&conf.Quantize, "quantize", conf.Quantize, "Store the positions of the players rounded to whole units, which needs a fraction of the memory for long demos and -serve-demos")
+	flag.IntVar(&conf.PrefetchSize, "prefetch-size", conf.PrefetchSize, "Memory in MB that the next demo of the playlist or the demo selected in the picker, parsed in the background, may take up (0 disables parsing ahead)")
+	flag.BoolVar(&conf.Associate, "associate", conf.Associate, "Register csgoverview as the program that opens .dem files for the current user and exit")
+	flag.StringVar(&conf.Language, "lang", conf.Language, "Language of the user interface, e.g. pt_BR (defaults to $LANG)")
+	userHomeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalln("trying to get user home directory:", err)
+	}
+	defaultOverviewDirectory := fmt.Sprintf("%v\\csgoverview\\", userHomeDir)
+	flag.StringVar(&conf.FontPath, "fontpath", conf.FontPath, "Path to font file (.ttf), defaults to the built-in DejaVu Sans")
+	flag.StringVar(&conf.OverviewDir, "overviewdir", defaultOverviewDirectory, "Path to overview directory")
+	flag.StringVar(&conf.DemoDir, "demodir", conf.DemoDir, "Directory whose demos are listed if no demo is given (defaults to the directory of the last opened demo)")
+	flag.Parse()
+
+	err = run(&conf)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// fileDialogScript shows the native file dialog of Windows and prints the
+// selected file.
+const fileDialogScript = `Add-Type -AssemblyName System.Windows.Forms
+$dialog = New-Object System.Windows.Forms.OpenFileDialog
+$dialog.Filter = 'CS:GO demos (*.dem, *.zip, *.gz, *.bz2)|*.dem;*.zip;*.gz;*.bz2'
+if ($dialog.ShowDialog() -eq 'OK') { $dialog.FileName }`
+
+// openFileDialog lets the user select a demo with the native file dialog.
+func openFileDialog() (string, error) {
+	demoFileNameB, err := exec.Command("powershell", "-NoProfile", "-Command", fileDialogScript).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(demoFileNameB)), nil
+}
+
+// demoProgID is the programmatic identifier that csgoverview registers for
+// demos.
+const demoProgID = "csgoverview.demo"
+
+// associateDemos registers csgoverview as the program that opens .dem files
+// in HKEY_CURRENT_USER, so no administrator rights are needed. The demos are
+// also listed in 'Open with' afterwards.
+func associateDemos() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	classes := `HKCU\Software\Classes\`
+	values := []struct {
+		key, name, data string
+	}{
+		{classes + demoProgID, "", "CS:GO demo"},
+		{classes + demoProgID + `\DefaultIcon`, "", exe + ",0"},
+		{classes + demoProgID + `\shell\open\command`, "", fmt.Sprintf(`"%v" "%%1"`, exe)},
+		{classes + `.dem`, "", demoProgID},
+		{classes + `.dem\OpenWithProgids`, demoProgID, ""},
+	}
+	for _, v := range values {
+		args := []string{"add", v.key, "/f", "/d", v.data}
+		if v.name == "" {
+			args = append(args, "/ve")
+		} else {
+			args = append(args, "/v", v.name)
+		}
+		output, err := exec.Command("reg", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("trying to set %v: %v: %s", v.key, err, bytes.TrimSpace(output))
+		}
+	}
+
+	// the explorer only picks up the new association once it is notified
+	shChangeNotify := syscall.NewLazyDLL("shell32.dll").NewProc("SHChangeNotify")
+	shChangeNotify.Call(shcneAssocChanged, shcnfIDList, 0, 0)
+	return nil
+}
+
+// Parameters of SHChangeNotify.
+const (
+	shcneAssocChanged = 0x08000000
+	shcnfIDList       = 0
+)
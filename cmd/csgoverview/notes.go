@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/linus4/csgoverview/pkg/export"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var (
+	// editingNote is true while the reviewer types a note. Key bindings are
+	// disabled in the meantime.
+	editingNote bool
+	noteText    string
+	// noteRound is the number of the round whose note is edited or 0 if the
+	// note of the bookmark at noteFrame is edited.
+	noteRound int
+	noteFrame int
+)
+
+// editBookmarkNote starts editing the note of the bookmark at the current
+// frame. The bookmark is added when the note is saved if it does not exist.
+func editBookmarkNote(*match.Match) {
+	noteRound = 0
+	noteFrame = curFrame
+	noteText = ""
+	for _, b := range review.Bookmarks {
+		if b.Frame == curFrame {
+			noteText = b.Note
+		}
+	}
+	startEditingNote()
+}
+
+// editRoundNote starts editing the note of the current round.
+func editRoundNote(match *match.Match) {
+	i := match.RoundIndex(curFrame)
+	if i < 0 || i >= len(match.Rounds) {
+		return
+	}
+	noteRound = match.Rounds[i].Number
+	noteText = review.RoundNotes[noteRound]
+	startEditingNote()
+}
+
+func startEditingNote() {
+	editingNote = true
+	paused = true
+	sdl.StartTextInput()
+}
+
+func stopEditingNote() {
+	editingNote = false
+	sdl.StopTextInput()
+}
+
+// saveNote stores the edited note. An empty note removes the note of a round,
+// the bookmark of an empty note is kept.
+func saveNote() {
+	text := strings.TrimSpace(noteText)
+	changeReview(func(notes *reviewNotes) {
+		if noteRound > 0 {
+			if text == "" {
+				delete(notes.RoundNotes, noteRound)
+				return
+			}
+			if notes.RoundNotes == nil {
+				notes.RoundNotes = make(map[int]string)
+			}
+			notes.RoundNotes[noteRound] = text
+			return
+		}
+		for i := range notes.Bookmarks {
+			if notes.Bookmarks[i].Frame == noteFrame {
+				notes.Bookmarks[i].Note = text
+				return
+			}
+		}
+		notes.Bookmarks = append(notes.Bookmarks, bookmark{Frame: noteFrame, Note: text})
+	})
+}
+
+// handleNoteKeyboardEvents edits the note while it is typed. Return starts a
+// new line, ctrl+return saves the note and escape discards the changes.
+func handleNoteKeyboardEvents(eventT *sdl.KeyboardEvent) {
+	if eventT.Type != sdl.KEYDOWN {
+		return
+	}
+	switch eventT.Keysym.Sym {
+	case sdl.K_RETURN:
+		if eventT.Keysym.Mod&sdl.KMOD_CTRL != 0 {
+			saveNote()
+			stopEditingNote()
+		} else {
+			noteText += "\n"
+		}
+	case sdl.K_BACKSPACE:
+		if runes := []rune(noteText); len(runes) > 0 {
+			noteText = string(runes[:len(runes)-1])
+		}
+	case sdl.K_ESCAPE:
+		stopEditingNote()
+	}
+}
+
+// reportNotes returns the notes of the review file for the match report.
+func reportNotes(match *match.Match) []export.Note {
+	notes := make([]export.Note, 0)
+	for i, round := range match.Rounds {
+		if text, ok := review.RoundNotes[round.Number]; ok {
+			notes = append(notes, export.Note{Round: round.Number, Frame: round.StartFrame, Time: match.TimeAt(round.StartFrame), Text: text})
+		}
+		end := match.TotalFrames()
+		if i+1 < len(match.Rounds) {
+			end = match.Rounds[i+1].StartFrame
+		}
+		for _, b := range review.Bookmarks {
+			if b.Note != "" && b.Frame >= round.StartFrame && b.Frame < end {
+				notes = append(notes, export.Note{Round: round.Number, Frame: b.Frame, Time: match.TimeAt(b.Frame), Text: b.Note})
+			}
+		}
+	}
+	return notes
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	// defaultRefreshRate is used if the refresh rate of the display is
+	// unknown.
+	defaultRefreshRate = 60
+	// maxFrameTime is the longest time the playback advances at once, so that
+	// it does not jump ahead after the loop was blocked, e.g. by opening a
+	// demo.
+	maxFrameTime = 250 * time.Millisecond
+)
+
+var (
+	// playbackElapsed is the time that was played back since curFrame was
+	// reached, already multiplied by the speed.
+	playbackElapsed time.Duration
+	// drawInterval is the time between two iterations of the main loop.
+	drawInterval = time.Second / defaultRefreshRate
+)
+
+// updateDrawInterval sets the time between two drawn frames to fps or, if it
+// is not positive, to the refresh rate of the display the window is on.
+func updateDrawInterval(window *sdl.Window, fps int) {
+	if fps > 0 {
+		drawInterval = time.Second / time.Duration(fps)
+		return
+	}
+	drawInterval = time.Second / defaultRefreshRate
+	index, err := window.GetDisplayIndex()
+	if err != nil {
+		return
+	}
+	mode, err := sdl.GetCurrentDisplayMode(index)
+	if err != nil || mode.RefreshRate <= 0 {
+		return
+	}
+	drawInterval = time.Second / time.Duration(mode.RefreshRate)
+}
+
+// advancePlayback moves curFrame forward by the frames of the demo that are
+// played in elapsed time at the given speed. The rest of a frame is kept
+// for the next call, so the playback runs at the same speed no matter how
+// often it is drawn.
+func advancePlayback(match *match.Match, elapsed time.Duration, speed float64) {
+	if match.FrameRate <= 0 {
+		return
+	}
+	if elapsed > maxFrameTime {
+		elapsed = maxFrameTime
+	}
+	playbackElapsed += time.Duration(float64(elapsed) * speed)
+	frameDuration := time.Duration(float64(time.Second) / match.FrameRate)
+	frames := int(playbackElapsed / frameDuration)
+	playbackElapsed -= time.Duration(frames) * frameDuration
+	curFrame += frames
+	if curFrame >= match.LastFrame() {
+		curFrame = match.LastFrame()
+		playbackElapsed = 0
+	}
+}
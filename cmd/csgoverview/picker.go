@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/viewer"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	pickerLineHeight int32 = 20
+	pickerMargin     int32 = 20
+)
+
+type demoFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// listDemos returns all demos in dir, the newest first.
+func listDemos(dir string) ([]demoFile, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var demos []demoFile
+	for _, info := range infos {
+		if info.IsDir() || !match.IsDemoFile(info.Name()) {
+			continue
+		}
+		demos = append(demos, demoFile{
+			path:    filepath.Join(dir, info.Name()),
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		})
+	}
+	sort.Slice(demos, func(i, j int) bool { return demos[i].modTime.After(demos[j].modTime) })
+	return demos, nil
+}
+
+// pickDemo shows the demos in dir and lets the user choose one with the arrow
+// keys and enter or with a double click. It returns an empty string if the
+// window was closed. The selected demo is parsed in the background while the
+// user decides.
+func pickDemo(renderer *sdl.Renderer, window *sdl.Window, font *ttf.Font, dir string, c *Config) (string, error) {
+	demos, err := listDemos(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(demos) == 0 {
+		return "", errors.New(locale.Sprintf("picker.no_demos", dir))
+	}
+	window.SetTitle(locale.T("picker.title"))
+
+	visibleLines := int((mapOverviewHeight+mapYOffset-3*pickerMargin)/pickerLineHeight) - 1
+	selected := 0
+	offset := 0
+	lastSelected := -1
+	var selectedSince time.Time
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch eventT := event.(type) {
+			case *sdl.QuitEvent:
+				return "", nil
+
+			case *sdl.DropEvent:
+				if eventT.Type == sdl.DROPFILE {
+					return eventT.File, nil
+				}
+
+			case *sdl.KeyboardEvent:
+				if eventT.Type != sdl.KEYDOWN {
+					break
+				}
+				switch eventT.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					return "", nil
+				case sdl.K_RETURN:
+					return demos[selected].path, nil
+				case sdl.K_UP:
+					selected--
+				case sdl.K_DOWN:
+					selected++
+				case sdl.K_PAGEUP:
+					selected -= visibleLines
+				case sdl.K_PAGEDOWN:
+					selected += visibleLines
+				}
+
+			case *sdl.MouseButtonEvent:
+				if eventT.Type != sdl.MOUSEBUTTONDOWN || eventT.Button != sdl.BUTTON_LEFT {
+					break
+				}
+				line := int((eventT.Y - 2*pickerMargin) / pickerLineHeight)
+				if eventT.Y < 2*pickerMargin || line >= visibleLines || offset+line >= len(demos) {
+					break
+				}
+				selected = offset + line
+				if eventT.Clicks >= 2 {
+					return demos[selected].path, nil
+				}
+
+			case *sdl.MouseWheelEvent:
+				selected -= int(eventT.Y)
+			}
+		}
+
+		if selected < 0 {
+			selected = 0
+		}
+		if selected >= len(demos) {
+			selected = len(demos) - 1
+		}
+		if selected < offset {
+			offset = selected
+		}
+		if selected >= offset+visibleLines {
+			offset = selected - visibleLines + 1
+		}
+		if selected != lastSelected {
+			lastSelected = selected
+			selectedSince = time.Now()
+		} else if time.Since(selectedSince) >= pickerPrefetchDelay {
+			prefetchDemo(c, demos[selected].path)
+		}
+
+		drawPicker(renderer, font, dir, demos, selected, offset, visibleLines)
+		sdl.Delay(16)
+	}
+}
+
+func drawPicker(renderer *sdl.Renderer, font *ttf.Font, dir string, demos []demoFile, selected, offset, visibleLines int) {
+	renderer.SetDrawColor(10, 10, 10, 255)
+	renderer.Clear()
+
+	header := locale.Sprintf("picker.header", dir)
+	viewer.DrawString(renderer, header, colorDarkWhite, pickerMargin, pickerMargin/2, font)
+	for line := 0; line < visibleLines && offset+line < len(demos); line++ {
+		demo := demos[offset+line]
+		y := 2*pickerMargin + int32(line)*pickerLineHeight
+		color := colorDarkWhite
+		if offset+line == selected {
+			renderer.SetDrawColor(60, 60, 60, 255)
+			renderer.FillRect(&sdl.Rect{X: pickerMargin / 2, Y: y - 2, W: mapOverviewWidth + 2*mapXOffset - pickerMargin, H: pickerLineHeight})
+			color = colorCounter
+		}
+		text := fmt.Sprintf("%-60s %s  %4d MB", cropStringToN(filepath.Base(demo.path), 60),
+			demo.modTime.Format("2006-01-02 15:04"), demo.size/(1<<20))
+		viewer.DrawString(renderer, text, color, pickerMargin, y, font)
+	}
+
+	renderer.Present()
+}
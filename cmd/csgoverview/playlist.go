@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// playlist contains the demos that can be reviewed one after another
+	// without restarting the viewer.
+	playlist      []string
+	playlistIndex int
+	// playlistStep is set by the key bindings to move to another demo of the
+	// playlist. The demo is loaded in the main loop.
+	playlistStep int
+)
+
+// isPlaylistFile reports whether the file is a playlist instead of a demo.
+func isPlaylistFile(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".txt", ".m3u", ".m3u8":
+		return true
+	}
+	return false
+}
+
+// expandPlaylist returns the demos of the command-line arguments. Arguments
+// that are playlist files are replaced by the demos they list.
+func expandPlaylist(args []string) ([]string, error) {
+	demos := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !isPlaylistFile(arg) {
+			demos = append(demos, arg)
+			continue
+		}
+		listed, err := readPlaylist(arg)
+		if err != nil {
+			return nil, err
+		}
+		demos = append(demos, listed...)
+	}
+	return demos, nil
+}
+
+// readPlaylist reads a playlist file with one demo per line. Empty lines and
+// lines starting with # are skipped, relative paths are relative to the
+// directory of the playlist.
+func readPlaylist(fileName string) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var demos []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(fileName), line)
+		}
+		demos = append(demos, line)
+	}
+	return demos, scanner.Err()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/pkg/assets"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/viewer"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	povPanelWidth   int32 = 300
+	povGraphHeight  int32 = 60
+	povRecentEvents int   = 5
+)
+
+var colorHealthGraph = sdl.Color{120, 220, 80, 255}
+
+// povPlayer returns the selected player whose stats the POV panel shows. If
+// several players are selected, it is the one with the lowest SteamID.
+func povPlayer(match *match.Match) (common.Player, bool) {
+	players := make([]common.Player, 0)
+	for _, player := range match.StateAtFrame(curFrame).Players {
+		if mapViewer.Selected[player.ID] {
+			players = append(players, player)
+		}
+	}
+	if len(players) == 0 {
+		return common.Player{}, false
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].SteamID64 < players[j].SteamID64 })
+	return players[0], true
+}
+
+// drawPOVPanel draws a panel at the top right of the map with the health of
+// the selected player over the current round, their money, weapon, flash and
+// their latest kills and death in the round.
+func drawPOVPanel(renderer *sdl.Renderer, match *match.Match, font *ttf.Font) {
+	x := mapXOffset + mapOverviewWidth - povPanelWidth - 10
+	y := mapYOffset + 10
+	player, ok := povPlayer(match)
+	if !ok {
+		gfx.BoxColor(renderer, x-5, y-5, x+povPanelWidth, y+serverInfoLineHeight+5, colorOverlayBackground)
+		viewer.DrawString(renderer, locale.T("pov.hint"), colorDarkWhite, x, y, font)
+		return
+	}
+
+	lines := []string{
+		fmt.Sprintf("%v $   %v", player.Money, weaponName(player.ActiveWeapon)),
+	}
+	if player.FlashTimeRemaining > 0 {
+		lines = append(lines, locale.Sprintf("pov.flashed", player.FlashTimeRemaining.Seconds()))
+	}
+	if round := match.RoundIndex(curFrame); round >= 0 && round < len(match.Rounds) {
+		lines = append(lines, povEvents(match, player.SteamID64, match.Rounds[round].StartFrame)...)
+	}
+
+	height := serverInfoLineHeight*int32(len(lines)+1) + povGraphHeight + 10
+	gfx.BoxColor(renderer, x-5, y-5, x+povPanelWidth, y+height+5, colorOverlayBackground)
+	color := colorCounter
+	if player.Team == demoinfo.TeamTerrorists {
+		color = colorTerror
+	}
+	viewer.DrawString(renderer, fmt.Sprintf("%v   %v HP", cropStringToN(player.Name, 20), player.Health), color, x, y, font)
+	viewer.DrawIcon(renderer, player.ActiveWeapon, colorDarkWhite, x+povPanelWidth-2*assets.IconWidth-10, y, 2)
+	y += serverInfoLineHeight
+	drawHealthGraph(renderer, match, player.SteamID64, x, y, povPanelWidth-10, povGraphHeight)
+	y += povGraphHeight + 10
+	for _, line := range lines {
+		viewer.DrawString(renderer, line, colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
+
+// drawHealthGraph draws the health of the player from the start of the round
+// until the current frame, with the whole round spanning the width of the
+// graph.
+func drawHealthGraph(renderer *sdl.Renderer, match *match.Match, steamID64 uint64, x, y, width, height int32) {
+	gfx.RectangleColor(renderer, x, y, x+width, y+height, colorRoundUndecided)
+	health, ok := currentRoundHealth(match)
+	if !ok {
+		return
+	}
+	for _, player := range health.Players {
+		if player.SteamID64 == steamID64 {
+			drawHealthLine(renderer, player.Health, health.SampleAt(curFrame), x, y, width, height, 1, colorHealthGraph)
+		}
+	}
+}
+
+// povEvents returns the latest kills and the death of the player since start
+// up to the current frame, the newest first.
+func povEvents(match *match.Match, steamID64 uint64, start int) []string {
+	events := make([]string, 0)
+	for i := len(match.Kills) - 1; i >= 0 && len(events) < povRecentEvents; i-- {
+		kill := match.Kills[i]
+		if kill.Frame > curFrame {
+			continue
+		}
+		if kill.Frame < start {
+			break
+		}
+		ago := (match.TimeAt(curFrame) - match.TimeAt(kill.Frame)).Seconds()
+		switch {
+		case kill.KillerSteamID64 == steamID64 && kill.VictimSteamID64 != steamID64:
+			events = append(events, locale.Sprintf("pov.kill", ago, kill.VictimName, kill.Weapon))
+		case kill.VictimSteamID64 == steamID64 && kill.HasKiller():
+			events = append(events, locale.Sprintf("pov.death", ago, kill.KillerName, kill.Weapon))
+		case kill.VictimSteamID64 == steamID64:
+			events = append(events, locale.Sprintf("pov.died", ago))
+		}
+	}
+	return events
+}
+
+func weaponName(weapon demoinfo.EquipmentType) string {
+	if weapon == demoinfo.EqUnknown {
+		return "-"
+	}
+	return weapon.String()
+}
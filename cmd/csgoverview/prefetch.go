@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// pickerPrefetchDelay is how long a demo has to stay selected in the picker
+// before it is parsed in the background, so that scrolling through the list
+// does not start a parse for every demo.
+const pickerPrefetchDelay = 500 * time.Millisecond
+
+// prefetch is a demo that is parsed in the background.
+type prefetch struct {
+	demoFileName string
+	cancel       context.CancelFunc
+	// done is closed once match and err are set.
+	done  chan struct{}
+	match *match.Match
+	err   error
+}
+
+// nextDemo is the demo that is being parsed or was parsed in the background.
+// It is only accessed by the main loop.
+var nextDemo *prefetch
+
+// sizeRatios contains for every extension of demo files (e.g. .dem or .gz)
+// the estimated size of the last parsed match divided by the size of its
+// file. Demos of the same kind take up about the same memory per byte, so
+// the ratio predicts whether a demo fits into c.PrefetchSize before it is
+// parsed. It is written by the background parses.
+var (
+	sizeRatiosMu sync.Mutex
+	sizeRatios   = make(map[string]float64)
+)
+
+// prefetchDemo starts parsing the demo in the background so that opening it
+// later is instant. A demo that is already being parsed is kept, any other
+// one is cancelled. Demos that are predicted to take up more than
+// c.PrefetchSize MB are not parsed ahead. If no prediction is possible yet,
+// the parsed match is dropped if it turns out to be too large.
+func prefetchDemo(c *Config, demoFileName string) {
+	if c.PrefetchSize <= 0 {
+		return
+	}
+	if nextDemo != nil {
+		if nextDemo.demoFileName == demoFileName {
+			return
+		}
+		cancelPrefetch()
+	}
+	budget := int64(c.PrefetchSize) << 20
+	if size, ok := predictedSize(demoFileName); ok && size > budget {
+		log.Printf("demo %v would take up about %d MB, more than -prefetch-size, it is parsed when it is opened",
+			demoFileName, size>>20)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &prefetch{
+		demoFileName: demoFileName,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	go func() {
+		defer close(p.done)
+		m, err := parseDemo(ctx, c, demoFileName)
+		if err == nil && m.EstimatedSize() > budget {
+			log.Printf("parsed demo %v takes up %d MB, more than -prefetch-size, it is parsed again when it is opened",
+				demoFileName, m.EstimatedSize()>>20)
+			m = nil
+		}
+		p.match, p.err = m, err
+	}()
+	nextDemo = p
+}
+
+// prefetchNext parses the demo after the current one of the playlist in the
+// background.
+func prefetchNext(c *Config) {
+	if playlistIndex+1 < len(playlist) {
+		prefetchDemo(c, playlist[playlistIndex+1])
+	}
+}
+
+// takePrefetched returns the match of the demo if it was parsed in the
+// background, waiting for the parse to finish if it is still running. The
+// match is nil if the demo was not prefetched, a prefetch of another demo is
+// cancelled then.
+func takePrefetched(demoFileName string) (*match.Match, error) {
+	p := nextDemo
+	if p == nil || p.demoFileName != demoFileName {
+		cancelPrefetch()
+		return nil, nil
+	}
+	nextDemo = nil
+	<-p.done
+	p.cancel()
+	return p.match, p.err
+}
+
+// cancelPrefetch stops parsing the demo in the background and drops its
+// match.
+func cancelPrefetch() {
+	if nextDemo == nil {
+		return
+	}
+	nextDemo.cancel()
+	nextDemo = nil
+}
+
+// parseDemo parses the demo and prepares the match as configured. It does not
+// use SDL, so it can run on any goroutine.
+func parseDemo(ctx context.Context, c *Config, demoFileName string) (*match.Match, error) {
+	m, err := match.NewMatchContext(ctx, demoFileName, c.FrameRate, c.TickRate)
+	if err != nil {
+		return nil, err
+	}
+	if c.SmoothGaps {
+		m.SmoothGaps()
+	}
+	if c.Quantize {
+		m.Quantize()
+	}
+	observeSize(demoFileName, m)
+	return m, nil
+}
+
+// observeSize records the ratio of the estimated size of the match to the
+// size of its demo file, see sizeRatios.
+func observeSize(demoFileName string, m *match.Match) {
+	info, err := os.Stat(demoFileName)
+	if err != nil || info.Size() == 0 {
+		return
+	}
+	ratio := float64(m.EstimatedSize()) / float64(info.Size())
+	sizeRatiosMu.Lock()
+	sizeRatios[strings.ToLower(filepath.Ext(demoFileName))] = ratio
+	sizeRatiosMu.Unlock()
+}
+
+// predictedSize returns the estimated size of the match of the demo before it
+// is parsed, based on the size of the file and the ratio of the last parsed
+// demo of the same kind. It returns false if no such demo was parsed yet.
+func predictedSize(demoFileName string) (int64, bool) {
+	sizeRatiosMu.Lock()
+	ratio, ok := sizeRatios[strings.ToLower(filepath.Ext(demoFileName))]
+	sizeRatiosMu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	info, err := os.Stat(demoFileName)
+	if err != nil {
+		return 0, false
+	}
+	return int64(ratio * float64(info.Size())), true
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var (
+	// screenshotRequested is set by the key binding, the screenshot is taken
+	// by updateGraphics after the next frame was drawn.
+	screenshotRequested bool
+	screenshotDir       string
+)
+
+// screenshotDirectory returns the directory the screenshots of the demo are
+// saved to. The HTML report of -export includes all screenshots in it.
+func screenshotDirectory(demoFileName string) string {
+	return demoFileName + ".screenshots"
+}
+
+// saveScreenshot saves what the renderer has drawn as PNG file in the
+// screenshot directory of the demo. It has to be called before the renderer
+// presents the frame.
+func saveScreenshot(renderer *sdl.Renderer, match *match.Match) error {
+	err := os.MkdirAll(screenshotDir, 0755)
+	if err != nil {
+		return err
+	}
+	// the pixels are read in the size of the window, not the logical size
+	width, height, err := renderer.GetOutputSize()
+	if err != nil {
+		return err
+	}
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, width, height, 32, sdl.PIXELFORMAT_ARGB8888)
+	if err != nil {
+		return err
+	}
+	defer surface.Free()
+	err = renderer.ReadPixels(nil, sdl.PIXELFORMAT_ARGB8888, surface.Data(), int(surface.Pitch))
+	if err != nil {
+		return err
+	}
+	fileName := fmt.Sprintf("round%02d_%06d.png", match.RoundIndex(curFrame)+1, curFrame)
+	return img.SavePNG(surface, filepath.Join(screenshotDir, fileName))
+}
+
+// screenshotFiles returns the PNG files in the screenshot directory of the
+// demo, ordered by round and frame. A missing directory is not an error.
+func screenshotFiles(demoFileName string) ([]string, error) {
+	dir := screenshotDirectory(demoFileName)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fileNames []string
+	for _, file := range files {
+		if !file.IsDir() && strings.EqualFold(filepath.Ext(file.Name()), ".png") {
+			fileNames = append(fileNames, filepath.Join(dir, file.Name()))
+		}
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linus4/csgoverview/locale"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/query"
+	"github.com/linus4/csgoverview/viewer"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+var (
+	// editingSearch is true while the reviewer types a query. Key bindings
+	// are disabled in the meantime.
+	editingSearch bool
+	searchText    string
+	searchError   error
+	// searchResults are the events found by the last query, searchIndex is
+	// the index of the result that was jumped to last or -1.
+	searchResults []query.Event
+	searchIndex   = -1
+)
+
+func startSearch(*match.Match) {
+	editingSearch = true
+	searchError = nil
+	sdl.StartTextInput()
+}
+
+func stopSearch() {
+	editingSearch = false
+	sdl.StopTextInput()
+}
+
+func clearSearch() {
+	searchText = ""
+	searchError = nil
+	searchResults = nil
+	searchIndex = -1
+}
+
+// handleSearchKeyboardEvents edits the query while it is typed. Return runs
+// the query and jumps to the first result after the current frame, escape
+// closes the search box.
+func handleSearchKeyboardEvents(eventT *sdl.KeyboardEvent, match *match.Match) {
+	if eventT.Type != sdl.KEYDOWN {
+		return
+	}
+	switch eventT.Keysym.Sym {
+	case sdl.K_RETURN:
+		q, err := query.Parse(searchText)
+		if err != nil {
+			searchError = err
+			return
+		}
+		searchResults = query.Run(match, q)
+		searchIndex = -1
+		stopSearch()
+		nextSearchResult(match)
+	case sdl.K_BACKSPACE:
+		if runes := []rune(searchText); len(runes) > 0 {
+			searchText = string(runes[:len(runes)-1])
+		}
+	case sdl.K_ESCAPE:
+		stopSearch()
+	}
+}
+
+func nextSearchResult(*match.Match) {
+	for i, result := range searchResults {
+		if result.Frame > curFrame {
+			curFrame = result.Frame
+			searchIndex = i
+			break
+		}
+	}
+}
+
+func previousSearchResult(*match.Match) {
+	for i := len(searchResults) - 1; i >= 0; i-- {
+		if searchResults[i].Frame < curFrame {
+			curFrame = searchResults[i].Frame
+			searchIndex = i
+			break
+		}
+	}
+}
+
+// drawSearch draws the search box while a query is typed and the number of
+// results of the last query otherwise.
+func drawSearch(renderer *sdl.Renderer, font *ttf.Font) {
+	var lines []string
+	switch {
+	case editingSearch:
+		lines = append(lines, locale.T("search.title"), locale.T("search.hint"), searchText+"_")
+		if searchError != nil {
+			lines = append(lines, locale.Sprintf("search.error", searchError))
+		}
+	case searchText != "":
+		if searchIndex >= 0 {
+			result := searchResults[searchIndex]
+			lines = append(lines, locale.Sprintf("search.result", searchIndex+1, len(searchResults), searchText),
+				fmt.Sprintf("%v %v %v %v", result.Type, result.Player, result.Weapon, result.Victim))
+		} else {
+			lines = append(lines, locale.Sprintf("search.results", len(searchResults), searchText))
+		}
+	default:
+		return
+	}
+	x := mapXOffset + 10
+	y := mapYOffset + mapOverviewHeight - int32(len(lines))*serverInfoLineHeight - 10
+	gfx.BoxColor(renderer, x-5, y-5, x+500, y+int32(len(lines))*serverInfoLineHeight+5, colorOverlayBackground)
+	for _, line := range lines {
+		viewer.DrawString(renderer, line, colorDarkWhite, x, y, font)
+		y += serverInfoLineHeight
+	}
+}
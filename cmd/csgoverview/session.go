@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// Types of the actions of a review session.
+const (
+	sessionSeek      = "seek"
+	sessionPause     = "pause"
+	sessionResume    = "resume"
+	sessionSpeed     = "speed"
+	sessionBookmarks = "bookmarks"
+	sessionGhost     = "ghost"
+)
+
+// sessionAction is an action of the reviewer. At is the time since the start
+// of the session. Ghost is the new position of the ghost, a ghost action
+// without it removes the ghost.
+type sessionAction struct {
+	At        time.Duration
+	Type      string
+	Frame     int           `json:",omitempty"`
+	Speed     float64       `json:",omitempty"`
+	Bookmarks []bookmark    `json:",omitempty"`
+	Ghost     *common.Point `json:",omitempty"`
+}
+
+// sessionFile contains the actions of a review session, which can be
+// replayed with -play-session.
+type sessionFile struct {
+	Demo    string
+	Actions []sessionAction
+}
+
+// sessionRecorder records the actions of the reviewer by comparing the state
+// of the playback after the events of the main loop were handled with the
+// state after the previous iteration.
+type sessionRecorder struct {
+	session   sessionFile
+	start     time.Time
+	frame     int
+	paused    bool
+	speed     float64
+	bookmarks []bookmark
+	ghost     *common.Point
+}
+
+func newSessionRecorder(demoFileName string) *sessionRecorder {
+	return &sessionRecorder{
+		session: sessionFile{Demo: demoFileName, Actions: make([]sessionAction, 0)},
+		start:   time.Now(),
+		frame:   -1,
+		speed:   1,
+	}
+}
+
+// observe records the changes of the playback since the last call.
+func (r *sessionRecorder) observe() {
+	at := time.Since(r.start)
+	if curFrame != r.frame {
+		r.session.Actions = append(r.session.Actions, sessionAction{At: at, Type: sessionSeek, Frame: curFrame})
+	}
+	if paused != r.paused {
+		actionType := sessionResume
+		if paused {
+			actionType = sessionPause
+		}
+		r.session.Actions = append(r.session.Actions, sessionAction{At: at, Type: actionType, Frame: curFrame})
+	}
+	if playbackSpeed != r.speed {
+		r.session.Actions = append(r.session.Actions, sessionAction{At: at, Type: sessionSpeed, Speed: playbackSpeed})
+	}
+	if !bookmarksEqual(review.Bookmarks, r.bookmarks) {
+		bookmarks := append([]bookmark{}, review.Bookmarks...)
+		r.session.Actions = append(r.session.Actions, sessionAction{At: at, Type: sessionBookmarks, Bookmarks: bookmarks})
+		r.bookmarks = bookmarks
+	}
+	if !ghostsEqual(ghost, r.ghost) {
+		r.session.Actions = append(r.session.Actions, sessionAction{At: at, Type: sessionGhost, Ghost: ghost})
+		r.ghost = ghost
+	}
+	r.frame, r.paused, r.speed = curFrame, paused, playbackSpeed
+}
+
+// advance takes the frame that the playback advanced to into account, so
+// that it is not recorded as a seek.
+func (r *sessionRecorder) advance() {
+	r.frame = curFrame
+}
+
+// save writes the recorded session to fileName.
+func (r *sessionRecorder) save(fileName string) error {
+	data, err := json.MarshalIndent(r.session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+func bookmarksEqual(a, b []bookmark) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func ghostsEqual(a, b *common.Point) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sessionPlayer replays a recorded session. The reviewer can still control
+// the playback in between the recorded actions.
+type sessionPlayer struct {
+	file  sessionFile
+	start time.Time
+	next  int
+}
+
+// loadSession reads the session file.
+func loadSession(fileName string) (*sessionPlayer, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var session sessionFile
+	err = json.Unmarshal(data, &session)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionPlayer{file: session, start: time.Now()}, nil
+}
+
+// apply carries out all actions that are due.
+func (p *sessionPlayer) apply(match *match.Match) {
+	elapsed := time.Since(p.start)
+	for ; p.next < len(p.file.Actions); p.next++ {
+		action := p.file.Actions[p.next]
+		if action.At > elapsed {
+			break
+		}
+		switch action.Type {
+		case sessionSeek:
+			seek(match, action.Frame)
+		case sessionPause:
+			paused = true
+		case sessionResume:
+			paused = false
+		case sessionSpeed:
+			if action.Speed >= minPlaybackSpeed && action.Speed <= maxPlaybackSpeed {
+				playbackSpeed = action.Speed
+			}
+		case sessionBookmarks:
+			// the bookmarks are only shown, not saved to the review file
+			review.Bookmarks = action.Bookmarks
+		case sessionGhost:
+			ghost = action.Ghost
+		}
+	}
+}
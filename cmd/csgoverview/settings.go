@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	minPlaybackSpeed float64 = 0.25
+	maxPlaybackSpeed float64 = 4
+	// defaultReplaySeconds is the number of seconds the instant replay jumps
+	// back unless the settings file contains another value.
+	defaultReplaySeconds int = 10
+	// replaySpeed is the factor by which the playback speed is reduced
+	// during the instant replay.
+	replaySpeed float64 = 0.5
+)
+
+// settings contains the state of the viewer that is restored on the next
+// launch.
+type settings struct {
+	WindowWidth    int32
+	WindowHeight   int32
+	PlaybackSpeed  float64
+	AWPOverlay     bool
+	ServerInfo     bool
+	POVPanel       bool
+	HealthGraphs   bool
+	RoundStrip     bool
+	InfernoExtents bool
+	// AltitudeShading draws players and grenades larger the higher they are.
+	AltitudeShading bool
+	SoundOverlay    bool
+	EntryPaths      bool
+	SmokeKills      bool
+	LineOfSight     bool
+	Crossfire       bool
+	Zones           bool
+	AfterplantSite  string
+	// ReplaySeconds is the number of seconds the instant replay jumps back.
+	ReplaySeconds int
+	// HiddenLayers contains the names of the layers that are not drawn, e.g.
+	// "shots" or "dead_players".
+	HiddenLayers []string `json:",omitempty"`
+	// LastDirectory is the directory of the last opened demo.
+	LastDirectory string
+	// KeyBindings maps the names of actions to keys, e.g. "pause": "F5" or
+	// "previous_plant": "Shift+P". Actions that are left out keep their
+	// default key.
+	KeyBindings map[string]string `json:",omitempty"`
+}
+
+// customKeyBindings are the key bindings from the settings file, which are
+// written back unchanged.
+var customKeyBindings map[string]string
+
+var defaultSettings = settings{
+	WindowWidth:   winWidth,
+	WindowHeight:  winHeight,
+	PlaybackSpeed: 1,
+	RoundStrip:    true,
+	ReplaySeconds: defaultReplaySeconds,
+}
+
+// settingsPath returns the path of the settings file in the user config
+// directory.
+func settingsPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "csgoverview", "settings.json"), nil
+}
+
+// loadSettings reads the settings file. Missing or invalid values are replaced
+// by their defaults, a missing file is not an error.
+func loadSettings() (settings, error) {
+	s := defaultSettings
+	path, err := settingsPath()
+	if err != nil {
+		return s, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return defaultSettings, err
+	}
+
+	if s.WindowWidth <= 0 || s.WindowHeight <= 0 {
+		s.WindowWidth, s.WindowHeight = winWidth, winHeight
+	}
+	if s.PlaybackSpeed < minPlaybackSpeed || s.PlaybackSpeed > maxPlaybackSpeed {
+		s.PlaybackSpeed = 1
+	}
+	if s.AfterplantSite != "A" && s.AfterplantSite != "B" {
+		s.AfterplantSite = ""
+	}
+	if s.ReplaySeconds <= 0 {
+		s.ReplaySeconds = defaultReplaySeconds
+	}
+	return s, nil
+}
+
+// save writes the settings file and creates its directory if necessary.
+func (s settings) save() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// applySettings restores the toggles, the playback speed and the key bindings
+// of the viewer.
+func applySettings(s settings) {
+	playbackSpeed = s.PlaybackSpeed
+	awpOverlay = s.AWPOverlay
+	serverInfo = s.ServerInfo
+	povPanel = s.POVPanel
+	healthGraphs = s.HealthGraphs
+	roundStrip = s.RoundStrip
+	infernoExtents = s.InfernoExtents
+	altitudeShading = s.AltitudeShading
+	soundOverlay = s.SoundOverlay
+	entryPaths = s.EntryPaths
+	smokeKills = s.SmokeKills
+	lineOfSight = s.LineOfSight
+	crossfire = s.Crossfire
+	zones = s.Zones
+	afterplantSite = s.AfterplantSite
+	replaySeconds = s.ReplaySeconds
+	hiddenLayers = make(common.LayerFilter)
+	for _, name := range s.HiddenLayers {
+		layer, err := common.ParseLayer(name)
+		if err != nil {
+			log.Println("trying to hide layer:", err)
+			continue
+		}
+		hiddenLayers[layer] = true
+	}
+	customKeyBindings = s.KeyBindings
+	applyKeyBindings(s.KeyBindings)
+}
+
+// currentSettings returns the settings that correspond to the current state
+// of the viewer.
+func currentSettings(window *sdl.Window, lastDirectory string) settings {
+	width, height := window.GetSize()
+	return settings{
+		WindowWidth:     width,
+		WindowHeight:    height,
+		PlaybackSpeed:   playbackSpeed,
+		AWPOverlay:      awpOverlay,
+		ServerInfo:      serverInfo,
+		POVPanel:        povPanel,
+		HealthGraphs:    healthGraphs,
+		RoundStrip:      roundStrip,
+		InfernoExtents:  infernoExtents,
+		AltitudeShading: altitudeShading,
+		SoundOverlay:    soundOverlay,
+		EntryPaths:      entryPaths,
+		SmokeKills:      smokeKills,
+		LineOfSight:     lineOfSight,
+		Crossfire:       crossfire,
+		Zones:           zones,
+		AfterplantSite:  afterplantSite,
+		ReplaySeconds:   replaySeconds,
+		HiddenLayers:    hiddenLayers.Names(),
+		LastDirectory:   lastDirectory,
+		KeyBindings:     customKeyBindings,
+	}
+}
@@ -0,0 +1,47 @@
+// Command golden checks the parser against the golden files of the demo
+// fixtures in testdata/demos.
+//
+// Usage:
+//
+//	go run ./cmd/golden [-update] [directory]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/linus4/csgoverview/internal/golden"
+)
+
+func main() {
+	update := flag.Bool("update", false, "Write the golden files instead of comparing against them")
+	flag.Parse()
+	dir := "testdata/demos"
+	if len(flag.Args()) > 0 {
+		dir = flag.Args()[0]
+	}
+
+	results, err := golden.Check(dir, *update)
+	if err != nil {
+		log.Fatalln("trying to check fixtures:", err)
+	}
+	if len(results) == 0 {
+		log.Fatalln("no demos found in", dir)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("FAIL %v: %v\n", r.Demo, r.Err)
+		} else {
+			fmt.Printf("ok   %v\n", r.Demo)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d fixtures failed\n", failed, len(results))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,98 @@
+// Command parsebench measures the time and allocations of parsing reference
+// demos with match.NewMatch or match.ParseEvents, so that performance
+// regressions of the parser can be compared with data. The demo fixtures of
+// the regression harness are benchmarked by go test -bench . -benchmem in
+// pkg/match.
+//
+// Usage:
+//
+//	go run ./cmd/parsebench [-count n] [-events-only] [-cpuprofile file] [-memprofile file] demo...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+func main() {
+	count := flag.Int("count", 1, "Number of times every demo is benchmarked")
+	cpuProfile := flag.String("cpuprofile", "", "Write a CPU profile of all runs to this file")
+	memProfile := flag.String("memprofile", "", "Write an allocation profile to this file")
+	eventsOnly := flag.Bool("events-only", false, "Benchmark match.ParseEvents instead of match.NewMatch")
+	flag.Parse()
+	if len(flag.Args()) == 0 {
+		fmt.Println("Usage: parsebench [flags] demo...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if *cpuProfile != "" {
+		file, err := os.Create(*cpuProfile)
+		if err != nil {
+			log.Fatalln("trying to create CPU profile:", err)
+		}
+		defer file.Close()
+		err = pprof.StartCPUProfile(file)
+		if err != nil {
+			log.Fatalln("trying to start CPU profile:", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if *memProfile != "" {
+		runtime.MemProfileRate = 64 * 1024
+	}
+
+	parse := match.NewMatch
+	if *eventsOnly {
+		parse = match.ParseEvents
+	}
+	for _, demo := range flag.Args() {
+		for i := 0; i < *count; i++ {
+			err := benchmark(demo, parse)
+			if err != nil {
+				log.Fatalf("trying to parse %v: %v\n", demo, err)
+			}
+		}
+	}
+
+	if *memProfile != "" {
+		file, err := os.Create(*memProfile)
+		if err != nil {
+			log.Fatalln("trying to create allocation profile:", err)
+		}
+		defer file.Close()
+		err = pprof.Lookup("allocs").WriteTo(file, 0)
+		if err != nil {
+			log.Fatalln("trying to write allocation profile:", err)
+		}
+	}
+}
+
+func benchmark(demo string, parse func(string, float64, float64) (*match.Match, error)) error {
+	var m *match.Match
+	var err error
+	result := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m, err = parse(demo, -1, -1)
+			if err != nil {
+				b.SkipNow()
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%v\t%v\t%v\n", demo, result.String(), result.MemString())
+	fmt.Printf("\tframes: %d, kills: %d, shots: %d, hits: %d\n",
+		len(m.States), len(m.Kills), len(m.FiredShots), len(m.Hits))
+	return nil
+}
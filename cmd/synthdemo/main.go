@@ -0,0 +1,473 @@
+// Command synthdemo writes the synthetic demo fixture of the regression
+// harness. The demo is a scripted match of two short rounds on de_dust2 with
+// two players and two bots, so that the harness covers the parser without
+// committing recordings of real matches.
+//
+// The script is deterministic, running the command again writes the same
+// demo. After changing the script, regenerate the golden file with
+// go run ./cmd/golden -update.
+//
+// Usage:
+//
+//	go run ./cmd/synthdemo [-out file]
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+
+	"github.com/linus4/csgoverview/internal/demowriter"
+)
+
+const (
+	tickRate = 64
+	// ticksPerFrame is the number of ticks between two frames, i.e. the demo
+	// is recorded with 16 frames per second.
+	ticksPerFrame = 4
+	// speed is the distance a player moves per second.
+	speed = 200
+	// invalidHandle is the entity handle of an empty weapon slot.
+	invalidHandle = 0x1fffff
+	maxPlayers    = 10
+)
+
+// Team numbers as in m_iTeamNum.
+const (
+	teamUnassigned = 0
+	teamSpectators = 1
+	teamTerrorists = 2
+	teamCTs        = 3
+)
+
+// Entity IDs of the entities that are not players.
+const (
+	entityGameRules = 70 + iota
+	entityPlayerResource
+	entityTeamUnassigned
+	entityTeamSpectators
+	entityTeamTerrorists
+	entityTeamCTs
+)
+
+// Round end reasons as in the round_end event.
+const (
+	reasonCTWin        = 8
+	reasonTerroristWin = 9
+)
+
+// Game phases as in m_gamePhase.
+const (
+	gamePhaseStartGamePhase = 2
+	gamePhaseGameEnded      = 5
+)
+
+// point is a position on de_dust2.
+type point struct {
+	X, Y, Z float32
+}
+
+// Positions on de_dust2.
+var (
+	tSpawn      = point{-680, -760, 100}
+	tSpawn2     = point{-600, -840, 100}
+	outsideLong = point{520, 380, 0}
+	longDoors   = point{730, 1000, 0}
+	lowerTunnel = point{-860, 1200, -110}
+	midDoors    = point{-470, 1550, -60}
+	ctSpawn     = point{260, 2320, -120}
+	ctSpawn2    = point{340, 2240, -120}
+	bSite       = point{-1480, 2560, 30}
+	aSite       = point{1120, 2480, 100}
+)
+
+type player struct {
+	entityID  int
+	userID    int
+	steamID64 uint64
+	name      string
+	isBot     bool
+	team      int
+
+	position point
+	target   point
+	health   int
+	armor    int
+	money    int
+	kills    int
+	deaths   int
+	yaw      float32
+}
+
+func (p *player) info() demowriter.PlayerInfo {
+	guid := "BOT"
+	if !p.isBot {
+		guid = fmt.Sprintf("STEAM_1:%d:%d", p.steamID64&1, (p.steamID64-76561197960265728)>>1)
+	}
+	return demowriter.PlayerInfo{
+		EntityID:  p.entityID,
+		SteamID64: p.steamID64,
+		Name:      p.name,
+		UserID:    p.userID,
+		GUID:      guid,
+		IsBot:     p.isBot,
+	}
+}
+
+// script is the match that is written into the demo.
+type script struct {
+	w       *demowriter.Writer
+	players []*player
+	scoreT  int
+	scoreCT int
+	rounds  int
+	// actions maps ticks to what happens in the frame of the tick.
+	actions map[int][]func(p *demowriter.Packet)
+	endTick int
+}
+
+func main() {
+	out := flag.String("out", "testdata/demos/synthetic.dem", "Write the demo to this file")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	err := writeDemo(&buf)
+	if err != nil {
+		log.Fatalln("trying to write demo:", err)
+	}
+	err = ioutil.WriteFile(*out, buf.Bytes(), 0644)
+	if err != nil {
+		log.Fatalln("trying to write demo:", err)
+	}
+}
+
+func writeDemo(buf *bytes.Buffer) error {
+	w := demowriter.NewWriter(buf, demowriter.Header{
+		ServerName: "csgoverview synthetic fixture",
+		ClientName: "GOTV Demo",
+		MapName:    "de_dust2",
+		TickRate:   tickRate,
+	})
+	s := &script{
+		w: w,
+		players: []*player{
+			{entityID: 1, userID: 2, steamID64: 76561197960265730, name: "alpha", team: teamTerrorists},
+			{entityID: 2, userID: 3, steamID64: 76561197960265731, name: "bravo", team: teamCTs},
+			{entityID: 3, userID: 4, name: "BOT Charlie", isBot: true, team: teamTerrorists},
+			{entityID: 4, userID: 5, name: "BOT Delta", isBot: true, team: teamCTs},
+		},
+		actions: make(map[int][]func(p *demowriter.Packet)),
+	}
+	s.plan()
+
+	err := w.Signon(gameEvents)
+	if err != nil {
+		return err
+	}
+	err = w.DataTables(serverClasses())
+	if err != nil {
+		return err
+	}
+	var infos []demowriter.PlayerInfo
+	for _, p := range s.players {
+		infos = append(infos, p.info())
+	}
+	w.StringTables(0, infos)
+	w.Synctick(0)
+
+	for tick := ticksPerFrame; tick <= s.endTick; tick += ticksPerFrame {
+		p := w.Packet()
+		for _, action := range s.actions[tick] {
+			action(p)
+		}
+		s.move(p)
+		err = w.WritePacket(tick, p)
+		if err != nil {
+			return fmt.Errorf("trying to write frame of tick %d: %v", tick, err)
+		}
+	}
+	return w.Close()
+}
+
+// at schedules the action for the frame at the time in seconds.
+func (s *script) at(seconds float64, action func(p *demowriter.Packet)) {
+	tick := int(math.Round(seconds*tickRate/ticksPerFrame)) * ticksPerFrame
+	if tick < ticksPerFrame {
+		tick = ticksPerFrame
+	}
+	s.actions[tick] = append(s.actions[tick], action)
+	if tick > s.endTick {
+		s.endTick = tick
+	}
+}
+
+func (s *script) player(name string) *player {
+	for _, p := range s.players {
+		if p.name == name {
+			return p
+		}
+	}
+	panic("unknown player " + name)
+}
+
+// plan schedules the match: a short warmup, two rounds and a technical pause
+// in the first round.
+func (s *script) plan() {
+	alpha, bravo := s.player("alpha"), s.player("bravo")
+	charlie, delta := s.player("BOT Charlie"), s.player("BOT Delta")
+
+	s.at(0, func(p *demowriter.Packet) {
+		p.ConVars(map[string]string{
+			"mp_freezetime":       "3",
+			"mp_maxrounds":        "2",
+			"mp_roundtime":        "1.92",
+			"mp_roundtime_defuse": "1.92",
+			"mp_startmoney":       "800",
+		})
+		s.createEntities(p)
+	})
+	s.at(1, func(p *demowriter.Packet) {
+		p.UpdateEntity(entityGameRules, demowriter.Values{
+			"cs_gamerules_data.m_bWarmupPeriod":    false,
+			"cs_gamerules_data.m_bHasMatchStarted": true,
+		})
+		p.GameEvent("begin_new_match", nil)
+	})
+
+	// first round, the terrorists take long A
+	s.startRound(1, map[*player]point{alpha: tSpawn, charlie: tSpawn2, bravo: ctSpawn, delta: ctSpawn2})
+	s.at(4.5, func(*demowriter.Packet) {
+		alpha.target = longDoors
+		charlie.target = outsideLong
+		bravo.target = aSite
+		delta.target = longDoors
+	})
+	s.at(7, func(p *demowriter.Packet) {
+		p.GameEvent("smokegrenade_detonate", demowriter.Values{
+			"userid": bravo.userID, "entityid": 90, "x": longDoors.X, "y": longDoors.Y + 120, "z": longDoors.Z,
+		})
+	})
+	s.shoot(8, alpha, delta, "ak47", 27, false)
+	s.shoot(8.5, alpha, delta, "ak47", 100, true)
+	// technical pause
+	s.at(9, func(p *demowriter.Packet) {
+		p.UpdateEntity(entityGameRules, demowriter.Values{"cs_gamerules_data.m_bMatchWaitingForResume": true})
+		for _, pl := range s.players {
+			pl.target = pl.position
+		}
+	})
+	s.at(12, func(p *demowriter.Packet) {
+		p.UpdateEntity(entityGameRules, demowriter.Values{"cs_gamerules_data.m_bMatchWaitingForResume": false})
+		alpha.target = aSite
+		charlie.target = longDoors
+	})
+	s.shoot(14, charlie, bravo, "glock", 40, false)
+	s.shoot(14.5, charlie, bravo, "glock", 100, true)
+	s.at(15, func(p *demowriter.Packet) {
+		p.GameEvent("smokegrenade_expired", demowriter.Values{
+			"userid": bravo.userID, "entityid": 90, "x": longDoors.X, "y": longDoors.Y + 120, "z": longDoors.Z,
+		})
+	})
+	s.endRound(15, teamTerrorists, reasonTerroristWin)
+
+	// second round, the terrorists go mid to B and lose
+	s.startRound(18, map[*player]point{alpha: tSpawn, charlie: tSpawn2, bravo: ctSpawn, delta: ctSpawn2})
+	s.at(21.5, func(*demowriter.Packet) {
+		alpha.target = lowerTunnel
+		charlie.target = lowerTunnel
+		bravo.target = midDoors
+		delta.target = bSite
+	})
+	s.at(25, func(*demowriter.Packet) {
+		alpha.target = bSite
+		charlie.target = bSite
+	})
+	s.shoot(27, delta, alpha, "m4a1", 100, true)
+	s.shoot(28, bravo, charlie, "hkp2000", 55, false)
+	s.shoot(28.5, bravo, charlie, "hkp2000", 100, false)
+	s.endRound(29, teamCTs, reasonCTWin)
+	s.at(31, func(p *demowriter.Packet) {
+		p.UpdateEntity(entityGameRules, demowriter.Values{"cs_gamerules_data.m_gamePhase": gamePhaseGameEnded})
+		p.GameEvent("cs_win_panel_match", nil)
+	})
+	s.at(32, func(*demowriter.Packet) {})
+}
+
+func (s *script) createEntities(p *demowriter.Packet) {
+	p.CreateEntity(entityGameRules, "CCSGameRulesProxy", demowriter.Values{
+		"cs_gamerules_data.m_gamePhase":         gamePhaseStartGamePhase,
+		"cs_gamerules_data.m_bWarmupPeriod":     true,
+		"cs_gamerules_data.m_totalRoundsPlayed": 0,
+	})
+	p.CreateEntity(entityPlayerResource, "CCSPlayerResource", demowriter.Values{
+		"m_bombsiteCenterA": demowriter.Vector{X: aSite.X, Y: aSite.Y, Z: aSite.Z},
+		"m_bombsiteCenterB": demowriter.Vector{X: bSite.X, Y: bSite.Y, Z: bSite.Z},
+	})
+	teams := []struct {
+		id   int
+		name string
+		num  int
+	}{
+		{entityTeamUnassigned, "Unassigned", teamUnassigned},
+		{entityTeamSpectators, "Spectator", teamSpectators},
+		{entityTeamTerrorists, "TERRORIST", teamTerrorists},
+		{entityTeamCTs, "CT", teamCTs},
+	}
+	for _, t := range teams {
+		p.CreateEntity(t.id, "CCSTeam", demowriter.Values{
+			"m_szTeamname":     t.name,
+			"m_iTeamNum":       t.num,
+			"m_szClanTeamname": "",
+		})
+	}
+	for _, pl := range s.players {
+		pl.health = 100
+		pl.money = 800
+		values := demowriter.Values{
+			"m_iTeamNum":                   pl.team,
+			"m_iHealth":                    pl.health,
+			"m_iAccount":                   pl.money,
+			"m_hActiveWeapon":              invalidHandle,
+			"cslocaldata.m_vecOrigin":      demowriter.Vector{X: pl.position.X, Y: pl.position.Y},
+			"cslocaldata.m_vecOrigin[2]":   pl.position.Z,
+			"m_unCurrentEquipmentValue":    200,
+			"m_unRoundStartEquipmentValue": 200,
+		}
+		for i := 0; i < 64; i++ {
+			values[fmt.Sprintf("m_hMyWeapons.%03d", i)] = invalidHandle
+		}
+		p.CreateEntity(pl.entityID, "CCSPlayer", values)
+	}
+}
+
+// startRound respawns the players at the positions and ends the freeze time
+// after three seconds.
+func (s *script) startRound(seconds float64, spawns map[*player]point) {
+	s.at(seconds, func(p *demowriter.Packet) {
+		p.GameEvent("round_start", demowriter.Values{"timelimit": 115, "objective": "BOMB TARGET"})
+		for _, pl := range s.players {
+			pl.position = spawns[pl]
+			pl.target = pl.position
+			pl.health = 100
+			pl.armor = 100
+			pl.money += 1400
+			if pl.team == teamTerrorists {
+				pl.yaw = 90
+			} else {
+				pl.yaw = 270
+			}
+			p.UpdateEntity(pl.entityID, demowriter.Values{
+				"m_iHealth":                  pl.health,
+				"m_ArmorValue":               pl.armor,
+				"m_iAccount":                 pl.money,
+				"m_angEyeAngles[1]":          pl.yaw,
+				"cslocaldata.m_vecOrigin":    demowriter.Vector{X: pl.position.X, Y: pl.position.Y},
+				"cslocaldata.m_vecOrigin[2]": pl.position.Z,
+			})
+		}
+	})
+	s.at(seconds+3, func(p *demowriter.Packet) {
+		p.GameEvent("round_freeze_end", nil)
+	})
+}
+
+// shoot lets the attacker fire at the victim, leaving it with health - damage
+// health. The victim dies at 100 damage.
+func (s *script) shoot(seconds float64, attacker, victim *player, weapon string, damage int, headshot bool) {
+	s.at(seconds, func(p *demowriter.Packet) {
+		attacker.target = attacker.position
+		attacker.yaw = float32(math.Atan2(float64(victim.position.Y-attacker.position.Y),
+			float64(victim.position.X-attacker.position.X)) * 180 / math.Pi)
+		p.GameEvent("weapon_fire", demowriter.Values{"userid": attacker.userID, "weapon": "weapon_" + weapon})
+		health := victim.health - damage
+		if health < 0 {
+			health = 0
+		}
+		dmg := victim.health - health
+		victim.health = health
+		hitGroup := 2
+		if headshot {
+			hitGroup = 1
+		}
+		p.GameEvent("player_hurt", demowriter.Values{
+			"userid":     victim.userID,
+			"attacker":   attacker.userID,
+			"health":     victim.health,
+			"armor":      victim.armor,
+			"weapon":     weapon,
+			"dmg_health": dmg,
+			"hitgroup":   hitGroup,
+		})
+		p.UpdateEntity(victim.entityID, demowriter.Values{"m_iHealth": victim.health})
+		p.UpdateEntity(attacker.entityID, demowriter.Values{"m_angEyeAngles[1]": attacker.yaw})
+		if victim.health > 0 {
+			return
+		}
+		victim.target = victim.position
+		attacker.kills++
+		victim.deaths++
+		p.GameEvent("player_death", demowriter.Values{
+			"userid":   victim.userID,
+			"attacker": attacker.userID,
+			"weapon":   weapon,
+			"headshot": headshot,
+		})
+		p.UpdateEntity(entityPlayerResource, demowriter.Values{
+			fmt.Sprintf("m_iKills.%03d", attacker.entityID): attacker.kills,
+			fmt.Sprintf("m_iDeaths.%03d", victim.entityID):  victim.deaths,
+		})
+	})
+}
+
+// endRound ends the round with the winner and officially ends it three
+// seconds later.
+func (s *script) endRound(seconds float64, winner, reason int) {
+	s.at(seconds, func(p *demowriter.Packet) {
+		s.rounds++
+		message := "#SFUI_Notice_Terrorists_Win"
+		if winner == teamTerrorists {
+			s.scoreT++
+			p.UpdateEntity(entityTeamTerrorists, demowriter.Values{"m_scoreTotal": s.scoreT})
+		} else {
+			s.scoreCT++
+			message = "#SFUI_Notice_CTs_Win"
+			p.UpdateEntity(entityTeamCTs, demowriter.Values{"m_scoreTotal": s.scoreCT})
+		}
+		p.GameEvent("round_end", demowriter.Values{"winner": winner, "reason": reason, "message": message})
+		p.UpdateEntity(entityGameRules, demowriter.Values{"cs_gamerules_data.m_totalRoundsPlayed": s.rounds})
+		for _, pl := range s.players {
+			pl.target = pl.position
+		}
+	})
+	s.at(seconds+2.5, func(p *demowriter.Packet) {
+		p.GameEvent("round_officially_ended", nil)
+	})
+}
+
+// move moves the living players by one frame towards their targets.
+func (s *script) move(p *demowriter.Packet) {
+	const step = speed * ticksPerFrame / tickRate
+	for _, pl := range s.players {
+		if pl.health == 0 || pl.position == pl.target {
+			continue
+		}
+		dx, dy, dz := pl.target.X-pl.position.X, pl.target.Y-pl.position.Y, pl.target.Z-pl.position.Z
+		dist := float32(math.Sqrt(float64(dx*dx + dy*dy)))
+		if dist <= step {
+			pl.position = pl.target
+		} else {
+			pl.position.X += dx / dist * step
+			pl.position.Y += dy / dist * step
+			pl.position.Z += dz / dist * step
+			pl.yaw = float32(math.Atan2(float64(dy), float64(dx)) * 180 / math.Pi)
+		}
+		p.UpdateEntity(pl.entityID, demowriter.Values{
+			"cslocaldata.m_vecOrigin":    demowriter.Vector{X: pl.position.X, Y: pl.position.Y},
+			"cslocaldata.m_vecOrigin[2]": pl.position.Z,
+			"m_angEyeAngles[1]":          pl.yaw,
+		})
+	}
+}
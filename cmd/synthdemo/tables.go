@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linus4/csgoverview/internal/demowriter"
+)
+
+// gameEvents are the game events of the demo. The keys are the ones the
+// parser reads.
+var gameEvents = []demowriter.Event{
+	{Name: "begin_new_match"},
+	{Name: "round_start", Keys: []demowriter.Key{
+		{Name: "timelimit", Type: demowriter.KeyLong},
+		{Name: "fraglimit", Type: demowriter.KeyLong},
+		{Name: "objective", Type: demowriter.KeyString},
+	}},
+	{Name: "round_freeze_end"},
+	{Name: "round_end", Keys: []demowriter.Key{
+		{Name: "winner", Type: demowriter.KeyByte},
+		{Name: "reason", Type: demowriter.KeyByte},
+		{Name: "message", Type: demowriter.KeyString},
+	}},
+	{Name: "round_officially_ended"},
+	{Name: "cs_win_panel_match"},
+	{Name: "weapon_fire", Keys: []demowriter.Key{
+		{Name: "userid", Type: demowriter.KeyShort},
+		{Name: "weapon", Type: demowriter.KeyString},
+		{Name: "silenced", Type: demowriter.KeyBool},
+	}},
+	{Name: "player_hurt", Keys: []demowriter.Key{
+		{Name: "userid", Type: demowriter.KeyShort},
+		{Name: "attacker", Type: demowriter.KeyShort},
+		{Name: "health", Type: demowriter.KeyByte},
+		{Name: "armor", Type: demowriter.KeyByte},
+		{Name: "weapon", Type: demowriter.KeyString},
+		{Name: "dmg_health", Type: demowriter.KeyShort},
+		{Name: "dmg_armor", Type: demowriter.KeyByte},
+		{Name: "hitgroup", Type: demowriter.KeyByte},
+	}},
+	{Name: "player_death", Keys: []demowriter.Key{
+		{Name: "userid", Type: demowriter.KeyShort},
+		{Name: "attacker", Type: demowriter.KeyShort},
+		{Name: "assister", Type: demowriter.KeyShort},
+		{Name: "weapon", Type: demowriter.KeyString},
+		{Name: "headshot", Type: demowriter.KeyBool},
+		{Name: "penetrated", Type: demowriter.KeyShort},
+	}},
+	{Name: "smokegrenade_detonate", Keys: grenadeKeys},
+	{Name: "smokegrenade_expired", Keys: grenadeKeys},
+}
+
+var grenadeKeys = []demowriter.Key{
+	{Name: "userid", Type: demowriter.KeyShort},
+	{Name: "entityid", Type: demowriter.KeyShort},
+	{Name: "x", Type: demowriter.KeyFloat},
+	{Name: "y", Type: demowriter.KeyFloat},
+	{Name: "z", Type: demowriter.KeyFloat},
+}
+
+// serverClasses returns the server classes of the demo. They contain the
+// properties the parser reads and the classes it expects in every demo.
+func serverClasses() []demowriter.Class {
+	return []demowriter.Class{
+		{Name: "CCSPlayer", TableName: "DT_CSPlayer", Props: playerProps()},
+		{Name: "CCSPlayerResource", TableName: "DT_CSPlayerResource", Props: playerResourceProps()},
+		{Name: "CCSTeam", TableName: "DT_CSTeam", Props: []demowriter.Prop{
+			{Name: "m_iTeamNum", Type: demowriter.PropInt},
+			{Name: "m_scoreTotal", Type: demowriter.PropInt},
+			{Name: "m_szTeamname", Type: demowriter.PropString},
+			{Name: "m_szClanTeamname", Type: demowriter.PropString},
+			{Name: "m_szTeamFlagImage", Type: demowriter.PropString},
+		}},
+		{Name: "CCSGameRulesProxy", TableName: "DT_CSGameRulesProxy", Props: []demowriter.Prop{
+			{Name: "cs_gamerules_data.m_gamePhase", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_totalRoundsPlayed", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_bWarmupPeriod", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_bHasMatchStarted", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_bMatchWaitingForResume", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_bTerroristTimeOutActive", Type: demowriter.PropInt},
+			{Name: "cs_gamerules_data.m_bCTTimeOutActive", Type: demowriter.PropInt},
+		}},
+		{Name: "CBaseTrigger", TableName: "DT_BaseTrigger", Props: []demowriter.Prop{
+			{Name: "m_Collision.m_vecMins", Type: demowriter.PropVector},
+			{Name: "m_Collision.m_vecMaxs", Type: demowriter.PropVector},
+		}},
+		{Name: "CC4", TableName: "DT_WeaponC4", Props: append(positionProps(),
+			demowriter.Prop{Name: "m_hOwner", Type: demowriter.PropInt},
+			demowriter.Prop{Name: "m_bStartedArming", Type: demowriter.PropInt},
+		)},
+		{Name: "CPlantedC4", TableName: "DT_PlantedC4", Props: positionProps()},
+		{Name: "CInferno", TableName: "DT_Inferno", Props: append(positionProps(),
+			demowriter.Prop{Name: "m_fireCount", Type: demowriter.PropInt},
+		)},
+	}
+}
+
+func positionProps() []demowriter.Prop {
+	return []demowriter.Prop{
+		{Name: "m_cellbits", Type: demowriter.PropInt},
+		{Name: "m_cellX", Type: demowriter.PropInt},
+		{Name: "m_cellY", Type: demowriter.PropInt},
+		{Name: "m_cellZ", Type: demowriter.PropInt},
+		{Name: "m_vecOrigin", Type: demowriter.PropVector},
+	}
+}
+
+func playerProps() []demowriter.Prop {
+	props := []demowriter.Prop{
+		{Name: "cslocaldata.m_vecOrigin", Type: demowriter.PropVectorXY},
+		{Name: "cslocaldata.m_vecOrigin[2]", Type: demowriter.PropFloat},
+		{Name: "m_angEyeAngles[0]", Type: demowriter.PropFloat},
+		{Name: "m_angEyeAngles[1]", Type: demowriter.PropFloat},
+		{Name: "m_flFlashDuration", Type: demowriter.PropFloat},
+		{Name: "localdata.m_vecVelocity[0]", Type: demowriter.PropFloat},
+		{Name: "localdata.m_vecVelocity[1]", Type: demowriter.PropFloat},
+		{Name: "localdata.m_vecVelocity[2]", Type: demowriter.PropFloat},
+	}
+	for _, name := range []string{
+		"m_iTeamNum", "m_iHealth", "m_ArmorValue", "m_iAccount", "m_bHasDefuser", "m_bHasHelmet",
+		"m_hActiveWeapon", "m_hGroundEntity", "m_bIsDefusing", "m_bIsControllingBot",
+		"m_iControlledBotEntIndex", "m_bInBombZone", "m_bInBuyZone", "m_bIsWalking", "m_bIsScoped",
+		"m_zoomLevel", "localdata.m_Local.m_bDucking", "m_bSpottedByMask.000", "m_bSpottedByMask.001",
+		"m_unCurrentEquipmentValue", "m_unRoundStartEquipmentValue", "m_unFreezetimeEndEquipmentValue",
+	} {
+		props = append(props, demowriter.Prop{Name: name, Type: demowriter.PropInt})
+	}
+	for i := 0; i < 64; i++ {
+		props = append(props, demowriter.Prop{Name: fmt.Sprintf("m_hMyWeapons.%03d", i), Type: demowriter.PropInt})
+	}
+	for i := 0; i < 32; i++ {
+		props = append(props, demowriter.Prop{Name: fmt.Sprintf("m_iAmmo.%03d", i), Type: demowriter.PropInt})
+	}
+	return props
+}
+
+func playerResourceProps() []demowriter.Prop {
+	props := []demowriter.Prop{
+		{Name: "m_bombsiteCenterA", Type: demowriter.PropVector},
+		{Name: "m_bombsiteCenterB", Type: demowriter.PropVector},
+	}
+	for i := 0; i <= maxPlayers; i++ {
+		for _, name := range []string{
+			"m_iKills", "m_iDeaths", "m_iAssists", "m_iScore", "m_iMVPs", "m_iPing",
+			"m_iTotalCashSpent", "m_iCashSpentThisRound",
+		} {
+			props = append(props, demowriter.Prop{Name: fmt.Sprintf("%v.%03d", name, i), Type: demowriter.PropInt})
+		}
+		props = append(props, demowriter.Prop{Name: fmt.Sprintf("m_szClan.%03d", i), Type: demowriter.PropString})
+	}
+	return props
+}
@@ -5,6 +5,7 @@ require (
 	github.com/go-gl/glfw v0.0.0-20200222043503-6f7a984d4dc4 // indirect
 	github.com/golang/geo v0.0.0-20200319012246-673a6f80352d
 	github.com/jung-kurt/gofpdf v1.16.2 // indirect
+	github.com/linus4/csgoverview/pkg v0.0.0-00010101000000-000000000000
 	github.com/llgcode/draw2d v0.0.0-20200110163050-b96d8208fcfc // indirect
 	github.com/markus-wa/demoinfocs-golang/v2 v2.3.0
 	github.com/markus-wa/quickhull-go v0.0.0-20190116183559-9fb9702adbda // indirect
@@ -23,4 +24,6 @@ require (
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
 
-go 1.13
+replace github.com/linus4/csgoverview/pkg => ./pkg
+
+go 1.16
@@ -0,0 +1,129 @@
+package demowriter
+
+import "math"
+
+// bitWriter writes bits in the order in which the bit reader of the parser
+// reads them, i.e. starting with the least significant bit of every byte.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+// writeBits writes the n least significant bits of v.
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	for n > 0 {
+		take := n
+		if take > 32 {
+			take = 32
+		}
+		w.acc |= (v & (1<<take - 1)) << w.nBits
+		w.nBits += take
+		v >>= take
+		n -= take
+		for w.nBits >= 8 {
+			w.buf = append(w.buf, byte(w.acc))
+			w.acc >>= 8
+			w.nBits -= 8
+		}
+	}
+}
+
+func (w *bitWriter) writeBit(b bool) {
+	if b {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+func (w *bitWriter) writeByte(b byte) {
+	w.writeBits(uint64(b), 8)
+}
+
+func (w *bitWriter) writeBytes(b []byte) {
+	for _, c := range b {
+		w.writeByte(c)
+	}
+}
+
+func (w *bitWriter) writeInt32(v int32) {
+	w.writeBits(uint64(uint32(v)), 32)
+}
+
+func (w *bitWriter) writeInt16(v int16) {
+	w.writeBits(uint64(uint16(v)), 16)
+}
+
+func (w *bitWriter) writeFloat(f float32) {
+	w.writeBits(uint64(math.Float32bits(f)), 32)
+}
+
+// writeCString writes s terminated by a zero byte.
+func (w *bitWriter) writeCString(s string) {
+	w.writeBytes([]byte(s))
+	w.writeByte(0)
+}
+
+// writeFixedString writes s padded with zero bytes to n bytes.
+func (w *bitWriter) writeFixedString(s string, n int) {
+	b := make([]byte, n)
+	copy(b, s)
+	w.writeBytes(b)
+}
+
+// writeVarInt32 writes v as a protobuf varint.
+func (w *bitWriter) writeVarInt32(v uint32) {
+	for v >= 0x80 {
+		w.writeByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	w.writeByte(byte(v))
+}
+
+func (w *bitWriter) writeSignedVarInt32(v int32) {
+	w.writeVarInt32(uint32(v<<1) ^ uint32(v>>31))
+}
+
+// writeUBitInt writes v in the variable length format of the entity indices.
+func (w *bitWriter) writeUBitInt(v uint) {
+	switch {
+	case v < 1<<4:
+		w.writeBits(uint64(v), 6)
+	case v < 1<<8:
+		w.writeBits(uint64(v&15|16), 6)
+		w.writeBits(uint64(v>>4), 4)
+	case v < 1<<12:
+		w.writeBits(uint64(v&15|32), 6)
+		w.writeBits(uint64(v>>4), 8)
+	default:
+		w.writeBits(uint64(v&15|48), 6)
+		w.writeBits(uint64(v>>4), 28)
+	}
+}
+
+// writeFieldIndexDelta writes the distance to the previous field index of an
+// entity update without the short encodings.
+func (w *bitWriter) writeFieldIndexDelta(v uint) {
+	switch {
+	case v < 1<<5:
+		w.writeBits(uint64(v), 7)
+	case v < 1<<7:
+		w.writeBits(uint64(v&31|32), 7)
+		w.writeBits(uint64(v>>5), 2)
+	case v < 1<<9:
+		w.writeBits(uint64(v&31|64), 7)
+		w.writeBits(uint64(v>>5), 4)
+	default:
+		w.writeBits(uint64(v&31|96), 7)
+		w.writeBits(uint64(v>>5), 7)
+	}
+}
+
+// bytes pads the written bits to full bytes and returns them.
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.writeBits(0, 8-w.nBits)
+	}
+	return w.buf
+}
@@ -0,0 +1,659 @@
+// Package demowriter writes minimal CS:GO demos. It only supports what the
+// parser needs to follow a match: send tables without nested tables, player
+// infos, entities and game events. It is used to generate the synthetic demo
+// fixtures of the regression harness, see cmd/synthdemo.
+package demowriter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/msg"
+)
+
+// Demo commands, see the parser of demoinfocs.
+const (
+	cmdSignon       = 1
+	cmdPacket       = 2
+	cmdSynctick     = 3
+	cmdDataTables   = 6
+	cmdStop         = 7
+	cmdStringTables = 9
+)
+
+const (
+	maxOsPath = 260
+	// commandInfoSize is the size of the command info and the two sequence
+	// numbers in front of every packet, which the parser skips.
+	commandInfoSize = 152 + 4 + 4
+	// entitySerialBits is the size of the serial number of an entity that
+	// enters the PVS.
+	entitySerialBits = 10
+	// fieldIndexEndMarker ends the list of updated properties.
+	fieldIndexEndMarker = 0xfff
+	maxEntities         = 2048
+)
+
+// PropType is the type of an entity property.
+type PropType int
+
+// Possible values for PropType.
+const (
+	PropInt PropType = iota
+	PropFloat
+	PropVector
+	PropVectorXY
+	PropString
+)
+
+// Property flags, see the send table parser of demoinfocs.
+const (
+	propFlagNoScale = 1 << 2
+	propFlagVarInt  = 1 << 19
+)
+
+// sendPropType maps the property types to the types of the send tables.
+var sendPropType = map[PropType]int32{
+	PropInt:      0,
+	PropFloat:    1,
+	PropVector:   2,
+	PropVectorXY: 3,
+	PropString:   4,
+}
+
+// Prop is a property of a server class.
+type Prop struct {
+	Name string
+	Type PropType
+}
+
+// Class is a server class. Every class has its own data table.
+type Class struct {
+	Name      string
+	TableName string
+	Props     []Prop
+}
+
+// KeyType is the type of a key of a game event.
+type KeyType int32
+
+// Possible values for KeyType.
+const (
+	KeyString KeyType = 1
+	KeyFloat  KeyType = 2
+	KeyLong   KeyType = 3
+	KeyShort  KeyType = 4
+	KeyByte   KeyType = 5
+	KeyBool   KeyType = 6
+)
+
+// Key is a key of a game event.
+type Key struct {
+	Name string
+	Type KeyType
+}
+
+// Event describes a game event.
+type Event struct {
+	Name string
+	Keys []Key
+}
+
+// Values maps the names of properties or keys of game events to their
+// values. Supported values are bool, int, float32, float64, string and
+// Vector.
+type Values map[string]interface{}
+
+// Vector is the value of a PropVector or PropVectorXY property.
+type Vector struct {
+	X, Y, Z float32
+}
+
+// PlayerInfo is the user info of the player with the entity ID EntityID.
+type PlayerInfo struct {
+	EntityID  int
+	SteamID64 uint64
+	Name      string
+	UserID    int
+	GUID      string
+	IsBot     bool
+}
+
+// Header contains the fields of the demo header that are not derived from
+// the frames.
+type Header struct {
+	ServerName string
+	ClientName string
+	MapName    string
+	// TickRate is the tick rate of the server.
+	TickRate float64
+}
+
+// Writer writes a demo. The frames are buffered until Close because the
+// header contains the length of the demo.
+type Writer struct {
+	out      io.Writer
+	header   Header
+	body     bitWriter
+	frames   int
+	lastTick int
+
+	classes  []Class
+	classIDs map[string]int
+	// propIndices maps the class ID and property name to the index of the
+	// property in the flattened properties of the class.
+	propIndices []map[string]int
+	entities    map[int]int
+	events      []Event
+	eventIDs    map[string]int
+}
+
+// NewWriter returns a Writer that writes the demo to out.
+func NewWriter(out io.Writer, header Header) *Writer {
+	return &Writer{
+		out:      out,
+		header:   header,
+		entities: make(map[int]int),
+		eventIDs: make(map[string]int),
+	}
+}
+
+func (w *Writer) beginFrame(cmd byte, tick int) {
+	w.body.writeByte(cmd)
+	w.body.writeInt32(int32(tick))
+	// player slot
+	w.body.writeByte(0)
+	w.frames++
+	if tick > w.lastTick {
+		w.lastTick = tick
+	}
+}
+
+// Signon writes the signon packet with the server info and the descriptors
+// of the game events.
+func (w *Writer) Signon(events []Event) error {
+	w.events = events
+	list := &msg.CSVCMsg_GameEventList{}
+	for i, e := range events {
+		w.eventIDs[e.Name] = i
+		desc := &msg.CSVCMsg_GameEventListDescriptorT{Eventid: int32(i), Name: e.Name}
+		for _, k := range e.Keys {
+			desc.Keys = append(desc.Keys, &msg.CSVCMsg_GameEventListKeyT{Type: int32(k.Type), Name: k.Name})
+		}
+		list.Descriptors = append(list.Descriptors, desc)
+	}
+	info := &msg.CSVCMsg_ServerInfo{TickInterval: float32(1 / w.header.TickRate)}
+
+	p := &Packet{w: w}
+	p.message(int(msg.SVC_Messages_svc_ServerInfo), info)
+	p.message(int(msg.SVC_Messages_svc_GameEventList), list)
+	return w.writePacket(cmdSignon, 0, p)
+}
+
+// DataTables writes the send tables and server classes.
+func (w *Writer) DataTables(classes []Class) error {
+	w.classes = classes
+	w.classIDs = make(map[string]int)
+	w.propIndices = make([]map[string]int, len(classes))
+
+	var data bitWriter
+	for i, c := range classes {
+		w.classIDs[c.Name] = i
+		w.propIndices[i] = make(map[string]int)
+		table := &msg.CSVCMsg_SendTable{NetTableName: c.TableName}
+		for j, p := range c.Props {
+			w.propIndices[i][p.Name] = j
+			table.Props = append(table.Props, sendProp(p))
+		}
+		if err := writeSendTable(&data, table); err != nil {
+			return err
+		}
+	}
+	if err := writeSendTable(&data, &msg.CSVCMsg_SendTable{IsEnd: true}); err != nil {
+		return err
+	}
+	data.writeInt16(int16(len(classes)))
+	for i, c := range classes {
+		data.writeInt16(int16(i))
+		data.writeCString(c.Name)
+		data.writeCString(c.TableName)
+	}
+
+	w.beginFrame(cmdDataTables, 0)
+	w.writeChunk(data.bytes())
+	return nil
+}
+
+func sendProp(p Prop) *msg.CSVCMsg_SendTableSendpropT {
+	prop := &msg.CSVCMsg_SendTableSendpropT{
+		Type:     sendPropType[p.Type],
+		VarName:  p.Name,
+		Priority: 128,
+	}
+	switch p.Type {
+	case PropInt:
+		prop.Flags = propFlagVarInt
+		prop.NumBits = 32
+	case PropFloat, PropVector, PropVectorXY:
+		prop.Flags = propFlagNoScale
+		prop.NumBits = 32
+	}
+	return prop
+}
+
+func writeSendTable(data *bitWriter, table *msg.CSVCMsg_SendTable) error {
+	b, err := table.Marshal()
+	if err != nil {
+		return fmt.Errorf("trying to marshal send table %v: %v", table.NetTableName, err)
+	}
+	data.writeVarInt32(uint32(msg.SVC_Messages_svc_SendTable))
+	data.writeVarInt32(uint32(len(b)))
+	data.writeBytes(b)
+	return nil
+}
+
+// StringTables writes the user infos of the players.
+func (w *Writer) StringTables(tick int, players []PlayerInfo) {
+	var data bitWriter
+	// number of tables
+	data.writeByte(1)
+	data.writeCString("userinfo")
+	data.writeInt16(int16(len(players)))
+	for _, p := range players {
+		data.writeCString(fmt.Sprint(p.EntityID - 1))
+		data.writeBit(true)
+		info := playerInfo(p)
+		data.writeInt16(int16(len(info)))
+		data.writeBytes(info)
+	}
+	// no client side entries
+	data.writeBit(false)
+
+	w.beginFrame(cmdStringTables, tick)
+	w.writeChunk(data.bytes())
+}
+
+func playerInfo(p PlayerInfo) []byte {
+	var data bitWriter
+	// version
+	data.writeBytes(make([]byte, 8))
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, p.SteamID64)
+	data.writeBytes(b)
+	data.writeFixedString(p.Name, 128)
+	b = make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(p.UserID))
+	data.writeBytes(b)
+	data.writeFixedString(p.GUID, 33)
+	// friends ID
+	data.writeBytes(make([]byte, 4))
+	// friends name
+	data.writeFixedString("", 128)
+	if p.IsBot {
+		data.writeByte(1)
+	} else {
+		data.writeByte(0)
+	}
+	// HLTV
+	data.writeByte(0)
+	// custom files and downloaded files
+	data.writeBytes(make([]byte, 4*4+1))
+	return data.bytes()
+}
+
+// Synctick writes a sync tick frame.
+func (w *Writer) Synctick(tick int) {
+	w.beginFrame(cmdSynctick, tick)
+}
+
+// Packet returns a new packet for the next frame.
+func (w *Writer) Packet() *Packet {
+	return &Packet{w: w}
+}
+
+// WritePacket writes the packet as the frame of the tick.
+func (w *Writer) WritePacket(tick int, p *Packet) error {
+	return w.writePacket(cmdPacket, tick, p)
+}
+
+func (w *Writer) writePacket(cmd byte, tick int, p *Packet) error {
+	if len(p.entities) > 0 {
+		data, err := p.encodeEntities()
+		if err != nil {
+			return err
+		}
+		p.message(int(msg.SVC_Messages_svc_PacketEntities), &msg.CSVCMsg_PacketEntities{
+			MaxEntries:     maxEntities,
+			UpdatedEntries: int32(len(p.entities)),
+			IsDelta:        true,
+			EntityData:     data,
+		})
+	}
+	if p.err != nil {
+		return p.err
+	}
+	w.beginFrame(cmd, tick)
+	w.body.writeBytes(make([]byte, commandInfoSize))
+	w.writeChunk(p.data.bytes())
+	return nil
+}
+
+func (w *Writer) writeChunk(b []byte) {
+	w.body.writeInt32(int32(len(b)))
+	w.body.writeBytes(b)
+}
+
+// Close writes the stop frame and the demo to the underlying writer.
+func (w *Writer) Close() error {
+	w.beginFrame(cmdStop, w.lastTick)
+
+	var header bitWriter
+	header.writeFixedString("HL2DEMO", 8)
+	// demo protocol and network protocol
+	header.writeInt32(4)
+	header.writeInt32(13776)
+	header.writeFixedString(w.header.ServerName, maxOsPath)
+	header.writeFixedString(w.header.ClientName, maxOsPath)
+	header.writeFixedString(w.header.MapName, maxOsPath)
+	header.writeFixedString("csgo", maxOsPath)
+	playbackTime := time.Duration(float64(w.lastTick) / w.header.TickRate * float64(time.Second))
+	header.writeFloat(float32(playbackTime.Seconds()))
+	header.writeInt32(int32(w.lastTick))
+	header.writeInt32(int32(w.frames))
+	// signon length
+	header.writeInt32(0)
+
+	if _, err := w.out.Write(header.bytes()); err != nil {
+		return err
+	}
+	_, err := w.out.Write(w.body.bytes())
+	return err
+}
+
+// Packet collects the messages of a packet.
+type Packet struct {
+	w        *Writer
+	data     bitWriter
+	entities []entityUpdate
+	err      error
+}
+
+type entityUpdate struct {
+	id      int
+	classID int
+	create  bool
+	destroy bool
+	values  Values
+}
+
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+func (p *Packet) message(cmd int, m marshaler) {
+	b, err := m.Marshal()
+	if err != nil {
+		p.setError(fmt.Errorf("trying to marshal message %d: %v", cmd, err))
+		return
+	}
+	p.data.writeVarInt32(uint32(cmd))
+	p.data.writeVarInt32(uint32(len(b)))
+	p.data.writeBytes(b)
+}
+
+func (p *Packet) setError(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// ConVars sets console variables.
+func (p *Packet) ConVars(vars map[string]string) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cvars := &msg.CMsg_CVars{}
+	for _, name := range names {
+		cvars.Cvars = append(cvars.Cvars, &msg.CMsg_CVars_CVar{Name: name, Value: vars[name]})
+	}
+	p.message(int(msg.NET_Messages_net_SetConVar), &msg.CNETMsg_SetConVar{Convars: cvars})
+}
+
+// GameEvent adds a game event that was passed to Signon. Keys that are
+// missing in values are zero.
+func (p *Packet) GameEvent(name string, values Values) {
+	id, ok := p.w.eventIDs[name]
+	if !ok {
+		p.setError(fmt.Errorf("unknown game event %q", name))
+		return
+	}
+	e := &msg.CSVCMsg_GameEvent{EventName: name, Eventid: int32(id)}
+	for _, k := range p.w.events[id].Keys {
+		key, err := eventKey(k, values[k.Name])
+		if err != nil {
+			p.setError(fmt.Errorf("trying to encode key %v of game event %v: %v", k.Name, name, err))
+			return
+		}
+		e.Keys = append(e.Keys, key)
+	}
+	p.message(int(msg.SVC_Messages_svc_GameEvent), e)
+}
+
+func eventKey(k Key, v interface{}) (*msg.CSVCMsg_GameEventKeyT, error) {
+	key := &msg.CSVCMsg_GameEventKeyT{Type: int32(k.Type)}
+	if v == nil {
+		return key, nil
+	}
+	switch k.Type {
+	case KeyString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", v)
+		}
+		key.ValString = s
+	case KeyFloat:
+		f, err := floatValue(v)
+		if err != nil {
+			return nil, err
+		}
+		key.ValFloat = f
+	case KeyBool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a bool", v)
+		}
+		key.ValBool = b
+	default:
+		i, err := intValue(v)
+		if err != nil {
+			return nil, err
+		}
+		switch k.Type {
+		case KeyLong:
+			key.ValLong = i
+		case KeyShort:
+			key.ValShort = i
+		case KeyByte:
+			key.ValByte = i
+		}
+	}
+	return key, nil
+}
+
+// CreateEntity adds an entity of the class that enters the PVS with the
+// values.
+func (p *Packet) CreateEntity(id int, class string, values Values) {
+	classID, ok := p.w.classIDs[class]
+	if !ok {
+		p.setError(fmt.Errorf("unknown server class %q", class))
+		return
+	}
+	p.w.entities[id] = classID
+	created := make(Values, len(values))
+	for name, v := range values {
+		created[name] = v
+	}
+	p.entities = append(p.entities, entityUpdate{id: id, classID: classID, create: true, values: created})
+}
+
+// UpdateEntity adds an update of the values of an entity. Updates of an
+// entity that was already created or updated in the packet are merged.
+func (p *Packet) UpdateEntity(id int, values Values) {
+	classID, ok := p.w.entities[id]
+	if !ok {
+		p.setError(fmt.Errorf("unknown entity %d", id))
+		return
+	}
+	for _, e := range p.entities {
+		if e.id == id && !e.destroy {
+			for name, v := range values {
+				e.values[name] = v
+			}
+			return
+		}
+	}
+	merged := make(Values, len(values))
+	for name, v := range values {
+		merged[name] = v
+	}
+	p.entities = append(p.entities, entityUpdate{id: id, classID: classID, values: merged})
+}
+
+// DestroyEntity adds an entity that leaves the PVS.
+func (p *Packet) DestroyEntity(id int) {
+	if _, ok := p.w.entities[id]; !ok {
+		p.setError(fmt.Errorf("unknown entity %d", id))
+		return
+	}
+	delete(p.w.entities, id)
+	p.entities = append(p.entities, entityUpdate{id: id, destroy: true})
+}
+
+func (p *Packet) encodeEntities() ([]byte, error) {
+	sort.SliceStable(p.entities, func(i, j int) bool {
+		return p.entities[i].id < p.entities[j].id
+	})
+	classBits := uint(math.Ceil(math.Log2(float64(len(p.w.classes)))))
+
+	var data bitWriter
+	last := -1
+	for _, e := range p.entities {
+		if e.id <= last {
+			return nil, fmt.Errorf("entity %d is updated twice in a packet", e.id)
+		}
+		data.writeUBitInt(uint(e.id - last - 1))
+		last = e.id
+		switch {
+		case e.destroy:
+			data.writeBits(3, 2)
+			continue
+		case e.create:
+			data.writeBits(2, 2)
+			data.writeBits(uint64(e.classID), classBits)
+			data.writeBits(0, entitySerialBits)
+		default:
+			data.writeBits(0, 2)
+		}
+		if err := p.encodeValues(&data, e); err != nil {
+			return nil, err
+		}
+	}
+	// the bit reader of the parser reads ahead
+	return append(data.bytes(), make([]byte, 8)...), nil
+}
+
+func (p *Packet) encodeValues(data *bitWriter, e entityUpdate) error {
+	class := p.w.classes[e.classID]
+	indices := make([]int, 0, len(e.values))
+	for name := range e.values {
+		i, ok := p.w.propIndices[e.classID][name]
+		if !ok {
+			return fmt.Errorf("unknown property %v of server class %v", name, class.Name)
+		}
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	// no new way of encoding the field indices
+	data.writeBit(false)
+	last := -1
+	for _, i := range indices {
+		data.writeFieldIndexDelta(uint(i - last - 1))
+		last = i
+	}
+	data.writeFieldIndexDelta(fieldIndexEndMarker)
+
+	for _, i := range indices {
+		prop := class.Props[i]
+		if err := encodeValue(data, prop, e.values[prop.Name]); err != nil {
+			return fmt.Errorf("trying to encode property %v of server class %v: %v", prop.Name, class.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeValue(data *bitWriter, prop Prop, v interface{}) error {
+	switch prop.Type {
+	case PropInt:
+		i, err := intValue(v)
+		if err != nil {
+			return err
+		}
+		data.writeSignedVarInt32(i)
+	case PropFloat:
+		f, err := floatValue(v)
+		if err != nil {
+			return err
+		}
+		data.writeFloat(f)
+	case PropVector, PropVectorXY:
+		vec, ok := v.(Vector)
+		if !ok {
+			return fmt.Errorf("%v is not a Vector", v)
+		}
+		data.writeFloat(vec.X)
+		data.writeFloat(vec.Y)
+		if prop.Type == PropVector {
+			data.writeFloat(vec.Z)
+		}
+	case PropString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%v is not a string", v)
+		}
+		data.writeBits(uint64(len(s)), 9)
+		data.writeBytes([]byte(s))
+	}
+	return nil
+}
+
+func intValue(v interface{}) (int32, error) {
+	switch v := v.(type) {
+	case int:
+		return int32(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%v is not an int", v)
+}
+
+func floatValue(v interface{}) (float32, error) {
+	switch v := v.(type) {
+	case float32:
+		return v, nil
+	case float64:
+		return float32(v), nil
+	case int:
+		return float32(v), nil
+	}
+	return 0, fmt.Errorf("%v is not a float", v)
+}
@@ -0,0 +1,220 @@
+// Package golden contains a regression harness for the parser. It parses demo
+// fixtures, converts the resulting matches into a canonical JSON document and
+// compares it against golden files that were generated with a known good
+// version of the parser.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// goldenSuffix is appended to the name of a demo to get the name of its
+// golden file.
+const goldenSuffix = ".golden.json"
+
+// sampleInterval is the demo time between two states in the canonical
+// document. Taking every frame would make the golden files unreadable.
+const sampleInterval = time.Second
+
+// Document is the canonical representation of a match. It contains the
+// parsed events and a sample of the states with rounded coordinates so that
+// the golden files only change when the behavior of the parser changes.
+type Document struct {
+	MapName    string
+	FrameRate  float64
+	TickRate   float64
+	Frames     int
+	Summary    common.Summary
+	Halves     []common.Half
+	Rounds     []common.Round
+	Kills      []common.Kill
+	Hits       []common.Hit
+	Smokes     []common.Smoke
+	BombPlants []common.BombPlant
+	Pauses     []common.Pause
+	Gaps       []common.Gap
+	States     []State
+}
+
+// State is a sampled state of the match.
+type State struct {
+	Frame              int
+	IngameTick         int
+	Time               time.Duration
+	Timer              common.Timer
+	ScoreCT            byte
+	ScoreT             byte
+	Players            []Player
+	Grenades           int
+	Infernos           int
+	BombPosition       common.Point
+	BombIsBeingCarried bool
+}
+
+// Player is a sampled player.
+type Player struct {
+	SteamID64 uint64
+	Name      string
+	Position  common.Point
+	Health    int16
+	Armor     int16
+	Money     int16
+	IsAlive   bool
+}
+
+// Canonicalize converts the match into its canonical document.
+func Canonicalize(m *match.Match) Document {
+	doc := Document{
+		MapName:    m.MapName,
+		FrameRate:  m.FrameRate,
+		TickRate:   m.TickRate,
+		Frames:     m.TotalFrames(),
+		Summary:    m.Summary,
+		Halves:     m.Halves,
+		Rounds:     m.Rounds,
+		Kills:      m.Kills,
+		Hits:       m.Hits,
+		Smokes:     m.Smokes,
+		BombPlants: m.BombPlants,
+		Pauses:     m.Pauses,
+		Gaps:       m.Gaps,
+	}
+	// the event is looked up online and does not belong to the parser output
+	doc.Summary.Event = nil
+
+	nextSample := time.Duration(0)
+	for _, state := range m.States {
+		if state.Time < nextSample {
+			continue
+		}
+		nextSample = state.Time + sampleInterval
+		doc.States = append(doc.States, canonicalState(m, state))
+	}
+	return doc
+}
+
+func canonicalState(m *match.Match, state common.OverviewState) State {
+	s := State{
+		Frame:              state.Frame,
+		IngameTick:         state.IngameTick,
+		Time:               state.Time,
+		Timer:              m.TimerAt(state.Frame),
+		ScoreCT:            state.TeamCounterTerrorists.Score,
+		ScoreT:             state.TeamTerrorists.Score,
+		Grenades:           len(state.Grenades),
+		Infernos:           len(state.Infernos),
+		BombPosition:       roundPoint(state.Bomb.Position),
+		BombIsBeingCarried: state.Bomb.IsBeingCarried,
+	}
+	for _, p := range state.Players {
+		s.Players = append(s.Players, Player{
+			SteamID64: p.SteamID64,
+			Name:      p.Name,
+			Position:  roundPoint(p.Position),
+			Health:    p.Health,
+			Armor:     p.Armor,
+			Money:     p.Money,
+			IsAlive:   p.IsAlive,
+		})
+	}
+	sort.Slice(s.Players, func(i, j int) bool {
+		if s.Players[i].SteamID64 != s.Players[j].SteamID64 {
+			return s.Players[i].SteamID64 < s.Players[j].SteamID64
+		}
+		return s.Players[i].Name < s.Players[j].Name
+	})
+	return s
+}
+
+func roundPoint(p common.Point) common.Point {
+	return common.Point{
+		X: float32(math.Round(float64(p.X))),
+		Y: float32(math.Round(float64(p.Y))),
+	}
+}
+
+// Encode returns the canonical JSON of the match.
+func Encode(m *match.Match) ([]byte, error) {
+	data, err := json.MarshalIndent(Canonicalize(m), "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Result is the outcome of checking a single fixture.
+type Result struct {
+	Demo string
+	// Err is set if the demo could not be parsed, the golden file is missing
+	// or differs from the output of the parser.
+	Err error
+}
+
+// Check parses every demo in dir and compares its canonical JSON against the
+// golden file next to it. If update is true, the golden files are written
+// instead.
+func Check(dir string, update bool) ([]Result, error) {
+	demos, err := filepath.Glob(filepath.Join(dir, "*.dem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(demos)
+
+	var results []Result
+	for _, demo := range demos {
+		results = append(results, Result{Demo: demo, Err: checkDemo(demo, update)})
+	}
+	return results, nil
+}
+
+func checkDemo(demo string, update bool) error {
+	m, err := match.NewMatch(demo, -1, -1)
+	if err != nil {
+		return fmt.Errorf("trying to parse demo: %v", err)
+	}
+	got, err := Encode(m)
+	if err != nil {
+		return fmt.Errorf("trying to encode match: %v", err)
+	}
+
+	goldenFile := strings.TrimSuffix(demo, filepath.Ext(demo)) + goldenSuffix
+	if update {
+		return ioutil.WriteFile(goldenFile, got, 0644)
+	}
+	want, err := ioutil.ReadFile(goldenFile)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("golden file %v is missing, run with -update to create it", goldenFile)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("output differs from %v: %v", goldenFile, firstDifference(string(want), string(got)))
+	}
+	return nil
+}
+
+// firstDifference returns a short description of the first line that
+// differs between want and got.
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) && i < len(gotLines); i++ {
+		if wantLines[i] != gotLines[i] {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, strings.TrimSpace(wantLines[i]), strings.TrimSpace(gotLines[i]))
+		}
+	}
+	return fmt.Sprintf("want %d lines, got %d lines", len(wantLines), len(gotLines))
+}
@@ -0,0 +1,18 @@
+package golden
+
+import "testing"
+
+func TestGolden(t *testing.T) {
+	results, err := Check("../../testdata/demos", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatal("no demo fixtures found")
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Demo, result.Err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package locale
+
+// english contains the English strings of the user interface. Its keys are
+// the keys of the locale files.
+var english = map[string]string{
+	// message boxes
+	"error.title":              "Error",
+	"error.sdl_init":           "trying to initialize SDL:\n%v",
+	"error.ttf_init":           "trying to initialize the TTF lib:\n%v",
+	"error.font_file":          "trying to open font file, using the built-in font instead:\n%v",
+	"error.font_builtin":       "trying to open the built-in font:\n%v",
+	"error.create_window":      "trying to create SDL window:\n%v",
+	"error.create_renderer":    "trying to create SDL renderer:\n%v",
+	"error.select_demo":        "trying to select a demo:\n%v",
+	"error.parse_demo":         "trying to parse demo file:\n%v",
+	"error.overview_dir":       "trying to load map overview image from %v: \n%v \nFollow the instructions on https://github.com/linus4/csgoverview to place the overview images in this directory.",
+	"error.overview_current":   "trying to load map overview image from current directory: \n%v\n%v\nFollow the instructions on https://github.com/linus4/csgoverview to place the overview images in this directory.",
+	"error.create_map_texture": "trying to create mapTexture from Surface:\n%v",
+
+	// demo picker
+	"picker.title":    "csgoverview - Select a demo",
+	"picker.header":   "Select a demo in %v (arrow keys and enter or double click, escape to quit)",
+	"picker.no_demos": "no demos found in %v, use the command-line option -demodir or pass the path to a demo",
+
+	// window title
+	"title.counter_terrorists": "Counter Terrorists",
+	"title.terrorists":         "Terrorists",
+	"title.round":              "Round %d",
+	"title.afterplants":        "Afterplants on %s",
+	"title.replay":             "Replay",
+	"title.round_loop":         "Round loop",
+	"title.playlist":           "Demo %d/%d",
+	"title.custom_loop":        "Loop (%.1f s)",
+
+	// infobar and timer
+	"infobar.vac":     "VAC",
+	"infobar.helmet":  "H",
+	"infobar.armor":   "A",
+	"infobar.defuser": "D",
+	"timer.warmup":    "Warmup",
+	"timer.paused":    "Paused",
+	"timer.defuse":    "Defuse %.1f s",
+
+	"killfeed.suicide":     "suicide",
+	"killfeed.fall_damage": "fall damage",
+
+	// notes panel
+	"notes.edit_bookmark": "Note",
+	"notes.edit_round":    "Note on round %d",
+	"notes.edit_hint":     "ctrl+return saves, escape discards",
+
+	"calibration.title": "Calibrating %v",
+	"calibration.move":  "arrow keys move the positions (with shift 10 pixels)",
+	"calibration.scale": "page up/down scales the positions (with shift faster)",
+	"calibration.save":  "return saves to %v, escape cancels",
+
+	"search.title":   "Search",
+	"search.hint":    "kill, shot, plant, throw, smoke, player:, victim:, weapon:, area:, team:, round:",
+	"search.error":   "Error: %v",
+	"search.result":  "Result %d of %d for %v (j/J)",
+	"search.results": "%d results for %v",
+
+	// tooltips
+	"tooltip.killed_by":    "killed by %v with %v",
+	"tooltip.died":         "died (%v)",
+	"tooltip.suicide":      "killed themselves with %v",
+	"tooltip.fall_damage":  "died of fall damage",
+	"tooltip.team_kill":    "killed by teammate %v with %v",
+	"tooltip.health":       "%v HP",
+	"tooltip.armor":        "%v armor",
+	"tooltip.armor_helmet": "%v armor + helmet",
+	"tooltip.defuse_kit":   "Defuse Kit",
+	"tooltip.kda":          "K / A / D: %v / %v / %v",
+	"tooltip.thrown_by":    "thrown by %v",
+
+	// measurement
+	"measure.distance":    "%.0f units (%.1f m)",
+	"measure.travel_time": "%.1f s running with the knife",
+	"timing.t":            "T spawn: %.1f s",
+	"timing.ct":           "CT spawn: %.1f s",
+	"timing.unknown":      "spawns unknown",
+
+	// POV panel
+	"pov.hint":    "select a player to see their stats",
+	"pov.flashed": "flashed for %.1f s",
+	"pov.kill":    "-%.0f s killed %v (%v)",
+	"pov.death":   "-%.0f s killed by %v (%v)",
+	"pov.died":    "-%.0f s died",
+
+	"health.title": "HP in round %d",
+
+	// economy simulator
+	"economy.team":       "%v: loss streak %d, loss bonus %d $",
+	"economy.win":        "  win: %d $ next round, %d/%d can full buy",
+	"economy.loss":       "  loss: %d $ next round, %d/%d can full buy, then loss bonus %d $",
+	"economy.plant_loss": "  loss after plant: %d $ next round, %d/%d can full buy",
+	"economy.hint":       "click a player to cycle actual, eco, force and full buy (kill rewards not included)",
+	"economy.no_round":   "no round",
+
+	// help overlay, one entry per key binding
+	"help.pause":           "toggle pause",
+	"help.backward_5s":     "5 s backwards",
+	"help.forward_5s":      "5 s forwards",
+	"help.backward_10s":    "10 s backwards",
+	"help.forward_10s":     "10 s forwards",
+	"help.step_backward":   "one frame back (shift 0.25 s, ctrl 1 s, alt 5 s)",
+	"help.step_forward":    "one frame forward (shift 0.25 s, ctrl 1 s, alt 5 s)",
+	"help.instant_replay":  "replay the last seconds at half speed",
+	"help.speed_up":        "hold to speed up 5 x",
+	"help.slow_down":       "hold to slow down to 0.5 x",
+	"help.faster":          "double the playback speed",
+	"help.slower":          "halve the playback speed",
+	"help.previous_round":  "round backwards",
+	"help.next_round":      "round forwards",
+	"help.previous_half":   "to start of previous half",
+	"help.next_half":       "to start of next half",
+	"help.round_loop":      "toggle playing the current round in a loop",
+	"help.loop_in":         "set the start of the loop to the current time",
+	"help.loop_out":        "set the end of the loop to the current time",
+	"help.clear_loop":      "remove the start and end of the loop",
+	"help.next_demo":       "to next demo of the playlist",
+	"help.previous_demo":   "to previous demo of the playlist",
+	"help.next_plant":      "to next bomb plant",
+	"help.previous_plant":  "to previous bomb plant",
+	"help.afterplant_site": "cycle site filter for bomb plants",
+	"help.awp_overlay":     "toggle AWP overlay",
+	"help.server_info":     "toggle server info and convars",
+	"help.health_graphs":   "toggle the HP of all players over the round",
+	"help.pov_panel":       "toggle the panel with the stats of the selected player",
+	"help.economy":         "toggle the economy simulator (click players to change their buys)",
+	"help.inferno_extents": "toggle outline of where molotovs will spread",
+	"help.round_strip":     "toggle round strip and progress bar (click to jump)",
+	"help.clear_selection": "clear the selection of players",
+	"help.clear_ghost":     "remove the ghost (place it with a right click)",
+	"help.measure":         "toggle measuring (click two points)",
+	"help.spawn_timing":    "toggle spawn timings (click a point)",
+	"help.screenshot":      "save a screenshot for the HTML report",
+	"help.map_section":     "show the next floor of the map",
+	"help.calibrate":       "align the positions with the overview",
+	"help.help":            "toggle this help",
+	"help.mouse_wheel_key": "Wheel",
+	"help.mouse_wheel":     "scroll 1 second forwards/backwards",
+
+	"help.altitude_shading": "toggle larger dots for higher positions",
+	"help.sound_overlay":    "toggle circles in which sounds can be heard",
+	"help.entry_paths":      "toggle arrows of the paths of the T to the sites",
+	"help.smoke_kills":      "toggle all kills through smokes",
+	"help.line_of_sight":    "toggle the area the selected players can see",
+	"help.crossfire":        "toggle the crossfire of the selected CTs and its gaps",
+	"help.zones":            "toggle the outlines of the bombsites and hostage zones",
+
+	"help.search":          "search events, e.g. kill weapon:awp player:name area:A",
+	"help.next_result":     "to next search result",
+	"help.previous_result": "to previous search result",
+
+	"help.toggle_bookmark":   "add or remove a bookmark at the current time",
+	"help.next_bookmark":     "to next bookmark",
+	"help.previous_bookmark": "to previous bookmark",
+	"help.bookmark_note":     "write a note at the current time (ctrl+return saves)",
+	"help.round_note":        "write a note on the current round (ctrl+return saves)",
+	"help.undo":              "undo the last change of the bookmarks and notes",
+	"help.redo":              "redo the last undone change of the bookmarks and notes",
+
+	"help.layer_shots":           "show/hide shots",
+	"help.layer_grenade_effects": "show/hide grenade effects",
+	"help.layer_killfeed":        "show/hide killfeed and kill lines",
+	"help.layer_grenades":        "show/hide flying grenades",
+	"help.layer_infernos":        "show/hide molotovs and incendiaries",
+	"help.layer_bomb":            "show/hide bomb",
+	"help.layer_dead_players":    "show/hide dead players",
+	"help.layer_dropped_weapons": "show/hide weapons on the ground",
+
+	// server info overlay
+	"serverinfo.protocol": "network protocol %d, %.0f tick",
+}
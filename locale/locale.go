@@ -0,0 +1,92 @@
+// Package locale translates the strings of the user interface. English is
+// built in, other languages are loaded from locale files.
+//
+// A locale file is a JSON object that maps the keys of the English strings in
+// en.go to their translations, e.g. pt_BR.json. Keys that are missing in a
+// locale file fall back to English.
+package locale
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var translations = map[string]string{}
+
+// Language is the language that was loaded with Load or "en" if no locale
+// file was loaded.
+var Language = "en"
+
+// T returns the translation of the string with the given key. If the key is
+// neither translated nor one of the English strings, the key itself is
+// returned, which makes missing strings visible in the interface.
+func T(key string) string {
+	if s, ok := translations[key]; ok {
+		return s
+	}
+	if s, ok := english[key]; ok {
+		return s
+	}
+	return key
+}
+
+// Sprintf formats the translation of the string with the given key.
+func Sprintf(key string, a ...interface{}) string {
+	return fmt.Sprintf(T(key), a...)
+}
+
+// Load reads the locale file of language from the first of dirs that contains
+// one. Both the full language (e.g. pt_BR) and its base (pt) are tried. English
+// needs no locale file.
+func Load(language string, dirs ...string) error {
+	translations = map[string]string{}
+	Language = "en"
+	if language == "" || language == "en" || strings.HasPrefix(language, "en_") {
+		return nil
+	}
+
+	candidates := []string{language}
+	if i := strings.IndexAny(language, "_-"); i > 0 {
+		candidates = append(candidates, language[:i])
+	}
+	for _, candidate := range candidates {
+		for _, dir := range dirs {
+			data, err := ioutil.ReadFile(filepath.Join(dir, candidate+".json"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			err = json.Unmarshal(data, &translations)
+			if err != nil {
+				return fmt.Errorf("trying to parse locale file %v.json: %v", candidate, err)
+			}
+			Language = candidate
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no locale file found for language %v", language)
+}
+
+// Detect returns the language of the user from the environment variables
+// LC_ALL, LC_MESSAGES and LANG, e.g. pt_BR for pt_BR.UTF-8. It returns an
+// empty string if none of them is set.
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" || value == "C" || value == "POSIX" {
+			continue
+		}
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+// Package metadata looks up information about the event a match was played
+// at, e.g. the tournament and bracket stage, and attaches it to the summary
+// of the match.
+//
+// HLTV does not offer an API, so only Liquipedia is supported.
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/network"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+const (
+	liquipediaAPIURL = "https://api.liquipedia.net/api/v3/match"
+	liquipediaWiki   = "counterstrike"
+	liquipediaURL    = "https://liquipedia.net/counterstrike/"
+	// userAgent identifies the application as required by the Liquipedia API
+	// terms of use.
+	userAgent = "csgoverview (https://github.com/linus4/csgoverview)"
+	// searchWindow is the time around the given date in which matches are
+	// considered, the date of a demo file is usually not exact.
+	searchWindow = 24 * time.Hour
+)
+
+// Provider looks up the event of a match between two teams around a date.
+type Provider interface {
+	// Lookup returns the information about the match or false if no match
+	// was found.
+	Lookup(teamA, teamB string, date time.Time) (common.EventInfo, bool, error)
+}
+
+// Attach looks up the event of the match with the clan names of both teams
+// and stores it in m.Summary.Event. Nothing is looked up if a clan name is
+// missing, e.g. in matchmaking demos.
+func Attach(m *match.Match, p Provider, date time.Time) error {
+	teamA := m.Summary.ClanNameCounterTerrorists
+	teamB := m.Summary.ClanNameTerrorists
+	if teamA == "" || teamB == "" {
+		return nil
+	}
+	info, ok, err := p.Lookup(teamA, teamB, date)
+	if err != nil {
+		return err
+	}
+	if ok {
+		m.Summary.Event = &info
+	}
+	return nil
+}
+
+// Liquipedia looks up matches with the Liquipedia database API, which
+// requires an API key.
+type Liquipedia struct {
+	Key        string
+	HTTPClient *http.Client
+}
+
+// NewLiquipedia returns a Liquipedia provider that uses the given API key.
+func NewLiquipedia(key string) *Liquipedia {
+	return &Liquipedia{
+		Key:        key,
+		HTTPClient: network.NewClient(10 * time.Second),
+	}
+}
+
+type liquipediaMatch struct {
+	PageName   string `json:"pagename"`
+	Tournament string `json:"tournament"`
+	Section    string `json:"section"`
+	Date       string `json:"date"`
+	Opponents  []struct {
+		Name string `json:"name"`
+	} `json:"match2opponents"`
+	BracketData struct {
+		Title string `json:"title"`
+	} `json:"match2bracketdata"`
+}
+
+// Lookup implements Provider.
+func (l *Liquipedia) Lookup(teamA, teamB string, date time.Time) (common.EventInfo, bool, error) {
+	conditions := fmt.Sprintf("[[opponent::%v]] AND [[date::>%v]] AND [[date::<%v]]",
+		teamA, date.Add(-searchWindow).Format("2006-01-02"), date.Add(searchWindow).Format("2006-01-02"))
+	query := url.Values{}
+	query.Set("wiki", liquipediaWiki)
+	query.Set("conditions", conditions)
+	query.Set("query", "pagename, tournament, section, date, match2opponents, match2bracketdata")
+	query.Set("limit", "50")
+
+	req, err := http.NewRequest(http.MethodGet, liquipediaAPIURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return common.EventInfo{}, false, err
+	}
+	req.Header.Set("Authorization", "Apikey "+l.Key)
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := l.HTTPClient.Do(req)
+	if err != nil {
+		return common.EventInfo{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return common.EventInfo{}, false, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+
+	var result struct {
+		Result []liquipediaMatch `json:"result"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return common.EventInfo{}, false, fmt.Errorf("trying to decode Liquipedia response: %v", err)
+	}
+
+	for _, lm := range result.Result {
+		if !hasOpponent(lm, teamA) || !hasOpponent(lm, teamB) {
+			continue
+		}
+		info := common.EventInfo{
+			Name:  lm.Tournament,
+			Stage: lm.Section,
+			URL:   liquipediaURL + lm.PageName,
+		}
+		if lm.BracketData.Title != "" {
+			info.Stage = lm.BracketData.Title
+		}
+		info.Date, _ = time.Parse("2006-01-02 15:04:05", lm.Date)
+		return info, true, nil
+	}
+	return common.EventInfo{}, false, nil
+}
+
+func hasOpponent(lm liquipediaMatch, team string) bool {
+	for _, o := range lm.Opponents {
+		if normalizeTeamName(o.Name) == normalizeTeamName(team) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTeamName makes clan names comparable to the team names of
+// Liquipedia, which often differ in case and prefixes.
+func normalizeTeamName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(name, "team ")
+	return strings.Join(strings.Fields(name), "")
+}
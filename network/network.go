@@ -0,0 +1,50 @@
+// Package network creates the HTTP clients of all features that access the
+// network, so that they all use the same proxy.
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// proxyURL is the proxy set with SetProxy. If it is nil, the proxy is taken
+// from the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+var proxyURL *url.URL
+
+// SetProxy sets the proxy of all clients, e.g. "http://proxy:3128" or
+// "socks5://localhost:1080". An empty string restores the proxy from the
+// environment variables.
+func SetProxy(rawURL string) error {
+	if rawURL == "" {
+		proxyURL = nil
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("trying to parse proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("trying to parse proxy URL: unsupported scheme %q, use http, https or socks5", u.Scheme)
+	}
+	proxyURL = u
+	return nil
+}
+
+func proxy(req *http.Request) (*url.URL, error) {
+	if proxyURL != nil {
+		return proxyURL, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// NewClient returns an HTTP client that uses the proxy. A timeout of 0 means
+// no timeout, e.g. for streams.
+func NewClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,305 @@
+// Package assets contains the images that are compiled into csgoverview, so
+// that the viewer and the exports draw the same icons without loading files.
+package assets
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// IconWidth and IconHeight are the size of an icon in the atlas in pixels.
+const (
+	IconWidth  = 24
+	IconHeight = 8
+)
+
+// Icon is an icon of the atlas. Weapons of a class share an icon, e.g. all
+// rifles that are not sniper rifles.
+type Icon byte
+
+// Possible values for Icon.
+const (
+	IconNone Icon = iota
+	IconPistol
+	IconSMG
+	IconShotgun
+	IconMachineGun
+	IconRifle
+	IconSniper
+	IconKnife
+	IconZeus
+	IconBomb
+	IconDefuseKit
+	IconKevlar
+	IconHelmet
+	IconHE
+	IconFlash
+	IconSmoke
+	IconMolotov
+	IconDecoy
+	iconCount
+)
+
+// iconMasks are the pixels of the icons, '#' is set and '.' is transparent.
+// The weapons point to the right.
+var iconMasks = map[Icon][IconHeight]string{
+	IconPistol: {
+		"........................",
+		"......###########.......",
+		"......############......",
+		"......#####..#..........",
+		"......####..#...........",
+		".....#####..............",
+		".....####...............",
+		"........................",
+	},
+	IconSMG: {
+		"........................",
+		"...#############........",
+		"..#################.....",
+		"..####..##...#..........",
+		"...##...##..#...........",
+		"........##..............",
+		"........#...............",
+		"........................",
+	},
+	IconShotgun: {
+		"........................",
+		".##...........#.........",
+		".#######################",
+		"#########...#######.....",
+		"####....................",
+		"###.....................",
+		"........................",
+		"........................",
+	},
+	IconMachineGun: {
+		"..........####..........",
+		".######################.",
+		"########################",
+		"#######..#####...#......",
+		"####....######..........",
+		"###......####...........",
+		"........................",
+		"........................",
+	},
+	IconRifle: {
+		"......#.............#...",
+		".#######################",
+		"########...########.....",
+		"####...##..##...........",
+		"###....##..##...........",
+		".......#....#...........",
+		"........................",
+		"........................",
+	},
+	IconSniper: {
+		"......#######...........",
+		"........###.............",
+		"########################",
+		"#######..###########....",
+		"####..#.................",
+		"###.....................",
+		"........................",
+		"........................",
+	},
+	IconKnife: {
+		"........................",
+		".....##.................",
+		".#####################..",
+		".#######################",
+		".#####################..",
+		".....##.................",
+		"........................",
+		"........................",
+	},
+	IconZeus: {
+		"..............#.........",
+		"......##########...#....",
+		"......############..#...",
+		"......#####..#.....#....",
+		"......####..............",
+		".....#####..............",
+		"........................",
+		"........................",
+	},
+	IconBomb: {
+		"...##################...",
+		"...#..##..##..##..#.#...",
+		"...##################...",
+		"...#..##..##..##..#.#...",
+		"...##################...",
+		"...##################...",
+		"........................",
+		"........................",
+	},
+	IconDefuseKit: {
+		".......##.....##........",
+		"........##...##.........",
+		".........##.##..........",
+		"..........###...........",
+		".........##.##..........",
+		"........##...##.........",
+		".......###...###........",
+		"........................",
+	},
+	IconKevlar: {
+		"......###....###........",
+		".....############.......",
+		".....############.......",
+		"......##########........",
+		"......##########........",
+		"......##########........",
+		"......##########........",
+		"........................",
+	},
+	IconHelmet: {
+		"........................",
+		".......#######..........",
+		".....###########........",
+		"....#############.......",
+		"....#############.......",
+		"....###############.....",
+		"....##.........##.......",
+		"........................",
+	},
+	IconHE: {
+		"..........###...........",
+		".........##.##..........",
+		".......######...........",
+		"......########..........",
+		"......########..........",
+		"......########..........",
+		".......######...........",
+		"........................",
+	},
+	IconFlash: {
+		".........####...........",
+		"........##..............",
+		".......######...........",
+		".......######...........",
+		".......######...........",
+		".......######...........",
+		".......######...........",
+		"........................",
+	},
+	IconSmoke: {
+		".........####...........",
+		"........##..............",
+		".......######...........",
+		".......#....#...........",
+		".......######...........",
+		".......#....#...........",
+		".......######...........",
+		"........................",
+	},
+	IconMolotov: {
+		"..........#.#...........",
+		"..........##............",
+		"..........##............",
+		".........####...........",
+		"........######..........",
+		"........######..........",
+		"........######..........",
+		"........######..........",
+	},
+	IconDecoy: {
+		"........................",
+		".........##.............",
+		"........####............",
+		".......#.##.#...........",
+		".......######...........",
+		".......#....#...........",
+		".......######...........",
+		"........................",
+	},
+}
+
+var (
+	atlas     *image.NRGBA
+	atlasOnce sync.Once
+)
+
+// Atlas returns the image with all icons next to each other in the order of
+// their values, white on a transparent background so that they can be tinted.
+// The image is created on the first call and must not be modified.
+func Atlas() *image.NRGBA {
+	atlasOnce.Do(func() {
+		atlas = image.NewNRGBA(image.Rect(0, 0, int(iconCount-1)*IconWidth, IconHeight))
+		for icon, mask := range iconMasks {
+			bounds := icon.Bounds()
+			for y, row := range mask {
+				for x, pixel := range row {
+					if pixel == '#' {
+						atlas.SetNRGBA(bounds.Min.X+x, bounds.Min.Y+y, color.NRGBA{255, 255, 255, 255})
+					}
+				}
+			}
+		}
+	})
+	return atlas
+}
+
+// Bounds returns the area of the icon in the atlas. It is empty for IconNone.
+func (i Icon) Bounds() image.Rectangle {
+	if i == IconNone || i >= iconCount {
+		return image.Rectangle{}
+	}
+	x := int(i-1) * IconWidth
+	return image.Rect(x, 0, x+IconWidth, IconHeight)
+}
+
+// Image returns the icon as part of the atlas.
+func (i Icon) Image() image.Image {
+	return Atlas().SubImage(i.Bounds())
+}
+
+// IconFor returns the icon of the equipment or IconNone if it has none, e.g.
+// for EqWorld.
+func IconFor(equipment demoinfo.EquipmentType) Icon {
+	switch equipment {
+	case demoinfo.EqKnife:
+		return IconKnife
+	case demoinfo.EqZeus:
+		return IconZeus
+	case demoinfo.EqBomb:
+		return IconBomb
+	case demoinfo.EqDefuseKit:
+		return IconDefuseKit
+	case demoinfo.EqKevlar:
+		return IconKevlar
+	case demoinfo.EqHelmet:
+		return IconHelmet
+	case demoinfo.EqHE:
+		return IconHE
+	case demoinfo.EqFlash:
+		return IconFlash
+	case demoinfo.EqSmoke:
+		return IconSmoke
+	case demoinfo.EqMolotov, demoinfo.EqIncendiary:
+		return IconMolotov
+	case demoinfo.EqDecoy:
+		return IconDecoy
+	case demoinfo.EqM249, demoinfo.EqNegev:
+		return IconMachineGun
+	}
+	if common.IsSniperRifle(equipment) {
+		return IconSniper
+	}
+	switch equipment.Class() {
+	case demoinfo.EqClassPistols:
+		return IconPistol
+	case demoinfo.EqClassSMG:
+		return IconSMG
+	case demoinfo.EqClassHeavy:
+		return IconShotgun
+	case demoinfo.EqClassRifle:
+		return IconRifle
+	default:
+		return IconNone
+	}
+}
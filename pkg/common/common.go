@@ -0,0 +1,748 @@
+// Package common contains types that are used throughout this project.
+package common
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Phase corresponds to a phase of a round.
+type Phase int
+
+// Possible values for Phase type.
+const (
+	PhaseFreezetime Phase = iota
+	PhaseRegular
+	PhasePlanted
+	PhaseRestart
+	PhaseWarmup
+	PhaseHalftime
+)
+
+// RoundWinType is the way a round was won.
+type RoundWinType int
+
+// Possible values for RoundWinType type.
+const (
+	RoundWinTypeUnknown RoundWinType = iota
+	RoundWinTypeElimination
+	RoundWinTypeBombDefused
+	RoundWinTypeBombExploded
+	RoundWinTypeTime
+	RoundWinTypeSurrender
+)
+
+func (t RoundWinType) String() string {
+	switch t {
+	case RoundWinTypeElimination:
+		return "elimination"
+	case RoundWinTypeBombDefused:
+		return "defuse"
+	case RoundWinTypeBombExploded:
+		return "explosion"
+	case RoundWinTypeTime:
+		return "time"
+	case RoundWinTypeSurrender:
+		return "surrender"
+	default:
+		return "unknown"
+	}
+}
+
+// Round contains general information about a round and the economy of both
+// teams.
+type Round struct {
+	// Number is the number of the round, starting at 1.
+	Number             int
+	StartFrame         int
+	FreezetimeEndFrame int
+	// EndFrame is the frame at which the round was decided or -1 if the
+	// round did not end.
+	EndFrame          int
+	Winner            demoinfo.Team
+	WinType           RoundWinType
+	CounterTerrorists RoundTeam
+	Terrorists        RoundTeam
+	// IsKnifeRound is true if all kills of the round were made with knives
+	// and the score was reset afterwards.
+	IsKnifeRound bool
+	// Kills is the number of kills from the start of the round until the
+	// start of the next round.
+	Kills       int
+	BombPlanted bool
+	// MultiKills are the players that killed three or more enemies in the
+	// round, ordered by the frame of their last kill.
+	MultiKills []MultiKill
+}
+
+// MultiKill is a player that killed three or more enemies in a round.
+type MultiKill struct {
+	PlayerName string
+	SteamID64  uint64
+	Team       demoinfo.Team
+	Kills      int
+	// Frame is the frame of the last kill of the player in the round.
+	Frame int
+}
+
+// IsAce reports whether the player killed the whole enemy team.
+func (k MultiKill) IsAce() bool {
+	return k.Kills >= 5
+}
+
+func (k MultiKill) String() string {
+	if k.IsAce() {
+		return "ace"
+	}
+	return strconv.Itoa(k.Kills) + "k"
+}
+
+// RoundTeam contains the economy of a team in a round.
+type RoundTeam struct {
+	ClanName string
+	// StartMoney is the money of all players at the start of the round.
+	StartMoney int
+	// EquipmentValue is the value of the equipment of all players at the
+	// end of the freezetime.
+	EquipmentValue int
+	MoneySpent     int
+	// LossStreak is the number of losses that count towards the loss bonus
+	// at the start of the round.
+	LossStreak int
+	// LossBonus is the money every player receives if the team loses the
+	// round.
+	LossBonus int
+	// Reward is the money every player of the team received at the end of
+	// the round for winning or losing it, without the rewards for kills.
+	Reward int
+	// Survivors is the number of players that were alive when the round
+	// ended and SavedValue the value of their equipment, which they carry
+	// into the next round.
+	Survivors  int
+	SavedValue int
+}
+
+// Team returns the RoundTeam of the given side.
+func (r *Round) Team(team demoinfo.Team) *RoundTeam {
+	if team == demoinfo.TeamCounterTerrorists {
+		return &r.CounterTerrorists
+	}
+	return &r.Terrorists
+}
+
+// Half contains the frames at which a half of the match started and ended.
+type Half struct {
+	// Number is the number of the half, starting at 1. Halves after the
+	// second are overtime halves.
+	Number     int
+	StartFrame int
+	EndFrame   int
+	IsOvertime bool
+}
+
+// Summary contains the outcome of a match.
+type Summary struct {
+	ClanNameCounterTerrorists string
+	ClanNameTerrorists        string
+	// The final scores are those of the teams that played on the respective
+	// side in the last round.
+	ScoreCounterTerrorists int
+	ScoreTerrorists        int
+	RoundsPlayed           int
+	// KnifeRounds contains the numbers of all knife rounds.
+	KnifeRounds []int
+	// IsSurrendered is true if the match ended because a team surrendered.
+	IsSurrendered bool
+	// SurrenderedTeam is the side of the team that surrendered.
+	SurrenderedTeam demoinfo.Team
+	// Event is the event the match was played at. It is only set if the
+	// match was looked up with metadata.Attach.
+	Event *EventInfo
+}
+
+// EventInfo contains information about the event a match was played at.
+type EventInfo struct {
+	Name string
+	// Stage is the stage of the event or the round of the bracket, e.g.
+	// "Group A" or "Grand Final".
+	Stage string
+	Date  time.Time
+	URL   string
+}
+
+// ServerInfo contains information about the server and the client that
+// recorded the demo.
+type ServerInfo struct {
+	Name       string
+	ClientName string
+	// GameDirectory is the directory of the game on the server, e.g. csgo.
+	GameDirectory string
+	// Protocol is the version of the demo protocol.
+	Protocol int
+	// NetworkProtocol is the version of the game.
+	NetworkProtocol int
+	TickRate        float64
+}
+
+// Profile contains information about the Steam account of a player.
+type Profile struct {
+	SteamID64        uint64
+	PersonaName      string
+	ProfileURL       string
+	AvatarURL        string
+	VACBanned        bool
+	NumberOfVACBans  int
+	NumberOfGameBans int
+	DaysSinceLastBan int
+}
+
+// PauseKind is the reason the match was paused.
+type PauseKind int
+
+// Possible values for PauseKind type.
+const (
+	PauseTechnical PauseKind = iota
+	PauseTimeoutTerrorists
+	PauseTimeoutCounterTerrorists
+)
+
+// Pause contains the frames at which the match was paused and resumed.
+type Pause struct {
+	StartFrame int
+	EndFrame   int
+	Kind       PauseKind
+}
+
+// Gap is a point in the demo at which ticks are missing, e.g. because the
+// GOTV server lagged. Players teleport and the timers jump at gaps.
+// StartFrame is the last frame before and EndFrame the first frame after
+// the gap.
+type Gap struct {
+	StartFrame   int
+	EndFrame     int
+	MissingTicks int
+	Duration     time.Duration
+}
+
+// OverviewState contains all information that will be displayed for a single tick.
+type OverviewState struct {
+	// Frame is the frame of the demo that the state was parsed at, which the
+	// events refer to. Frames that the parser could not read have no state,
+	// so Frame can be larger than the index of the state.
+	Frame      int
+	IngameTick int
+	// Time is the time that passed since the start of the demo. It never
+	// decreases from one state to the next.
+	Time                  time.Duration
+	Players               []Player
+	Grenades              []GrenadeProjectile
+	Infernos              []Inferno
+	DroppedWeapons        []DroppedWeapon
+	Bomb                  Bomb
+	TeamCounterTerrorists TeamState
+	TeamTerrorists        TeamState
+}
+
+// GrenadeEffect extends the GrenadeEvent type from the parser by the Lifetime
+// variable that is used to draw the effect.
+type GrenadeEffect struct {
+	Position    Point
+	GrenadeType demoinfo.EquipmentType
+	Lifetime    int32
+}
+
+// GrenadeProjectile conains all information that is used to draw a grenade
+// mid air on the map.
+type GrenadeProjectile struct {
+	ID       int64
+	Position Point
+	// PositionZ is the height of the grenade, it is used to find the vertical
+	// section of the map the grenade is in.
+	PositionZ   float32
+	Type        demoinfo.EquipmentType
+	ThrowerName string
+	ThrowerTeam demoinfo.Team
+}
+
+// Kill contains all information that is displayed on the killfeed.
+type Kill struct {
+	Frame           int
+	KillerName      string
+	KillerSteamID64 uint64
+	// KillerID identifies the killer like Player.ID, also if the killer is a
+	// bot.
+	KillerID       uint64
+	KillerTeam     demoinfo.Team
+	KillerPosition Point
+	// KillerViewDirectionX is the yaw and KillerViewDirectionY the pitch
+	// of the killer in degrees, see NormalizePitch.
+	KillerViewDirectionX float32
+	KillerViewDirectionY float32
+	KillerPositionZ      float32
+	VictimName           string
+	VictimSteamID64      uint64
+	VictimTeam           demoinfo.Team
+	VictimPosition       Point
+	// VictimViewDirectionX is the yaw and VictimViewDirectionY the pitch of
+	// the victim in degrees.
+	VictimViewDirectionX float32
+	VictimViewDirectionY float32
+	VictimPositionZ      float32
+	Weapon               demoinfo.EquipmentType
+	Type                 KillType
+	// KillerBlind is true if the killer was flashed, IsNoScope if the killer
+	// used a sniper rifle without zooming in.
+	KillerBlind bool
+	IsNoScope   bool
+	// ThroughSmoke is true if the line from the killer to the victim crossed
+	// a smoke.
+	ThroughSmoke bool
+}
+
+// KillType distinguishes kills of enemies from team kills and deaths without
+// an enemy.
+type KillType byte
+
+// Possible values for KillType. KillWorld covers deaths without a killer
+// other than fall damage, e.g. by the explosion of the bomb.
+const (
+	KillEnemy KillType = iota
+	KillTeam
+	KillSuicide
+	KillFallDamage
+	KillWorld
+)
+
+func (t KillType) String() string {
+	switch t {
+	case KillTeam:
+		return "team kill"
+	case KillSuicide:
+		return "suicide"
+	case KillFallDamage:
+		return "fall damage"
+	case KillWorld:
+		return "world"
+	default:
+		return "enemy"
+	}
+}
+
+// IsSniperRifle reports whether the weapon has a scope that players zoom in
+// with to shoot accurately.
+func IsSniperRifle(weapon demoinfo.EquipmentType) bool {
+	switch weapon {
+	case demoinfo.EqAWP, demoinfo.EqSSG08, demoinfo.EqScar20, demoinfo.EqG3SG1:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasKiller reports whether the kill was made by a player, as opposed to
+// e.g. fall damage.
+func (k Kill) HasKiller() bool {
+	return k.KillerTeam != demoinfo.TeamUnassigned
+}
+
+// Distance returns the distance between killer and victim in world units.
+func (k Kill) Distance() float32 {
+	return k.KillerPosition.Distance(k.VictimPosition)
+}
+
+// VictimAngleOff returns the angle in degrees between the view direction of
+// the victim and the direction towards the killer. A large value means that
+// the victim was not looking at the killer, e.g. because the killer held an
+// off-angle.
+func (k Kill) VictimAngleOff() float32 {
+	return angleOff(k.VictimViewDirectionX, k.VictimPosition, k.KillerPosition)
+}
+
+// KillerAngleOff returns the angle in degrees between the view direction of
+// the killer and the direction towards the victim.
+func (k Kill) KillerAngleOff() float32 {
+	return angleOff(k.KillerViewDirectionX, k.KillerPosition, k.VictimPosition)
+}
+
+// KillerPitchOff returns the angle in degrees by which the crosshair of the
+// killer was below the head of the victim, negative values mean above. A large
+// value means that the killer aimed at the body or the feet.
+func (k Kill) KillerPitchOff() float32 {
+	distance := k.Distance()
+	if distance == 0 {
+		return 0
+	}
+	// the eyes and the head of standing players are at the same height, so
+	// the difference of the positions is the difference of the heights
+	heightDiff := float64(k.VictimPositionZ - k.KillerPositionZ)
+	headPitch := -math.Atan2(heightDiff, float64(distance)) * 180 / math.Pi
+	return NormalizePitch(k.KillerViewDirectionY) - float32(headPitch)
+}
+
+// NormalizePitch converts a pitch from the demo, which is in [0, 360), to
+// [-180, 180), so that looking up is negative and looking down positive.
+func NormalizePitch(pitch float32) float32 {
+	if pitch >= 180 {
+		return pitch - 360
+	}
+	return pitch
+}
+
+func angleOff(viewDirectionX float32, from, to Point) float32 {
+	if from == to {
+		return 0
+	}
+	direction := math.Atan2(float64(to.Y-from.Y), float64(to.X-from.X)) * 180 / math.Pi
+	diff := math.Mod(math.Abs(direction-float64(viewDirectionX)), 360)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return float32(diff)
+}
+
+// Timer contains the time remaining in the current phase of the round. It is
+// computed with match.Match.TimerAt.
+type Timer struct {
+	TimeRemaining time.Duration
+	// Duration is the total duration of the phase.
+	Duration time.Duration
+	Phase    Phase
+	IsPaused bool
+	// IsDefusing is true if the bomb is being defused. DefuseRemaining is the
+	// time until the defuse finishes, DefuseInTime reports whether that
+	// happens before the bomb explodes.
+	IsDefusing      bool
+	DefuseRemaining time.Duration
+	DefuseInTime    bool
+}
+
+// Shot contains information about a shot from a weapon.
+type Shot struct {
+	Frame            int
+	ShooterName      string
+	ShooterSteamID64 uint64
+	Weapon           demoinfo.EquipmentType
+	Position         Point
+	ViewDirectionX   float32
+	ViewDirectionY   float32
+	IsAwpShot        bool
+}
+
+// SoundType is the kind of noise a player made.
+type SoundType byte
+
+// Possible values for SoundType. Pulling the pin of a grenade is not recorded
+// in demos, the throw is used instead.
+const (
+	SoundFootstep SoundType = iota
+	SoundJump
+	SoundReload
+	SoundGrenadeThrow
+)
+
+// Radius returns roughly the distance in game units at which enemies can hear
+// the sound.
+func (t SoundType) Radius() float32 {
+	switch t {
+	case SoundFootstep, SoundJump:
+		return 1100
+	case SoundReload:
+		return 750
+	case SoundGrenadeThrow:
+		return 600
+	default:
+		return 0
+	}
+}
+
+// Sound is a noise that a player made, e.g. a footstep or a reload. Many
+// demos do not contain footsteps.
+type Sound struct {
+	Frame     int
+	Type      SoundType
+	SteamID64 uint64
+	Team      demoinfo.Team
+	Position  Point
+}
+
+// ThrowTechnique is the way a player moved while releasing a grenade.
+type ThrowTechnique byte
+
+// Possible values for ThrowTechnique.
+const (
+	ThrowStanding ThrowTechnique = iota
+	ThrowWalking
+	ThrowRunning
+	ThrowJump
+	ThrowRunningJump
+)
+
+func (t ThrowTechnique) String() string {
+	switch t {
+	case ThrowWalking:
+		return "walking"
+	case ThrowRunning:
+		return "running"
+	case ThrowJump:
+		return "jump"
+	case ThrowRunningJump:
+		return "running jump"
+	default:
+		return "standing"
+	}
+}
+
+// GrenadeThrow is a grenade that a player threw, with the position and the
+// view direction of the player at the release, which are needed to repeat
+// the lineup.
+type GrenadeThrow struct {
+	Frame     int
+	Grenade   demoinfo.EquipmentType
+	Name      string
+	SteamID64 uint64
+	Team      demoinfo.Team
+	Technique ThrowTechnique
+	// Position and PositionZ are the position of the feet of the player.
+	Position  Point
+	PositionZ float32
+	// ViewDirectionX is the yaw and ViewDirectionY the pitch of the player
+	// in degrees, see NormalizePitch.
+	ViewDirectionX float32
+	ViewDirectionY float32
+	// Speed is the horizontal speed of the player in units per second.
+	Speed float32
+	// Landing is the position where the grenade detonated or came to rest
+	// and HasLanding is false if the projectile was not destroyed before the
+	// end of the demo.
+	Landing    Point
+	LandingZ   float32
+	HasLanding bool
+}
+
+// Hit contains information about damage that a player took.
+type Hit struct {
+	Frame             int
+	AttackerName      string
+	AttackerSteamID64 uint64
+	AttackerTeam      demoinfo.Team
+	VictimName        string
+	VictimSteamID64   uint64
+	VictimTeam        demoinfo.Team
+	Weapon            demoinfo.EquipmentType
+	HealthDamage      int16
+	ArmorDamage       int16
+	IsHeadshot        bool
+}
+
+// Smoke contains information about a smoke grenade from the moment it started
+// to emit smoke until it expired.
+type Smoke struct {
+	Position    Point
+	StartFrame  int
+	EndFrame    int
+	ThrowerName string
+	ThrowerTeam demoinfo.Team
+}
+
+// BombPlant contains information about a planted C4 and how the round ended
+// afterwards.
+type BombPlant struct {
+	Frame       int
+	Site        string
+	Position    Point
+	PlanterName string
+	Winner      demoinfo.Team
+	Defused     bool
+	Exploded    bool
+	Defuses     []Defuse
+}
+
+// Defuse is an attempt to defuse the bomb.
+type Defuse struct {
+	StartFrame int
+	// EndFrame is the frame at which the defuse was aborted or finished or
+	// -1 if the demo ended before.
+	EndFrame         int
+	DefuserName      string
+	DefuserSteamID64 uint64
+	HasKit           bool
+	// Duration is the time the defuse takes, which depends on the kit.
+	Duration time.Duration
+}
+
+// PlantAttempt is an attempt to plant the bomb.
+type PlantAttempt struct {
+	StartFrame int
+	// EndFrame is the frame at which the plant was aborted or finished or -1
+	// if the demo ended before.
+	EndFrame         int
+	PlanterName      string
+	PlanterSteamID64 uint64
+	Site             string
+	Planted          bool
+	// Duration is the time the plant takes.
+	Duration time.Duration
+}
+
+// ActionProgress is the progress of a player that is planting or defusing the
+// bomb.
+type ActionProgress struct {
+	SteamID64 uint64
+	IsDefuse  bool
+	// Progress is the share of the action that is done, from 0 to 1.
+	Progress float64
+}
+
+// DroppedWeapon is a weapon or grenade that lies on the ground.
+type DroppedWeapon struct {
+	ID       int64
+	Type     demoinfo.EquipmentType
+	Position Point
+}
+
+// Pickup is a weapon that a player picked up after another player dropped
+// it, e.g. a weapon that was given to a teammate or taken from a dead enemy.
+// Weapons that are bought are not picked up from the ground and left out.
+type Pickup struct {
+	Frame              int
+	PlayerName         string
+	PlayerSteamID64    uint64
+	PlayerTeam         demoinfo.Team
+	Weapon             demoinfo.EquipmentType
+	DroppedByName      string
+	DroppedBySteamID64 uint64
+	DroppedByTeam      demoinfo.Team
+	// DropFrame is the frame at which the weapon was dropped.
+	DropFrame int
+}
+
+// Inferno contains the hull points of the surface area of a molotov or
+// incendiary grenade.
+type Inferno struct {
+	ID           int64
+	ConvexHull2D []Point
+}
+
+// Bomb contains all relevant information about the C4.
+type Bomb struct {
+	Position       Point
+	IsBeingCarried bool
+}
+
+// Player contains all relevant information about a player in the match.
+type Player struct {
+	// ID identifies the player within the match. It is the SteamID64 or,
+	// for bots, which all have the SteamID64 0, a number derived from the
+	// user ID.
+	ID        uint64
+	Name      string
+	SteamID64 uint64
+	Team      demoinfo.Team
+	Position  Point
+	// PositionZ is the height of the player, it is only used by exports
+	// that need three dimensional positions.
+	PositionZ          float32
+	LastAlivePosition  Point
+	ViewDirectionX     float32
+	ViewDirectionY     float32
+	FlashDuration      time.Duration
+	FlashTimeRemaining time.Duration
+	Inventory          []InventoryItem
+	Health             int16
+	Armor              int16
+	Money              int16
+	Kills              int16
+	Deaths             int16
+	Assists            int16
+	IsAlive            bool
+	IsDefusing         bool
+	HasHelmet          bool
+	HasDefuseKit       bool
+	HasBomb            bool
+	// HUD is what the infobar shows about the player.
+	HUD HUD
+	// ActiveWeapon is the weapon the player holds or EqUnknown if the player
+	// holds nothing.
+	ActiveWeapon demoinfo.EquipmentType
+	// ObserverSlot is the key (0 to 9) that casters press to spectate the
+	// player or -1 if the player has no slot.
+	ObserverSlot int
+}
+
+// InventoryItem contains the weapons or grenades of one type that a player
+// carries. Count is the number of items of the type, e.g. 2 for two
+// flashbangs, and Ammo is the number of bullets in the magazine of a weapon.
+type InventoryItem struct {
+	Type  demoinfo.EquipmentType
+	Count int
+	Ammo  int
+}
+
+// ArmorType is the armor that a player wears.
+type ArmorType byte
+
+// Possible values for ArmorType.
+const (
+	ArmorNone ArmorType = iota
+	ArmorKevlar
+	ArmorKevlarHelmet
+)
+
+// HUD contains the equipment and the status of a player as the infobar shows
+// them. It is computed while parsing so that renderers do not have to search
+// the inventory in every frame. Primary and Secondary are EqUnknown if the
+// player has no such weapon.
+type HUD struct {
+	Health       int16
+	Armor        ArmorType
+	HasDefuseKit bool
+	HasBomb      bool
+	Money        int16
+	Primary      demoinfo.EquipmentType
+	Secondary    demoinfo.EquipmentType
+	Grenades     []InventoryItem
+}
+
+// TeamState contains information about a team in the match.
+type TeamState struct {
+	ClanName string
+	Score    byte
+}
+
+// Point contains the coordinates for a point on the map.
+type Point struct {
+	X float32
+	Y float32
+}
+
+// Distance returns the euclidean distance between p and q.
+func (p Point) Distance(q Point) float32 {
+	dx := float64(p.X - q.X)
+	dy := float64(p.Y - q.Y)
+	return float32(math.Sqrt(dx*dx + dy*dy))
+}
+
+// DistanceToSegment returns the shortest distance between p and the line
+// segment from a to b.
+func (p Point) DistanceToSegment(a, b Point) float32 {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return p.Distance(a)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lengthSquared
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	projection := Point{
+		X: a.X + t*dx,
+		Y: a.Y + t*dy,
+	}
+	return p.Distance(projection)
+}
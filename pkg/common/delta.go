@@ -0,0 +1,293 @@
+package common
+
+import (
+	"reflect"
+	"time"
+)
+
+// StateDelta contains the differences between two OverviewStates. It can be
+// used to transmit only the changes from one state to the next instead of the
+// complete state. Fields that did not change are nil or empty.
+type StateDelta struct {
+	Frame      int
+	IngameTick int
+	Time       time.Duration
+	Players    []PlayerDelta `json:",omitempty"`
+	// RemovedPlayers contains the IDs of the players that left.
+	RemovedPlayers        []uint64            `json:",omitempty"`
+	Grenades              []GrenadeProjectile `json:",omitempty"`
+	RemovedGrenades       []int64             `json:",omitempty"`
+	Infernos              []Inferno           `json:",omitempty"`
+	InfernosChanged       bool                `json:",omitempty"`
+	DroppedWeapons        []DroppedWeapon     `json:",omitempty"`
+	DroppedWeaponsChanged bool                `json:",omitempty"`
+	Bomb                  *Bomb               `json:",omitempty"`
+	TeamCounterTerrorists *TeamState          `json:",omitempty"`
+	TeamTerrorists        *TeamState          `json:",omitempty"`
+}
+
+// PlayerDelta contains the changes of a single player. Movement and damage
+// are transmitted on their own because they change most often; if anything
+// else changed, Player contains the complete new state of the player.
+type PlayerDelta struct {
+	// ID is the Player.ID of the player.
+	ID             uint64
+	Position       *Point   `json:",omitempty"`
+	PositionZ      *float32 `json:",omitempty"`
+	ViewDirectionX *float32 `json:",omitempty"`
+	ViewDirectionY *float32 `json:",omitempty"`
+	Health         *int16   `json:",omitempty"`
+	Armor          *int16   `json:",omitempty"`
+	Player         *Player  `json:",omitempty"`
+}
+
+// IsEmpty reports whether nothing but the frame, the tick and the time
+// changed.
+func (d StateDelta) IsEmpty() bool {
+	return len(d.Players) == 0 && len(d.RemovedPlayers) == 0 && len(d.Grenades) == 0 &&
+		len(d.RemovedGrenades) == 0 && !d.InfernosChanged && !d.DroppedWeaponsChanged && d.Bomb == nil &&
+		d.TeamCounterTerrorists == nil && d.TeamTerrorists == nil
+}
+
+// DiffStates returns the changes that turn state a into state b.
+func DiffStates(a, b OverviewState) StateDelta {
+	delta := StateDelta{
+		Frame:      b.Frame,
+		IngameTick: b.IngameTick,
+		Time:       b.Time,
+	}
+
+	oldPlayers := make(map[uint64]*Player, len(a.Players))
+	for i := range a.Players {
+		oldPlayers[a.Players[i].ID] = &a.Players[i]
+	}
+	for i := range b.Players {
+		newPlayer := &b.Players[i]
+		oldPlayer, ok := oldPlayers[newPlayer.ID]
+		delete(oldPlayers, newPlayer.ID)
+		if !ok {
+			player := *newPlayer
+			delta.Players = append(delta.Players, PlayerDelta{ID: player.ID, Player: &player})
+			continue
+		}
+		if playerDelta, changed := diffPlayers(oldPlayer, newPlayer); changed {
+			delta.Players = append(delta.Players, playerDelta)
+		}
+	}
+	for _, p := range a.Players {
+		if _, removed := oldPlayers[p.ID]; removed {
+			delta.RemovedPlayers = append(delta.RemovedPlayers, p.ID)
+		}
+	}
+
+	oldGrenades := make(map[int64]GrenadeProjectile, len(a.Grenades))
+	for _, g := range a.Grenades {
+		oldGrenades[g.ID] = g
+	}
+	for _, g := range b.Grenades {
+		old, ok := oldGrenades[g.ID]
+		delete(oldGrenades, g.ID)
+		if !ok || old != g {
+			delta.Grenades = append(delta.Grenades, g)
+		}
+	}
+	for _, g := range a.Grenades {
+		if _, removed := oldGrenades[g.ID]; removed {
+			delta.RemovedGrenades = append(delta.RemovedGrenades, g.ID)
+		}
+	}
+
+	if !infernosEqual(a.Infernos, b.Infernos) {
+		delta.Infernos = b.Infernos
+		delta.InfernosChanged = true
+	}
+	if !reflect.DeepEqual(a.DroppedWeapons, b.DroppedWeapons) {
+		delta.DroppedWeapons = b.DroppedWeapons
+		delta.DroppedWeaponsChanged = true
+	}
+	if a.Bomb != b.Bomb {
+		bomb := b.Bomb
+		delta.Bomb = &bomb
+	}
+	if a.TeamCounterTerrorists != b.TeamCounterTerrorists {
+		team := b.TeamCounterTerrorists
+		delta.TeamCounterTerrorists = &team
+	}
+	if a.TeamTerrorists != b.TeamTerrorists {
+		team := b.TeamTerrorists
+		delta.TeamTerrorists = &team
+	}
+	return delta
+}
+
+func diffPlayers(a, b *Player) (PlayerDelta, bool) {
+	delta := PlayerDelta{ID: b.ID}
+	// compare everything except the attributes that are transmitted separately
+	aRest, bRest := *a, *b
+	aRest.Position, bRest.Position = Point{}, Point{}
+	aRest.PositionZ, bRest.PositionZ = 0, 0
+	aRest.ViewDirectionX, bRest.ViewDirectionX = 0, 0
+	aRest.ViewDirectionY, bRest.ViewDirectionY = 0, 0
+	aRest.Health, bRest.Health = 0, 0
+	aRest.Armor, bRest.Armor = 0, 0
+	if !playersEqual(&aRest, &bRest) {
+		player := *b
+		delta.Player = &player
+		return delta, true
+	}
+
+	changed := false
+	if a.Position != b.Position {
+		position := b.Position
+		delta.Position = &position
+		changed = true
+	}
+	if a.PositionZ != b.PositionZ {
+		positionZ := b.PositionZ
+		delta.PositionZ = &positionZ
+		changed = true
+	}
+	if a.ViewDirectionX != b.ViewDirectionX {
+		viewDirectionX := b.ViewDirectionX
+		delta.ViewDirectionX = &viewDirectionX
+		changed = true
+	}
+	if a.ViewDirectionY != b.ViewDirectionY {
+		viewDirectionY := b.ViewDirectionY
+		delta.ViewDirectionY = &viewDirectionY
+		changed = true
+	}
+	if a.Health != b.Health {
+		health := b.Health
+		delta.Health = &health
+		changed = true
+	}
+	if a.Armor != b.Armor {
+		armor := b.Armor
+		delta.Armor = &armor
+		changed = true
+	}
+	return delta, changed
+}
+
+func playersEqual(a, b *Player) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func infernosEqual(a, b []Inferno) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || len(a[i].ConvexHull2D) != len(b[i].ConvexHull2D) {
+			return false
+		}
+		for j := range a[i].ConvexHull2D {
+			if a[i].ConvexHull2D[j] != b[i].ConvexHull2D[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Apply returns the state that results from applying the delta to state. The
+// players and grenades of state are not modified.
+func (d StateDelta) Apply(state OverviewState) OverviewState {
+	result := state
+	result.Frame = d.Frame
+	result.IngameTick = d.IngameTick
+	result.Time = d.Time
+
+	removedPlayers := make(map[uint64]bool, len(d.RemovedPlayers))
+	for _, id := range d.RemovedPlayers {
+		removedPlayers[id] = true
+	}
+	playerDeltas := make(map[uint64]PlayerDelta, len(d.Players))
+	for _, pd := range d.Players {
+		playerDeltas[pd.ID] = pd
+	}
+	result.Players = make([]Player, 0, len(state.Players)+len(d.Players))
+	for _, p := range state.Players {
+		if removedPlayers[p.ID] {
+			continue
+		}
+		if pd, ok := playerDeltas[p.ID]; ok {
+			p = pd.apply(p)
+			delete(playerDeltas, p.ID)
+		}
+		result.Players = append(result.Players, p)
+	}
+	for _, pd := range d.Players {
+		if _, isNew := playerDeltas[pd.ID]; isNew && pd.Player != nil {
+			result.Players = append(result.Players, *pd.Player)
+		}
+	}
+
+	removedGrenades := make(map[int64]bool, len(d.RemovedGrenades))
+	for _, id := range d.RemovedGrenades {
+		removedGrenades[id] = true
+	}
+	changedGrenades := make(map[int64]GrenadeProjectile, len(d.Grenades))
+	for _, g := range d.Grenades {
+		changedGrenades[g.ID] = g
+	}
+	result.Grenades = make([]GrenadeProjectile, 0, len(state.Grenades)+len(d.Grenades))
+	for _, g := range state.Grenades {
+		if removedGrenades[g.ID] {
+			continue
+		}
+		if changed, ok := changedGrenades[g.ID]; ok {
+			g = changed
+			delete(changedGrenades, g.ID)
+		}
+		result.Grenades = append(result.Grenades, g)
+	}
+	for _, g := range d.Grenades {
+		if _, isNew := changedGrenades[g.ID]; isNew {
+			result.Grenades = append(result.Grenades, g)
+		}
+	}
+
+	if d.InfernosChanged {
+		result.Infernos = d.Infernos
+	}
+	if d.DroppedWeaponsChanged {
+		result.DroppedWeapons = d.DroppedWeapons
+	}
+	if d.Bomb != nil {
+		result.Bomb = *d.Bomb
+	}
+	if d.TeamCounterTerrorists != nil {
+		result.TeamCounterTerrorists = *d.TeamCounterTerrorists
+	}
+	if d.TeamTerrorists != nil {
+		result.TeamTerrorists = *d.TeamTerrorists
+	}
+	return result
+}
+
+func (pd PlayerDelta) apply(p Player) Player {
+	if pd.Player != nil {
+		return *pd.Player
+	}
+	if pd.Position != nil {
+		p.Position = *pd.Position
+	}
+	if pd.PositionZ != nil {
+		p.PositionZ = *pd.PositionZ
+	}
+	if pd.ViewDirectionX != nil {
+		p.ViewDirectionX = *pd.ViewDirectionX
+	}
+	if pd.ViewDirectionY != nil {
+		p.ViewDirectionY = *pd.ViewDirectionY
+	}
+	if pd.Health != nil {
+		p.Health = *pd.Health
+	}
+	if pd.Armor != nil {
+		p.Armor = *pd.Armor
+	}
+	return p
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffStatesRoundTrip(t *testing.T) {
+	// bots all have the SteamID64 0 and are only told apart by their ID
+	bot1 := Player{ID: 1<<63 | 2, Name: "BOT Albert", Health: 100, Position: Point{X: 10, Y: 20}}
+	bot2 := Player{ID: 1<<63 | 3, Name: "BOT Bert", Health: 100, Position: Point{X: 30, Y: 40}}
+	human := Player{ID: 76561197960265728, SteamID64: 76561197960265728, Name: "human", Health: 100}
+
+	moved1, moved2 := bot1, bot2
+	moved1.Position = Point{X: 11, Y: 21}
+	moved2.Position = Point{X: 31, Y: 41}
+	moved2.Health = 73
+	rebought := human
+	rebought.Inventory = []InventoryItem{{Type: 301, Count: 1, Ammo: 30}}
+	rebought.Money = 1200
+
+	tests := []struct {
+		name string
+		a, b OverviewState
+	}{
+		{
+			name: "bots move",
+			a:    OverviewState{Frame: 1, Players: []Player{bot1, bot2}},
+			b:    OverviewState{Frame: 2, Players: []Player{moved1, moved2}},
+		},
+		{
+			name: "bot leaves",
+			a:    OverviewState{Frame: 1, Players: []Player{bot1, bot2, human}},
+			b:    OverviewState{Frame: 2, Players: []Player{moved2, human}},
+		},
+		{
+			name: "bot joins",
+			a:    OverviewState{Frame: 1, Players: []Player{bot1, human}},
+			b:    OverviewState{Frame: 2, Players: []Player{moved1, rebought, bot2}},
+		},
+		{
+			name: "nothing changes",
+			a:    OverviewState{Frame: 1, Players: []Player{bot1, bot2}},
+			b:    OverviewState{Frame: 2, Players: []Player{bot1, bot2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := DiffStates(test.a, test.b).Apply(test.a)
+			if got.Frame != test.b.Frame {
+				t.Errorf("Apply(DiffStates(a, b)).Frame = %v, want %v", got.Frame, test.b.Frame)
+			}
+			if !reflect.DeepEqual(got.Players, test.b.Players) {
+				t.Errorf("Apply(DiffStates(a, b)).Players = %+v, want %+v", got.Players, test.b.Players)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer is a category of information that is drawn on the map and can be
+// hidden to declutter the view.
+type Layer int
+
+// Possible values for Layer type.
+const (
+	LayerShots Layer = iota
+	LayerGrenadeEffects
+	LayerKillfeed
+	LayerGrenades
+	LayerInfernos
+	LayerBomb
+	LayerDeadPlayers
+	LayerDroppedWeapons
+)
+
+// Layers contains all layers in the order they are listed to users.
+var Layers = []Layer{
+	LayerShots,
+	LayerGrenadeEffects,
+	LayerKillfeed,
+	LayerGrenades,
+	LayerInfernos,
+	LayerBomb,
+	LayerDeadPlayers,
+	LayerDroppedWeapons,
+}
+
+var layerNames = map[Layer]string{
+	LayerShots:          "shots",
+	LayerGrenadeEffects: "grenade_effects",
+	LayerKillfeed:       "killfeed",
+	LayerGrenades:       "grenades",
+	LayerInfernos:       "infernos",
+	LayerBomb:           "bomb",
+	LayerDeadPlayers:    "dead_players",
+	LayerDroppedWeapons: "dropped_weapons",
+}
+
+func (l Layer) String() string {
+	if name, ok := layerNames[l]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseLayer returns the layer with the given name.
+func ParseLayer(name string) (Layer, error) {
+	for layer, layerName := range layerNames {
+		if layerName == name {
+			return layer, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown layer %q", name)
+}
+
+// LayerFilter contains the layers that are hidden.
+type LayerFilter map[Layer]bool
+
+// ParseLayerFilter returns a filter that hides the layers in the comma
+// separated list of names, e.g. "shots,infernos".
+func ParseLayerFilter(names string) (LayerFilter, error) {
+	filter := make(LayerFilter)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		layer, err := ParseLayer(name)
+		if err != nil {
+			return nil, err
+		}
+		filter[layer] = true
+	}
+	return filter, nil
+}
+
+// Shows reports whether the layer is not hidden.
+func (f LayerFilter) Shows(layer Layer) bool {
+	return !f[layer]
+}
+
+// Toggle hides the layer if it is shown and shows it otherwise.
+func (f LayerFilter) Toggle(layer Layer) {
+	if f[layer] {
+		delete(f, layer)
+	} else {
+		f[layer] = true
+	}
+}
+
+// Names returns the names of the hidden layers.
+func (f LayerFilter) Names() []string {
+	names := make([]string, 0, len(f))
+	for _, layer := range Layers {
+		if f[layer] {
+			names = append(names, layer.String())
+		}
+	}
+	return names
+}
+
+// FilterState removes the hidden layers that are part of the state. Shots,
+// grenade effects and the killfeed are not part of the state and have to be
+// filtered when they are drawn. Dead players stay in the state because they
+// are needed for the scoreboard.
+func (f LayerFilter) FilterState(state OverviewState) OverviewState {
+	if f[LayerGrenades] {
+		state.Grenades = nil
+	}
+	if f[LayerInfernos] {
+		state.Infernos = nil
+	}
+	if f[LayerBomb] {
+		state.Bomb = Bomb{}
+	}
+	if f[LayerDroppedWeapons] {
+		state.DroppedWeapons = nil
+	}
+	return state
+}
+
+// FilterDelta removes the changes of the hidden layers from the delta. It
+// matches FilterState, i.e. applying a filtered delta to a filtered state
+// results in the filtered new state.
+func (f LayerFilter) FilterDelta(delta StateDelta) StateDelta {
+	if f[LayerGrenades] {
+		delta.Grenades = nil
+		delta.RemovedGrenades = nil
+	}
+	if f[LayerInfernos] {
+		delta.Infernos = nil
+		delta.InfernosChanged = false
+	}
+	if f[LayerBomb] {
+		delta.Bomb = nil
+	}
+	if f[LayerDroppedWeapons] {
+		delta.DroppedWeapons = nil
+		delta.DroppedWeaponsChanged = false
+	}
+	return delta
+}
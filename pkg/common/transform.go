@@ -0,0 +1,83 @@
+package common
+
+// overviewSize is the width and height of the overview images in pixels.
+const overviewSize float32 = 1024
+
+// MapTransform describes how an overview image is oriented relative to the
+// coordinates of the game. Some custom overview images are rotated or
+// mirrored.
+type MapTransform struct {
+	// Rotate is the clockwise rotation of the image in degrees, it must be
+	// 0, 90, 180 or 270.
+	Rotate int  `json:",omitempty"`
+	FlipX  bool `json:",omitempty"`
+	FlipY  bool `json:",omitempty"`
+}
+
+// IsIdentity reports whether the transform leaves the positions unchanged.
+func (t MapTransform) IsIdentity() bool {
+	return t == MapTransform{}
+}
+
+// Apply rotates and then mirrors a position on the overview image around the
+// center of the image.
+func (t MapTransform) Apply(x, y float32) (float32, float32) {
+	switch t.Rotate {
+	case 90:
+		x, y = overviewSize-y, x
+	case 180:
+		x, y = overviewSize-x, overviewSize-y
+	case 270:
+		x, y = y, overviewSize-x
+	}
+	if t.FlipX {
+		x = overviewSize - x
+	}
+	if t.FlipY {
+		y = overviewSize - y
+	}
+	return x, y
+}
+
+// Invert reverses Apply.
+func (t MapTransform) Invert(x, y float32) (float32, float32) {
+	if t.FlipX {
+		x = overviewSize - x
+	}
+	if t.FlipY {
+		y = overviewSize - y
+	}
+	inverse := MapTransform{Rotate: (360 - t.Rotate) % 360}
+	return inverse.Apply(x, y)
+}
+
+// ApplyAngle transforms a direction on the overview image the same way as
+// Apply transforms positions. The angle is in degrees, clockwise from the
+// x axis of the image.
+func (t MapTransform) ApplyAngle(angle float32) float32 {
+	angle += float32(t.Rotate)
+	if t.FlipX {
+		angle = 180 - angle
+	}
+	if t.FlipY {
+		angle = -angle
+	}
+	return angle
+}
+
+// MapSection is a vertical section of a map with several floors, e.g. the
+// lower level of de_nuke, that has its own overview image.
+type MapSection struct {
+	// Name is the suffix of the overview image of the section, e.g.
+	// de_nuke_lower.jpg for the section "lower". The image of the first
+	// section of a map is the overview image of the map.
+	Name string
+	// AltitudeMin and AltitudeMax are the range of heights of the section.
+	AltitudeMin float32
+	AltitudeMax float32
+}
+
+// Contains reports whether the height lies in the section.
+func (s MapSection) Contains(z float32) bool {
+	return z >= s.AltitudeMin && z < s.AltitudeMax
+}
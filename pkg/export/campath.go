@@ -0,0 +1,137 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+const (
+	// campathEyeHeight is the height of the eyes of a standing player above
+	// the player's position.
+	campathEyeHeight = 64
+	// campathFOV is the default field of view of CS:GO.
+	campathFOV = 90
+)
+
+// CampathPoint is a key frame of a HLAE camera path. T is the time since the
+// start of the demo, HLAE interpolates the camera between the key frames with
+// a spline.
+type CampathPoint struct {
+	T     float64 `xml:"t,attr"`
+	X     float32 `xml:"x,attr"`
+	Y     float32 `xml:"y,attr"`
+	Z     float32 `xml:"z,attr"`
+	FOV   float32 `xml:"fov,attr"`
+	Roll  float32 `xml:"rx,attr"`
+	Pitch float32 `xml:"ry,attr"`
+	Yaw   float32 `xml:"rz,attr"`
+}
+
+type campath struct {
+	XMLName xml.Name       `xml:"campath"`
+	Points  []CampathPoint `xml:"points>p"`
+}
+
+// PlayerCampath returns a camera path that follows the eyes of the player with
+// the given SteamID from startFrame up to and including endFrame. Frames in
+// which the player is dead are left out.
+func PlayerCampath(m *match.Match, steamID64 uint64, startFrame, endFrame int) []CampathPoint {
+	if endFrame > m.LastFrame() {
+		endFrame = m.LastFrame()
+	}
+	var points []CampathPoint
+	lastStateFrame := -1
+	for frame := startFrame; frame <= endFrame; frame++ {
+		state := m.StateAtFrame(frame)
+		// frames without a state return the previous state, HLAE cannot
+		// interpolate between key frames with the same time
+		if state.Frame == lastStateFrame {
+			continue
+		}
+		lastStateFrame = state.Frame
+		for _, p := range state.Players {
+			if p.SteamID64 != steamID64 || !p.IsAlive {
+				continue
+			}
+			points = append(points, playerCampathPoint(state, p))
+		}
+	}
+	return points
+}
+
+// RoundCampath returns the camera path of the player with the given SteamID
+// for the round with the given number (starting at 1).
+func RoundCampath(m *match.Match, steamID64 uint64, roundNumber int) ([]CampathPoint, error) {
+	for _, r := range m.Rounds {
+		if r.Number != roundNumber {
+			continue
+		}
+		endFrame := r.EndFrame
+		if endFrame == -1 {
+			endFrame = m.LastFrame()
+		}
+		points := PlayerCampath(m, steamID64, r.StartFrame, endFrame)
+		if len(points) == 0 {
+			return nil, fmt.Errorf("player %v is not alive in round %v", steamID64, roundNumber)
+		}
+		return points, nil
+	}
+	return nil, fmt.Errorf("round %v not found", roundNumber)
+}
+
+func playerCampathPoint(state common.OverviewState, p common.Player) CampathPoint {
+	// HLAE expects looking up to be negative
+	pitch := common.NormalizePitch(p.ViewDirectionY)
+	return CampathPoint{
+		T:     state.Time.Seconds(),
+		X:     p.Position.X,
+		Y:     p.Position.Y,
+		Z:     p.PositionZ + campathEyeHeight,
+		FOV:   campathFOV,
+		Pitch: pitch,
+		Yaw:   p.ViewDirectionX,
+	}
+}
+
+// WriteCampath writes the points as HLAE campath XML that can be loaded with
+// mirv_campath load. The times are relative to the start of the demo, they
+// can be moved with mirv_campath edit start.
+func WriteCampath(w io.Writer, points []CampathPoint) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err = enc.Encode(campath{Points: points})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// CampathFile writes the camera path of the player with the given SteamID in
+// the given round to fileName.
+func CampathFile(fileName string, m *match.Match, steamID64 uint64, roundNumber int) error {
+	points, err := RoundCampath(m, steamID64, roundNumber)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = WriteCampath(file, points)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+const (
+	// DatasetGridSize is the number of rows and columns of the grid the
+	// overview is divided into to bin the positions of the players.
+	DatasetGridSize int = 4
+	// the overview images are 1024 pixels wide and high
+	overviewSize float32 = 1024
+)
+
+// writeDecisionPoints writes one feature vector per second of every round,
+// from the end of the freezetime until the round was decided, labeled with
+// the outcome of the round. All values are numeric so that the file can be
+// used to train models directly. Rounds without a winner and knife rounds
+// (unless they are included in the analyses) are left out.
+func writeDecisionPoints(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	header := []string{"round", "frame", "time", "time_remaining", "bomb_planted"}
+	for _, side := range []string{"ct", "t"} {
+		header = append(header,
+			side+"_score",
+			side+"_alive",
+			side+"_health",
+			side+"_armor",
+			side+"_helmets",
+			side+"_defuse_kits",
+			side+"_money",
+			side+"_equipment_value",
+		)
+		for row := 0; row < DatasetGridSize; row++ {
+			for column := 0; column < DatasetGridSize; column++ {
+				header = append(header, fmt.Sprintf("%v_area_%v_%v", side, row, column))
+			}
+		}
+	}
+	header = append(header, "ct_win")
+	err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range m.Rounds {
+		if r.FreezetimeEndFrame < 0 || r.EndFrame < 0 || r.Winner == demoinfo.TeamUnassigned {
+			continue
+		}
+		if r.IsKnifeRound && !opts.IncludeKnifeRounds {
+			continue
+		}
+		for frame := r.FreezetimeEndFrame; frame < r.EndFrame && frame <= m.LastFrame(); frame += m.FrameRateRounded {
+			err = w.Write(decisionPoint(m, r, frame))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decisionPoint(m *match.Match, r common.Round, frame int) []string {
+	state := m.StateAtFrame(frame)
+	record := []string{
+		strconv.Itoa(r.Number),
+		strconv.Itoa(frame),
+		formatSeconds(m.TimeAt(frame).Seconds() - m.TimeAt(r.FreezetimeEndFrame).Seconds()),
+		formatSeconds(m.TimerAt(frame).TimeRemaining.Seconds()),
+		boolString(isBombPlanted(m, r, frame)),
+	}
+	for _, team := range []demoinfo.Team{demoinfo.TeamCounterTerrorists, demoinfo.TeamTerrorists} {
+		var alive, health, armor, helmets, defuseKits, money int
+		areas := make([]int, DatasetGridSize*DatasetGridSize)
+		for _, p := range state.Players {
+			if p.Team != team {
+				continue
+			}
+			money += int(p.Money)
+			if !p.IsAlive {
+				continue
+			}
+			alive++
+			health += int(p.Health)
+			armor += int(p.Armor)
+			if p.HasHelmet {
+				helmets++
+			}
+			if p.HasDefuseKit {
+				defuseKits++
+			}
+			x, y := m.TranslateScale(p.Position.X, p.Position.Y)
+			areas[gridCell(y)*DatasetGridSize+gridCell(x)]++
+		}
+		score := state.TeamCounterTerrorists.Score
+		if team == demoinfo.TeamTerrorists {
+			score = state.TeamTerrorists.Score
+		}
+		record = append(record,
+			strconv.Itoa(int(score)),
+			strconv.Itoa(alive),
+			strconv.Itoa(health),
+			strconv.Itoa(armor),
+			strconv.Itoa(helmets),
+			strconv.Itoa(defuseKits),
+			strconv.Itoa(money),
+			strconv.Itoa(r.Team(team).EquipmentValue),
+		)
+		for _, count := range areas {
+			record = append(record, strconv.Itoa(count))
+		}
+	}
+	return append(record, boolString(r.Winner == demoinfo.TeamCounterTerrorists))
+}
+
+// isBombPlanted reports whether the bomb was planted in the round before the
+// given frame.
+func isBombPlanted(m *match.Match, r common.Round, frame int) bool {
+	for _, plant := range m.BombPlants {
+		if plant.Frame >= r.StartFrame && plant.Frame <= frame {
+			return true
+		}
+	}
+	return false
+}
+
+// gridCell returns the row or column of the grid for a coordinate on the
+// overview. Positions outside of the overview are put into the outer cells.
+func gridCell(coordinate float32) int {
+	cell := int(coordinate / (overviewSize / float32(DatasetGridSize)))
+	if cell < 0 {
+		return 0
+	}
+	if cell >= DatasetGridSize {
+		return DatasetGridSize - 1
+	}
+	return cell
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 2, 64)
+}
+
+func boolString(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+)
+
+const (
+	entryPathColor    = "#fcb00c"
+	entryPathMaxWidth = 12
+)
+
+// EntryPaths draws the flows of the terrorists towards each site (see
+// stats.EntryFlows) as arrows on the overview and writes them to
+// entry_paths_<site>.svg in dir. The wider and more opaque an arrow, the more
+// terrorists took the way. overviewFile is embedded below the arrows, it is
+// left out if it is empty.
+func EntryPaths(dir string, m *match.Match, overviewFile string, opts stats.Options) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	bySite := make(map[string][]stats.EntryFlow)
+	var sites []string
+	for _, flow := range stats.EntryFlows(m, opts) {
+		if _, ok := bySite[flow.Site]; !ok {
+			sites = append(sites, flow.Site)
+		}
+		bySite[flow.Site] = append(bySite[flow.Site], flow)
+	}
+	for _, site := range sites {
+		err = writeEntryPaths(filepath.Join(dir, "entry_paths_"+site+".svg"), m, bySite[site], overviewFile)
+		if err != nil {
+			return fmt.Errorf("trying to export entry paths to %v: %v", site, err)
+		}
+	}
+	return nil
+}
+
+func writeEntryPaths(fileName string, m *match.Match, flows []stats.EntryFlow, overviewFile string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, `<svg viewBox="0 0 %v %v" width="%v" height="%v" xmlns="http://www.w3.org/2000/svg">`+"\n",
+		overviewSize, overviewSize, overviewSize, overviewSize)
+	fmt.Fprintf(w, `<defs><marker id="arrow" viewBox="0 0 10 10" refX="5" refY="5" markerWidth="2" markerHeight="2" orient="auto">`+
+		`<path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker></defs>`+"\n", entryPathColor)
+	fmt.Fprintf(w, `<rect width="%v" height="%v" fill="#111"/>`+"\n", overviewSize, overviewSize)
+	if overviewFile != "" {
+		url, err := dataURL(overviewFile)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, `<image href="%s" width="%v" height="%v"/>`+"\n", url, overviewSize, overviewSize)
+	}
+	// the flows are sorted with the most common first
+	var maxPlayers int
+	if len(flows) > 0 {
+		maxPlayers = flows[0].Players
+	}
+	for i := len(flows) - 1; i >= 0; i-- {
+		flow := flows[i]
+		share := float64(flow.Players) / float64(maxPlayers)
+		fromX, fromY := m.TranslateScale(flow.From.X, flow.From.Y)
+		toX, toY := m.TranslateScale(flow.To.X, flow.To.Y)
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f" stroke-opacity="%.2f" marker-end="url(#arrow)"><title>%d</title></line>`+"\n",
+			fromX, fromY, toX, toY, entryPathColor, 1+share*(entryPathMaxWidth-1), 0.2+0.8*share, flow.Players)
+	}
+	fmt.Fprintln(w, "</svg>")
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,417 @@
+// Package export writes parsed match data and the results of the analyses to
+// files that can be processed by other tools.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+type table struct {
+	name  string
+	write func(w *csv.Writer, m *match.Match, opts stats.Options) error
+}
+
+// tables contains all tables that are exported as CSV files.
+var tables = []table{
+	{"rounds", writeRounds},
+	{"sides", writeSides},
+	{"players", writePlayers},
+	{"pickups", writePickups},
+	{"engagements", writeEngagements},
+	{"engagement_summary", writeEngagementSummary},
+	{"decision_points", writeDecisionPoints},
+	{"strategies", writeStrategies},
+	{"rotations", writeRotations},
+	{"multi_kills", writeMultiKills},
+	{"man_advantages", writeManAdvantages},
+	{"grenade_throws", writeGrenadeThrows},
+	{"health", writeHealth},
+}
+
+// CSV writes every table of the match into a separate CSV file in dir. The
+// directory is created if it does not exist.
+func CSV(dir string, m *match.Match, opts stats.Options) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		err = writeCSVFile(filepath.Join(dir, t.name+".csv"), func(w *csv.Writer, m *match.Match) error {
+			return t.write(w, m, opts)
+		}, m)
+		if err != nil {
+			return fmt.Errorf("trying to export %v: %v", t.name, err)
+		}
+	}
+	return nil
+}
+
+func writeCSVFile(fileName string, write func(*csv.Writer, *match.Match) error, m *match.Match) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	err = write(w, m)
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func writeRounds(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "start_frame", "freezetime_end_frame", "end_frame", "winner", "win_type", "is_knife_round",
+		"ct_clan_name", "ct_start_money", "ct_equipment_value", "ct_money_spent", "ct_loss_streak", "ct_loss_bonus",
+		"ct_reward", "ct_survivors", "ct_saved_value",
+		"t_clan_name", "t_start_money", "t_equipment_value", "t_money_spent", "t_loss_streak", "t_loss_bonus",
+		"t_reward", "t_survivors", "t_saved_value"})
+	if err != nil {
+		return err
+	}
+	for _, r := range m.Rounds {
+		record := []string{
+			strconv.Itoa(r.Number),
+			strconv.Itoa(r.StartFrame),
+			strconv.Itoa(r.FreezetimeEndFrame),
+			strconv.Itoa(r.EndFrame),
+			teamString(r.Winner),
+			r.WinType.String(),
+			strconv.FormatBool(r.IsKnifeRound),
+		}
+		for _, team := range []common.RoundTeam{r.CounterTerrorists, r.Terrorists} {
+			record = append(record,
+				team.ClanName,
+				strconv.Itoa(team.StartMoney),
+				strconv.Itoa(team.EquipmentValue),
+				strconv.Itoa(team.MoneySpent),
+				strconv.Itoa(team.LossStreak),
+				strconv.Itoa(team.LossBonus),
+				strconv.Itoa(team.Reward),
+				strconv.Itoa(team.Survivors),
+				strconv.Itoa(team.SavedValue),
+			)
+		}
+		err = w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSides(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"team", "side", "rounds_played", "rounds_won", "win_rate",
+		"pistol_rounds_played", "pistol_rounds_won", "anti_eco_rounds", "anti_eco_losses"})
+	if err != nil {
+		return err
+	}
+	for _, s := range stats.SideBreakdown(m, opts) {
+		err = w.Write([]string{
+			s.Team,
+			teamString(s.Side),
+			strconv.Itoa(s.RoundsPlayed),
+			strconv.Itoa(s.RoundsWon),
+			strconv.FormatFloat(s.WinRate(), 'f', 3, 64),
+			strconv.Itoa(s.PistolRoundsPlayed),
+			strconv.Itoa(s.PistolRoundsWon),
+			strconv.Itoa(s.AntiEcoRounds),
+			strconv.Itoa(s.AntiEcoLosses),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePlayers(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"steam_id64", "name", "team", "kills", "assists", "deaths",
+		"team_kills", "suicides", "blind_kills", "no_scopes", "smoke_kills",
+		"persona_name", "profile_url", "vac_banned", "number_of_vac_bans", "number_of_game_bans", "days_since_last_ban"})
+	if err != nil {
+		return err
+	}
+	// bots have no SteamID and are told apart by their names like in
+	// stats.KillsAndDeaths
+	killKey := func(name string, steamID64 uint64) string {
+		if steamID64 != 0 {
+			return strconv.FormatUint(steamID64, 10)
+		}
+		return "bot/" + name
+	}
+	killStats := make(map[string]stats.PlayerKills)
+	for _, k := range stats.KillsAndDeaths(m, opts) {
+		killStats[killKey(k.Name, k.SteamID64)] = k
+	}
+	for _, p := range m.Players() {
+		// the profile columns stay empty if the match was not enriched
+		profile, _ := m.Profile(p.SteamID64)
+		kills := killStats[killKey(p.Name, p.SteamID64)]
+		record := []string{
+			strconv.FormatUint(p.SteamID64, 10),
+			p.Name,
+			teamString(p.Team),
+			strconv.Itoa(int(p.Kills)),
+			strconv.Itoa(int(p.Assists)),
+			strconv.Itoa(int(p.Deaths)),
+			strconv.Itoa(kills.TeamKills),
+			strconv.Itoa(kills.Suicides),
+			strconv.Itoa(kills.BlindKills),
+			strconv.Itoa(kills.NoScopes),
+			strconv.Itoa(kills.SmokeKills),
+			profile.PersonaName,
+			profile.ProfileURL,
+			strconv.FormatBool(profile.VACBanned),
+			strconv.Itoa(profile.NumberOfVACBans),
+			strconv.Itoa(profile.NumberOfGameBans),
+			strconv.Itoa(profile.DaysSinceLastBan),
+		}
+		err = w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePickups(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "frame", "player", "player_team", "weapon",
+		"dropped_by", "dropped_by_team", "drop_frame"})
+	if err != nil {
+		return err
+	}
+	for _, p := range m.Pickups {
+		err = w.Write([]string{
+			strconv.Itoa(m.RoundIndex(p.Frame) + 1),
+			strconv.Itoa(p.Frame),
+			p.PlayerName,
+			teamString(p.PlayerTeam),
+			p.Weapon.String(),
+			p.DroppedByName,
+			teamString(p.DroppedByTeam),
+			strconv.Itoa(p.DropFrame),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEngagements(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "frame", "killer", "killer_team", "victim", "victim_team",
+		"weapon", "distance", "victim_angle_off", "killer_angle_off", "off_angle",
+		"killer_pitch", "victim_pitch", "killer_pitch_off"})
+	if err != nil {
+		return err
+	}
+	for _, e := range stats.Engagements(m, opts) {
+		err = w.Write([]string{
+			strconv.Itoa(e.Round),
+			strconv.Itoa(e.Kill.Frame),
+			e.Kill.KillerName,
+			teamString(e.Kill.KillerTeam),
+			e.Kill.VictimName,
+			teamString(e.Kill.VictimTeam),
+			e.Kill.Weapon.String(),
+			formatFloat(e.Distance),
+			formatFloat(e.VictimAngleOff),
+			formatFloat(e.KillerAngleOff),
+			strconv.FormatBool(e.IsOffAngle()),
+			formatFloat(common.NormalizePitch(e.Kill.KillerViewDirectionY)),
+			formatFloat(common.NormalizePitch(e.Kill.VictimViewDirectionY)),
+			formatFloat(e.KillerPitchOff),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEngagementSummary(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	header := []string{"player", "weapon", "kills", "mean_distance", "median_distance",
+		"mean_victim_angle_off", "off_angle_kills", "mean_killer_pitch_off"}
+	for _, bound := range stats.DistanceBuckets {
+		header = append(header, fmt.Sprintf("kills_below_%v", bound))
+	}
+	header = append(header, "kills_above")
+	err := w.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, s := range stats.SummarizeEngagements(stats.Engagements(m, opts)) {
+		record := []string{
+			s.PlayerName,
+			s.Weapon.String(),
+			strconv.Itoa(s.Kills),
+			formatFloat(s.MeanDistance),
+			formatFloat(s.MedianDistance),
+			formatFloat(s.MeanVictimAngleOff),
+			strconv.Itoa(s.OffAngleKills),
+			formatFloat(s.MeanKillerPitchOff),
+		}
+		for _, count := range s.Distribution {
+			record = append(record, strconv.Itoa(count))
+		}
+		err = w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeStrategies(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "team", "cluster", "strategy", "t_won"})
+	if err != nil {
+		return err
+	}
+	for _, s := range stats.TStrategies(m, opts) {
+		err = w.Write([]string{
+			strconv.Itoa(s.Round),
+			s.Team,
+			strconv.Itoa(s.Cluster),
+			s.Strategy,
+			strconv.FormatBool(s.TWon),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRotations(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "player", "steam_id64", "team", "from", "to",
+		"contact_frame", "start_frame", "end_frame", "start", "reaction", "duration"})
+	if err != nil {
+		return err
+	}
+	for _, r := range stats.Rotations(m, opts) {
+		err = w.Write([]string{
+			strconv.Itoa(r.Round),
+			r.PlayerName,
+			strconv.FormatUint(r.SteamID64, 10),
+			r.Team,
+			r.From,
+			r.To,
+			strconv.Itoa(r.ContactFrame),
+			strconv.Itoa(r.StartFrame),
+			strconv.Itoa(r.EndFrame),
+			formatSeconds(r.Start.Seconds()),
+			formatSeconds(r.Reaction.Seconds()),
+			formatSeconds(r.Duration.Seconds()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMultiKills(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "player", "steam_id64", "team", "kills", "frame"})
+	if err != nil {
+		return err
+	}
+	for _, k := range stats.MultiKills(m, opts) {
+		err = w.Write([]string{
+			strconv.Itoa(k.Round),
+			k.PlayerName,
+			strconv.FormatUint(k.SteamID64, 10),
+			teamString(k.Team),
+			strconv.Itoa(k.Kills),
+			strconv.Itoa(k.Frame),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeManAdvantages(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"side", "players", "enemies", "rounds", "wins", "conversion_rate"})
+	if err != nil {
+		return err
+	}
+	for _, a := range stats.ManAdvantages(m, opts) {
+		err = w.Write([]string{
+			teamString(a.Side),
+			strconv.Itoa(a.Players),
+			strconv.Itoa(a.Enemies),
+			strconv.Itoa(a.Rounds),
+			strconv.Itoa(a.Wins),
+			strconv.FormatFloat(a.ConversionRate(), 'f', 3, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGrenadeThrows(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "frame", "player", "steam_id64", "team", "grenade", "technique",
+		"x", "y", "z", "yaw", "pitch", "speed", "landing_x", "landing_y", "landing_z"})
+	if err != nil {
+		return err
+	}
+	for _, t := range m.GrenadeThrows {
+		landing := []string{"", "", ""}
+		if t.HasLanding {
+			landing = []string{formatFloat(t.Landing.X), formatFloat(t.Landing.Y), formatFloat(t.LandingZ)}
+		}
+		err = w.Write(append([]string{
+			strconv.Itoa(m.RoundIndex(t.Frame) + 1),
+			strconv.Itoa(t.Frame),
+			t.Name,
+			strconv.FormatUint(t.SteamID64, 10),
+			teamString(t.Team),
+			t.Grenade.String(),
+			t.Technique.String(),
+			formatFloat(t.Position.X),
+			formatFloat(t.Position.Y),
+			formatFloat(t.PositionZ),
+			formatFloat(t.ViewDirectionX),
+			formatFloat(common.NormalizePitch(t.ViewDirectionY)),
+			formatFloat(t.Speed),
+		}, landing...))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', 1, 32)
+}
+
+func teamString(team demoinfo.Team) string {
+	switch team {
+	case demoinfo.TeamTerrorists:
+		return "T"
+	case demoinfo.TeamCounterTerrorists:
+		return "CT"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+const (
+	healthGraphWidth  = 800
+	healthGraphHeight = 200
+	healthGraphMargin = 10
+)
+
+var (
+	healthGraphBackground = color.RGBA{17, 17, 17, 255}
+	healthGraphGrid       = color.RGBA{60, 60, 60, 255}
+	// the players of a team are told apart by the brightness of their line
+	healthGraphCT = []color.RGBA{{89, 206, 200, 255}, {60, 150, 230, 255}, {150, 230, 225, 255}, {40, 120, 120, 255}, {120, 170, 255, 255}}
+	healthGraphT  = []color.RGBA{{252, 176, 12, 255}, {230, 110, 30, 255}, {255, 220, 120, 255}, {160, 110, 10, 255}, {240, 80, 80, 255}}
+)
+
+// writeHealth writes the health of the players in every round. To keep the
+// file small, a row is only written when the health of a player changes.
+func writeHealth(w *csv.Writer, m *match.Match, opts stats.Options) error {
+	err := w.Write([]string{"round", "time", "player", "steam_id64", "team", "health"})
+	if err != nil {
+		return err
+	}
+	for _, round := range stats.HealthByRound(m, opts) {
+		for _, player := range round.Players {
+			for i, health := range player.Health {
+				if i > 0 && player.Health[i-1] == health {
+					continue
+				}
+				err = w.Write([]string{
+					strconv.Itoa(round.Round),
+					formatSeconds(round.Time(i).Seconds()),
+					player.Name,
+					strconv.FormatUint(player.SteamID64, 10),
+					teamString(player.Team),
+					strconv.Itoa(int(health)),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// HealthGraphs draws the health of the players over every round as a line
+// graph and writes it to health_round_<number>.png in dir.
+func HealthGraphs(dir string, m *match.Match, opts stats.Options) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, round := range stats.HealthByRound(m, opts) {
+		fileName := filepath.Join(dir, fmt.Sprintf("health_round_%d.png", round.Round))
+		err = writeHealthGraph(fileName, round)
+		if err != nil {
+			return fmt.Errorf("trying to export health of round %d: %v", round.Round, err)
+		}
+	}
+	return nil
+}
+
+func writeHealthGraph(fileName string, round stats.RoundHealth) error {
+	img := image.NewRGBA(image.Rect(0, 0, healthGraphWidth, healthGraphHeight))
+	for x := 0; x < healthGraphWidth; x++ {
+		for y := 0; y < healthGraphHeight; y++ {
+			img.Set(x, y, healthGraphBackground)
+		}
+	}
+	plotHeight := healthGraphHeight - 2*healthGraphMargin
+	plotWidth := healthGraphWidth - 2*healthGraphMargin
+	for _, hp := range []int{0, 50, 100} {
+		y := healthGraphMargin + plotHeight - hp*plotHeight/100
+		drawLine(img, healthGraphMargin, y, healthGraphMargin+plotWidth, y, healthGraphGrid)
+	}
+
+	samples := round.Samples()
+	var ct, t int
+	for _, player := range round.Players {
+		var c color.RGBA
+		if player.Team == demoinfo.TeamCounterTerrorists {
+			c = healthGraphCT[ct%len(healthGraphCT)]
+			ct++
+		} else {
+			c = healthGraphT[t%len(healthGraphT)]
+			t++
+		}
+		for i := 1; i < samples; i++ {
+			x1 := healthGraphMargin + (i-1)*plotWidth/(samples-1)
+			x2 := healthGraphMargin + i*plotWidth/(samples-1)
+			y1 := healthGraphMargin + plotHeight - int(player.Health[i-1])*plotHeight/100
+			y2 := healthGraphMargin + plotHeight - int(player.Health[i])*plotHeight/100
+			drawLine(img, x1, y1, x2, y2, c)
+		}
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = png.Encode(file, img)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// drawLine draws a line from x1, y1 to x2, y2 with Bresenham's algorithm.
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x1, y1, c)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		if 2*e >= dy {
+			e += dy
+			x1 += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y1 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
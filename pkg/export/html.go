@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// htmlReport contains the data of the HTML template.
+type htmlReport struct {
+	Report
+	Stats       string
+	Overview    template.URL
+	Heatmaps    []heatmap
+	Screenshots []screenshot
+}
+
+type heatmap struct {
+	Title  string
+	Color  string
+	Points []heatmapPoint
+}
+
+type heatmapPoint struct {
+	X, Y float32
+}
+
+type screenshot struct {
+	Name string
+	Data template.URL
+}
+
+// HTML writes a printable report with the results of all analyses, a summary
+// of every round, heatmaps of the deaths of both sides, the notes and the
+// screenshots to report.html in dir. overviewFile is the overview image of the
+// map that is drawn below the heatmaps, it is left out if it is empty. The
+// images are embedded, so the report is a single file that can be shared.
+func HTML(dir string, m *match.Match, notes []Note, overviewFile string, screenshotFiles []string, opts stats.Options) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	var statsOutput bytes.Buffer
+	err = stats.WriteReport(&statsOutput, m, opts)
+	if err != nil {
+		return err
+	}
+	data := htmlReport{
+		Report:   NewReport(m, notes, opts),
+		Stats:    statsOutput.String(),
+		Heatmaps: deathHeatmaps(m, opts),
+	}
+	if overviewFile != "" {
+		data.Overview, err = dataURL(overviewFile)
+		if err != nil {
+			return err
+		}
+	}
+	for _, fileName := range screenshotFiles {
+		url, err := dataURL(fileName)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+		data.Screenshots = append(data.Screenshots, screenshot{Name: name, Data: url})
+	}
+
+	file, err := os.Create(filepath.Join(dir, "report.html"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return htmlTemplate.Execute(file, data)
+}
+
+// deathHeatmaps returns the positions where the players of each side died.
+func deathHeatmaps(m *match.Match, opts stats.Options) []heatmap {
+	heatmaps := []heatmap{
+		{Title: "Deaths of the Counter Terrorists", Color: "#59cec8"},
+		{Title: "Deaths of the Terrorists", Color: "#fcb00c"},
+	}
+	for _, kill := range m.Kills {
+		if m.IsKnifeRound(kill.Frame) && !opts.IncludeKnifeRounds {
+			continue
+		}
+		x, y := m.TranslateScale(kill.VictimPosition.X, kill.VictimPosition.Y)
+		point := heatmapPoint{X: x, Y: y}
+		switch kill.VictimTeam {
+		case demoinfo.TeamCounterTerrorists:
+			heatmaps[0].Points = append(heatmaps[0].Points, point)
+		case demoinfo.TeamTerrorists:
+			heatmaps[1].Points = append(heatmaps[1].Points, point)
+		}
+	}
+	return heatmaps
+}
+
+// dataURL returns the content of the file as data URL.
+func dataURL(fileName string) (template.URL, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(fileName))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content)), nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"team":        teamString,
+	"heatmapSize": func() float32 { return overviewSize },
+	"percent":     func(f float64) string { return strconv.FormatFloat(100*f, 'f', 0, 64) + " %" },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.MapName}} - {{.Summary.ClanNameCounterTerrorists}} vs {{.Summary.ClanNameTerrorists}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+th { background: #eee; }
+pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+.heatmaps, .screenshots { display: flex; flex-wrap: wrap; gap: 1em; }
+.heatmaps svg { width: 480px; height: 480px; background: #111; }
+.screenshots img { max-width: 100%; }
+.note { white-space: pre-wrap; }
+@media print {
+	body { margin: 0; }
+	section { page-break-inside: avoid; }
+	pre { white-space: pre-wrap; }
+}
+</style>
+</head>
+<body>
+<h1>{{.MapName}}</h1>
+<p>{{.Summary.ClanNameCounterTerrorists}} {{.Summary.ScoreCounterTerrorists}}:{{.Summary.ScoreTerrorists}} {{.Summary.ClanNameTerrorists}} after {{.Summary.RoundsPlayed}} rounds
+{{- with .Summary.Event}}<br>{{.Name}}, {{.Stage}}{{end}}</p>
+
+<section>
+<h2>Sides</h2>
+<table>
+<tr><th>Team</th><th>Side</th><th>Rounds</th><th>Won</th><th>Win rate</th><th>Pistol rounds won</th><th>Anti-eco losses</th></tr>
+{{- range .Sides}}
+<tr><td>{{.Team}}</td><td>{{.Side}}</td><td>{{.RoundsPlayed}}</td><td>{{.RoundsWon}}</td><td>{{percent .WinRate}}</td><td>{{.PistolRoundsWon}}/{{.PistolRoundsPlayed}}</td><td>{{.AntiEcoLosses}}/{{.AntiEcoRounds}}</td></tr>
+{{- end}}
+</table>
+</section>
+
+<section>
+<h2>Rounds</h2>
+<table>
+<tr><th>Round</th><th>Winner</th><th>Win type</th><th>Kills</th><th>Bomb planted</th><th>CT equipment</th><th>T equipment</th><th>CT survivors</th><th>T survivors</th></tr>
+{{- range .Rounds}}
+<tr><td>{{.Number}}</td><td>{{team .Winner}}</td><td>{{.WinType}}</td><td>{{.Kills}}</td><td>{{if .BombPlanted}}yes{{end}}</td><td>{{.CounterTerrorists.EquipmentValue}}</td><td>{{.Terrorists.EquipmentValue}}</td><td>{{.CounterTerrorists.Survivors}}</td><td>{{.Terrorists.Survivors}}</td></tr>
+{{- end}}
+</table>
+</section>
+
+{{- if .Notes}}
+<section>
+<h2>Notes</h2>
+{{- range .Notes}}
+<h3>Round {{.Round}} ({{.Time}})</h3>
+<p class="note">{{.Text}}</p>
+{{- end}}
+</section>
+{{- end}}
+
+<section>
+<h2>Heatmaps</h2>
+<div class="heatmaps">
+{{- $overview := .Overview}}
+{{- range .Heatmaps}}
+<figure>
+<svg viewBox="0 0 {{heatmapSize}} {{heatmapSize}}" xmlns="http://www.w3.org/2000/svg">
+{{- if $overview}}<image href="{{$overview}}" width="{{heatmapSize}}" height="{{heatmapSize}}"/>{{end}}
+{{- $color := .Color}}
+{{- range .Points}}<circle cx="{{.X}}" cy="{{.Y}}" r="14" fill="{{$color}}" fill-opacity="0.25"/>{{end}}
+</svg>
+<figcaption>{{.Title}}</figcaption>
+</figure>
+{{- end}}
+</div>
+</section>
+
+{{- if .Screenshots}}
+<section>
+<h2>Screenshots</h2>
+<div class="screenshots">
+{{- range .Screenshots}}
+<figure><img src="{{.Data}}" alt="{{.Name}}"><figcaption>{{.Name}}</figcaption></figure>
+{{- end}}
+</div>
+</section>
+{{- end}}
+
+<section>
+<h2>Analysis</h2>
+<pre>{{.Stats}}</pre>
+</section>
+</body>
+</html>
+`))
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+)
+
+// Report is the summary of a match that is written as JSON, e.g. for scouting
+// reports.
+type Report struct {
+	MapName   string
+	FrameRate float64
+	// Frames is the number of frames of the demo, the frames of the rounds
+	// lie between 0 and Frames-1.
+	Frames   int
+	Duration time.Duration
+	Summary  common.Summary
+	Rounds   []common.Round
+	Sides    []Side
+	// Notes contains the notes of the reviewer, ordered by round.
+	Notes []Note `json:",omitempty"`
+}
+
+// Note is a note of the reviewer on a round or a moment of a round.
+type Note struct {
+	Round int
+	Frame int
+	Time  time.Duration
+	Text  string
+}
+
+// Side contains the results of a team on one side.
+type Side struct {
+	stats.SideStats
+	Side    string
+	WinRate float64
+}
+
+// NewReport collects the report of the match and the notes of the reviewer.
+func NewReport(m *match.Match, notes []Note, opts stats.Options) Report {
+	report := Report{
+		MapName:   m.MapName,
+		FrameRate: m.FrameRate,
+		Frames:    m.TotalFrames(),
+		Duration:  m.Duration(),
+		Summary:   m.Summary,
+		Rounds:    m.Rounds,
+		Sides:     make([]Side, 0),
+		Notes:     notes,
+	}
+	for _, s := range stats.SideBreakdown(m, opts) {
+		report.Sides = append(report.Sides, Side{
+			SideStats: s,
+			Side:      teamString(s.Side),
+			WinRate:   s.WinRate(),
+		})
+	}
+	return report
+}
+
+// JSON writes the report of the match with the notes to report.json in dir.
+// The directory is created if it does not exist.
+func JSON(dir string, m *match.Match, notes []Note, opts stats.Options) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, "report.json"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(NewReport(m, notes, opts))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/query"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Lineup is a grenade throw as it is written to lineups.json. Position is
+// the position of the feet of the player, Pitch and Yaw the view angles in
+// degrees like setang expects them.
+type Lineup struct {
+	Name      string
+	Map       string
+	Round     int
+	Player    string
+	Grenade   string
+	Technique string
+	Position  [3]float32
+	Pitch     float32
+	Yaw       float32
+	// Landing is where the grenade landed or nil if it is unknown.
+	Landing *[3]float32 `json:",omitempty"`
+	// Command teleports to the lineup on a server with sv_cheats 1.
+	Command string
+}
+
+// grenadeItems contains the names that give expects for the grenades.
+var grenadeItems = map[demoinfo.EquipmentType]string{
+	demoinfo.EqSmoke:      "weapon_smokegrenade",
+	demoinfo.EqFlash:      "weapon_flashbang",
+	demoinfo.EqHE:         "weapon_hegrenade",
+	demoinfo.EqMolotov:    "weapon_molotov",
+	demoinfo.EqIncendiary: "weapon_incgrenade",
+	demoinfo.EqDecoy:      "weapon_decoy",
+}
+
+// Lineups writes the grenade throws of the match that match the query to
+// lineups.json and to the practice config lineups.cfg in dir. The query is
+// restricted to throws. After executing the config on an offline server,
+// lineup1, lineup2 and so on teleport to the lineups and give the grenade.
+func Lineups(dir string, m *match.Match, q query.Query) error {
+	q.Type = query.EventThrow
+	lineups := make([]Lineup, 0)
+	for _, e := range query.Run(m, q) {
+		lineups = append(lineups, newLineup(len(lineups)+1, m, m.GrenadeThrows[e.Index], e.Round))
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	err = writeLineupConfig(filepath.Join(dir, "lineups.cfg"), m.MapName, lineups)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, "lineups.json"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(lineups)
+}
+
+func newLineup(number int, m *match.Match, t common.GrenadeThrow, round int) Lineup {
+	lineup := Lineup{
+		Name:      fmt.Sprintf("lineup%d", number),
+		Map:       m.MapName,
+		Round:     round,
+		Player:    t.Name,
+		Grenade:   t.Grenade.String(),
+		Technique: t.Technique.String(),
+		Position:  [3]float32{t.Position.X, t.Position.Y, t.PositionZ},
+		Pitch:     common.NormalizePitch(t.ViewDirectionY),
+		Yaw:       t.ViewDirectionX,
+	}
+	if t.HasLanding {
+		lineup.Landing = &[3]float32{t.Landing.X, t.Landing.Y, t.LandingZ}
+	}
+	lineup.Command = fmt.Sprintf("setpos %.2f %.2f %.2f; setang %.2f %.2f 0",
+		lineup.Position[0], lineup.Position[1], lineup.Position[2], lineup.Pitch, lineup.Yaw)
+	if item, ok := grenadeItems[t.Grenade]; ok {
+		lineup.Command += "; give " + item
+	}
+	return lineup
+}
+
+// writeLineupConfig writes an alias for every lineup and the cheats that help
+// to practice them.
+func writeLineupConfig(fileName, mapName string, lineups []Lineup) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "// %d lineups on %s, type lineup1 to lineup%d in the console\n", len(lineups), mapName, len(lineups))
+	fmt.Fprintln(w, "sv_cheats 1")
+	fmt.Fprintln(w, "sv_infinite_ammo 1")
+	fmt.Fprintln(w, "sv_grenade_trajectory 1")
+	fmt.Fprintln(w, "ammo_grenade_limit_total 5")
+	for _, l := range lineups {
+		description := configString(fmt.Sprintf("%s: %s by %s in round %d (%s)", l.Name, l.Grenade, l.Player, l.Round, l.Technique))
+		fmt.Fprintf(w, "alias %s \"%s; echo %s\"\n", l.Name, l.Command, description)
+		fmt.Fprintf(w, "echo \"%s\"\n", description)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// configString removes the characters that end a command or a string in the
+// console from s, e.g. in player names.
+func configString(s string) string {
+	return strings.NewReplacer(";", "", "\"", "", "\n", " ").Replace(s)
+}
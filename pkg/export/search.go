@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/csv"
+	"path/filepath"
+	"strconv"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/query"
+)
+
+// Search writes the events of the match that match the query to search.csv
+// in dir.
+func Search(dir string, m *match.Match, q query.Query) error {
+	return writeCSVFile(filepath.Join(dir, "search.csv"), func(w *csv.Writer, m *match.Match) error {
+		return writeSearchResults(w, m, q)
+	}, m)
+}
+
+func writeSearchResults(w *csv.Writer, m *match.Match, q query.Query) error {
+	err := w.Write([]string{"round", "frame", "time", "type", "player", "team", "weapon", "victim", "x", "y"})
+	if err != nil {
+		return err
+	}
+	for _, e := range query.Run(m, q) {
+		err = w.Write([]string{
+			strconv.Itoa(e.Round),
+			strconv.Itoa(e.Frame),
+			formatSeconds(m.TimeAt(e.Frame).Seconds()),
+			e.Type.String(),
+			e.Player,
+			teamString(e.Team),
+			e.Weapon.String(),
+			e.Victim,
+			formatFloat(e.Position.X),
+			formatFloat(e.Position.Y),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
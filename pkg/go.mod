@@ -0,0 +1,5 @@
+module github.com/linus4/csgoverview/pkg
+
+require github.com/markus-wa/demoinfocs-golang/v2 v2.3.0
+
+go 1.16
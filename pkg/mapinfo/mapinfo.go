@@ -0,0 +1,180 @@
+// Package mapinfo contains geometric information about maps that is used to
+// analyze what happened in a match, e.g. chokepoints that are commonly
+// smoked off.
+package mapinfo
+
+import (
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// Chokepoint is a narrow passage on a map, represented by a line segment in
+// world coordinates that spans the passage from wall to wall.
+type Chokepoint struct {
+	Name string
+	// Site is the bombsite the chokepoint leads to ("A" or "B") or an empty
+	// string if it does not belong to a site, e.g. mid.
+	Site string
+	From common.Point
+	To   common.Point
+}
+
+// Zone is an area of a map, represented by a polygon in world coordinates.
+// The name of a bombsite is "A" or "B".
+type Zone struct {
+	Name    string
+	Polygon []common.Point
+}
+
+// Center returns the mean of the corners of the zone, e.g. to place a label.
+func (z Zone) Center() common.Point {
+	var center common.Point
+	for _, p := range z.Polygon {
+		center.X += p.X
+		center.Y += p.Y
+	}
+	if len(z.Polygon) > 0 {
+		center.X /= float32(len(z.Polygon))
+		center.Y /= float32(len(z.Polygon))
+	}
+	return center
+}
+
+// Info contains all geometric information about a map.
+type Info struct {
+	Name        string
+	Chokepoints []Chokepoint
+	// TSpawn and CTSpawn are the centers of the spawn areas of the teams.
+	TSpawn  common.Point
+	CTSpawn common.Point
+	// Occluders are the walls and buildings that block the view between the
+	// passages of the map.
+	Occluders []Occluder
+	// Bombsites are the zones in which the bomb can be planted and
+	// HostageZones the zones the hostages are rescued to.
+	Bombsites    []Zone
+	HostageZones []Zone
+}
+
+// ChokepointsForSite returns all chokepoints that lead to the given site.
+func (info Info) ChokepointsForSite(site string) []Chokepoint {
+	chokepoints := make([]Chokepoint, 0)
+	for _, c := range info.Chokepoints {
+		if c.Site == site {
+			chokepoints = append(chokepoints, c)
+		}
+	}
+	return chokepoints
+}
+
+// SiteAt returns the name of the bombsite that contains position or an empty
+// string if it is not on a site.
+func (info Info) SiteAt(position common.Point) string {
+	for _, site := range info.Bombsites {
+		if containsPoint(site.Polygon, position) {
+			return site.Name
+		}
+	}
+	return ""
+}
+
+// Lookup returns the Info for the map with the given name. The second return
+// value reports whether the map is known.
+func Lookup(mapName string) (Info, bool) {
+	info, ok := defaultInfos[mapName]
+	return info, ok
+}
+
+// rectangle returns the polygon of the axis-aligned rectangle spanned by the
+// corners (x1, y1) and (x2, y2).
+func rectangle(x1, y1, x2, y2 float32) []common.Point {
+	return []common.Point{{X: x1, Y: y1}, {X: x2, Y: y1}, {X: x2, Y: y2}, {X: x1, Y: y2}}
+}
+
+// The coordinates are approximations taken from the overview images and are
+// accurate enough to tell which passage a smoke blocks. The occluders only
+// cover the large buildings between the passages, small boxes and the walls
+// of the sites are left out. The bombsites are rectangles around the areas in
+// which the bomb can be planted.
+var defaultInfos = map[string]Info{
+	"de_mirage": {
+		Name:    "de_mirage",
+		TSpawn:  common.Point{X: 1250, Y: -150},
+		CTSpawn: common.Point{X: -1650, Y: -2050},
+		Chokepoints: []Chokepoint{
+			{Name: "CT", Site: "A", From: common.Point{X: -1270, Y: -2010}, To: common.Point{X: -1270, Y: -2280}},
+			{Name: "Jungle", Site: "A", From: common.Point{X: -980, Y: -1500}, To: common.Point{X: -980, Y: -1720}},
+			{Name: "Stairs", Site: "A", From: common.Point{X: -620, Y: -1500}, To: common.Point{X: -420, Y: -1500}},
+			{Name: "Palace", Site: "A", From: common.Point{X: 130, Y: -2050}, To: common.Point{X: 130, Y: -2300}},
+			{Name: "Window", Site: "", From: common.Point{X: -1120, Y: -700}, To: common.Point{X: -1120, Y: -920}},
+			{Name: "Top Mid", Site: "", From: common.Point{X: -200, Y: -300}, To: common.Point{X: -200, Y: -620}},
+			{Name: "Short", Site: "B", From: common.Point{X: -1750, Y: -300}, To: common.Point{X: -1520, Y: -300}},
+			{Name: "Market Door", Site: "B", From: common.Point{X: -2230, Y: -80}, To: common.Point{X: -2040, Y: -80}},
+			{Name: "Market Window", Site: "B", From: common.Point{X: -2420, Y: 200}, To: common.Point{X: -2420, Y: 380}},
+		},
+		Occluders: []Occluder{
+			{Name: "Palace", Polygon: rectangle(150, -2400, 700, -1700)},
+			{Name: "Tetris", Polygon: rectangle(-700, -1450, -250, -900)},
+			{Name: "Connector", Polygon: rectangle(-1000, -1350, -750, -900)},
+			{Name: "Jungle", Polygon: rectangle(-1500, -1450, -1050, -1050)},
+			{Name: "Apartments", Polygon: rectangle(-1100, 400, 200, 1000)},
+			{Name: "Underpass", Polygon: rectangle(-1300, -250, -700, 150)},
+			{Name: "Market", Polygon: rectangle(-2700, -650, -2250, -150)},
+		},
+		Bombsites: []Zone{
+			{Name: "A", Polygon: rectangle(-900, -2450, -100, -1700)},
+			{Name: "B", Polygon: rectangle(-2350, 50, -1750, 600)},
+		},
+	},
+	"de_dust2": {
+		Name:    "de_dust2",
+		TSpawn:  common.Point{X: -400, Y: -800},
+		CTSpawn: common.Point{X: 300, Y: 2250},
+		Chokepoints: []Chokepoint{
+			{Name: "Cross", Site: "A", From: common.Point{X: 480, Y: 1690}, To: common.Point{X: 480, Y: 2120}},
+			{Name: "CT", Site: "A", From: common.Point{X: 250, Y: 2300}, To: common.Point{X: 500, Y: 2300}},
+			{Name: "Short", Site: "A", From: common.Point{X: 200, Y: 1500}, To: common.Point{X: 480, Y: 1500}},
+			{Name: "Xbox", Site: "", From: common.Point{X: -420, Y: 1150}, To: common.Point{X: -200, Y: 1150}},
+			{Name: "Mid Doors", Site: "", From: common.Point{X: -600, Y: 2050}, To: common.Point{X: -340, Y: 2050}},
+			{Name: "B Doors", Site: "B", From: common.Point{X: -1400, Y: 2080}, To: common.Point{X: -1160, Y: 2080}},
+			{Name: "B Window", Site: "B", From: common.Point{X: -1280, Y: 2520}, To: common.Point{X: -1100, Y: 2520}},
+		},
+		Occluders: []Occluder{
+			{Name: "Mid Wall", Polygon: rectangle(-200, 200, 200, 1300)},
+			{Name: "Long Wall", Polygon: rectangle(600, 200, 1100, 1100)},
+			{Name: "Short", Polygon: rectangle(-100, 1600, 150, 2200)},
+			{Name: "Lower Tunnels", Polygon: rectangle(-1200, 400, -700, 1100)},
+			{Name: "Upper Tunnels", Polygon: rectangle(-2000, 1100, -1500, 1800)},
+			{Name: "B Doors", Polygon: rectangle(-1100, 1700, -650, 2000)},
+		},
+		Bombsites: []Zone{
+			{Name: "A", Polygon: rectangle(950, 2250, 1450, 2850)},
+			{Name: "B", Polygon: rectangle(-2100, 2200, -1450, 2950)},
+		},
+	},
+	"de_inferno": {
+		Name:    "de_inferno",
+		TSpawn:  common.Point{X: -1600, Y: 450},
+		CTSpawn: common.Point{X: 2400, Y: 2000},
+		Chokepoints: []Chokepoint{
+			{Name: "CT", Site: "B", From: common.Point{X: 650, Y: 2550}, To: common.Point{X: 650, Y: 2850}},
+			{Name: "Coffins", Site: "B", From: common.Point{X: 140, Y: 3150}, To: common.Point{X: 380, Y: 3150}},
+			{Name: "Banana", Site: "B", From: common.Point{X: 240, Y: 2100}, To: common.Point{X: 540, Y: 2100}},
+			{Name: "Long", Site: "A", From: common.Point{X: 1650, Y: 280}, To: common.Point{X: 1650, Y: 560}},
+			{Name: "Short", Site: "A", From: common.Point{X: 1720, Y: 780}, To: common.Point{X: 1960, Y: 780}},
+			{Name: "Library", Site: "A", From: common.Point{X: 2180, Y: 840}, To: common.Point{X: 2400, Y: 840}},
+			{Name: "Pit", Site: "A", From: common.Point{X: 2380, Y: -120}, To: common.Point{X: 2380, Y: 120}},
+		},
+		Occluders: []Occluder{
+			{Name: "Second Mid", Polygon: rectangle(-200, 300, 500, 1500)},
+			{Name: "Apartments", Polygon: rectangle(600, -200, 1400, 250)},
+			{Name: "Arch", Polygon: rectangle(1700, 1000, 2100, 1800)},
+			{Name: "Banana", Polygon: rectangle(-100, 1600, 200, 2600)},
+			{Name: "Construction", Polygon: rectangle(800, 2800, 1300, 3300)},
+			{Name: "Library", Polygon: rectangle(2250, 200, 2700, 700)},
+		},
+		Bombsites: []Zone{
+			{Name: "A", Polygon: rectangle(1750, 0, 2350, 750)},
+			{Name: "B", Polygon: rectangle(200, 2300, 650, 3100)},
+		},
+	},
+}
@@ -0,0 +1,24 @@
+package mapinfo
+
+import (
+	"time"
+)
+
+const (
+	// RunSpeed is the speed in units per second of a player who runs with
+	// the knife out, the fastest way to move without jumping.
+	RunSpeed float32 = 250
+	// MetersPerUnit converts units to meters, one unit is an inch.
+	MetersPerUnit float32 = 0.0254
+)
+
+// Meters converts a distance in units to meters.
+func Meters(units float32) float32 {
+	return units * MetersPerUnit
+}
+
+// TravelTime returns the time a player needs to run the distance in units at
+// RunSpeed. Walls are not taken into account.
+func TravelTime(units float32) time.Duration {
+	return time.Duration(float64(units) / float64(RunSpeed) * float64(time.Second))
+}
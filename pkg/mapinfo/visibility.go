@@ -0,0 +1,151 @@
+package mapinfo
+
+import (
+	"math"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+const (
+	// FieldOfView is the horizontal field of view in degrees of a player
+	// with the default fov on a 16:9 screen.
+	FieldOfView float32 = 106
+	// ViewDistance is the distance up to which the visible area reaches
+	// where nothing blocks the view.
+	ViewDistance float32 = 3000
+	// visibilityRays is the number of rays that are cast across the field of
+	// view to find the visible area.
+	visibilityRays = 90
+)
+
+// Occluder is a wall or a building that blocks the view, represented by a
+// polygon in world coordinates.
+type Occluder struct {
+	Name    string
+	Polygon []common.Point
+}
+
+// VisibleArea returns the outline of the area that a player at origin who
+// looks in the direction yaw (in degrees, like the view direction of the
+// players) can see, starting with origin. Only the occluders of the map
+// block the view, occluders that contain origin are left out, since the
+// polygons are approximations.
+func (info Info) VisibleArea(origin common.Point, yaw float32) []common.Point {
+	occluders := info.occludersAround(origin)
+	area := make([]common.Point, 0, visibilityRays+2)
+	area = append(area, origin)
+	for i := 0; i <= visibilityRays; i++ {
+		angle := yaw - FieldOfView/2 + FieldOfView*float32(i)/visibilityRays
+		radians := float64(angle) * math.Pi / 180
+		direction := common.Point{X: float32(math.Cos(radians)), Y: float32(math.Sin(radians))}
+		distance := castRay(occluders, origin, direction, ViewDistance)
+		area = append(area, common.Point{
+			X: origin.X + direction.X*distance,
+			Y: origin.Y + direction.Y*distance,
+		})
+	}
+	return area
+}
+
+// IsVisible reports whether no occluder of the map lies between from and to.
+// Occluders that contain from are left out like in VisibleArea.
+func (info Info) IsVisible(from, to common.Point) bool {
+	distance := from.Distance(to)
+	if distance == 0 {
+		return true
+	}
+	direction := common.Point{X: (to.X - from.X) / distance, Y: (to.Y - from.Y) / distance}
+	return castRay(info.occludersAround(from), from, direction, distance) >= distance
+}
+
+// Sees reports whether a player at origin who looks in the direction yaw can
+// see target, i.e. whether target lies within the field of view and the view
+// distance and no occluder is in between.
+func (info Info) Sees(origin common.Point, yaw float32, target common.Point) bool {
+	if origin.Distance(target) > ViewDistance {
+		return false
+	}
+	if origin != target {
+		direction := math.Atan2(float64(target.Y-origin.Y), float64(target.X-origin.X)) * 180 / math.Pi
+		diff := math.Mod(math.Abs(direction-float64(yaw)), 360)
+		if diff > 180 {
+			diff = 360 - diff
+		}
+		if diff > float64(FieldOfView)/2 {
+			return false
+		}
+	}
+	return info.IsVisible(origin, target)
+}
+
+// Watches reports whether a player at origin who looks in the direction yaw
+// can see a part of the passage of the chokepoint. The ends of the chokepoint
+// lie on walls, so only points in between are checked.
+func (info Info) Watches(origin common.Point, yaw float32, chokepoint Chokepoint) bool {
+	for _, t := range []float32{0.25, 0.5, 0.75} {
+		target := common.Point{
+			X: chokepoint.From.X + (chokepoint.To.X-chokepoint.From.X)*t,
+			Y: chokepoint.From.Y + (chokepoint.To.Y-chokepoint.From.Y)*t,
+		}
+		if info.Sees(origin, yaw, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// occludersAround returns the occluders that do not contain position.
+func (info Info) occludersAround(position common.Point) []Occluder {
+	occluders := make([]Occluder, 0, len(info.Occluders))
+	for _, o := range info.Occluders {
+		if !containsPoint(o.Polygon, position) {
+			occluders = append(occluders, o)
+		}
+	}
+	return occluders
+}
+
+// castRay returns the distance from origin in the given unit direction to the
+// nearest edge of the occluders, at most maxDistance.
+func castRay(occluders []Occluder, origin, direction common.Point, maxDistance float32) float32 {
+	nearest := maxDistance
+	for _, o := range occluders {
+		for i := range o.Polygon {
+			a := o.Polygon[i]
+			b := o.Polygon[(i+1)%len(o.Polygon)]
+			if t, ok := raySegmentIntersection(origin, direction, a, b); ok && t < nearest {
+				nearest = t
+			}
+		}
+	}
+	return nearest
+}
+
+// raySegmentIntersection returns the distance along the ray from origin in
+// direction at which it crosses the segment from a to b.
+func raySegmentIntersection(origin, direction, a, b common.Point) (float32, bool) {
+	edge := common.Point{X: b.X - a.X, Y: b.Y - a.Y}
+	denominator := direction.X*edge.Y - direction.Y*edge.X
+	if denominator == 0 {
+		return 0, false
+	}
+	toA := common.Point{X: a.X - origin.X, Y: a.Y - origin.Y}
+	t := (toA.X*edge.Y - toA.Y*edge.X) / denominator
+	u := (toA.X*direction.Y - toA.Y*direction.X) / denominator
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// containsPoint reports whether p lies inside of the polygon.
+func containsPoint(polygon []common.Point, p common.Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
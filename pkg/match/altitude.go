@@ -0,0 +1,48 @@
+package match
+
+import "sort"
+
+// altitudePercentile is the share of the heights of the players that are left
+// out at both ends of the altitude range, so that players who fall off the map
+// do not stretch it.
+const altitudePercentile float64 = 0.01
+
+// detectAltitudeRange sets AltitudeMin and AltitudeMax to the range of
+// heights the players were at, sampled once per second.
+func (m *Match) detectAltitudeRange() {
+	step := m.FrameRateRounded
+	if step < 1 {
+		step = 1
+	}
+	heights := make([]float32, 0)
+	for i := 0; i < len(m.States); i += step {
+		for _, p := range m.States[i].Players {
+			if p.IsAlive {
+				heights = append(heights, p.PositionZ)
+			}
+		}
+	}
+	if len(heights) == 0 {
+		return
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	cut := int(float64(len(heights)) * altitudePercentile)
+	m.AltitudeMin = heights[cut]
+	m.AltitudeMax = heights[len(heights)-1-cut]
+}
+
+// RelativeAltitude returns where the height lies in the altitude range of the
+// match, from 0 for the lowest to 1 for the highest positions.
+func (m Match) RelativeAltitude(z float32) float32 {
+	if m.AltitudeMax <= m.AltitudeMin {
+		return 0.5
+	}
+	relative := (z - m.AltitudeMin) / (m.AltitudeMax - m.AltitudeMin)
+	if relative < 0 {
+		return 0
+	}
+	if relative > 1 {
+		return 1
+	}
+	return relative
+}
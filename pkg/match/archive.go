@@ -0,0 +1,124 @@
+package match
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Magic bytes at the start of the supported file formats.
+var (
+	magicDemo  = []byte("HL2DEMO\x00")
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicZip   = []byte("PK\x03\x04")
+	magicRar   = []byte("Rar!\x1a\x07")
+)
+
+// DemoExtensions contains the file extensions of demos and of the archives
+// that OpenDemo can read.
+var DemoExtensions = []string{".dem", ".gz", ".bz2", ".zip"}
+
+// IsDemoFile reports whether the file has one of the DemoExtensions.
+func IsDemoFile(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, demoExt := range DemoExtensions {
+		if ext == demoExt {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenDemo opens the demo at fileName. Demos that are compressed with gzip or
+// bzip2 or that are the first demo in a zip archive are decompressed while
+// they are read. The format is detected by the first bytes of the file, not by
+// its extension.
+func OpenDemo(fileName string) (io.ReadCloser, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	magic, err := reader.Peek(len(magicDemo))
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("trying to decompress gzip: %v", err)
+		}
+		return demoReader{Reader: gzipReader, closers: []io.Closer{gzipReader, file}}, nil
+	case bytes.HasPrefix(magic, magicBzip2):
+		return demoReader{Reader: bzip2.NewReader(reader), closers: []io.Closer{file}}, nil
+	case bytes.HasPrefix(magic, magicZip):
+		file.Close()
+		return openZippedDemo(fileName)
+	case bytes.HasPrefix(magic, magicRar):
+		file.Close()
+		return nil, errors.New("RAR archives are not supported, extract the demo first")
+	}
+	return demoReader{Reader: reader, closers: []io.Closer{file}}, nil
+}
+
+// openZippedDemo opens the first .dem file in the zip archive.
+func openZippedDemo(fileName string) (io.ReadCloser, error) {
+	archive, err := zip.OpenReader(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("trying to open zip archive: %v", err)
+	}
+	for _, f := range archive.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".dem") {
+			continue
+		}
+		entry, err := f.Open()
+		if err != nil {
+			archive.Close()
+			return nil, fmt.Errorf("trying to open %v in zip archive: %v", f.Name, err)
+		}
+		return demoReader{Reader: entry, closers: []io.Closer{entry, archive}}, nil
+	}
+	archive.Close()
+	return nil, errors.New("zip archive does not contain a demo")
+}
+
+// demoReader reads a demo and closes all underlying readers and files.
+type demoReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Read fills p completely unless the end of the demo is reached. The bit
+// reader of the parser takes a short read for the end of the demo, but
+// bufio and the decompressors return short reads in the middle of it.
+func (r demoReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(r.Reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (r demoReader) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		err := c.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,63 @@
+package match
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenDemoFillsReads(t *testing.T) {
+	demo := append([]byte(nil), magicDemo...)
+	for len(demo) < 3*4096 {
+		demo = append(demo, byte(len(demo)))
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(demo)
+	gz.Close()
+
+	dir, err := ioutil.TempDir("", "csgoverview")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"test.dem", demo},
+		{"test.dem.gz", compressed.Bytes()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(dir, test.name)
+			if err := ioutil.WriteFile(fileName, test.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := OpenDemo(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			// the parser reads the demo in blocks larger than the buffer of bufio
+			buf := make([]byte, 2*4096)
+			n, err := r.Read(buf)
+			if n != len(buf) || err != nil {
+				t.Fatalf("Read() = %d, %v, want %d, nil", n, err, len(buf))
+			}
+			n, err = r.Read(buf)
+			if n != len(demo)-len(buf) || err != nil {
+				t.Fatalf("Read() = %d, %v at the end, want %d, nil", n, err, len(demo)-len(buf))
+			}
+			if _, err = r.Read(buf); err != io.EOF {
+				t.Fatalf("Read() after the end = %v, want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package match
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// Defaults for demos whose header does not contain the frame rate or the tick
+// rate, e.g. old demos or demos whose recording was interrupted. They are only
+// used if no fallback value is given.
+const (
+	defaultFrameRate float64 = 32
+	defaultTickRate  float64 = 64
+)
+
+func frameRateOrDefault(frameRate, fallback float64) float64 {
+	if isValidRate(frameRate) {
+		return frameRate
+	}
+	if fallback != -1 {
+		return fallback
+	}
+	logger.Log(LevelWarn, "could not parse framerate from demo, assuming the default (command-line option -framerate)",
+		Field{"framerate", defaultFrameRate})
+	return defaultFrameRate
+}
+
+func tickRateOrDefault(tickRate, fallback float64) float64 {
+	if isValidRate(tickRate) {
+		return tickRate
+	}
+	if fallback != -1 {
+		return fallback
+	}
+	logger.Log(LevelWarn, "could not parse tickrate from demo, assuming the default (command-line option -tickrate)",
+		Field{"tickrate", defaultTickRate})
+	return defaultTickRate
+}
+
+// normalizeMapName removes the workshop path from map names like
+// workshop/125438255/de_cache, which some older tournament demos contain.
+func normalizeMapName(mapName string) string {
+	if mapName == "" {
+		return ""
+	}
+	return path.Base(strings.ReplaceAll(mapName, "\\", "/"))
+}
+
+func isValidRate(rate float64) bool {
+	return rate > 0 && !math.IsNaN(rate) && !math.IsInf(rate, 0)
+}
+
+// fixMissingEvents fills in the events that old demos (roughly before 2017)
+// often lack, so that the rounds and the timer still make sense:
+// the end of the freezetime is estimated with mp_freezetime and rounds without
+// an end are ended by the start of the next round.
+func (m *Match) fixMissingEvents() {
+	freezetime, _ := phaseDuration(m.conVars, common.PhaseFreezetime)
+	for i := range m.Rounds {
+		r := &m.Rounds[i]
+		if r.EndFrame == -1 && i+1 < len(m.Rounds) {
+			r.EndFrame = m.Rounds[i+1].StartFrame
+		}
+		if r.FreezetimeEndFrame != -1 || len(m.frameTimes) == 0 {
+			continue
+		}
+		frame := m.frameAt(m.TimeAt(r.StartFrame) + freezetime)
+		if r.EndFrame != -1 && frame > r.EndFrame {
+			continue
+		}
+		r.FreezetimeEndFrame = frame
+		m.insertPhaseChange(frame, common.PhaseRegular)
+	}
+}
+
+// frameAt returns the first frame at or after the given demo time.
+func (m Match) frameAt(t time.Duration) int {
+	i := sort.Search(len(m.frameTimes), func(i int) bool { return m.frameTimes[i] >= t })
+	if i == len(m.frameTimes) {
+		return m.TotalFrames()
+	}
+	return m.frameNumbers[i]
+}
+
+// insertPhaseChange adds a phase change that was not recorded during parsing
+// unless the phase already changed at that frame.
+func (m *Match) insertPhaseChange(frame int, phase common.Phase) {
+	i := sort.Search(len(m.phaseChanges), func(i int) bool { return m.phaseChanges[i].frame > frame })
+	if i > 0 && m.phaseChanges[i-1].frame == frame {
+		return
+	}
+	duration, _ := phaseDuration(m.conVars, phase)
+	m.phaseChanges = append(m.phaseChanges, phaseChange{})
+	copy(m.phaseChanges[i+1:], m.phaseChanges[i:])
+	m.phaseChanges[i] = phaseChange{frame: frame, phase: phase, duration: duration}
+}
@@ -0,0 +1,101 @@
+package match
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+// relevantConVars are the game convars that are stored in Match.conVars. All
+// sv_ convars are stored as well.
+var relevantConVars = map[string]bool{
+	"mp_roundtime":                    true,
+	"mp_roundtime_defuse":             true,
+	"mp_roundtime_hostage":            true,
+	"mp_freezetime":                   true,
+	"mp_buytime":                      true,
+	"mp_c4timer":                      true,
+	"mp_maxrounds":                    true,
+	"mp_overtime_enable":              true,
+	"mp_overtime_maxrounds":           true,
+	"mp_overtime_startmoney":          true,
+	"mp_startmoney":                   true,
+	"mp_maxmoney":                     true,
+	"mp_round_restart_delay":          true,
+	"mp_halftime_duration":            true,
+	"mp_team_timeout_time":            true,
+	"mp_technical_timeout_duration_s": true,
+	"mp_friendlyfire":                 true,
+}
+
+// timerTolerance is the deviation of a measured duration from the configured
+// one that is still considered correct.
+const timerTolerance = time.Second
+
+func newServerInfo(header demoinfo.DemoHeader, tickRate float64) common.ServerInfo {
+	return common.ServerInfo{
+		Name:            header.ServerName,
+		ClientName:      header.ClientName,
+		GameDirectory:   header.GameDirectory,
+		Protocol:        header.Protocol,
+		NetworkProtocol: header.NetworkProtocol,
+		TickRate:        tickRate,
+	}
+}
+
+func registerConVarHandlers(parser dem.Parser, match *Match) {
+	parser.RegisterEventHandler(func(e event.ConVarsUpdated) {
+		for name, value := range e.UpdatedConVars {
+			if relevantConVars[name] || strings.HasPrefix(name, "sv_") {
+				match.conVars[name] = value
+			}
+		}
+	})
+}
+
+// TimerWarnings compares the durations of the freezetimes and rounds with the
+// convars of the server and describes every round that does not match them.
+// Rounds that contain a pause are skipped.
+func (m Match) TimerWarnings() []string {
+	var warnings []string
+	freezetime, hasFreezetime := phaseDuration(m.conVars, common.PhaseFreezetime)
+	roundtime, hasRoundtime := phaseDuration(m.conVars, common.PhaseRegular)
+	c4time, _ := phaseDuration(m.conVars, common.PhasePlanted)
+
+	for _, r := range m.Rounds {
+		if r.FreezetimeEndFrame <= 0 || m.hasPauseBetween(r.StartFrame, r.EndFrame) {
+			continue
+		}
+		if hasFreezetime {
+			measured := m.TimeAt(r.FreezetimeEndFrame) - m.TimeAt(r.StartFrame)
+			if measured > freezetime+timerTolerance || measured < freezetime-timerTolerance {
+				warnings = append(warnings, fmt.Sprintf("round %d: freezetime took %v, mp_freezetime is %v",
+					r.Number, measured.Round(time.Second), freezetime))
+			}
+		}
+		if hasRoundtime && r.EndFrame != -1 {
+			measured := m.TimeAt(r.EndFrame) - m.TimeAt(r.FreezetimeEndFrame)
+			if measured > roundtime+c4time+timerTolerance {
+				warnings = append(warnings, fmt.Sprintf("round %d: round took %v, round time and c4 timer are only %v",
+					r.Number, measured.Round(time.Second), roundtime+c4time))
+			}
+		}
+	}
+	return warnings
+}
+
+// hasPauseBetween reports whether the match was paused between the frames.
+// An endFrame of -1 stands for the end of the demo.
+func (m Match) hasPauseBetween(startFrame, endFrame int) bool {
+	for _, p := range m.Pauses {
+		if (endFrame == -1 || p.StartFrame <= endFrame) && (p.EndFrame == -1 || p.EndFrame >= startFrame) {
+			return true
+		}
+	}
+	return false
+}
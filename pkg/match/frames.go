@@ -0,0 +1,41 @@
+package match
+
+import (
+	"sort"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// StateAtFrame returns the state of the given frame of the demo. The parser
+// skips frames it cannot read, for those the state of the last frame before
+// is returned, so that seeking stays correct for demos with gaps. Frames
+// outside of the demo are clamped to the first or last state. The match
+// must have states, see LastFrame.
+func (m Match) StateAtFrame(frame int) common.OverviewState {
+	i := sort.Search(len(m.States), func(i int) bool { return m.States[i].Frame > frame }) - 1
+	if i < 0 {
+		i = 0
+	}
+	state := m.States[i]
+	state.Players = m.statePlayers(i)
+	return state
+}
+
+// LastFrame returns the frame of the last state or -1 if the match has no
+// states, e.g. because it was parsed with ParseEvents.
+func (m Match) LastFrame() int {
+	if len(m.States) == 0 {
+		return -1
+	}
+	return m.States[len(m.States)-1].Frame
+}
+
+// frameIndex returns the index of the last frame in frameNumbers that is not
+// after frame, clamped to the first and last frame.
+func frameIndex(frameNumbers []int, frame int) int {
+	i := sort.Search(len(frameNumbers), func(i int) bool { return frameNumbers[i] > frame }) - 1
+	if i < 0 {
+		return 0
+	}
+	return i
+}
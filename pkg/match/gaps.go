@@ -0,0 +1,115 @@
+package match
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+)
+
+const (
+	// gapTickFactor is how many times more ticks than usual have to pass
+	// between two frames for a gap to be recorded.
+	gapTickFactor = 4
+	// smoothMaxDuration is the longest gap that SmoothGaps interpolates
+	// across. Players can move anywhere in longer gaps.
+	smoothMaxDuration = time.Second
+)
+
+// detectGap records a gap if more ticks passed since the previous frame than
+// the tick rate and the frame rate suggest. It has to be called once for
+// every frame before the frame is recorded.
+func (m *Match) detectGap(parser dem.Parser) {
+	tick := parser.GameState().IngameTick()
+	previousTick := m.lastTick
+	m.lastTick = tick
+	if len(m.frameNumbers) == 0 || previousTick <= 0 || tick <= previousTick {
+		return
+	}
+	ticksPerFrame := math.Max(m.TickRate/m.FrameRate, 1)
+	missing := tick - previousTick - int(math.Round(ticksPerFrame))
+	if float64(tick-previousTick) < gapTickFactor*ticksPerFrame || missing <= 0 {
+		return
+	}
+	m.Gaps = append(m.Gaps, common.Gap{
+		StartFrame:   m.frameNumbers[len(m.frameNumbers)-1],
+		EndFrame:     parser.CurrentFrame(),
+		MissingTicks: missing,
+		Duration:     time.Duration(float64(missing) / m.TickRate * float64(time.Second)),
+	})
+}
+
+// SmoothGaps interpolates the positions of the players across gaps that are
+// shorter than a second, so that they glide instead of teleporting during
+// playback. The positions around a gap are replaced by a linear movement
+// from before to after it over about as many frames as are missing. It
+// modifies the states, so it panics if the match is frozen.
+func (m *Match) SmoothGaps() {
+	m.mustNotBeFrozen("SmoothGaps")
+	ticksPerFrame := math.Max(m.TickRate/m.FrameRate, 1)
+	for _, gap := range m.Gaps {
+		if gap.Duration > smoothMaxDuration {
+			continue
+		}
+		end := sort.Search(len(m.States), func(i int) bool { return m.States[i].Frame >= gap.EndFrame })
+		if end == 0 || end == len(m.States) {
+			continue
+		}
+		half := (int(math.Round(float64(gap.MissingTicks)/ticksPerFrame)) + 1) / 2
+		if half < 1 {
+			half = 1
+		}
+		from := end - 1 - half
+		if from < 0 {
+			from = 0
+		}
+		to := end + half
+		if to > len(m.States)-1 {
+			to = len(m.States) - 1
+		}
+		m.interpolatePlayers(from, to)
+	}
+}
+
+// interpolatePlayers moves the players that are alive in the states with the
+// indices from and to linearly between their positions in these states.
+func (m *Match) interpolatePlayers(from, to int) {
+	for _, start := range m.States[from].Players {
+		end, ok := findSamePlayer(m.States[to].Players, start)
+		if !ok || !start.IsAlive || !end.IsAlive {
+			continue
+		}
+		for i := from + 1; i < to; i++ {
+			t := float32(i-from) / float32(to-from)
+			players := m.States[i].Players
+			for j := range players {
+				if !isSamePlayer(players[j], start) || !players[j].IsAlive {
+					continue
+				}
+				players[j].Position.X = start.Position.X + (end.Position.X-start.Position.X)*t
+				players[j].Position.Y = start.Position.Y + (end.Position.Y-start.Position.Y)*t
+				players[j].PositionZ = start.PositionZ + (end.PositionZ-start.PositionZ)*t
+			}
+		}
+	}
+}
+
+func findSamePlayer(players []common.Player, player common.Player) (common.Player, bool) {
+	for _, p := range players {
+		if isSamePlayer(p, player) {
+			return p, true
+		}
+	}
+	return common.Player{}, false
+}
+
+// isSamePlayer compares players by their SteamID and bots, which all have
+// the SteamID 0, by their name.
+func isSamePlayer(a, b common.Player) bool {
+	if a.SteamID64 == 0 || b.SteamID64 == 0 {
+		return a.SteamID64 == b.SteamID64 && a.Name == b.Name
+	}
+	return a.SteamID64 == b.SteamID64
+}
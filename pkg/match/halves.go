@@ -0,0 +1,86 @@
+package match
+
+import (
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+// regulationHalves is the number of halves before overtime.
+const regulationHalves int = 2
+
+func (m *Match) currentHalf() *common.Half {
+	if len(m.Halves) == 0 {
+		return nil
+	}
+	return &m.Halves[len(m.Halves)-1]
+}
+
+func (m *Match) startHalf(frame int) {
+	number := len(m.Halves) + 1
+	m.Halves = append(m.Halves, common.Half{
+		Number:     number,
+		StartFrame: frame,
+		EndFrame:   -1,
+		IsOvertime: number > regulationHalves,
+	})
+}
+
+func (m *Match) endHalf(frame int) {
+	half := m.currentHalf()
+	if half != nil && half.EndFrame < 0 {
+		half.EndFrame = frame
+	}
+}
+
+func registerHalfHandlers(parser dem.Parser, match *Match) {
+	// a new half starts with the first round after the previous half ended
+	halfEnded := true
+
+	parser.RegisterEventHandler(func(event.MatchStart) {
+		// the match might be restarted, e.g. after the knife round
+		match.Halves = match.Halves[:0]
+		match.startHalf(parser.CurrentFrame())
+		halfEnded = false
+	})
+	parser.RegisterEventHandler(func(event.RoundStart) {
+		if halfEnded {
+			match.startHalf(parser.CurrentFrame())
+			halfEnded = false
+		}
+	})
+	parser.RegisterEventHandler(func(event.GameHalfEnded) {
+		match.endHalf(parser.CurrentFrame())
+		halfEnded = true
+	})
+	parser.RegisterEventHandler(func(event.AnnouncementWinPanelMatch) {
+		match.endHalf(parser.CurrentFrame())
+		halfEnded = true
+	})
+}
+
+// finishHalves ends the last half at the end of the demo if it did not end
+// regularly.
+func (m *Match) finishHalves() {
+	m.endHalf(m.TotalFrames() - 1)
+}
+
+// HalfIndex returns the index into Halves of the half that is being played at
+// the given frame or -1 if the frame does not belong to any half.
+func (m Match) HalfIndex(frame int) int {
+	for i, half := range m.Halves {
+		if frame >= half.StartFrame && (half.EndFrame < 0 || frame <= half.EndFrame) {
+			return i
+		}
+	}
+	return -1
+}
+
+// HalfStartFrames returns the start frames of all halves.
+func (m Match) HalfStartFrames() []int {
+	frames := make([]int, 0, len(m.Halves))
+	for _, half := range m.Halves {
+		frames = append(frames, half.StartFrame)
+	}
+	return frames
+}
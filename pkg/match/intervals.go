@@ -0,0 +1,58 @@
+package match
+
+import "sort"
+
+// interval is the range of frames [start, end) in which an event is visible.
+type interval struct {
+	start int
+	end   int
+}
+
+// intervalIndex finds the events that are visible at a frame. Intervals must
+// be added in the order of their start frames, which is the case for events
+// during parsing, so adding is O(1) and looking up is O(log n + k) where k is
+// the number of intervals that start within the longest lifetime.
+type intervalIndex struct {
+	intervals []interval
+	maxLength int
+}
+
+// add adds the interval [start, end) and returns its index, which is the same
+// as the index of the event in the slice it is stored in.
+func (idx *intervalIndex) add(start, end int) int {
+	idx.intervals = append(idx.intervals, interval{start: start, end: end})
+	if end-start > idx.maxLength {
+		idx.maxLength = end - start
+	}
+	return len(idx.intervals) - 1
+}
+
+// truncate ends every interval that is still visible after frame at frame.
+func (idx *intervalIndex) truncate(frame int) {
+	for i := len(idx.intervals) - 1; i >= 0; i-- {
+		iv := &idx.intervals[i]
+		if iv.start+idx.maxLength <= frame {
+			break
+		}
+		if iv.end > frame {
+			iv.end = frame
+		}
+	}
+}
+
+// at calls f with the index and the start frame of every interval that
+// contains frame in the order they were added.
+func (idx *intervalIndex) at(frame int, f func(i, start int)) {
+	// intervals that start before lo ended before frame
+	lo := sort.Search(len(idx.intervals), func(i int) bool {
+		return idx.intervals[i].start > frame-idx.maxLength
+	})
+	hi := sort.Search(len(idx.intervals), func(i int) bool {
+		return idx.intervals[i].start > frame
+	})
+	for i := lo; i < hi; i++ {
+		if idx.intervals[i].end > frame {
+			f(i, idx.intervals[i].start)
+		}
+	}
+}
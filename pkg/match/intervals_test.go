@@ -0,0 +1,108 @@
+package match
+
+import (
+	"reflect"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+func TestIntervalIndexAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []interval
+		frame     int
+		want      []int
+	}{
+		{"empty", nil, 0, nil},
+		{"before first", []interval{{10, 20}}, 9, nil},
+		{"start is included", []interval{{10, 20}}, 10, []int{0}},
+		{"end is excluded", []interval{{10, 20}}, 20, nil},
+		{"last frame", []interval{{10, 20}}, 19, []int{0}},
+		{"overlapping", []interval{{10, 20}, {12, 14}, {15, 30}}, 13, []int{0, 1}},
+		{"overlapping in added order", []interval{{10, 20}, {12, 14}, {15, 30}}, 16, []int{0, 2}},
+		{"long interval before short ones", []interval{{0, 100}, {50, 51}, {60, 61}}, 70, []int{0}},
+		{"same start", []interval{{10, 11}, {10, 20}}, 11, []int{1}},
+		{"after last", []interval{{10, 20}, {15, 30}}, 30, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var idx intervalIndex
+			for _, iv := range test.intervals {
+				idx.add(iv.start, iv.end)
+			}
+			var got []int
+			idx.at(test.frame, func(i, start int) {
+				if start != test.intervals[i].start {
+					t.Errorf("at(%d) passed start %d for interval %d, want %d", test.frame, start, i, test.intervals[i].start)
+				}
+				got = append(got, i)
+			})
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("at(%d) = %v, want %v", test.frame, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntervalIndexTruncate(t *testing.T) {
+	var idx intervalIndex
+	idx.add(0, 100)
+	idx.add(10, 20)
+	idx.add(30, 60)
+	idx.truncate(40)
+	want := []interval{{0, 40}, {10, 20}, {30, 40}}
+	if !reflect.DeepEqual(idx.intervals, want) {
+		t.Errorf("intervals after truncate(40) = %v, want %v", idx.intervals, want)
+	}
+}
+
+func TestMatchAtFrame(t *testing.T) {
+	var m Match
+	for i := 0; i < 3; i++ {
+		m.grenadeEffects = append(m.grenadeEffects, common.GrenadeEffect{Position: common.Point{X: float32(i)}})
+		m.grenadeEffectIndex.add(10*i, 10*i+15)
+		m.FiredShots = append(m.FiredShots, common.Shot{Frame: 10 * i})
+		m.shotIndex.add(10*i, 10*i+5)
+	}
+	for i := 0; i < maxKillfeedLength+2; i++ {
+		m.Kills = append(m.Kills, common.Kill{Frame: i})
+		m.killfeedIndex.add(i, i+50)
+	}
+
+	tests := []struct {
+		frame    int
+		effects  []common.GrenadeEffect
+		shots    []common.Shot
+		killfeed int
+		// newestKill is the frame of the last kill in the killfeed
+		newestKill int
+	}{
+		{-1, nil, nil, 0, 0},
+		{0, []common.GrenadeEffect{{Lifetime: 0}}, []common.Shot{{Frame: 0}}, 1, 0},
+		{5, []common.GrenadeEffect{{Lifetime: 5}}, nil, maxKillfeedLength, 5},
+		{12, []common.GrenadeEffect{
+			{Lifetime: 12},
+			{Position: common.Point{X: 1}, Lifetime: 2},
+		}, []common.Shot{{Frame: 10}}, maxKillfeedLength, maxKillfeedLength + 1},
+		{35, nil, nil, maxKillfeedLength, maxKillfeedLength + 1},
+		{55, nil, nil, 2, maxKillfeedLength + 1},
+		{100, nil, nil, 0, 0},
+	}
+	for _, test := range tests {
+		if got := m.EffectsAt(test.frame); !reflect.DeepEqual(got, test.effects) {
+			t.Errorf("EffectsAt(%d) = %v, want %v", test.frame, got, test.effects)
+		}
+		if got := m.ShotsAt(test.frame); !reflect.DeepEqual(got, test.shots) {
+			t.Errorf("ShotsAt(%d) = %v, want %v", test.frame, got, test.shots)
+		}
+		got := m.KillfeedAt(test.frame)
+		if len(got) != test.killfeed {
+			t.Errorf("KillfeedAt(%d) has %d kills, want %d", test.frame, len(got), test.killfeed)
+		}
+		// the killfeed keeps the newest kills
+		if len(got) > 0 && got[len(got)-1].Frame != test.newestKill {
+			t.Errorf("KillfeedAt(%d) ends with the kill of frame %d, want %d", test.frame, got[len(got)-1].Frame, test.newestKill)
+		}
+	}
+}
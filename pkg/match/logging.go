@@ -0,0 +1,125 @@
+package match
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// Level is the severity of a message of the parser.
+type Level int
+
+// Possible values for Level.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// Field is additional data of a message, e.g. the frame an error occurred at.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the messages of the parser, e.g. warnings about demos with
+// a broken header. Programs that embed the parser can pass them on to their
+// own logging.
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// Tracer starts a span for every phase of parsing a demo, e.g. parsing the
+// frames and analyzing the events afterwards. It can be used to connect the
+// parser to a tracing system.
+type Tracer interface {
+	StartSpan(name string) Span
+}
+
+// Span is a phase of parsing that was started by a Tracer. End is called with
+// the error the phase failed with or nil.
+type Span interface {
+	End(err error)
+}
+
+var (
+	logger Logger = nopLogger{}
+	tracer Tracer = nopTracer{}
+)
+
+// SetLogger sets the logger of the parser. By default all messages are
+// discarded. It must not be called while demos are parsed.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
+
+// SetTracer sets the tracer of the parser. By default no spans are recorded.
+// It must not be called while demos are parsed.
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = nopTracer{}
+	}
+	tracer = t
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(Level, string, ...Field) {}
+
+type nopTracer struct{}
+
+func (nopTracer) StartSpan(string) Span { return nopSpan{} }
+
+type nopSpan struct{}
+
+func (nopSpan) End(error) {}
+
+// textLogger writes the messages as lines of text, e.g.
+// "WARN could not parse framerate from demo assumed=32".
+type textLogger struct {
+	out      *log.Logger
+	minLevel Level
+}
+
+// NewTextLogger returns a logger that writes all messages with at least the
+// given level to w, prefixed with the date and time.
+func NewTextLogger(w io.Writer, minLevel Level) Logger {
+	return textLogger{
+		out:      log.New(w, "", log.LstdFlags),
+		minLevel: minLevel,
+	}
+}
+
+func (l textLogger) Log(level Level, msg string, fields ...Field) {
+	if level < l.minLevel {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %v=%v", f.Key, f.Value)
+	}
+	l.out.Println(b.String())
+}
@@ -0,0 +1,213 @@
+package match
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	meta "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/metadata"
+)
+
+// MapConfig contains the position and the scale of the overview image of a
+// map. The values can be copied from the overview file of the map that is
+// shipped with the game, e.g. resource/overviews/de_dust2.txt.
+type MapConfig struct {
+	// PosX and PosY are the coordinates of the upper left corner of the
+	// overview image in the game.
+	PosX float64 `json:"pos_x"`
+	PosY float64 `json:"pos_y"`
+	// Scale is the number of units in the game per pixel of the overview.
+	Scale float64 `json:"scale"`
+	// Rotate is the clockwise rotation of the overview image in degrees (0,
+	// 90, 180 or 270) and FlipX and FlipY mirror it, for custom overview
+	// images that are not oriented like the game.
+	Rotate int  `json:"rotate,omitempty"`
+	FlipX  bool `json:"flip_x,omitempty"`
+	FlipY  bool `json:"flip_y,omitempty"`
+	// Sections are the vertical sections of maps with several floors, the
+	// first one is the section of the overview image of the map.
+	Sections []MapSectionConfig `json:"sections,omitempty"`
+}
+
+// MapSectionConfig is a vertical section of a map in a map config, like the
+// verticalsections of the overview files of the game.
+type MapSectionConfig struct {
+	Name        string  `json:"name"`
+	AltitudeMin float64 `json:"altitude_min"`
+	AltitudeMax float64 `json:"altitude_max"`
+}
+
+// defaultSections are the vertical sections of the maps with several floors
+// that the parser knows, as defined in the overview files of the game.
+var defaultSections = map[string][]MapSectionConfig{
+	"de_nuke": {
+		{Name: "default", AltitudeMin: -495, AltitudeMax: 10000},
+		{Name: "lower", AltitudeMin: -10000, AltitudeMax: -495},
+	},
+	"de_vertigo": {
+		{Name: "default", AltitudeMin: 11700, AltitudeMax: 20000},
+		{Name: "lower", AltitudeMin: -10000, AltitudeMax: 11700},
+	},
+}
+
+// customMaps contains the maps that were registered in addition to those the
+// parser knows. It is guarded by customMapsMu, because maps can be registered
+// while demos are parsed in the background.
+var (
+	customMaps   = make(map[string]MapConfig)
+	customMapsMu sync.RWMutex
+)
+
+// RegisterMap adds a map that the parser does not know or replaces the
+// configuration of a known map. Demos that are parsed while it is called use
+// either the old or the new configuration.
+func RegisterMap(mapName string, config MapConfig) {
+	customMapsMu.Lock()
+	defer customMapsMu.Unlock()
+	customMaps[mapName] = config
+}
+
+// LoadMapConfigs registers the maps in a JSON file that maps the names of the
+// maps to their configuration, e.g.
+// {"de_cbble": {"pos_x": -3840, "pos_y": 3072, "scale": 6}}.
+func LoadMapConfigs(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	var configs map[string]MapConfig
+	err = json.Unmarshal(data, &configs)
+	if err != nil {
+		return err
+	}
+	for mapName, config := range configs {
+		if config.Scale <= 0 {
+			return fmt.Errorf("the scale of map %v must be positive", mapName)
+		}
+		if config.Rotate != 0 && config.Rotate != 90 && config.Rotate != 180 && config.Rotate != 270 {
+			return fmt.Errorf("the rotation of map %v must be 0, 90, 180 or 270", mapName)
+		}
+		for _, section := range config.Sections {
+			if section.AltitudeMin >= section.AltitudeMax {
+				return fmt.Errorf("the section %v of map %v must have a lower minimum than maximum altitude", section.Name, mapName)
+			}
+		}
+		RegisterMap(mapName, config)
+	}
+	return nil
+}
+
+// SupportedMaps returns the names of all maps whose overview position is
+// known, ordered by name.
+func SupportedMaps() []string {
+	customMapsMu.RLock()
+	defer customMapsMu.RUnlock()
+	maps := make([]string, 0, len(meta.MapNameToMap)+len(customMaps))
+	for mapName := range meta.MapNameToMap {
+		if _, ok := customMaps[mapName]; !ok {
+			maps = append(maps, mapName)
+		}
+	}
+	for mapName := range customMaps {
+		maps = append(maps, mapName)
+	}
+	sort.Strings(maps)
+	return maps
+}
+
+// UnknownMapError is returned if the position of the overview of the map of
+// a demo is not known, so that the positions of the players could not be
+// drawn on the overview.
+type UnknownMapError struct {
+	MapName string
+}
+
+func (e *UnknownMapError) Error() string {
+	return fmt.Sprintf("no overview metadata for map %v, supported maps are %v; other maps can be added with a map config",
+		e.MapName, strings.Join(SupportedMaps(), ", "))
+}
+
+// mapConfig returns the configuration of the map. Registered maps take
+// precedence over the maps the parser knows. Registered maps without
+// sections use the default sections of the map.
+func mapConfig(mapName string) (MapConfig, error) {
+	customMapsMu.RLock()
+	config, ok := customMaps[mapName]
+	customMapsMu.RUnlock()
+	if !ok {
+		m, ok := meta.MapNameToMap[mapName]
+		if !ok {
+			return MapConfig{}, &UnknownMapError{MapName: mapName}
+		}
+		config = MapConfig{PosX: m.PZero.X, PosY: m.PZero.Y, Scale: m.Scale}
+	}
+	if len(config.Sections) == 0 {
+		config.Sections = defaultSections[mapName]
+	}
+	return config, nil
+}
+
+// setMap sets the position and the scale of the overview of the match.
+func (m *Match) setMap(mapName string) error {
+	m.MapName = mapName
+	config, err := mapConfig(mapName)
+	if err != nil {
+		return err
+	}
+	m.MapPZero = common.Point{X: float32(config.PosX), Y: float32(config.PosY)}
+	m.MapScale = float32(config.Scale)
+	m.MapTransform = common.MapTransform{Rotate: config.Rotate, FlipX: config.FlipX, FlipY: config.FlipY}
+	m.MapSections = make([]common.MapSection, 0, len(config.Sections))
+	for _, section := range config.Sections {
+		m.MapSections = append(m.MapSections, common.MapSection{
+			Name:        section.Name,
+			AltitudeMin: float32(section.AltitudeMin),
+			AltitudeMax: float32(section.AltitudeMax),
+		})
+	}
+	return nil
+}
+
+// SectionAt returns the index of the vertical section of the map that
+// contains the height, or 0 if the map has no sections or the height lies
+// outside of all of them.
+func (m Match) SectionAt(z float32) int {
+	for i, section := range m.MapSections {
+		if section.Contains(z) {
+			return i
+		}
+	}
+	return 0
+}
+
+// SaveMapConfig registers the configuration of the map and saves it to the
+// map config file. The other maps in the file are kept, the file is created
+// if it does not exist.
+func SaveMapConfig(fileName, mapName string, config MapConfig) error {
+	configs := make(map[string]MapConfig)
+	data, err := ioutil.ReadFile(fileName)
+	if err == nil {
+		err = json.Unmarshal(data, &configs)
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	configs[mapName] = config
+	data, err = json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(fileName, data, 0644)
+	if err != nil {
+		return err
+	}
+	RegisterMap(mapName, config)
+	return nil
+}
@@ -0,0 +1,1133 @@
+// Package match contains a high-level parser for demos.
+//
+// The parser does not modify a Match anymore once NewMatch, ParseEvents or
+// Stream returned it. Quantize, SmoothGaps and SetProfiles (see steam.Enrich)
+// modify it afterwards, so they have to be called before the match is
+// shared. Freeze marks the match as read-only, after that these methods
+// panic and the match can be read from several goroutines at the same time,
+// e.g. by the exporters or by the requests of a server. The match that the
+// StateHandler of Stream receives is still being filled and must only be read
+// within the handler.
+package match
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+	st "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/sendtables"
+)
+
+const (
+	propMatchWaitingForResume = "cs_gamerules_data.m_bMatchWaitingForResume"
+	propTerroristTimeOut      = "cs_gamerules_data.m_bTerroristTimeOutActive"
+	propCTTimeOut             = "cs_gamerules_data.m_bCTTimeOutActive"
+)
+
+const (
+	flashEffectLifetime int32         = 10
+	heEffectLifetime    int32         = 10
+	killfeedLifetime    int           = 10
+	maxKillfeedLength   int           = 6
+	defuseTime          time.Duration = 10 * time.Second
+	defuseTimeWithKit   time.Duration = 5 * time.Second
+	plantTime           time.Duration = 3 * time.Second
+)
+
+// Match contains general information about the demo and all relevant, parsed
+// data from every tick of the demo that will be displayed.
+type Match struct {
+	MapName  string
+	MapPZero common.Point
+	MapScale float32
+	// MapTransform rotates and mirrors the positions like the overview image.
+	MapTransform common.MapTransform
+	// MapSections are the vertical sections of maps with several floors
+	// that have their own overview images. It is empty for other maps.
+	MapSections []common.MapSection
+	// AltitudeMin and AltitudeMax are the range of heights the players were
+	// at. They are only set by NewMatch.
+	AltitudeMin float32
+	AltitudeMax float32
+	Server      common.ServerInfo
+	// conVars contains the last values of the game convars that are relevant
+	// for the timers and the economy, and all sv_ convars, see ConVar.
+	conVars map[string]string
+	Halves  []common.Half
+	// HalfStarts contains the frames of all events that are related to the
+	// start or end of a half. It is only kept for backward compatibility,
+	// use Halves instead.
+	HalfStarts          []int
+	RoundStarts         []int
+	Rounds              []common.Round
+	Summary             common.Summary
+	FrameRate           float64
+	TickRate            float64
+	FrameRateRounded    int
+	States              []common.OverviewState
+	SmokeEffectLifetime int32
+	Kills               []common.Kill
+	FiredShots          []common.Shot
+	Sounds              []common.Sound
+	GrenadeThrows       []common.GrenadeThrow
+	Hits                []common.Hit
+	Smokes              []common.Smoke
+	BombPlants          []common.BombPlant
+	PlantAttempts       []common.PlantAttempt
+	Pickups             []common.Pickup
+	// infernoExtents contains for every inferno the convex hull of all fires
+	// it had over its life, see InfernoExtent.
+	infernoExtents map[int64][]common.Point
+	Pauses         []common.Pause
+	// Gaps contains the points at which ticks of the demo are missing.
+	Gaps []common.Gap
+	// profiles contains the Steam profiles of the players, see Profile.
+	profiles map[uint64]common.Profile
+	// frozen is set by Freeze.
+	frozen             bool
+	activeSmokes       map[int]int
+	currentBombPlant   int
+	currentPause       int
+	demoTime           time.Duration
+	grenadeEffects     []common.GrenadeEffect
+	grenadeEffectIndex intervalIndex
+	shotIndex          intervalIndex
+	soundIndex         intervalIndex
+	killfeedIndex      intervalIndex
+	frameTimes         []time.Duration
+	phaseChanges       []phaseChange
+	observerSlots      map[uint64]int
+	infernoFires       map[int64]int
+	drops              map[int64]common.Pickup
+	// activeThrows maps the projectiles in the air to their index in
+	// GrenadeThrows.
+	activeThrows map[int64]int
+	// finalState is the state at the end of the demo if the states are not
+	// stored, see ParseEvents and Stream.
+	finalState common.OverviewState
+	// players contains the last known state of every player, see Players.
+	players []common.Player
+	// frameNumbers contains the frame numbers of the demo that frameTimes
+	// refer to. Frames that the parser could not read are missing.
+	frameNumbers []int
+	lastTick     int
+	// quantizedPlayers contains the players of all states after Quantize,
+	// those of the state i start at quantizedStarts[i]. It is nil before.
+	quantizedPlayers []quantizedPlayer
+	quantizedStarts  []int32
+	playerDetails    []common.Player
+}
+
+// NewMatch parses the demo at the specified path in the argument and returns a
+// match.Match containing all relevant data from the demo.
+// fallbackFrameRate and fallbackTickRate are used in case the values cannot be
+// parsed from the demo. If they are not set, they must be -1, in which case
+// common defaults are assumed.
+func NewMatch(demoFileName string, fallbackFrameRate, fallbackTickRate float64) (*Match, error) {
+	return NewMatchContext(context.Background(), demoFileName, fallbackFrameRate, fallbackTickRate)
+}
+
+// NewMatchContext parses the demo like NewMatch, but stops and returns
+// ctx.Err() once ctx is done, e.g. to abort parsing a demo in the background
+// that is no longer needed.
+func NewMatchContext(ctx context.Context, demoFileName string, fallbackFrameRate, fallbackTickRate float64) (*Match, error) {
+	demo, err := OpenDemo(demoFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer demo.Close()
+
+	parser := dem.NewParser(demo)
+	defer parser.Close()
+	match, err := parseHeader(parser, fallbackFrameRate, fallbackTickRate)
+	if err != nil {
+		return nil, err
+	}
+
+	span := tracer.StartSpan("parse_frames")
+	match.States, err = parseGameStates(ctx, parser, match)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	span = tracer.StartSpan("analyze")
+	match.fixMissingEvents()
+	match.finishHalves()
+	match.countRoundEvents()
+	match.detectSmokeKills()
+	match.detectKnifeRounds()
+	match.detectAltitudeRange()
+	match.summarize()
+	match.finish()
+	span.End(nil)
+
+	return match, nil
+}
+
+// ParseEvents parses the demo like NewMatch, but only collects the events,
+// rounds and halves and skips the states of the frames, which is a lot
+// faster. States of the returned match is empty, Players returns the players
+// that were connected at the end of the demo.
+func ParseEvents(demoFileName string, fallbackFrameRate, fallbackTickRate float64) (*Match, error) {
+	demo, err := OpenDemo(demoFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer demo.Close()
+
+	parser := dem.NewParser(demo)
+	defer parser.Close()
+	match, err := parseHeader(parser, fallbackFrameRate, fallbackTickRate)
+	if err != nil {
+		return nil, err
+	}
+	match.States = make([]common.OverviewState, 0)
+
+	span := tracer.StartSpan("parse_frames")
+	for ok, err := parser.ParseNextFrame(); ok; ok, err = parser.ParseNextFrame() {
+		if err != nil {
+			logFrameError(parser, err)
+			continue
+		}
+		match.advanceDemoTime(parser)
+	}
+	match.finalState = parseGameState(parser, match)
+	span.End(nil)
+
+	span = tracer.StartSpan("analyze")
+	match.fixMissingEvents()
+	match.finishHalves()
+	match.countRoundEvents()
+	match.detectSmokeKills()
+	match.detectKnifeRounds()
+	match.summarize()
+	match.finish()
+	span.End(nil)
+
+	return match, nil
+}
+
+// parseHeader parses the header of the demo and creates the Match that is
+// filled by the event handlers while the frames are parsed.
+func parseHeader(parser dem.Parser, fallbackFrameRate, fallbackTickRate float64) (*Match, error) {
+	span := tracer.StartSpan("parse_header")
+	header, err := parser.ParseHeader()
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	match, err := newMatch(parser, header, fallbackFrameRate, fallbackTickRate)
+	span.End(err)
+	return match, err
+}
+
+// logFrameError reports an error of the parser in a frame. Parsing continues
+// with the next frame.
+func logFrameError(parser dem.Parser, err error) {
+	logger.Log(LevelWarn, "trying to parse frame", Field{"frame", parser.CurrentFrame()}, Field{"error", err})
+}
+
+// newMatch creates a Match from the header of the demo and registers all
+// event handlers that fill it during parsing.
+func newMatch(parser dem.Parser, header demoinfo.DemoHeader, fallbackFrameRate, fallbackTickRate float64) (*Match, error) {
+	match := &Match{
+		Halves:           make([]common.Half, 0),
+		HalfStarts:       make([]int, 0),
+		RoundStarts:      make([]int, 0),
+		Rounds:           make([]common.Round, 0),
+		Kills:            make([]common.Kill, 0),
+		FiredShots:       make([]common.Shot, 0),
+		Sounds:           make([]common.Sound, 0),
+		GrenadeThrows:    make([]common.GrenadeThrow, 0),
+		activeThrows:     make(map[int64]int),
+		Hits:             make([]common.Hit, 0),
+		Smokes:           make([]common.Smoke, 0),
+		BombPlants:       make([]common.BombPlant, 0),
+		PlantAttempts:    make([]common.PlantAttempt, 0),
+		Pickups:          make([]common.Pickup, 0),
+		drops:            make(map[int64]common.Pickup),
+		infernoExtents:   make(map[int64][]common.Point),
+		infernoFires:     make(map[int64]int),
+		activeSmokes:     make(map[int]int),
+		currentBombPlant: -1,
+		Pauses:           make([]common.Pause, 0),
+		Gaps:             make([]common.Gap, 0),
+		currentPause:     -1,
+		conVars:          make(map[string]string),
+		observerSlots:    make(map[uint64]int),
+	}
+
+	match.FrameRate = frameRateOrDefault(header.FrameRate(), fallbackFrameRate)
+	match.TickRate = tickRateOrDefault(parser.TickRate(), fallbackTickRate)
+	match.FrameRateRounded = int(math.Round(match.FrameRate))
+	match.Server = newServerInfo(header, match.TickRate)
+	err := match.setMap(normalizeMapName(header.MapName))
+	if err != nil {
+		return nil, err
+	}
+	match.SmokeEffectLifetime = int32(18 * match.FrameRate)
+
+	registerEventHandlers(parser, match)
+	registerRoundHandlers(parser, match)
+	registerHalfHandlers(parser, match)
+	registerConVarHandlers(parser, match)
+	registerSoundHandlers(parser, match)
+	registerThrowHandlers(parser, match)
+
+	return match, nil
+}
+
+func grenadeEventHandler(lifetime int32, frame int, e event.GrenadeEvent, match *Match) {
+	effect := common.GrenadeEffect{
+		Position: common.Point{
+			X: float32(e.Position.X),
+			Y: float32(e.Position.Y),
+		},
+		GrenadeType: e.GrenadeType,
+	}
+	match.grenadeEffects = append(match.grenadeEffects, effect)
+	match.grenadeEffectIndex.add(frame, frame+int(lifetime))
+}
+
+func weaponFireEventHandler(frame int, e event.WeaponFire, match *Match) {
+	if e.Shooter == nil {
+		return
+	}
+	if e.Weapon.Class() == demoinfo.EqClassEquipment ||
+		e.Weapon.Class() == demoinfo.EqClassGrenade ||
+		e.Weapon.Class() == demoinfo.EqClassUnknown {
+		return
+	}
+	isAwpShot := e.Weapon.Type == demoinfo.EqAWP
+	shot := common.Shot{
+		Frame:            frame,
+		ShooterName:      e.Shooter.Name,
+		ShooterSteamID64: e.Shooter.SteamID64,
+		Weapon:           e.Weapon.Type,
+		Position: common.Point{
+			X: float32(e.Shooter.Position().X),
+			Y: float32(e.Shooter.Position().Y),
+		},
+		ViewDirectionX: e.Shooter.ViewDirectionX(),
+		ViewDirectionY: e.Shooter.ViewDirectionY(),
+		IsAwpShot:      isAwpShot,
+	}
+
+	lifetime := int((match.FrameRate + 1) / 32)
+	if lifetime == 0 {
+		lifetime = 1
+	}
+	if isAwpShot {
+		lifetime = int((match.FrameRate + 1) / 8)
+	}
+	match.FiredShots = append(match.FiredShots, shot)
+	match.shotIndex.add(frame, frame+lifetime)
+}
+
+func smokeStartEventHandler(frame int, e event.SmokeStart, match *Match) {
+	smoke := common.Smoke{
+		Position: common.Point{
+			X: float32(e.Position.X),
+			Y: float32(e.Position.Y),
+		},
+		StartFrame:  frame,
+		EndFrame:    frame + int(match.SmokeEffectLifetime),
+		ThrowerName: "World",
+		ThrowerTeam: demoinfo.TeamUnassigned,
+	}
+	if e.Thrower != nil {
+		smoke.ThrowerName = e.Thrower.Name
+		smoke.ThrowerTeam = e.Thrower.Team
+	}
+	match.activeSmokes[e.GrenadeEntityID] = len(match.Smokes)
+	match.Smokes = append(match.Smokes, smoke)
+}
+
+func smokeExpiredEventHandler(frame int, e event.SmokeExpired, match *Match) {
+	index, ok := match.activeSmokes[e.GrenadeEntityID]
+	if !ok {
+		return
+	}
+	match.Smokes[index].EndFrame = frame
+	delete(match.activeSmokes, e.GrenadeEntityID)
+}
+
+func bombPlantedEventHandler(frame int, e event.BombPlanted, match *Match) {
+	plant := common.BombPlant{
+		Frame:  frame,
+		Winner: demoinfo.TeamUnassigned,
+	}
+	if e.Site == event.BombsiteA || e.Site == event.BombsiteB {
+		plant.Site = string(rune(e.Site))
+	}
+	if e.Player != nil {
+		plant.PlanterName = e.Player.Name
+		plant.Position = common.Point{
+			X: float32(e.Player.Position().X),
+			Y: float32(e.Player.Position().Y),
+		}
+		// the site is unknown if the bombsite entity could not be resolved,
+		// then it is classified by the zones of the map
+		if info, ok := mapinfo.Lookup(match.MapName); ok && plant.Site == "" {
+			plant.Site = info.SiteAt(plant.Position)
+		}
+	}
+	match.currentBombPlant = len(match.BombPlants)
+	match.BombPlants = append(match.BombPlants, plant)
+}
+
+func defuseStartEventHandler(frame int, e event.BombDefuseStart, match *Match) {
+	if match.currentBombPlant < 0 {
+		return
+	}
+	defuse := common.Defuse{
+		StartFrame: frame,
+		EndFrame:   -1,
+		HasKit:     e.HasKit,
+		Duration:   defuseTime,
+	}
+	if e.HasKit {
+		defuse.Duration = defuseTimeWithKit
+	}
+	if e.Player != nil {
+		defuse.DefuserName = e.Player.Name
+		defuse.DefuserSteamID64 = e.Player.SteamID64
+	}
+	plant := &match.BombPlants[match.currentBombPlant]
+	plant.Defuses = append(plant.Defuses, defuse)
+}
+
+// endDefuse ends the running defuse of the current bomb plant, if any.
+func (m *Match) endDefuse(frame int) {
+	if m.currentBombPlant < 0 {
+		return
+	}
+	defuses := m.BombPlants[m.currentBombPlant].Defuses
+	if len(defuses) > 0 && defuses[len(defuses)-1].EndFrame == -1 {
+		defuses[len(defuses)-1].EndFrame = frame
+	}
+}
+
+func plantBeginEventHandler(frame int, e event.BombPlantBegin, match *Match) {
+	match.endPlantAttempt(frame, false)
+	attempt := common.PlantAttempt{
+		StartFrame: frame,
+		EndFrame:   -1,
+		Duration:   plantTime,
+	}
+	if e.Site == event.BombsiteA || e.Site == event.BombsiteB {
+		attempt.Site = string(rune(e.Site))
+	}
+	if e.Player != nil {
+		attempt.PlanterName = e.Player.Name
+		attempt.PlanterSteamID64 = e.Player.SteamID64
+	}
+	match.PlantAttempts = append(match.PlantAttempts, attempt)
+}
+
+// endPlantAttempt ends the running plant attempt, if any.
+func (m *Match) endPlantAttempt(frame int, planted bool) {
+	if len(m.PlantAttempts) == 0 {
+		return
+	}
+	attempt := &m.PlantAttempts[len(m.PlantAttempts)-1]
+	if attempt.EndFrame == -1 {
+		attempt.EndFrame = frame
+		attempt.Planted = planted
+	}
+}
+
+// itemDropEventHandler remembers who dropped the weapon, the pickup is only
+// recorded once someone picks it up.
+func itemDropEventHandler(frame int, e event.ItemDrop, match *Match) {
+	if e.Player == nil || e.Weapon == nil || !isWeaponOrGrenade(e.Weapon.Type) {
+		return
+	}
+	match.drops[e.Weapon.UniqueID()] = common.Pickup{
+		Weapon:             e.Weapon.Type,
+		DroppedByName:      e.Player.Name,
+		DroppedBySteamID64: e.Player.SteamID64,
+		DroppedByTeam:      e.Player.Team,
+		DropFrame:          frame,
+	}
+}
+
+func itemPickupEventHandler(frame int, e event.ItemPickup, match *Match) {
+	if e.Player == nil || e.Weapon == nil {
+		return
+	}
+	pickup, ok := match.drops[e.Weapon.UniqueID()]
+	if !ok {
+		return
+	}
+	delete(match.drops, e.Weapon.UniqueID())
+	pickup.Frame = frame
+	pickup.PlayerName = e.Player.Name
+	pickup.PlayerSteamID64 = e.Player.SteamID64
+	pickup.PlayerTeam = e.Player.Team
+	match.Pickups = append(match.Pickups, pickup)
+}
+
+func playerHurtEventHandler(frame int, e event.PlayerHurt, match *Match) {
+	if e.Player == nil || e.Attacker == nil {
+		return
+	}
+	hit := common.Hit{
+		Frame:             frame,
+		AttackerName:      e.Attacker.Name,
+		AttackerSteamID64: e.Attacker.SteamID64,
+		AttackerTeam:      e.Attacker.Team,
+		VictimName:        e.Player.Name,
+		VictimSteamID64:   e.Player.SteamID64,
+		VictimTeam:        e.Player.Team,
+		HealthDamage:      int16(e.HealthDamage),
+		ArmorDamage:       int16(e.ArmorDamage),
+		IsHeadshot:        e.HitGroup == event.HitGroupHead,
+	}
+	if e.Weapon != nil {
+		hit.Weapon = e.Weapon.Type
+	}
+	match.Hits = append(match.Hits, hit)
+}
+
+func killEventHandler(frame int, e event.Kill, match *Match) {
+	kill := common.Kill{
+		Frame:      frame,
+		KillerName: "World",
+		KillerTeam: demoinfo.TeamUnassigned,
+		VictimName: "World",
+		VictimTeam: demoinfo.TeamUnassigned,
+	}
+	if e.Weapon != nil {
+		kill.Weapon = e.Weapon.Type
+	}
+	if e.Killer != nil {
+		kill.KillerName = e.Killer.Name
+		kill.KillerSteamID64 = e.Killer.SteamID64
+		kill.KillerID = playerID(e.Killer)
+		kill.KillerTeam = e.Killer.Team
+		kill.KillerPosition = common.Point{
+			X: float32(e.Killer.Position().X),
+			Y: float32(e.Killer.Position().Y),
+		}
+		kill.KillerViewDirectionX = e.Killer.ViewDirectionX()
+		kill.KillerViewDirectionY = e.Killer.ViewDirectionY()
+		kill.KillerPositionZ = float32(e.Killer.Position().Z)
+		kill.KillerBlind = e.Killer.IsBlinded()
+		kill.IsNoScope = common.IsSniperRifle(kill.Weapon) && !e.Killer.IsScoped()
+	}
+	if e.Victim != nil {
+		kill.VictimName = e.Victim.Name
+		kill.VictimSteamID64 = e.Victim.SteamID64
+		kill.VictimTeam = e.Victim.Team
+		kill.VictimPosition = common.Point{
+			X: float32(e.Victim.Position().X),
+			Y: float32(e.Victim.Position().Y),
+		}
+		kill.VictimViewDirectionX = e.Victim.ViewDirectionX()
+		kill.VictimViewDirectionY = e.Victim.ViewDirectionY()
+		kill.VictimPositionZ = float32(e.Victim.Position().Z)
+	}
+	if e.Killer == nil {
+		kill.KillerPosition = kill.VictimPosition
+		kill.KillerPositionZ = kill.VictimPositionZ
+	}
+	kill.Type = killType(e)
+	match.Kills = append(match.Kills, kill)
+	match.killfeedIndex.add(frame, frame+match.FrameRateRounded*killfeedLifetime)
+}
+
+// killType tells team kills, suicides and deaths without a killer apart
+// from kills of enemies.
+func killType(e event.Kill) common.KillType {
+	switch {
+	case e.Killer == nil && e.Weapon != nil && e.Weapon.Type == demoinfo.EqWorld:
+		return common.KillFallDamage
+	case e.Killer == nil:
+		return common.KillWorld
+	case e.Victim == nil:
+		return common.KillEnemy
+	case e.Killer == e.Victim:
+		return common.KillSuicide
+	case e.Killer.Team == e.Victim.Team:
+		return common.KillTeam
+	default:
+		return common.KillEnemy
+	}
+}
+
+func registerPauseHandlers(parser dem.Parser, match *Match) {
+	gameRules := parser.ServerClasses().FindByName("CCSGameRulesProxy")
+	if gameRules == nil {
+		return
+	}
+	gameRules.OnEntityCreated(func(entity st.Entity) {
+		properties := map[string]common.PauseKind{
+			propMatchWaitingForResume: common.PauseTechnical,
+			propTerroristTimeOut:      common.PauseTimeoutTerrorists,
+			propCTTimeOut:             common.PauseTimeoutCounterTerrorists,
+		}
+		for name, kind := range properties {
+			kind := kind
+			property := entity.Property(name)
+			if property == nil {
+				continue
+			}
+			property.OnUpdate(func(val st.PropertyValue) {
+				if val.BoolVal() {
+					pauseStarted(parser.CurrentFrame(), kind, match)
+				} else {
+					pauseEnded(parser.CurrentFrame(), kind, match)
+				}
+			})
+		}
+	})
+}
+
+func pauseStarted(frame int, kind common.PauseKind, match *Match) {
+	if match.currentPause >= 0 {
+		return
+	}
+	match.currentPause = len(match.Pauses)
+	match.Pauses = append(match.Pauses, common.Pause{
+		StartFrame: frame,
+		EndFrame:   -1,
+		Kind:       kind,
+	})
+}
+
+func pauseEnded(frame int, kind common.PauseKind, match *Match) {
+	if match.currentPause < 0 || match.Pauses[match.currentPause].Kind != kind {
+		return
+	}
+	match.Pauses[match.currentPause].EndFrame = frame
+	match.currentPause = -1
+}
+
+func registerEventHandlers(parser dem.Parser, match *Match) {
+	parser.RegisterEventHandler(func(event.DataTablesParsed) {
+		registerPauseHandlers(parser, match)
+	})
+	parser.RegisterEventHandler(func(event.RoundStart) {
+		match.RoundStarts = append(match.RoundStarts, parser.CurrentFrame())
+		match.changePhase(parser, common.PhaseFreezetime)
+		match.endPlantAttempt(parser.CurrentFrame(), false)
+		match.currentBombPlant = -1
+		// weapons on the ground are removed when the round restarts
+		match.drops = make(map[int64]common.Pickup)
+		// effects of the previous round are visible until the round starts
+		match.grenadeEffectIndex.truncate(parser.CurrentFrame() + 1)
+	})
+	parser.RegisterEventHandler(func(event.MatchStart) {
+		match.HalfStarts = append(match.HalfStarts, parser.CurrentFrame())
+	})
+	parser.RegisterEventHandler(func(event.GameHalfEnded) {
+		match.HalfStarts = append(match.HalfStarts, parser.CurrentFrame())
+	})
+	parser.RegisterEventHandler(func(e event.WeaponFire) {
+		frame := parser.CurrentFrame()
+		weaponFireEventHandler(frame, e, match)
+	})
+	parser.RegisterEventHandler(func(e event.FlashExplode) {
+		frame := parser.CurrentFrame()
+		grenadeEventHandler(flashEffectLifetime, frame, e.GrenadeEvent, match)
+	})
+	parser.RegisterEventHandler(func(e event.HeExplode) {
+		frame := parser.CurrentFrame()
+		grenadeEventHandler(heEffectLifetime, frame, e.GrenadeEvent, match)
+	})
+	parser.RegisterEventHandler(func(e event.SmokeStart) {
+		frame := parser.CurrentFrame()
+		grenadeEventHandler(match.SmokeEffectLifetime, frame, e.GrenadeEvent, match)
+		smokeStartEventHandler(frame, e, match)
+	})
+	parser.RegisterEventHandler(func(e event.SmokeExpired) {
+		frame := parser.CurrentFrame()
+		smokeExpiredEventHandler(frame, e, match)
+	})
+	parser.RegisterEventHandler(func(e event.PlayerHurt) {
+		frame := parser.CurrentFrame()
+		playerHurtEventHandler(frame, e, match)
+	})
+	parser.RegisterEventHandler(func(e event.Kill) {
+		frame := parser.CurrentFrame()
+		killEventHandler(frame, e, match)
+	})
+	parser.RegisterEventHandler(func(e event.ItemDrop) {
+		itemDropEventHandler(parser.CurrentFrame(), e, match)
+	})
+	parser.RegisterEventHandler(func(e event.ItemPickup) {
+		itemPickupEventHandler(parser.CurrentFrame(), e, match)
+	})
+	parser.RegisterEventHandler(func(e event.RoundFreezetimeEnd) {
+		match.changePhase(parser, common.PhaseRegular)
+	})
+	parser.RegisterEventHandler(func(e event.BombPlantBegin) {
+		plantBeginEventHandler(parser.CurrentFrame(), e, match)
+	})
+	parser.RegisterEventHandler(func(e event.BombPlantAborted) {
+		match.endPlantAttempt(parser.CurrentFrame(), false)
+	})
+	parser.RegisterEventHandler(func(e event.BombPlanted) {
+		match.changePhase(parser, common.PhasePlanted)
+		match.endPlantAttempt(parser.CurrentFrame(), true)
+		bombPlantedEventHandler(parser.CurrentFrame(), e, match)
+	})
+	parser.RegisterEventHandler(func(e event.BombDefuseStart) {
+		defuseStartEventHandler(parser.CurrentFrame(), e, match)
+	})
+	parser.RegisterEventHandler(func(e event.BombDefuseAborted) {
+		match.endDefuse(parser.CurrentFrame())
+	})
+	parser.RegisterEventHandler(func(e event.BombDefused) {
+		match.endDefuse(parser.CurrentFrame())
+		if match.currentBombPlant >= 0 {
+			match.BombPlants[match.currentBombPlant].Defused = true
+		}
+	})
+	parser.RegisterEventHandler(func(e event.BombExplode) {
+		if match.currentBombPlant >= 0 {
+			match.BombPlants[match.currentBombPlant].Exploded = true
+		}
+	})
+	parser.RegisterEventHandler(func(e event.RoundEnd) {
+		match.changePhase(parser, common.PhaseRestart)
+		match.endDefuse(parser.CurrentFrame())
+		match.endPlantAttempt(parser.CurrentFrame(), false)
+		if match.currentBombPlant >= 0 {
+			match.BombPlants[match.currentBombPlant].Winner = e.Winner
+			match.currentBombPlant = -1
+		}
+	})
+	parser.RegisterEventHandler(func(e event.GameHalfEnded) {
+		match.changePhase(parser, common.PhaseHalftime)
+	})
+	parser.RegisterEventHandler(func(e event.IsWarmupPeriodChanged) {
+		if e.NewIsWarmupPeriod {
+			match.changePhase(parser, common.PhaseWarmup)
+		} else {
+			match.changePhase(parser, common.PhaseRestart)
+		}
+	})
+	parser.RegisterEventHandler(func(event.AnnouncementWinPanelMatch) {
+		match.HalfStarts = append(match.HalfStarts, parser.CurrentFrame())
+	})
+}
+
+// parse demo and save GameStates in slice
+func parseGameStates(ctx context.Context, parser dem.Parser, match *Match) ([]common.OverviewState, error) {
+	playbackFrames := parser.Header().PlaybackFrames
+	states := make([]common.OverviewState, 0, playbackFrames)
+
+	for ok, err := parser.ParseNextFrame(); ok; ok, err = parser.ParseNextFrame() {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			logFrameError(parser, err)
+			// return here or not?
+			continue
+		}
+
+		match.advanceDemoTime(parser)
+		states = append(states, parseGameState(parser, match))
+	}
+
+	return states, nil
+}
+
+// advanceDemoTime records the demo time of the current frame. It has to be
+// called once for every frame.
+func (m *Match) advanceDemoTime(parser dem.Parser) {
+	if parser.CurrentTime() > m.demoTime {
+		m.demoTime = parser.CurrentTime()
+	}
+	m.detectGap(parser)
+	m.frameTimes = append(m.frameTimes, m.demoTime)
+	m.frameNumbers = append(m.frameNumbers, parser.CurrentFrame())
+}
+
+// addInventoryItem adds the weapon w to the inventory. Grenades of the same
+// type are counted in one item, the reserve of a grenade holds the
+// additional grenades of its type, e.g. the second flashbang.
+func addInventoryItem(inventory []common.InventoryItem, w *demoinfo.Equipment) []common.InventoryItem {
+	count := 1
+	var ammo int
+	if w.Class() == demoinfo.EqClassGrenade {
+		count += w.AmmoReserve()
+	} else {
+		ammo = w.AmmoInMagazine()
+	}
+	for i := range inventory {
+		if inventory[i].Type == w.Type {
+			inventory[i].Count += count
+			return inventory
+		}
+	}
+	return append(inventory, common.InventoryItem{
+		Type:  w.Type,
+		Count: count,
+		Ammo:  ammo,
+	})
+}
+
+// playerHUD collects what the infobar shows about the player. The inventory
+// is sorted by type, so the grenades are at its end and Grenades can share
+// its array.
+func playerHUD(player common.Player) common.HUD {
+	hud := common.HUD{
+		Health:       player.Health,
+		HasDefuseKit: player.HasDefuseKit,
+		HasBomb:      player.HasBomb,
+		Money:        player.Money,
+	}
+	if player.Armor > 0 && player.HasHelmet {
+		hud.Armor = common.ArmorKevlarHelmet
+	} else if player.Armor > 0 {
+		hud.Armor = common.ArmorKevlar
+	}
+	for i, item := range player.Inventory {
+		switch item.Type.Class() {
+		case demoinfo.EqClassSMG, demoinfo.EqClassHeavy, demoinfo.EqClassRifle:
+			hud.Primary = item.Type
+		case demoinfo.EqClassPistols:
+			hud.Secondary = item.Type
+		case demoinfo.EqClassGrenade:
+			if hud.Grenades == nil {
+				hud.Grenades = player.Inventory[i:]
+			}
+		}
+	}
+	return hud
+}
+
+// parseGameState collects the state of the game at the current frame.
+func parseGameState(parser dem.Parser, match *Match) common.OverviewState {
+	gameState := parser.GameState()
+
+	players := make([]common.Player, 0, 10)
+
+	playing := gameState.Participants().Playing()
+	match.assignObserverSlots(playing)
+	for _, p := range playing {
+		var hasBomb bool
+		inventory := make([]common.InventoryItem, 0)
+		for _, w := range p.Weapons() {
+			if w.Type == demoinfo.EqBomb {
+				hasBomb = true
+			}
+			if isWeaponOrGrenade(w.Type) {
+				inventory = addInventoryItem(inventory, w)
+			}
+		}
+		sort.Slice(inventory, func(i, j int) bool { return inventory[i].Type < inventory[j].Type })
+		activeWeapon := demoinfo.EqUnknown
+		if weapon := p.ActiveWeapon(); weapon != nil {
+			activeWeapon = weapon.Type
+		}
+		player := common.Player{
+			ID:        playerID(p),
+			Name:      p.Name,
+			SteamID64: p.SteamID64,
+			Team:      p.Team,
+			Position: common.Point{
+				X: float32(p.Position().X),
+				Y: float32(p.Position().Y),
+			},
+			PositionZ: float32(p.Position().Z),
+			LastAlivePosition: common.Point{
+				X: float32(p.LastAlivePosition.X),
+				Y: float32(p.LastAlivePosition.Y),
+			},
+			ViewDirectionX:     p.ViewDirectionX(),
+			ViewDirectionY:     p.ViewDirectionY(),
+			FlashDuration:      p.FlashDurationTime(),
+			FlashTimeRemaining: p.FlashDurationTimeRemaining(),
+			Inventory:          inventory,
+			ActiveWeapon:       activeWeapon,
+			Health:             int16(p.Health()),
+			Armor:              int16(p.Armor()),
+			Money:              int16(p.Money()),
+			Kills:              int16(p.Kills()),
+			Deaths:             int16(p.Deaths()),
+			Assists:            int16(p.Assists()),
+			IsAlive:            p.IsAlive(),
+			IsDefusing:         p.IsDefusing,
+			HasHelmet:          p.HasHelmet(),
+			HasDefuseKit:       p.HasDefuseKit(),
+			HasBomb:            hasBomb,
+			ObserverSlot:       match.observerSlots[playerID(p)],
+		}
+		player.HUD = playerHUD(player)
+		players = append(players, player)
+	}
+
+	grenades := make([]common.GrenadeProjectile, 0)
+
+	for _, grenade := range gameState.GrenadeProjectiles() {
+		g := common.GrenadeProjectile{
+			ID: grenade.UniqueID(),
+			Position: common.Point{
+				X: float32(grenade.Position().X),
+				Y: float32(grenade.Position().Y),
+			},
+			PositionZ: float32(grenade.Position().Z),
+			Type:      grenade.WeaponInstance.Type,
+		}
+		if grenade.Thrower != nil {
+			g.ThrowerName = grenade.Thrower.Name
+			g.ThrowerTeam = grenade.Thrower.Team
+		}
+		grenades = append(grenades, g)
+	}
+
+	infernos := make([]common.Inferno, 0)
+	for _, inferno := range gameState.Infernos() {
+		r2Points := inferno.Fires().Active().ConvexHull2D()
+		commonPoints := make([]common.Point, 0)
+		for _, point := range r2Points {
+			commonPoint := common.Point{
+				X: float32(point.X),
+				Y: float32(point.Y),
+			}
+			commonPoints = append(commonPoints, commonPoint)
+		}
+		i := common.Inferno{
+			ID:           inferno.UniqueID(),
+			ConvexHull2D: commonPoints,
+		}
+		infernos = append(infernos, i)
+		match.updateInfernoExtent(inferno)
+	}
+
+	droppedWeapons := make([]common.DroppedWeapon, 0)
+	for _, weapon := range gameState.Weapons() {
+		if weapon.Owner != nil || weapon.Entity == nil || !isWeaponOrGrenade(weapon.Type) {
+			continue
+		}
+		droppedWeapons = append(droppedWeapons, common.DroppedWeapon{
+			ID:   weapon.UniqueID(),
+			Type: weapon.Type,
+			Position: common.Point{
+				X: float32(weapon.Entity.Position().X),
+				Y: float32(weapon.Entity.Position().Y),
+			},
+		})
+	}
+	sort.Slice(droppedWeapons, func(i, j int) bool { return droppedWeapons[i].ID < droppedWeapons[j].ID })
+
+	var isBeingCarried bool
+	if gameState.Bomb().Carrier != nil {
+		isBeingCarried = true
+	} else {
+		isBeingCarried = false
+	}
+	bomb := common.Bomb{
+		Position: common.Point{
+			X: float32(gameState.Bomb().Position().X),
+			Y: float32(gameState.Bomb().Position().Y),
+		},
+		IsBeingCarried: isBeingCarried,
+	}
+
+	cts := common.TeamState{
+		ClanName: gameState.TeamCounterTerrorists().ClanName(),
+		Score:    byte(gameState.TeamCounterTerrorists().Score()),
+	}
+	ts := common.TeamState{
+		ClanName: gameState.TeamTerrorists().ClanName(),
+		Score:    byte(gameState.TeamTerrorists().Score()),
+	}
+
+	state := common.OverviewState{
+		Frame:                 parser.CurrentFrame(),
+		IngameTick:            parser.GameState().IngameTick(),
+		Time:                  match.demoTime,
+		Players:               players,
+		Grenades:              grenades,
+		Infernos:              infernos,
+		DroppedWeapons:        droppedWeapons,
+		Bomb:                  bomb,
+		TeamCounterTerrorists: cts,
+		TeamTerrorists:        ts,
+	}
+
+	return state
+}
+
+// updateInfernoExtent extends the extent of the inferno by its new fires.
+// Extinguished fires stay in the list of fires, so the hull of all fires
+// only has to be computed again when the number of fires grew.
+func (m *Match) updateInfernoExtent(inferno *demoinfo.Inferno) {
+	id := inferno.UniqueID()
+	// older demos can lack the property, the extent is not updated then
+	fireCountProp, ok := inferno.Entity.PropertyValue("m_fireCount")
+	if !ok {
+		return
+	}
+	fireCount := fireCountProp.IntVal
+	if fireCount == m.infernoFires[id] {
+		return
+	}
+	m.infernoFires[id] = fireCount
+	hull := inferno.Fires().ConvexHull2D()
+	extent := make([]common.Point, 0, len(hull))
+	for _, point := range hull {
+		extent = append(extent, common.Point{X: float32(point.X), Y: float32(point.Y)})
+	}
+	m.infernoExtents[id] = extent
+}
+
+func isWeaponOrGrenade(e demoinfo.EquipmentType) bool {
+	return e.Class() == demoinfo.EqClassSMG ||
+		e.Class() == demoinfo.EqClassHeavy ||
+		e.Class() == demoinfo.EqClassRifle ||
+		e.Class() == demoinfo.EqClassPistols ||
+		e.Class() == demoinfo.EqClassGrenade
+
+}
+
+// EffectsAt returns the grenade effects that are visible at the given frame.
+// The Lifetime of an effect is the number of frames since it started.
+func (m Match) EffectsAt(frame int) []common.GrenadeEffect {
+	var effects []common.GrenadeEffect
+	m.grenadeEffectIndex.at(frame, func(i, start int) {
+		effect := m.grenadeEffects[i]
+		effect.Lifetime = int32(frame - start)
+		effects = append(effects, effect)
+	})
+	return effects
+}
+
+// ShotsAt returns the shots that are visible at the given frame.
+func (m Match) ShotsAt(frame int) []common.Shot {
+	var shots []common.Shot
+	m.shotIndex.at(frame, func(i, start int) {
+		shots = append(shots, m.FiredShots[i])
+	})
+	return shots
+}
+
+// KillfeedAt returns the kills that are shown in the killfeed at the given
+// frame, oldest first.
+func (m Match) KillfeedAt(frame int) []common.Kill {
+	var kills []common.Kill
+	m.killfeedIndex.at(frame, func(i, start int) {
+		kills = append(kills, m.Kills[i])
+	})
+	if len(kills) > maxKillfeedLength {
+		kills = kills[len(kills)-maxKillfeedLength:]
+	}
+	return kills
+}
+
+// DeathsAt returns the kills of the round of the given frame up to the frame,
+// i.e. where the players died that are dead at that frame.
+func (m Match) DeathsAt(frame int) []common.Kill {
+	start := 0
+	if i := m.RoundIndex(frame); i >= 0 {
+		start = m.RoundStarts[i]
+	}
+	first := sort.Search(len(m.Kills), func(i int) bool { return m.Kills[i].Frame >= start })
+	var deaths []common.Kill
+	for _, kill := range m.Kills[first:] {
+		if kill.Frame > frame {
+			break
+		}
+		deaths = append(deaths, kill)
+	}
+	return deaths
+}
+
+// TotalFrames returns the number of frames of the demo, i.e. all frames lie
+// between 0 and TotalFrames()-1. Unlike LastFrame it is also known for
+// matches from ParseEvents.
+func (m Match) TotalFrames() int {
+	if len(m.frameNumbers) == 0 {
+		return 0
+	}
+	return m.frameNumbers[len(m.frameNumbers)-1] + 1
+}
+
+// Duration returns the demo time of the last frame.
+func (m Match) Duration() time.Duration {
+	if len(m.frameTimes) == 0 {
+		return 0
+	}
+	return m.frameTimes[len(m.frameTimes)-1]
+}
+
+// TimeAt returns the demo time of the given frame. Frames outside of the demo
+// are clamped to the first or last frame.
+func (m Match) TimeAt(frame int) time.Duration {
+	if len(m.frameTimes) == 0 {
+		return 0
+	}
+	return m.frameTimes[frameIndex(m.frameNumbers, frame)]
+}
+
+// RoundIndex returns the index into RoundStarts of the round that is being
+// played at the given frame or -1 if the frame lies before the first round.
+func (m Match) RoundIndex(frame int) int {
+	return sort.Search(len(m.RoundStarts), func(i int) bool { return m.RoundStarts[i] > frame }) - 1
+}
+
+// Players returns the last known state of every player of the match,
+// including bots, sorted by ID, so human players come first in the order of
+// their SteamIDs.
+func (m Match) Players() []common.Player {
+	return append([]common.Player(nil), m.players...)
+}
+
+// collectPlayers returns the last known state of every player in the states
+// or, if the states are not stored, in the final state.
+func (m Match) collectPlayers() []common.Player {
+	latest := make(map[uint64]common.Player)
+	for _, p := range m.finalState.Players {
+		latest[p.ID] = p
+	}
+	for _, state := range m.States {
+		for _, p := range state.Players {
+			latest[p.ID] = p
+		}
+	}
+	players := make([]common.Player, 0, len(latest))
+	for _, p := range latest {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].ID < players[j].ID })
+	return players
+}
+
+// Translate translates in-game world-relative coordinates to (0, 0) relative coordinates.
+func (m Match) Translate(x, y float32) (float32, float32) {
+	return x - m.MapPZero.X, m.MapPZero.Y - y
+}
+
+// TranslateScale translates and scales in-game world-relative coordinates to (0, 0) relative coordinates.
+// The coordinates are rotated and mirrored like the overview image.
+func (m Match) TranslateScale(x, y float32) (float32, float32) {
+	x, y = m.Translate(x, y)
+	return m.MapTransform.Apply(x/m.MapScale, y/m.MapScale)
+}
+
+// WorldPosition reverses TranslateScale, it returns the in-game position of
+// a position on the overview image.
+func (m Match) WorldPosition(x, y float32) (float32, float32) {
+	x, y = m.MapTransform.Invert(x, y)
+	return x*m.MapScale + m.MapPZero.X, m.MapPZero.Y - y*m.MapScale
+}
+
+// ScreenAngle converts the yaw of a player in degrees to the angle of the
+// view direction on the overview image, clockwise from the x axis.
+func (m Match) ScreenAngle(yaw float32) float32 {
+	// negated because the y axis of the image points down
+	return m.MapTransform.ApplyAngle(-yaw)
+}
@@ -0,0 +1,99 @@
+package match
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// fixtures are the demos of the regression harness, see testdata/demos.
+const fixtures = "../../testdata/demos/*.dem"
+
+func BenchmarkNewMatch(b *testing.B) {
+	benchmarkParse(b, NewMatch)
+}
+
+func BenchmarkParseEvents(b *testing.B) {
+	benchmarkParse(b, ParseEvents)
+}
+
+func benchmarkParse(b *testing.B, parse func(string, float64, float64) (*Match, error)) {
+	demos, err := filepath.Glob(fixtures)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(demos) == 0 {
+		b.Skip("no demo fixtures found")
+	}
+	for _, demo := range demos {
+		b.Run(filepath.Base(demo), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := parse(demo, -1, -1)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamMatchesNewMatch(t *testing.T) {
+	demos, err := filepath.Glob(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(demos) == 0 {
+		t.Skip("no demo fixtures found")
+	}
+	for _, demo := range demos {
+		t.Run(filepath.Base(demo), func(t *testing.T) {
+			parsed, err := NewMatch(demo, -1, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := OpenDemo(demo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			var frames []int
+			streamed, err := Stream(r, -1, -1, func(m *Match, frame int, state common.OverviewState) {
+				frames = append(frames, state.Frame)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(frames) != len(parsed.States) {
+				t.Fatalf("Stream() handled %d frames, NewMatch() parsed %d states", len(frames), len(parsed.States))
+			}
+			for i, state := range parsed.States {
+				if frames[i] != state.Frame {
+					t.Fatalf("frame %d of Stream() = %d, want %d", i, frames[i], state.Frame)
+				}
+			}
+			if !reflect.DeepEqual(streamed.Kills, parsed.Kills) {
+				t.Errorf("Stream() kills = %+v, want %+v", streamed.Kills, parsed.Kills)
+			}
+			// knife rounds are only detected by NewMatch, so only the number
+			// of rounds is compared
+			if len(streamed.Rounds) != len(parsed.Rounds) {
+				t.Errorf("Stream() found %d rounds, want %d", len(streamed.Rounds), len(parsed.Rounds))
+			}
+			if got, want := playerIDs(streamed.Players()), playerIDs(parsed.Players()); !reflect.DeepEqual(got, want) {
+				t.Errorf("Stream() players = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func playerIDs(players []common.Player) []uint64 {
+	ids := make([]uint64, 0, len(players))
+	for _, p := range players {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
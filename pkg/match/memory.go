@@ -0,0 +1,44 @@
+package match
+
+import (
+	"time"
+	"unsafe"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// EstimatedSize returns roughly the number of bytes the match occupies in
+// memory. Only the states and the events are counted, which make up nearly
+// all of it; strings and maps are left out.
+func (m Match) EstimatedSize() int64 {
+	size := int64(unsafe.Sizeof(m))
+	size += int64(cap(m.States)) * int64(unsafe.Sizeof(common.OverviewState{}))
+	for _, state := range m.States {
+		size += int64(cap(state.Players)) * int64(unsafe.Sizeof(common.Player{}))
+		for _, player := range state.Players {
+			size += int64(cap(player.Inventory)) * int64(unsafe.Sizeof(common.InventoryItem{}))
+		}
+		size += int64(cap(state.Grenades)) * int64(unsafe.Sizeof(common.GrenadeProjectile{}))
+		size += int64(cap(state.Infernos)) * int64(unsafe.Sizeof(common.Inferno{}))
+		for _, inferno := range state.Infernos {
+			size += int64(cap(inferno.ConvexHull2D)) * int64(unsafe.Sizeof(common.Point{}))
+		}
+		size += int64(cap(state.DroppedWeapons)) * int64(unsafe.Sizeof(common.DroppedWeapon{}))
+	}
+	size += int64(cap(m.Kills)) * int64(unsafe.Sizeof(common.Kill{}))
+	size += int64(cap(m.FiredShots)) * int64(unsafe.Sizeof(common.Shot{}))
+	size += int64(cap(m.Sounds)) * int64(unsafe.Sizeof(common.Sound{}))
+	size += int64(cap(m.GrenadeThrows)) * int64(unsafe.Sizeof(common.GrenadeThrow{}))
+	size += int64(cap(m.Hits)) * int64(unsafe.Sizeof(common.Hit{}))
+	size += int64(cap(m.Smokes)) * int64(unsafe.Sizeof(common.Smoke{}))
+	size += int64(cap(m.grenadeEffects)) * int64(unsafe.Sizeof(common.GrenadeEffect{}))
+	size += int64(cap(m.frameTimes)) * int64(unsafe.Sizeof(time.Duration(0)))
+	size += int64(cap(m.frameNumbers)) * int64(unsafe.Sizeof(0))
+	size += int64(cap(m.quantizedPlayers)) * int64(unsafe.Sizeof(quantizedPlayer{}))
+	size += int64(cap(m.quantizedStarts)) * int64(unsafe.Sizeof(int32(0)))
+	size += int64(cap(m.playerDetails)) * int64(unsafe.Sizeof(common.Player{}))
+	for _, player := range m.playerDetails {
+		size += int64(cap(player.Inventory)) * int64(unsafe.Sizeof(common.InventoryItem{}))
+	}
+	return size
+}
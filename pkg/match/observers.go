@@ -0,0 +1,70 @@
+package match
+
+import (
+	"sort"
+
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// noObserverSlot is the observer slot of players that did not get one, e.g.
+// substitutes that joined after all slots were taken.
+const noObserverSlot int = -1
+
+// observerSlotOrder contains the observer slots in the order in which they
+// are assigned. Like in the game, the team that starts as counter-terrorists
+// gets the keys 1 to 5 and the other team the keys 6 to 0.
+var observerSlotOrder = map[demoinfo.Team][]int{
+	demoinfo.TeamCounterTerrorists: {1, 2, 3, 4, 5},
+	demoinfo.TeamTerrorists:        {6, 7, 8, 9, 0},
+}
+
+// playerID identifies a player across reconnects, see common.Player.ID. Bots
+// have no SteamID and are told apart by their user ID.
+func playerID(p *demoinfo.Player) uint64 {
+	if p.IsBot {
+		return uint64(p.UserID) | 1<<63
+	}
+	return p.SteamID64
+}
+
+// assignObserverSlots gives every playing player that has no observer slot
+// yet the next free slot of the side the player is first seen on. The demo
+// does not contain the slots, so they are assigned in the order of the entity
+// IDs, which matches the order in which the players connected. Slots are kept
+// for the rest of the match, also after the teams switched sides.
+func (m *Match) assignObserverSlots(players []*demoinfo.Player) {
+	var newPlayers []*demoinfo.Player
+	for _, p := range players {
+		if _, ok := m.observerSlots[playerID(p)]; !ok {
+			newPlayers = append(newPlayers, p)
+		}
+	}
+	if len(newPlayers) == 0 {
+		return
+	}
+	sort.Slice(newPlayers, func(i, j int) bool { return newPlayers[i].EntityID < newPlayers[j].EntityID })
+
+	taken := make(map[int]bool, len(m.observerSlots))
+	for _, slot := range m.observerSlots {
+		taken[slot] = true
+	}
+	for _, p := range newPlayers {
+		slot := noObserverSlot
+		order := append([]int{}, observerSlotOrder[p.Team]...)
+		if p.Team == demoinfo.TeamTerrorists {
+			order = append(order, observerSlotOrder[demoinfo.TeamCounterTerrorists]...)
+		} else {
+			order = append(order, observerSlotOrder[demoinfo.TeamTerrorists]...)
+		}
+		for _, candidate := range order {
+			if !taken[candidate] {
+				slot = candidate
+				break
+			}
+		}
+		if slot != noObserverSlot {
+			taken[slot] = true
+		}
+		m.observerSlots[playerID(p)] = slot
+	}
+}
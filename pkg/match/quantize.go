@@ -0,0 +1,145 @@
+package match
+
+import (
+	"math"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// angleScale is the number of steps per degree in which quantized view
+// directions are stored.
+const angleScale = 64
+
+// quantizedPlayer is a player of a state with the positions rounded to whole
+// units of the world, which is still several times more precise than a
+// pixel of the overview. Everything that rarely changes from one frame to
+// the next, e.g. the inventory, is stored once in Match.playerDetails.
+type quantizedPlayer struct {
+	details                int32
+	x, y, z                int16
+	lastAliveX, lastAliveY int16
+	viewX, viewY           int16
+	// flashRemaining is in milliseconds.
+	flashRemaining uint16
+}
+
+// Quantize stores the players of the states in a compact form that takes up
+// a fraction of the memory, which is useful for long demos and for matches
+// that are kept in a cache. Positions are rounded to whole units and view
+// directions to 1/64 degree. StateAtFrame rebuilds the players of a state on
+// every call, which is a bit slower. Like SmoothGaps, Quantize modifies the
+// match, so it panics if the match is frozen; SmoothGaps has no effect
+// afterwards.
+func (m *Match) Quantize() {
+	m.mustNotBeFrozen("Quantize")
+	if m.quantizedStarts != nil {
+		return
+	}
+	starts := make([]int32, 0, len(m.States)+1)
+	players := make([]quantizedPlayer, 0, 10*len(m.States))
+	details := make([]common.Player, 0)
+	latest := make(map[uint64]int32)
+	for i := range m.States {
+		starts = append(starts, int32(len(players)))
+		for _, p := range m.States[i].Players {
+			q := quantizedPlayer{
+				x:              quantizeCoordinate(p.Position.X),
+				y:              quantizeCoordinate(p.Position.Y),
+				z:              quantizeCoordinate(p.PositionZ),
+				lastAliveX:     quantizeCoordinate(p.LastAlivePosition.X),
+				lastAliveY:     quantizeCoordinate(p.LastAlivePosition.Y),
+				viewX:          quantizeCoordinate(p.ViewDirectionX * angleScale),
+				viewY:          quantizeCoordinate(p.ViewDirectionY * angleScale),
+				flashRemaining: uint16(math.Min(float64(p.FlashTimeRemaining/time.Millisecond), math.MaxUint16)),
+			}
+			p.Position, p.PositionZ, p.LastAlivePosition = common.Point{}, 0, common.Point{}
+			p.ViewDirectionX, p.ViewDirectionY, p.FlashTimeRemaining = 0, 0, 0
+			index, ok := latest[p.ID]
+			if !ok || !sameDetails(details[index], p) {
+				index = int32(len(details))
+				details = append(details, p)
+				latest[p.ID] = index
+			}
+			q.details = index
+			players = append(players, q)
+		}
+		m.States[i].Players = nil
+	}
+	starts = append(starts, int32(len(players)))
+	m.quantizedStarts, m.quantizedPlayers, m.playerDetails = starts, players, details
+}
+
+// sameDetails reports whether the players have the same details, i.e. the
+// same values in all fields that are not quantized. It has to be extended
+// when fields are added to common.Player.
+func sameDetails(a, b common.Player) bool {
+	return a.ID == b.ID &&
+		a.Name == b.Name &&
+		a.SteamID64 == b.SteamID64 &&
+		a.Team == b.Team &&
+		a.FlashDuration == b.FlashDuration &&
+		sameItems(a.Inventory, b.Inventory) &&
+		a.Health == b.Health &&
+		a.Armor == b.Armor &&
+		a.Money == b.Money &&
+		a.Kills == b.Kills &&
+		a.Deaths == b.Deaths &&
+		a.Assists == b.Assists &&
+		a.IsAlive == b.IsAlive &&
+		a.IsDefusing == b.IsDefusing &&
+		a.HasHelmet == b.HasHelmet &&
+		a.HasDefuseKit == b.HasDefuseKit &&
+		a.HasBomb == b.HasBomb &&
+		a.HUD.Health == b.HUD.Health &&
+		a.HUD.Armor == b.HUD.Armor &&
+		a.HUD.HasDefuseKit == b.HUD.HasDefuseKit &&
+		a.HUD.HasBomb == b.HUD.HasBomb &&
+		a.HUD.Money == b.HUD.Money &&
+		a.HUD.Primary == b.HUD.Primary &&
+		a.HUD.Secondary == b.HUD.Secondary &&
+		sameItems(a.HUD.Grenades, b.HUD.Grenades) &&
+		a.ActiveWeapon == b.ActiveWeapon &&
+		a.ObserverSlot == b.ObserverSlot
+}
+
+func sameItems(a, b []common.InventoryItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// statePlayers returns the players of the state with the given index.
+func (m Match) statePlayers(i int) []common.Player {
+	if m.quantizedStarts == nil {
+		return m.States[i].Players
+	}
+	quantized := m.quantizedPlayers[m.quantizedStarts[i]:m.quantizedStarts[i+1]]
+	players := make([]common.Player, len(quantized))
+	for j, q := range quantized {
+		players[j] = m.player(q)
+	}
+	return players
+}
+
+// player returns the quantized player with its details.
+func (m Match) player(q quantizedPlayer) common.Player {
+	p := m.playerDetails[q.details]
+	p.Position = common.Point{X: float32(q.x), Y: float32(q.y)}
+	p.PositionZ = float32(q.z)
+	p.LastAlivePosition = common.Point{X: float32(q.lastAliveX), Y: float32(q.lastAliveY)}
+	p.ViewDirectionX = float32(q.viewX) / angleScale
+	p.ViewDirectionY = float32(q.viewY) / angleScale
+	p.FlashTimeRemaining = time.Duration(q.flashRemaining) * time.Millisecond
+	return p
+}
+
+func quantizeCoordinate(value float32) int16 {
+	return int16(math.Max(math.Min(math.Round(float64(value)), math.MaxInt16), math.MinInt16))
+}
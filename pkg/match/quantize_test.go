@@ -0,0 +1,92 @@
+package match
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+func TestQuantizeRoundTrip(t *testing.T) {
+	alice := common.Player{
+		ID:        76561197960265728,
+		Name:      "alice",
+		SteamID64: 76561197960265728,
+		Team:      demoinfo.TeamTerrorists,
+		Inventory: []common.InventoryItem{{Type: demoinfo.EqAK47, Count: 1, Ammo: 30}},
+		Health:    100,
+		Money:     800,
+		IsAlive:   true,
+		HUD:       common.HUD{Health: 100, Money: 800, Primary: demoinfo.EqAK47},
+	}
+	// a bot with the same details as alice apart from the ID
+	bot := alice
+	bot.ID = 3 | 1<<63
+	bot.SteamID64 = 0
+
+	var states [][]common.Player
+	for i := 0; i < 4; i++ {
+		a, b := alice, bot
+		a.Position = common.Point{X: 100.4 + float32(i), Y: -2000.6}
+		a.PositionZ = 64.2
+		a.LastAlivePosition = a.Position
+		a.ViewDirectionX = 359.99
+		a.ViewDirectionY = -89.3
+		a.FlashTimeRemaining = 1500*time.Millisecond + 400*time.Microsecond
+		b.Position = common.Point{X: -32767.9, Y: 40000}
+		if i >= 2 {
+			// only the details of alice change
+			a.Money = 300
+			a.HUD.Money = 300
+			a.Inventory = []common.InventoryItem{{Type: demoinfo.EqAK47, Count: 1, Ammo: 12}}
+		}
+		states = append(states, []common.Player{a, b})
+	}
+
+	var m Match
+	for _, players := range states {
+		m.States = append(m.States, common.OverviewState{Players: append([]common.Player(nil), players...)})
+	}
+	m.Quantize()
+
+	// alice before and after the change of money and the bot once
+	if len(m.playerDetails) != 3 {
+		t.Errorf("Quantize() stored %d player details, want 3", len(m.playerDetails))
+	}
+	for i, want := range states {
+		got := m.statePlayers(i)
+		if len(got) != len(want) {
+			t.Fatalf("state %d has %d players, want %d", i, len(got), len(want))
+		}
+		for j := range want {
+			w := want[j]
+			g := got[j]
+			if !sameDetails(g, w) {
+				t.Errorf("player %d of state %d = %+v, want the details of %+v", j, i, g, w)
+			}
+			checkWithin(t, "Position.X", g.Position.X, clamp(w.Position.X), 0.5)
+			checkWithin(t, "Position.Y", g.Position.Y, clamp(w.Position.Y), 0.5)
+			checkWithin(t, "PositionZ", g.PositionZ, w.PositionZ, 0.5)
+			checkWithin(t, "LastAlivePosition.X", g.LastAlivePosition.X, clamp(w.LastAlivePosition.X), 0.5)
+			checkWithin(t, "ViewDirectionX", g.ViewDirectionX, w.ViewDirectionX, 0.5/angleScale)
+			checkWithin(t, "ViewDirectionY", g.ViewDirectionY, w.ViewDirectionY, 0.5/angleScale)
+			if diff := w.FlashTimeRemaining - g.FlashTimeRemaining; diff < 0 || diff >= time.Millisecond {
+				t.Errorf("FlashTimeRemaining = %v, want %v", g.FlashTimeRemaining, w.FlashTimeRemaining)
+			}
+		}
+	}
+}
+
+// clamp limits a coordinate to the range of int16 like quantizeCoordinate.
+func clamp(value float32) float32 {
+	return float32(math.Max(math.Min(float64(value), math.MaxInt16), math.MinInt16))
+}
+
+func checkWithin(t *testing.T, name string, got, want, tolerance float32) {
+	t.Helper()
+	if math.Abs(float64(got-want)) > float64(tolerance) {
+		t.Errorf("%v = %v, want %v ± %v", name, got, want, tolerance)
+	}
+}
@@ -0,0 +1,82 @@
+package match
+
+import (
+	"sort"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// finish drops the bookkeeping of the parser once the match is complete and
+// collects the players.
+func (m *Match) finish() {
+	m.players = m.collectPlayers()
+	m.activeSmokes = nil
+	m.infernoFires = nil
+	m.drops = nil
+	m.activeThrows = nil
+	m.observerSlots = nil
+}
+
+// Freeze marks the match as read-only before it is shared between
+// goroutines. Quantize, SmoothGaps and SetProfiles panic afterwards, so that
+// a shared match cannot be modified by mistake.
+func (m *Match) Freeze() {
+	m.frozen = true
+}
+
+// Frozen reports whether the match was marked as read-only with Freeze.
+func (m Match) Frozen() bool {
+	return m.frozen
+}
+
+// mustNotBeFrozen panics if the match was frozen. It is called by the methods
+// that modify a parsed match.
+func (m *Match) mustNotBeFrozen(method string) {
+	if m.frozen {
+		panic("match: " + method + " called on a frozen match")
+	}
+}
+
+// ConVar returns the last value of the convar with the given name and whether
+// it was recorded. The convars that are relevant for the timers and the
+// economy and all sv_ convars are recorded.
+func (m Match) ConVar(name string) (string, bool) {
+	value, ok := m.conVars[name]
+	return value, ok
+}
+
+// ConVarNames returns the names of the recorded convars in alphabetical
+// order.
+func (m Match) ConVarNames() []string {
+	names := make([]string, 0, len(m.conVars))
+	for name := range m.conVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InfernoExtent returns the convex hull of all fires the inferno with the
+// given ID had over its life, i.e. the area it spread to in the end. It is
+// nil for matches from ParseEvents.
+func (m Match) InfernoExtent(id int64) []common.Point {
+	return m.infernoExtents[id]
+}
+
+// Profile returns the Steam profile of the player and whether it is known.
+// Profiles are only known if they were set with SetProfiles, see
+// steam.Enrich.
+func (m Match) Profile(steamID64 uint64) (common.Profile, bool) {
+	profile, ok := m.profiles[steamID64]
+	return profile, ok
+}
+
+// SetProfiles stores the Steam profiles of the players. It panics if the
+// match is frozen.
+func (m *Match) SetProfiles(profiles map[uint64]common.Profile) {
+	m.mustNotBeFrozen("SetProfiles")
+	m.profiles = make(map[uint64]common.Profile, len(profiles))
+	for id, profile := range profiles {
+		m.profiles[id] = profile
+	}
+}
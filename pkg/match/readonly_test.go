@@ -0,0 +1,40 @@
+package match
+
+import (
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+func TestFrozenMatchPanics(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *Match)
+	}{
+		{"Quantize", func(m *Match) { m.Quantize() }},
+		{"SmoothGaps", func(m *Match) { m.SmoothGaps() }},
+		{"SetProfiles", func(m *Match) { m.SetProfiles(nil) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var m Match
+			m.Freeze()
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v did not panic on a frozen match", test.name)
+				}
+			}()
+			test.modify(&m)
+		})
+	}
+}
+
+func TestSetProfilesCopies(t *testing.T) {
+	var m Match
+	profiles := map[uint64]common.Profile{1: {VACBanned: true}}
+	m.SetProfiles(profiles)
+	delete(profiles, 1)
+	if profile, ok := m.Profile(1); !ok || !profile.VACBanned {
+		t.Errorf("Profile(1) = %v, %v, want the stored profile", profile, ok)
+	}
+}
@@ -0,0 +1,371 @@
+package match
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+const (
+	lossBonusBase      int = 1400
+	lossBonusIncrement int = 500
+	maxLossStreak      int = 4
+	// the pistol round already counts as the second loss, i.e. the losing
+	// team receives 1900
+	initialLossStreak int = 1
+	// defaultMaxMoney is the value of mp_maxmoney in competitive matches.
+	defaultMaxMoney int = 16000
+)
+
+// The money every player of a team receives at the end of a round besides
+// the rewards for kills.
+const (
+	// WinReward is the reward for winning a round by eliminating the
+	// enemies or by running down the time.
+	WinReward int = 3250
+	// BombWinReward is the reward for winning a round by defusing the bomb
+	// or by letting it explode.
+	BombWinReward int = 3500
+	// PlantBonus is paid to the terrorists in addition to the loss bonus if
+	// they lose a round after planting the bomb.
+	PlantBonus int = 800
+)
+
+// roundTracker keeps track of the state that is needed to fill the Rounds of
+// a Match during parsing.
+type roundTracker struct {
+	lossStreaks map[demoinfo.Team]int
+}
+
+func newRoundTracker() *roundTracker {
+	return &roundTracker{
+		lossStreaks: map[demoinfo.Team]int{
+			demoinfo.TeamCounterTerrorists: initialLossStreak,
+			demoinfo.TeamTerrorists:        initialLossStreak,
+		},
+	}
+}
+
+// LossBonus returns the money every player of a team with the given loss
+// streak receives if the team loses the round, see RoundTeam.LossStreak.
+func LossBonus(lossStreak int) int {
+	return lossBonusBase + lossBonusIncrement*lossStreak
+}
+
+// NextLossStreak returns the loss streak of a team in the next round of the
+// same half. Since 2019 a win only reduces the loss streak instead of
+// resetting it.
+func NextLossStreak(lossStreak int, won bool) int {
+	if won {
+		if lossStreak > 0 {
+			return lossStreak - 1
+		}
+		return lossStreak
+	}
+	if lossStreak < maxLossStreak {
+		return lossStreak + 1
+	}
+	return lossStreak
+}
+
+// roundReward returns the money every player of the team received at the
+// end of the round.
+func roundReward(round common.Round, team demoinfo.Team, bombPlanted bool) int {
+	roundTeam := round.Team(team)
+	switch {
+	case round.Winner == team && (round.WinType == common.RoundWinTypeBombDefused || round.WinType == common.RoundWinTypeBombExploded):
+		return BombWinReward
+	case round.Winner == team:
+		return WinReward
+	case round.Winner == demoinfo.TeamUnassigned:
+		return 0
+	case team == demoinfo.TeamTerrorists && bombPlanted:
+		return roundTeam.LossBonus + PlantBonus
+	default:
+		return roundTeam.LossBonus
+	}
+}
+
+// MaxMoney returns the most money a player can have according to
+// mp_maxmoney.
+func (m Match) MaxMoney() int {
+	value, ok := m.ConVar("mp_maxmoney")
+	if !ok {
+		return defaultMaxMoney
+	}
+	maxMoney, err := strconv.Atoi(value)
+	if err != nil || maxMoney <= 0 {
+		return defaultMaxMoney
+	}
+	return maxMoney
+}
+
+func (m *Match) currentRound() *common.Round {
+	if len(m.Rounds) == 0 {
+		return nil
+	}
+	return &m.Rounds[len(m.Rounds)-1]
+}
+
+func registerRoundHandlers(parser dem.Parser, match *Match) {
+	tracker := newRoundTracker()
+
+	parser.RegisterEventHandler(func(event.RoundStart) {
+		gameState := parser.GameState()
+		round := common.Round{
+			Number:             len(match.Rounds) + 1,
+			StartFrame:         parser.CurrentFrame(),
+			FreezetimeEndFrame: -1,
+			EndFrame:           -1,
+			Winner:             demoinfo.TeamUnassigned,
+		}
+		for _, team := range []demoinfo.Team{demoinfo.TeamCounterTerrorists, demoinfo.TeamTerrorists} {
+			roundTeam := round.Team(team)
+			roundTeam.ClanName = gameState.Team(team).ClanName()
+			roundTeam.LossStreak = tracker.lossStreaks[team]
+			roundTeam.LossBonus = LossBonus(tracker.lossStreaks[team])
+			for _, p := range gameState.Participants().TeamMembers(team) {
+				roundTeam.StartMoney += p.Money()
+			}
+		}
+		match.Rounds = append(match.Rounds, round)
+	})
+
+	parser.RegisterEventHandler(func(event.MatchStart) {
+		// rounds before the restart of the match, e.g. warmup or knife
+		// rounds, do not count towards the loss bonus
+		for team := range tracker.lossStreaks {
+			tracker.lossStreaks[team] = initialLossStreak
+		}
+	})
+
+	parser.RegisterEventHandler(func(event.RoundFreezetimeEnd) {
+		round := match.currentRound()
+		if round == nil {
+			return
+		}
+		gameState := parser.GameState()
+		round.FreezetimeEndFrame = parser.CurrentFrame()
+		round.CounterTerrorists.EquipmentValue = gameState.TeamCounterTerrorists().FreezeTimeEndEquipmentValue()
+		round.Terrorists.EquipmentValue = gameState.TeamTerrorists().FreezeTimeEndEquipmentValue()
+	})
+
+	parser.RegisterEventHandler(func(e event.RoundEnd) {
+		round := match.currentRound()
+		if round == nil || round.EndFrame >= 0 {
+			return
+		}
+		gameState := parser.GameState()
+		round.EndFrame = parser.CurrentFrame()
+		round.Winner = e.Winner
+		round.WinType = winType(e.Reason)
+		round.CounterTerrorists.MoneySpent = gameState.TeamCounterTerrorists().MoneySpentThisRound()
+		round.Terrorists.MoneySpent = gameState.TeamTerrorists().MoneySpentThisRound()
+		// old demos lack RoundEndOfficial, so the saves are counted here
+		// first and updated at the end of the round restart delay
+		updateSaves(round, gameState)
+
+		bombPlanted := false
+		for _, plant := range match.BombPlants {
+			if plant.Frame >= round.StartFrame {
+				bombPlanted = true
+			}
+		}
+		for team, streak := range tracker.lossStreaks {
+			round.Team(team).Reward = roundReward(*round, team, bombPlanted)
+			if e.Winner != demoinfo.TeamUnassigned {
+				tracker.lossStreaks[team] = NextLossStreak(streak, team == e.Winner)
+			}
+		}
+	})
+
+	parser.RegisterEventHandler(func(event.RoundEndOfficial) {
+		round := match.currentRound()
+		if round == nil || round.EndFrame < 0 {
+			return
+		}
+		updateSaves(round, parser.GameState())
+	})
+
+	parser.RegisterEventHandler(func(event.GameHalfEnded) {
+		for team := range tracker.lossStreaks {
+			tracker.lossStreaks[team] = initialLossStreak
+		}
+	})
+}
+
+// updateSaves counts the players that are alive and the value of their
+// equipment. Players can still die or pick up weapons after the round was
+// decided.
+func updateSaves(round *common.Round, gameState dem.GameState) {
+	for _, team := range []demoinfo.Team{demoinfo.TeamCounterTerrorists, demoinfo.TeamTerrorists} {
+		roundTeam := round.Team(team)
+		roundTeam.Survivors = 0
+		roundTeam.SavedValue = 0
+		for _, p := range gameState.Team(team).Members() {
+			if p == nil || !p.IsAlive() {
+				continue
+			}
+			roundTeam.Survivors++
+			roundTeam.SavedValue += p.EquipmentValueCurrent()
+		}
+	}
+}
+
+func winType(reason event.RoundEndReason) common.RoundWinType {
+	switch reason {
+	case event.RoundEndReasonCTWin, event.RoundEndReasonTerroristsWin:
+		return common.RoundWinTypeElimination
+	case event.RoundEndReasonBombDefused:
+		return common.RoundWinTypeBombDefused
+	case event.RoundEndReasonTargetBombed:
+		return common.RoundWinTypeBombExploded
+	case event.RoundEndReasonTargetSaved:
+		return common.RoundWinTypeTime
+	case event.RoundEndReasonTerroristsSurrender, event.RoundEndReasonCTSurrender:
+		return common.RoundWinTypeSurrender
+	default:
+		return common.RoundWinTypeUnknown
+	}
+}
+
+// multiKillThreshold is the number of kills in a round from which on a
+// player is listed in the MultiKills of the round.
+const multiKillThreshold = 3
+
+// countRoundEvents counts the kills and bomb plants of every round and finds
+// the players with multi-kills.
+func (m *Match) countRoundEvents() {
+	for i := range m.Rounds {
+		round := &m.Rounds[i]
+		end := math.MaxInt32
+		if i+1 < len(m.Rounds) {
+			end = m.Rounds[i+1].StartFrame
+		}
+
+		round.Kills = 0
+		var killers []uint64
+		multiKills := make(map[uint64]*common.MultiKill)
+		for _, kill := range m.Kills {
+			if kill.Frame < round.StartFrame || kill.Frame >= end {
+				continue
+			}
+			round.Kills++
+			if kill.Type != common.KillEnemy {
+				continue
+			}
+			k, ok := multiKills[kill.KillerSteamID64]
+			if !ok {
+				k = &common.MultiKill{
+					PlayerName: kill.KillerName,
+					SteamID64:  kill.KillerSteamID64,
+					Team:       kill.KillerTeam,
+				}
+				multiKills[kill.KillerSteamID64] = k
+				killers = append(killers, kill.KillerSteamID64)
+			}
+			k.Kills++
+			k.Frame = kill.Frame
+		}
+		round.MultiKills = nil
+		for _, id := range killers {
+			if multiKills[id].Kills >= multiKillThreshold {
+				round.MultiKills = append(round.MultiKills, *multiKills[id])
+			}
+		}
+		sort.Slice(round.MultiKills, func(i, j int) bool { return round.MultiKills[i].Frame < round.MultiKills[j].Frame })
+		round.BombPlanted = false
+		for _, plant := range m.BombPlants {
+			if plant.Frame >= round.StartFrame && plant.Frame < end {
+				round.BombPlanted = true
+			}
+		}
+	}
+}
+
+// detectKnifeRounds marks all rounds in which every kill was made with a
+// knife and after which the score was reset.
+func (m *Match) detectKnifeRounds() {
+	for i := range m.Rounds {
+		round := &m.Rounds[i]
+		end := m.TotalFrames()
+		if i+1 < len(m.Rounds) {
+			end = m.Rounds[i+1].StartFrame
+		}
+
+		var kills int
+		allKnife := true
+		for _, kill := range m.Kills {
+			if kill.Frame < round.StartFrame || kill.Frame >= end {
+				continue
+			}
+			kills++
+			if kill.Weapon != demoinfo.EqKnife {
+				allKnife = false
+			}
+		}
+
+		scoreReset := true
+		if i+1 < len(m.Rounds) && m.Rounds[i+1].StartFrame <= m.LastFrame() {
+			next := m.StateAtFrame(m.Rounds[i+1].StartFrame)
+			scoreReset = next.TeamCounterTerrorists.Score == 0 && next.TeamTerrorists.Score == 0
+		}
+
+		round.IsKnifeRound = kills > 0 && allKnife && scoreReset
+	}
+}
+
+// LastEndedRound returns the latest round that ended at or before the given
+// frame.
+func (m Match) LastEndedRound(frame int) (common.Round, bool) {
+	for i := len(m.Rounds) - 1; i >= 0; i-- {
+		round := m.Rounds[i]
+		if round.EndFrame >= 0 && round.EndFrame <= frame {
+			return round, true
+		}
+	}
+	return common.Round{}, false
+}
+
+// IsKnifeRound reports whether the given frame belongs to a knife round.
+func (m Match) IsKnifeRound(frame int) bool {
+	i := m.RoundIndex(frame)
+	return i >= 0 && i < len(m.Rounds) && m.Rounds[i].IsKnifeRound
+}
+
+func (m *Match) summarize() {
+	summary := common.Summary{
+		KnifeRounds: make([]int, 0),
+	}
+	last := m.finalState
+	if len(m.States) > 0 {
+		last = m.States[len(m.States)-1]
+	}
+	summary.ClanNameCounterTerrorists = last.TeamCounterTerrorists.ClanName
+	summary.ClanNameTerrorists = last.TeamTerrorists.ClanName
+	summary.ScoreCounterTerrorists = int(last.TeamCounterTerrorists.Score)
+	summary.ScoreTerrorists = int(last.TeamTerrorists.Score)
+	for _, round := range m.Rounds {
+		if round.IsKnifeRound {
+			summary.KnifeRounds = append(summary.KnifeRounds, round.Number)
+			continue
+		}
+		if round.EndFrame >= 0 {
+			summary.RoundsPlayed++
+		}
+		if round.WinType == common.RoundWinTypeSurrender {
+			summary.IsSurrendered = true
+			if round.Winner == demoinfo.TeamTerrorists {
+				summary.SurrenderedTeam = demoinfo.TeamCounterTerrorists
+			} else {
+				summary.SurrenderedTeam = demoinfo.TeamTerrorists
+			}
+		}
+	}
+	m.Summary = summary
+}
@@ -0,0 +1,135 @@
+package match
+
+import (
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// SmokeRadius is the radius of a fully bloomed smoke in world units.
+const SmokeRadius float32 = 144
+
+const (
+	// smokeBloomTime is the time a smoke takes to grow to its full radius
+	// after it started to emit smoke.
+	smokeBloomTime time.Duration = 1500 * time.Millisecond
+	// smokeBloomStart is the share of the full radius the smoke starts with.
+	smokeBloomStart float32 = 0.4
+	// smokeFadeTime is the time before a smoke expires in which it thins out
+	// and becomes see-through.
+	smokeFadeTime time.Duration = 3 * time.Second
+	// oneWayMargin is the distance to the edge of a smoke within which a
+	// player inside the smoke might be able to see out of it.
+	oneWayMargin float32 = 40
+	// oneWayMinOpacity is the opacity a smoke needs to hide a player that
+	// looks out of it.
+	oneWayMinOpacity float64 = 0.9
+	// smokeKillMinOpacity is the opacity a smoke needs to block the view of
+	// a killer.
+	smokeKillMinOpacity float64 = 0.5
+)
+
+// SmokeShape returns the radius of a smoke in world units and its opacity from
+// 0 to 1, given the time it has been emitting smoke and the time until it
+// expires. The smoke blooms from a smaller radius to its full radius and thins
+// out shortly before it expires.
+// This models the smokes of CS:GO. The volumetric smokes of CS2 are not
+// supported because CS2 demos cannot be parsed.
+func SmokeShape(elapsed, remaining time.Duration) (float32, float64) {
+	if elapsed < 0 || remaining <= 0 {
+		return 0, 0
+	}
+	radius := SmokeRadius
+	if elapsed < smokeBloomTime {
+		t := float32(elapsed) / float32(smokeBloomTime)
+		// ease out, the smoke grows fast at first and slows down
+		grown := 1 - (1-t)*(1-t)
+		radius = SmokeRadius * (smokeBloomStart + (1-smokeBloomStart)*grown)
+	}
+	opacity := 1.0
+	if remaining < smokeFadeTime {
+		opacity = float64(remaining) / float64(smokeFadeTime)
+	}
+	return radius, opacity
+}
+
+// SmokeShapeAt returns the radius and opacity of the smoke at the given frame
+// and whether the smoke exists at that frame.
+func (m Match) SmokeShapeAt(smoke common.Smoke, frame int) (float32, float64, bool) {
+	if frame < smoke.StartFrame || frame >= smoke.EndFrame {
+		return 0, 0, false
+	}
+	radius, opacity := SmokeShape(m.TimeAt(frame)-m.TimeAt(smoke.StartFrame), m.TimeAt(smoke.EndFrame)-m.TimeAt(frame))
+	return radius, opacity, true
+}
+
+// OneWay is a player that stands inside a smoke close to its edge, where the
+// player might see enemies outside of the smoke without being seen.
+type OneWay struct {
+	SteamID64  uint64
+	PlayerName string
+	Team       demoinfo.Team
+	// Smoke is the index of the smoke in Smokes.
+	Smoke int
+	// DistanceToEdge is the distance from the player to the edge of the
+	// smoke in world units.
+	DistanceToEdge float32
+}
+
+// OneWaysAt returns the players that stand in potential one-way positions at
+// the given frame. Only the horizontal distance to the center of the smoke is
+// taken into account, so the positions are candidates that need to be
+// checked, e.g. with the height of the player.
+func (m Match) OneWaysAt(frame int) []OneWay {
+	if frame < 0 || frame > m.LastFrame() {
+		return nil
+	}
+	var oneWays []OneWay
+	for i, smoke := range m.Smokes {
+		radius, opacity, ok := m.SmokeShapeAt(smoke, frame)
+		if !ok || radius < SmokeRadius || opacity < oneWayMinOpacity {
+			continue
+		}
+		for _, player := range m.StateAtFrame(frame).Players {
+			if !player.IsAlive {
+				continue
+			}
+			distanceToEdge := radius - player.Position.Distance(smoke.Position)
+			if distanceToEdge < 0 || distanceToEdge > oneWayMargin {
+				continue
+			}
+			oneWays = append(oneWays, OneWay{
+				SteamID64:      player.SteamID64,
+				PlayerName:     player.Name,
+				Team:           player.Team,
+				Smoke:          i,
+				DistanceToEdge: distanceToEdge,
+			})
+		}
+	}
+	return oneWays
+}
+
+// detectSmokeKills flags the kills with a gun where the line from the killer
+// to the victim crossed a smoke. Like OneWaysAt, only the horizontal
+// positions are taken into account.
+func (m *Match) detectSmokeKills() {
+	for i := range m.Kills {
+		kill := &m.Kills[i]
+		kill.ThroughSmoke = false
+		class := kill.Weapon.Class()
+		if !kill.HasKiller() || kill.Type == common.KillSuicide ||
+			class == demoinfo.EqClassGrenade || class == demoinfo.EqClassEquipment {
+			continue
+		}
+		for _, smoke := range m.Smokes {
+			radius, opacity, ok := m.SmokeShapeAt(smoke, kill.Frame)
+			if ok && opacity >= smokeKillMinOpacity &&
+				smoke.Position.DistanceToSegment(kill.KillerPosition, kill.VictimPosition) < radius {
+				kill.ThroughSmoke = true
+				break
+			}
+		}
+	}
+}
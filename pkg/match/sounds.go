@@ -0,0 +1,54 @@
+package match
+
+import (
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+// soundLifetime is the number of seconds a sound is visible.
+const soundLifetime float64 = 1
+
+func registerSoundHandlers(parser dem.Parser, match *Match) {
+	parser.RegisterEventHandler(func(e event.Footstep) {
+		match.addSound(parser.CurrentFrame(), common.SoundFootstep, e.Player)
+	})
+	parser.RegisterEventHandler(func(e event.PlayerJump) {
+		match.addSound(parser.CurrentFrame(), common.SoundJump, e.Player)
+	})
+	parser.RegisterEventHandler(func(e event.WeaponReload) {
+		match.addSound(parser.CurrentFrame(), common.SoundReload, e.Player)
+	})
+	parser.RegisterEventHandler(func(e event.WeaponFire) {
+		if e.Weapon != nil && e.Weapon.Class() == demoinfo.EqClassGrenade {
+			match.addSound(parser.CurrentFrame(), common.SoundGrenadeThrow, e.Shooter)
+		}
+	})
+}
+
+func (m *Match) addSound(frame int, soundType common.SoundType, player *demoinfo.Player) {
+	if player == nil || !player.IsAlive() {
+		return
+	}
+	m.Sounds = append(m.Sounds, common.Sound{
+		Frame:     frame,
+		Type:      soundType,
+		SteamID64: player.SteamID64,
+		Team:      player.Team,
+		Position: common.Point{
+			X: float32(player.Position().X),
+			Y: float32(player.Position().Y),
+		},
+	})
+	m.soundIndex.add(frame, frame+int(soundLifetime*m.FrameRate))
+}
+
+// SoundsAt returns the sounds that are visible at the given frame.
+func (m Match) SoundsAt(frame int) []common.Sound {
+	var sounds []common.Sound
+	m.soundIndex.at(frame, func(i, start int) {
+		sounds = append(sounds, m.Sounds[i])
+	})
+	return sounds
+}
@@ -0,0 +1,56 @@
+package match
+
+import (
+	"io"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+)
+
+// StateHandler is called for every frame of a streamed demo with the state of
+// that frame. The match is modified between the calls, so it must not be
+// read outside of the handler until Stream returned.
+type StateHandler func(m *Match, frame int, state common.OverviewState)
+
+// Stream parses the demo that is read from r frame by frame and calls handler
+// with the state of every frame as soon as it has been parsed. In contrast to
+// NewMatch the states are not stored in the returned Match, but all events
+// like kills and rounds are. This makes it possible to follow demos that are
+// still being recorded.
+// fallbackFrameRate and fallbackTickRate are used in case the values cannot be
+// parsed from the demo, which is always the case for demos that are still
+// being recorded. If they are not set, they must be -1.
+// Files that are still being written and network connections return short
+// reads, so the reads of the parser are filled like those of OpenDemo.
+func Stream(r io.Reader, fallbackFrameRate, fallbackTickRate float64, handler StateHandler) (*Match, error) {
+	parser := dem.NewParser(demoReader{Reader: r})
+	defer parser.Close()
+	match, err := parseHeader(parser, fallbackFrameRate, fallbackTickRate)
+	if err != nil {
+		return nil, err
+	}
+	match.States = make([]common.OverviewState, 0)
+
+	span := tracer.StartSpan("parse_frames")
+	for ok, err := parser.ParseNextFrame(); ok; ok, err = parser.ParseNextFrame() {
+		if err != nil {
+			logFrameError(parser, err)
+			continue
+		}
+		match.advanceDemoTime(parser)
+		match.finalState = parseGameState(parser, match)
+		handler(match, parser.CurrentFrame(), match.finalState)
+	}
+	span.End(nil)
+
+	span = tracer.StartSpan("analyze")
+	match.fixMissingEvents()
+	match.finishHalves()
+	match.countRoundEvents()
+	match.detectSmokeKills()
+	match.summarize()
+	match.finish()
+	span.End(nil)
+
+	return match, nil
+}
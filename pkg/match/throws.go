@@ -0,0 +1,90 @@
+package match
+
+import (
+	"math"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	event "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+const (
+	// standingSpeed is the horizontal speed in units per second below which
+	// a throw counts as standing.
+	standingSpeed float32 = 10
+	// runningSpeed is the horizontal speed from which on a throw counts as
+	// running. Walking with a grenade is slightly slower.
+	runningSpeed float32 = 140
+)
+
+func registerThrowHandlers(parser dem.Parser, match *Match) {
+	parser.RegisterEventHandler(func(e event.GrenadeProjectileThrow) {
+		match.addThrow(parser.CurrentFrame(), e.Projectile)
+	})
+	parser.RegisterEventHandler(func(e event.GrenadeProjectileDestroy) {
+		match.endThrow(e.Projectile)
+	})
+}
+
+// addThrow records the throw of the projectile with the state of the thrower
+// at the release.
+func (m *Match) addThrow(frame int, projectile *demoinfo.GrenadeProjectile) {
+	thrower := projectile.Thrower
+	if thrower == nil || projectile.WeaponInstance == nil {
+		return
+	}
+	velocity := thrower.Velocity()
+	speed := float32(math.Hypot(velocity.X, velocity.Y))
+	m.activeThrows[projectile.UniqueID()] = len(m.GrenadeThrows)
+	m.GrenadeThrows = append(m.GrenadeThrows, common.GrenadeThrow{
+		Frame:     frame,
+		Grenade:   projectile.WeaponInstance.Type,
+		Name:      thrower.Name,
+		SteamID64: thrower.SteamID64,
+		Team:      thrower.Team,
+		Technique: throwTechnique(speed, thrower.IsAirborne()),
+		Position: common.Point{
+			X: float32(thrower.Position().X),
+			Y: float32(thrower.Position().Y),
+		},
+		PositionZ:      float32(thrower.Position().Z),
+		ViewDirectionX: thrower.ViewDirectionX(),
+		ViewDirectionY: thrower.ViewDirectionY(),
+		Speed:          speed,
+	})
+}
+
+// endThrow records where the projectile landed.
+func (m *Match) endThrow(projectile *demoinfo.GrenadeProjectile) {
+	index, ok := m.activeThrows[projectile.UniqueID()]
+	if !ok {
+		return
+	}
+	throw := &m.GrenadeThrows[index]
+	throw.Landing = common.Point{
+		X: float32(projectile.Position().X),
+		Y: float32(projectile.Position().Y),
+	}
+	throw.LandingZ = float32(projectile.Position().Z)
+	throw.HasLanding = true
+	delete(m.activeThrows, projectile.UniqueID())
+}
+
+// throwTechnique classifies a throw by the horizontal speed of the thrower
+// and whether the thrower was in the air. Players who fall down a ledge are
+// in the air as well, they are counted as jump throws.
+func throwTechnique(speed float32, airborne bool) common.ThrowTechnique {
+	switch {
+	case airborne && speed >= runningSpeed:
+		return common.ThrowRunningJump
+	case airborne:
+		return common.ThrowJump
+	case speed >= runningSpeed:
+		return common.ThrowRunning
+	case speed >= standingSpeed:
+		return common.ThrowWalking
+	default:
+		return common.ThrowStanding
+	}
+}
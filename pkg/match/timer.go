@@ -0,0 +1,205 @@
+package match
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+)
+
+// phaseChange is a change of the phase of the round. The time remaining in a
+// phase is only computed from it when it is needed.
+type phaseChange struct {
+	frame    int
+	phase    common.Phase
+	duration time.Duration
+}
+
+// phaseTimer describes where the duration of a phase is configured. The
+// convars are tried in order, fallback is used if none of them is set, which
+// happens in some demos.
+type phaseTimer struct {
+	conVars []string
+	// unit is the unit of the convar values, round times are set in minutes.
+	unit      time.Duration
+	allowZero bool
+	fallback  time.Duration
+}
+
+var phaseTimers = map[common.Phase]phaseTimer{
+	common.PhaseFreezetime: {
+		conVars:   []string{"mp_freezetime"},
+		unit:      time.Second,
+		allowZero: true,
+		fallback:  15 * time.Second,
+	},
+	common.PhaseRegular: {
+		// mp_roundtime_defuse is 0 if mp_roundtime is used on defuse maps
+		conVars:  []string{"mp_roundtime_defuse", "mp_roundtime"},
+		unit:     time.Minute,
+		fallback: 115 * time.Second,
+	},
+	common.PhasePlanted: {
+		conVars:  []string{"mp_c4timer"},
+		unit:     time.Second,
+		fallback: 40 * time.Second,
+	},
+	common.PhaseRestart: {
+		conVars:   []string{"mp_round_restart_delay"},
+		unit:      time.Second,
+		allowZero: true,
+		fallback:  7 * time.Second,
+	},
+	common.PhaseHalftime: {
+		conVars:   []string{"mp_halftime_duration"},
+		unit:      time.Second,
+		allowZero: true,
+		fallback:  15 * time.Second,
+	},
+}
+
+// phaseDuration returns the duration of the phase according to the convars.
+// The second return value is false if the fallback is used.
+func phaseDuration(conVars map[string]string, phase common.Phase) (time.Duration, bool) {
+	timer, ok := phaseTimers[phase]
+	if !ok {
+		return 0, false
+	}
+	for _, name := range timer.conVars {
+		value, err := strconv.ParseFloat(conVars[name], 64)
+		if err != nil || value < 0 || (value == 0 && !timer.allowZero) {
+			continue
+		}
+		return time.Duration(value * float64(timer.unit)), true
+	}
+	return timer.fallback, false
+}
+
+// changePhase is called whenever the phase of the round changes. The duration
+// of the new phase is taken from the convars that are active at this point.
+func (m *Match) changePhase(parser dem.Parser, phase common.Phase) {
+	duration, _ := phaseDuration(parser.GameState().ConVars(), phase)
+	m.phaseChanges = append(m.phaseChanges, phaseChange{
+		frame:    parser.CurrentFrame(),
+		phase:    phase,
+		duration: duration,
+	})
+}
+
+// TimerAt returns the phase of the round and the time remaining in it at the
+// given frame. Pauses are not counted as elapsed time.
+func (m Match) TimerAt(frame int) common.Timer {
+	timer := common.Timer{
+		Phase:    common.PhaseWarmup,
+		IsPaused: m.isPausedAt(frame),
+	}
+	i := sort.Search(len(m.phaseChanges), func(i int) bool { return m.phaseChanges[i].frame > frame }) - 1
+	if i < 0 {
+		return timer
+	}
+	change := m.phaseChanges[i]
+	timer.Phase = change.phase
+	if change.phase == common.PhaseWarmup {
+		return timer
+	}
+	elapsed := m.TimeAt(frame) - m.TimeAt(change.frame) - m.pausedBetween(change.frame, frame)
+	timer.TimeRemaining = change.duration - elapsed
+	timer.Duration = change.duration
+	if change.phase == common.PhasePlanted {
+		if defuse, ok := m.defuseAt(frame); ok {
+			timer.IsDefusing = true
+			timer.DefuseRemaining = defuse.Duration - (m.TimeAt(frame) - m.TimeAt(defuse.StartFrame))
+			timer.DefuseInTime = timer.DefuseRemaining <= timer.TimeRemaining
+		}
+	}
+	return timer
+}
+
+// defuseAt returns the defuse that is in progress at the given frame.
+func (m Match) defuseAt(frame int) (common.Defuse, bool) {
+	i := sort.Search(len(m.BombPlants), func(i int) bool { return m.BombPlants[i].Frame > frame }) - 1
+	if i < 0 {
+		return common.Defuse{}, false
+	}
+	for _, d := range m.BombPlants[i].Defuses {
+		if d.StartFrame <= frame && (d.EndFrame == -1 || d.EndFrame > frame) {
+			return d, true
+		}
+	}
+	return common.Defuse{}, false
+}
+
+// plantAttemptAt returns the plant attempt that is in progress at the given
+// frame.
+func (m Match) plantAttemptAt(frame int) (common.PlantAttempt, bool) {
+	i := sort.Search(len(m.PlantAttempts), func(i int) bool { return m.PlantAttempts[i].StartFrame > frame }) - 1
+	if i < 0 {
+		return common.PlantAttempt{}, false
+	}
+	attempt := m.PlantAttempts[i]
+	if attempt.EndFrame == -1 || attempt.EndFrame > frame {
+		return attempt, true
+	}
+	return common.PlantAttempt{}, false
+}
+
+// ProgressAt returns the progress of the players that are planting or
+// defusing the bomb at the given frame.
+func (m Match) ProgressAt(frame int) []common.ActionProgress {
+	var progress []common.ActionProgress
+	if attempt, ok := m.plantAttemptAt(frame); ok {
+		progress = append(progress, common.ActionProgress{
+			SteamID64: attempt.PlanterSteamID64,
+			Progress:  m.progressSince(attempt.StartFrame, frame, attempt.Duration),
+		})
+	}
+	if defuse, ok := m.defuseAt(frame); ok {
+		progress = append(progress, common.ActionProgress{
+			SteamID64: defuse.DefuserSteamID64,
+			IsDefuse:  true,
+			Progress:  m.progressSince(defuse.StartFrame, frame, defuse.Duration),
+		})
+	}
+	return progress
+}
+
+func (m Match) progressSince(startFrame, frame int, duration time.Duration) float64 {
+	if duration <= 0 {
+		return 1
+	}
+	progress := float64(m.TimeAt(frame)-m.TimeAt(startFrame)) / float64(duration)
+	if progress > 1 {
+		return 1
+	}
+	return progress
+}
+
+func (m Match) isPausedAt(frame int) bool {
+	for _, p := range m.Pauses {
+		if p.StartFrame <= frame && (p.EndFrame == -1 || p.EndFrame > frame) {
+			return true
+		}
+	}
+	return false
+}
+
+// pausedBetween returns the time the match was paused between the frames.
+func (m Match) pausedBetween(startFrame, endFrame int) time.Duration {
+	var paused time.Duration
+	for _, p := range m.Pauses {
+		start := p.StartFrame
+		if start < startFrame {
+			start = startFrame
+		}
+		end := p.EndFrame
+		if end == -1 || end > endFrame {
+			end = endFrame
+		}
+		if end > start {
+			paused += m.TimeAt(end) - m.TimeAt(start)
+		}
+	}
+	return paused
+}
@@ -0,0 +1,322 @@
+// Package query finds events of a match, e.g. all AWP kills of a player from
+// A site, and returns the frames where they occur.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// positions closer than this to a chokepoint are considered to be in it
+const chokepointDistance float32 = 300
+
+// EventType is the kind of an event.
+type EventType byte
+
+// Possible values for EventType. EventAny matches every type in a Query.
+const (
+	EventAny EventType = iota
+	EventKill
+	EventShot
+	EventPlant
+	EventThrow
+)
+
+var eventTypeNames = map[EventType]string{
+	EventAny:   "any",
+	EventKill:  "kill",
+	EventShot:  "shot",
+	EventPlant: "plant",
+	EventThrow: "throw",
+}
+
+func (t EventType) String() string {
+	return eventTypeNames[t]
+}
+
+// Query describes the events to find. Empty fields match every event.
+type Query struct {
+	Type EventType
+	// Player is a part of the name of the player who killed, shot, planted
+	// or threw, Victim a part of the name of the player who was killed. The
+	// case is ignored.
+	Player string
+	Victim string
+	Weapon demoinfo.EquipmentType
+	// Area is a site ("A" or "B") or the name of a chokepoint of the map,
+	// e.g. "Palace". The position of the killer, the shooter, the planter or
+	// the thrower has to be in it.
+	Area string
+	Team demoinfo.Team
+	// Round is the number of the round, starting at 1.
+	Round int
+	// ThroughSmoke restricts the events to kills through smokes.
+	ThroughSmoke bool
+}
+
+// Event is an event that matches a query.
+type Event struct {
+	Type EventType
+	// Index is the index of the event in the list of its type in the match,
+	// e.g. in Kills or GrenadeThrows.
+	Index     int
+	Frame     int
+	Round     int
+	Player    string
+	SteamID64 uint64
+	Team      demoinfo.Team
+	Weapon    demoinfo.EquipmentType
+	Position  common.Point
+	// Victim is the name of the killed player of kills.
+	Victim       string
+	ThroughSmoke bool
+}
+
+// Parse parses a query like "kill weapon:awp player:s1mple area:A". The
+// terms are separated by spaces and can be given in any order: "kill",
+// "shot", "plant" or "throw" restrict the type, "smoke" to kills through
+// smokes,
+// "player:", "victim:", "weapon:",
+// "area:", "team:" (CT or T) and "round:" the other fields of the Query.
+// Weapons are named like in the game files, e.g. "ak47" or "deagle". Names
+// with spaces can be quoted, e.g. player:"Bot Bob".
+func Parse(s string) (Query, error) {
+	var q Query
+	terms, err := splitTerms(s)
+	if err != nil {
+		return q, err
+	}
+	for _, term := range terms {
+		key, value := term, ""
+		if i := strings.Index(term, ":"); i >= 0 {
+			key, value = strings.ToLower(term[:i]), term[i+1:]
+		}
+		switch key {
+		case "kill", "kills":
+			q.Type = EventKill
+		case "shot", "shots":
+			q.Type = EventShot
+		case "plant", "plants":
+			q.Type = EventPlant
+		case "throw", "throws":
+			q.Type = EventThrow
+		case "smoke":
+			q.Type = EventKill
+			q.ThroughSmoke = true
+		case "player":
+			q.Player = value
+		case "victim":
+			q.Victim = value
+		case "weapon":
+			q.Weapon = demoinfo.MapEquipment(strings.ToLower(value))
+			if q.Weapon == demoinfo.EqUnknown {
+				return q, fmt.Errorf("unknown weapon %q", value)
+			}
+		case "area", "from":
+			q.Area = value
+		case "team":
+			switch strings.ToUpper(value) {
+			case "CT":
+				q.Team = demoinfo.TeamCounterTerrorists
+			case "T":
+				q.Team = demoinfo.TeamTerrorists
+			default:
+				return q, fmt.Errorf("unknown team %q, expected CT or T", value)
+			}
+		case "round":
+			q.Round, err = strconv.Atoi(value)
+			if err != nil || q.Round < 1 {
+				return q, fmt.Errorf("invalid round %q", value)
+			}
+		default:
+			return q, fmt.Errorf("unknown term %q", term)
+		}
+	}
+	return q, nil
+}
+
+// splitTerms splits s at spaces outside of double quotes and removes the
+// quotes.
+func splitTerms(s string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	var quoted bool
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("missing closing quote in %q", s)
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+// Run returns all events of the match that match the query, sorted by frame.
+func Run(m *match.Match, q Query) []Event {
+	var candidates []Event
+	if q.Type == EventAny || q.Type == EventKill {
+		for i, kill := range m.Kills {
+			if !kill.HasKiller() {
+				continue
+			}
+			candidates = append(candidates, Event{
+				Type:         EventKill,
+				Index:        i,
+				Frame:        kill.Frame,
+				Player:       kill.KillerName,
+				SteamID64:    kill.KillerSteamID64,
+				Team:         kill.KillerTeam,
+				Weapon:       kill.Weapon,
+				Position:     kill.KillerPosition,
+				Victim:       kill.VictimName,
+				ThroughSmoke: kill.ThroughSmoke,
+			})
+		}
+	}
+	if q.Type == EventAny || q.Type == EventShot {
+		for i, shot := range m.FiredShots {
+			candidates = append(candidates, Event{
+				Type:      EventShot,
+				Index:     i,
+				Frame:     shot.Frame,
+				Player:    shot.ShooterName,
+				SteamID64: shot.ShooterSteamID64,
+				Team:      teamOf(m, shot.Frame, shot.ShooterSteamID64),
+				Weapon:    shot.Weapon,
+				Position:  shot.Position,
+			})
+		}
+	}
+	if q.Type == EventAny || q.Type == EventPlant {
+		for i, plant := range m.BombPlants {
+			candidates = append(candidates, Event{
+				Type:     EventPlant,
+				Index:    i,
+				Frame:    plant.Frame,
+				Player:   plant.PlanterName,
+				Team:     demoinfo.TeamTerrorists,
+				Weapon:   demoinfo.EqBomb,
+				Position: plant.Position,
+			})
+		}
+	}
+
+	if q.Type == EventAny || q.Type == EventThrow {
+		for i, throw := range m.GrenadeThrows {
+			candidates = append(candidates, Event{
+				Type:      EventThrow,
+				Index:     i,
+				Frame:     throw.Frame,
+				Player:    throw.Name,
+				SteamID64: throw.SteamID64,
+				Team:      throw.Team,
+				Weapon:    throw.Grenade,
+				Position:  throw.Position,
+			})
+		}
+	}
+
+	matcher := newAreaMatcher(m, q.Area)
+	events := make([]Event, 0)
+	for _, e := range candidates {
+		e.Round = m.RoundIndex(e.Frame) + 1
+		if q.matches(e) && matcher.contains(e.Position) {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Frame < events[j].Frame })
+	return events
+}
+
+func (q Query) matches(e Event) bool {
+	return (q.Player == "" || containsFold(e.Player, q.Player)) &&
+		(q.Victim == "" || e.Type == EventKill && containsFold(e.Victim, q.Victim)) &&
+		(q.Weapon == demoinfo.EqUnknown || e.Weapon == q.Weapon) &&
+		(q.Team == demoinfo.TeamUnassigned || e.Team == q.Team) &&
+		(q.Round == 0 || e.Round == q.Round) &&
+		(!q.ThroughSmoke || e.ThroughSmoke)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// teamOf returns the team of the player at the frame.
+func teamOf(m *match.Match, frame int, steamID uint64) demoinfo.Team {
+	if frame < 0 || frame > m.LastFrame() {
+		return demoinfo.TeamUnassigned
+	}
+	for _, p := range m.StateAtFrame(frame).Players {
+		if p.SteamID64 == steamID {
+			return p.Team
+		}
+	}
+	return demoinfo.TeamUnassigned
+}
+
+// areaMatcher tells whether a position is in an area of the map.
+type areaMatcher struct {
+	sites       map[string]common.Point
+	site        string
+	chokepoints []mapinfo.Chokepoint
+	unknown     bool
+}
+
+func newAreaMatcher(m *match.Match, area string) areaMatcher {
+	if area == "" {
+		return areaMatcher{}
+	}
+	sites := stats.SiteCenters(m)
+	for site := range sites {
+		if strings.EqualFold(site, area) {
+			return areaMatcher{sites: sites, site: site}
+		}
+	}
+	info, _ := mapinfo.Lookup(m.MapName)
+	var chokepoints []mapinfo.Chokepoint
+	for _, c := range info.Chokepoints {
+		if strings.EqualFold(c.Name, area) {
+			chokepoints = append(chokepoints, c)
+		}
+	}
+	// areas that are neither a site nor a chokepoint match nothing
+	return areaMatcher{chokepoints: chokepoints, unknown: len(chokepoints) == 0}
+}
+
+func (a areaMatcher) contains(position common.Point) bool {
+	if a.unknown {
+		return false
+	}
+	if a.site != "" {
+		return stats.SiteAt(position, a.sites) == a.site
+	}
+	if len(a.chokepoints) == 0 {
+		return true
+	}
+	for _, c := range a.chokepoints {
+		if position.DistanceToSegment(c.From, c.To) <= chokepointDistance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// sprayGap is the maximum time between two shots of a player with the same
+// weapon for them to count as the same spray.
+const sprayGap = 250 * time.Millisecond
+
+// SprayBuckets are the upper bounds of the buckets that are used for the spray
+// length distribution. The last bucket contains all longer sprays.
+var SprayBuckets = []int{1, 3, 6, 10}
+
+// Accuracy contains the accuracy of a player with a weapon.
+type Accuracy struct {
+	PlayerName string
+	SteamID64  uint64
+	Weapon     demoinfo.EquipmentType
+	Shots      int
+	// Hits is the number of shots that damaged at least one enemy.
+	Hits      int
+	Headshots int
+	Sprays    int
+	// SprayDistribution contains the number of sprays per length bucket, see
+	// SprayBuckets.
+	SprayDistribution []int
+}
+
+// HitPercentage returns the share of shots that hit an enemy in percent.
+func (a Accuracy) HitPercentage() float64 {
+	if a.Shots == 0 {
+		return 0
+	}
+	return 100 * float64(a.Hits) / float64(a.Shots)
+}
+
+// HeadshotPercentage returns the share of hits that were headshots in
+// percent.
+func (a Accuracy) HeadshotPercentage() float64 {
+	if a.Hits == 0 {
+		return 0
+	}
+	return 100 * float64(a.Headshots) / float64(a.Hits)
+}
+
+// MeanSprayLength returns the mean number of shots per spray.
+func (a Accuracy) MeanSprayLength() float64 {
+	if a.Sprays == 0 {
+		return 0
+	}
+	return float64(a.Shots) / float64(a.Sprays)
+}
+
+type hitKey struct {
+	steamID64 uint64
+	weapon    demoinfo.EquipmentType
+	frame     int
+}
+
+// WeaponAccuracy correlates the shots with the damage dealt to enemies and
+// returns the accuracy of every player with every weapon they fired.
+func WeaponAccuracy(m *match.Match, opts Options) []Accuracy {
+	hits := make(map[hitKey]bool)
+	for _, hit := range m.Hits {
+		if hit.AttackerTeam == hit.VictimTeam || !opts.includeFrame(m, hit.Frame) {
+			continue
+		}
+		key := hitKey{hit.AttackerSteamID64, hit.Weapon, hit.Frame}
+		hits[key] = hits[key] || hit.IsHeadshot
+	}
+
+	accuracies := make([]Accuracy, 0)
+	indices := make(map[string]int)
+	lastShotFrames := make(map[string]int)
+	sprayLengths := make(map[string]int)
+
+	endSpray := func(key string) {
+		length := sprayLengths[key]
+		if length == 0 {
+			return
+		}
+		a := &accuracies[indices[key]]
+		a.Sprays++
+		a.SprayDistribution[sprayBucket(length)]++
+		sprayLengths[key] = 0
+	}
+
+	for _, shot := range m.FiredShots {
+		if !opts.includeFrame(m, shot.Frame) {
+			continue
+		}
+		key := fmt.Sprintf("%d/%d", shot.ShooterSteamID64, shot.Weapon)
+		i, ok := indices[key]
+		if !ok {
+			i = len(accuracies)
+			indices[key] = i
+			accuracies = append(accuracies, Accuracy{
+				PlayerName:        shot.ShooterName,
+				SteamID64:         shot.ShooterSteamID64,
+				Weapon:            shot.Weapon,
+				SprayDistribution: make([]int, len(SprayBuckets)+1),
+			})
+		}
+		a := &accuracies[i]
+		a.Shots++
+
+		// damage is usually registered in the same frame, sometimes in the next
+		for _, frame := range []int{shot.Frame, shot.Frame + 1} {
+			headshot, ok := hits[hitKey{shot.ShooterSteamID64, shot.Weapon, frame}]
+			if ok {
+				a.Hits++
+				if headshot {
+					a.Headshots++
+				}
+				delete(hits, hitKey{shot.ShooterSteamID64, shot.Weapon, frame})
+				break
+			}
+		}
+
+		if last, ok := lastShotFrames[key]; ok && durationBetween(last, shot.Frame, m) > sprayGap {
+			endSpray(key)
+		}
+		sprayLengths[key]++
+		lastShotFrames[key] = shot.Frame
+	}
+	for key := range sprayLengths {
+		endSpray(key)
+	}
+
+	sort.SliceStable(accuracies, func(i, j int) bool {
+		if accuracies[i].PlayerName != accuracies[j].PlayerName {
+			return accuracies[i].PlayerName < accuracies[j].PlayerName
+		}
+		return accuracies[i].Shots > accuracies[j].Shots
+	})
+
+	return accuracies
+}
+
+func sprayBucket(length int) int {
+	for i, bound := range SprayBuckets {
+		if length <= bound {
+			return i
+		}
+	}
+	return len(SprayBuckets)
+}
+
+// WriteAccuracy writes a human-readable summary of the weapon accuracy to w.
+func WriteAccuracy(w io.Writer, accuracies []Accuracy) error {
+	_, err := fmt.Fprintln(w, "Accuracy")
+	if err != nil {
+		return err
+	}
+	for _, a := range accuracies {
+		_, err = fmt.Fprintf(w, "%-16s %-12s %4d shots, hit %3.0f%%, headshot %3.0f%%, spray mean %4.1f %v\n",
+			cropString(a.PlayerName, 16), a.Weapon, a.Shots, a.HitPercentage(), a.HeadshotPercentage(),
+			a.MeanSprayLength(), a.SprayDistribution)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
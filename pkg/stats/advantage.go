@@ -0,0 +1,174 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// defaultTeamSize is the number of players per team that is assumed for
+// matches without states.
+const defaultTeamSize = 5
+
+// AliveCount is the number of alive players of both teams from Frame on.
+type AliveCount struct {
+	Frame             int
+	CounterTerrorists int
+	Terrorists        int
+}
+
+// Differential returns how many more counter-terrorists than terrorists are
+// alive, negative if the terrorists have more players.
+func (c AliveCount) Differential() int {
+	return c.CounterTerrorists - c.Terrorists
+}
+
+// RoundAliveCounts returns the alive counts of the round with the given index,
+// starting at the end of the freezetime or, if it did not end, at the start of
+// the round, with a new count after every kill until the round ended. The
+// players at the start are counted in the states of the match, without
+// states both teams are assumed to have five players.
+func RoundAliveCounts(m *match.Match, i int) []AliveCount {
+	round := m.Rounds[i]
+	start := round.FreezetimeEndFrame
+	if start < 0 {
+		start = round.StartFrame
+	}
+	end := round.EndFrame
+	if end < 0 {
+		end = m.LastFrame()
+		if i+1 < len(m.Rounds) {
+			end = m.Rounds[i+1].StartFrame
+		}
+	}
+
+	count := AliveCount{Frame: start, CounterTerrorists: defaultTeamSize, Terrorists: defaultTeamSize}
+	if m.LastFrame() >= 0 {
+		count.CounterTerrorists, count.Terrorists = 0, 0
+		for _, p := range m.StateAtFrame(start).Players {
+			if !p.IsAlive {
+				continue
+			}
+			switch p.Team {
+			case demoinfo.TeamCounterTerrorists:
+				count.CounterTerrorists++
+			case demoinfo.TeamTerrorists:
+				count.Terrorists++
+			}
+		}
+	}
+	counts := []AliveCount{count}
+	for _, kill := range m.Kills {
+		if kill.Frame < start || (end >= 0 && kill.Frame > end) {
+			continue
+		}
+		count.Frame = kill.Frame
+		switch {
+		case kill.VictimTeam == demoinfo.TeamCounterTerrorists && count.CounterTerrorists > 0:
+			count.CounterTerrorists--
+		case kill.VictimTeam == demoinfo.TeamTerrorists && count.Terrorists > 0:
+			count.Terrorists--
+		default:
+			continue
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// ManAdvantage is a situation in which the team on Side had more players
+// alive than their enemies, e.g. a 5v4 after the opening kill. Rounds is the
+// number of rounds in which the situation occurred and Wins how many of
+// them the team on Side won.
+type ManAdvantage struct {
+	Side    demoinfo.Team
+	Players int
+	Enemies int
+	Rounds  int
+	Wins    int
+}
+
+// ConversionRate returns the share of rounds with the advantage that were won,
+// ranging from 0 to 1.
+func (a ManAdvantage) ConversionRate() float64 {
+	if a.Rounds == 0 {
+		return 0
+	}
+	return float64(a.Wins) / float64(a.Rounds)
+}
+
+// ManAdvantages counts for every situation with a man advantage in how many
+// rounds it occurred and how often the team with the advantage won the round.
+// A situation is only counted once per round, even if it occurs again after
+// trades.
+func ManAdvantages(m *match.Match, opts Options) []ManAdvantage {
+	type situation struct {
+		side             demoinfo.Team
+		players, enemies int
+	}
+	bySituation := make(map[situation]*ManAdvantage)
+	advantages := make([]*ManAdvantage, 0)
+	for i, round := range m.Rounds {
+		if round.Winner == demoinfo.TeamUnassigned || !opts.includeFrame(m, round.StartFrame) {
+			continue
+		}
+		seen := make(map[situation]bool)
+		for _, count := range RoundAliveCounts(m, i) {
+			s := situation{demoinfo.TeamCounterTerrorists, count.CounterTerrorists, count.Terrorists}
+			if count.Terrorists > count.CounterTerrorists {
+				s = situation{demoinfo.TeamTerrorists, count.Terrorists, count.CounterTerrorists}
+			}
+			if s.players == s.enemies || s.enemies == 0 || seen[s] {
+				continue
+			}
+			seen[s] = true
+			advantage, ok := bySituation[s]
+			if !ok {
+				advantage = &ManAdvantage{Side: s.side, Players: s.players, Enemies: s.enemies}
+				bySituation[s] = advantage
+				advantages = append(advantages, advantage)
+			}
+			advantage.Rounds++
+			if round.Winner == s.side {
+				advantage.Wins++
+			}
+		}
+	}
+
+	sort.Slice(advantages, func(i, j int) bool {
+		a, b := advantages[i], advantages[j]
+		if a.Players != b.Players {
+			return a.Players > b.Players
+		}
+		if a.Enemies != b.Enemies {
+			return a.Enemies > b.Enemies
+		}
+		return a.Side == demoinfo.TeamCounterTerrorists && b.Side != demoinfo.TeamCounterTerrorists
+	})
+	result := make([]ManAdvantage, len(advantages))
+	for i, advantage := range advantages {
+		result[i] = *advantage
+	}
+	return result
+}
+
+// WriteManAdvantages writes how often each man advantage was converted to a
+// round win to w.
+func WriteManAdvantages(w io.Writer, advantages []ManAdvantage) error {
+	_, err := fmt.Fprintln(w, "Man advantages")
+	if err != nil {
+		return err
+	}
+	for _, a := range advantages {
+		_, err = fmt.Fprintf(w, "%dv%d %-2s converted %2d/%2d (%3.0f%%)\n",
+			a.Players, a.Enemies, sideString(a.Side), a.Wins, a.Rounds, a.ConversionRate()*100)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,217 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+const (
+	// players closer than this to the planted bomb are considered to be on
+	// the site
+	onSiteDistance float32 = 500
+	// T positions are sampled after the plant so that the planter has time
+	// to move to a post-plant position
+	postPlantPositionDelay = 5 * time.Second
+	positionSite           = "Site"
+	positionOther          = "Other"
+)
+
+// Afterplant describes a post-plant situation: where the terrorists played
+// after planting the bomb and from where the counter-terrorists retook.
+type Afterplant struct {
+	// Round is the number of the round, starting at 1.
+	Round int
+	Plant common.BombPlant
+	// TSetup and CTSetup summarize the positions of the alive players, e.g.
+	// "2 Site, 1 Palace".
+	TSetup  string
+	CTSetup string
+	TAlive  int
+	CTAlive int
+}
+
+// TWon reports whether the terrorists won the round after the plant.
+func (a Afterplant) TWon() bool {
+	return a.Plant.Winner == demoinfo.TeamTerrorists
+}
+
+// SetupSummary contains how often a setup was played on a site and how often
+// the terrorists won the round with it.
+type SetupSummary struct {
+	Site   string
+	Setup  string
+	Rounds int
+	TWins  int
+}
+
+// TWinRate returns the share of rounds the terrorists won with the setup.
+func (s SetupSummary) TWinRate() float64 {
+	if s.Rounds == 0 {
+		return 0
+	}
+	return float64(s.TWins) / float64(s.Rounds)
+}
+
+// Afterplants returns all post-plant situations of the match.
+func Afterplants(m *match.Match, opts Options) []Afterplant {
+	info, _ := mapinfo.Lookup(m.MapName)
+	afterplants := make([]Afterplant, 0, len(m.BombPlants))
+
+	for _, plant := range m.BombPlants {
+		if plant.Frame > m.LastFrame() || !opts.includeFrame(m, plant.Frame) {
+			continue
+		}
+		tFrame := plant.Frame
+		for tFrame < m.LastFrame() && durationBetween(plant.Frame, tFrame, m) < postPlantPositionDelay {
+			tFrame++
+		}
+		chokepoints := info.ChokepointsForSite(plant.Site)
+		tSetup, tAlive := setup(m.StateAtFrame(tFrame).Players, demoinfo.TeamTerrorists, plant.Position, chokepoints)
+		ctSetup, ctAlive := setup(m.StateAtFrame(plant.Frame).Players, demoinfo.TeamCounterTerrorists, plant.Position, chokepoints)
+		afterplants = append(afterplants, Afterplant{
+			Round:   m.RoundIndex(plant.Frame) + 1,
+			Plant:   plant,
+			TSetup:  tSetup,
+			CTSetup: ctSetup,
+			TAlive:  tAlive,
+			CTAlive: ctAlive,
+		})
+	}
+
+	return afterplants
+}
+
+func setup(players []common.Player, team demoinfo.Team, bomb common.Point, chokepoints []mapinfo.Chokepoint) (string, int) {
+	counts := make(map[string]int)
+	var alive int
+	for _, player := range players {
+		if player.Team != team || !player.IsAlive {
+			continue
+		}
+		alive++
+		counts[classifyPosition(player.Position, bomb, chokepoints)]++
+	}
+
+	positions := make([]string, 0, len(counts))
+	for position := range counts {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if counts[positions[i]] != counts[positions[j]] {
+			return counts[positions[i]] > counts[positions[j]]
+		}
+		return positions[i] < positions[j]
+	})
+	parts := make([]string, 0, len(positions))
+	for _, position := range positions {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[position], position))
+	}
+
+	return strings.Join(parts, ", "), alive
+}
+
+// classifyPosition returns "Site" if the position is close to the bomb and
+// otherwise the name of the nearest chokepoint of the site.
+func classifyPosition(position, bomb common.Point, chokepoints []mapinfo.Chokepoint) string {
+	if position.Distance(bomb) <= onSiteDistance {
+		return positionSite
+	}
+	nearest := positionOther
+	var nearestDistance float32
+	for _, c := range chokepoints {
+		distance := position.DistanceToSegment(c.From, c.To)
+		if nearest == positionOther || distance < nearestDistance {
+			nearest = c.Name
+			nearestDistance = distance
+		}
+	}
+	return nearest
+}
+
+// SummarizeTSetups groups the afterplants by site and T setup.
+func SummarizeTSetups(afterplants []Afterplant) []SetupSummary {
+	return summarize(afterplants, func(a Afterplant) string { return a.TSetup })
+}
+
+// SummarizeCTSetups groups the afterplants by site and CT retake setup.
+func SummarizeCTSetups(afterplants []Afterplant) []SetupSummary {
+	return summarize(afterplants, func(a Afterplant) string { return a.CTSetup })
+}
+
+func summarize(afterplants []Afterplant, key func(Afterplant) string) []SetupSummary {
+	summaries := make([]SetupSummary, 0)
+	indices := make(map[string]int)
+	for _, a := range afterplants {
+		k := a.Plant.Site + "/" + key(a)
+		i, ok := indices[k]
+		if !ok {
+			i = len(summaries)
+			indices[k] = i
+			summaries = append(summaries, SetupSummary{Site: a.Plant.Site, Setup: key(a)})
+		}
+		summaries[i].Rounds++
+		if a.TWon() {
+			summaries[i].TWins++
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Site != summaries[j].Site {
+			return summaries[i].Site < summaries[j].Site
+		}
+		return summaries[i].Rounds > summaries[j].Rounds
+	})
+	return summaries
+}
+
+// WriteAfterplants writes a human-readable summary of all post-plant
+// situations and the success rates of the setups to w.
+func WriteAfterplants(w io.Writer, afterplants []Afterplant) error {
+	_, err := fmt.Fprintln(w, "Afterplants")
+	if err != nil {
+		return err
+	}
+	for _, a := range afterplants {
+		result := "CT win"
+		if a.TWon() {
+			result = "T win"
+		}
+		_, err = fmt.Fprintf(w, "Round %2d: site %s, %dv%d, T [%s] vs CT [%s] -> %s\n",
+			a.Round, siteOrNone(a.Plant.Site), a.TAlive, a.CTAlive, a.TSetup, a.CTSetup, result)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeSetupSummaries(w, "T post-plant setups", SummarizeTSetups(afterplants))
+	if err != nil {
+		return err
+	}
+	err = writeSetupSummaries(w, "CT retake setups", SummarizeCTSetups(afterplants))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func writeSetupSummaries(w io.Writer, title string, summaries []SetupSummary) error {
+	_, err := fmt.Fprintf(w, "%s:\n", title)
+	if err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		_, err = fmt.Fprintf(w, "  %s  %-30s %2d rounds, T win rate %3.0f%%\n",
+			siteOrNone(s.Site), s.Setup, s.Rounds, s.TWinRate()*100)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// repositionDelay is the time after an AWP shot at which the position of the
+// shooter is compared to the position of the shot.
+const repositionDelay = 3 * time.Second
+
+// AWPStats contains statistics about a player with the AWP.
+type AWPStats struct {
+	PlayerName string
+	SteamID64  uint64
+	Shots      int
+	Kills      int
+	// MeanReposition is the mean distance in world units the player moved
+	// within a few seconds after a shot.
+	MeanReposition float32
+}
+
+// Conversion returns the share of AWP shots that resulted in a kill.
+func (s AWPStats) Conversion() float64 {
+	if s.Shots == 0 {
+		return 0
+	}
+	return float64(s.Kills) / float64(s.Shots)
+}
+
+// AWP returns the AWP statistics of every player that fired the AWP at least
+// once.
+func AWP(m *match.Match, opts Options) []AWPStats {
+	awpStats := make([]AWPStats, 0)
+	indices := make(map[uint64]int)
+	repositionTotals := make(map[uint64]float32)
+	repositionCounts := make(map[uint64]int)
+
+	for _, shot := range m.FiredShots {
+		if !shot.IsAwpShot || !opts.includeFrame(m, shot.Frame) {
+			continue
+		}
+		i, ok := indices[shot.ShooterSteamID64]
+		if !ok {
+			i = len(awpStats)
+			indices[shot.ShooterSteamID64] = i
+			awpStats = append(awpStats, AWPStats{
+				PlayerName: shot.ShooterName,
+				SteamID64:  shot.ShooterSteamID64,
+			})
+		}
+		awpStats[i].Shots++
+		if distance, ok := repositionDistance(shot, m); ok {
+			repositionTotals[shot.ShooterSteamID64] += distance
+			repositionCounts[shot.ShooterSteamID64]++
+		}
+	}
+
+	for _, kill := range m.Kills {
+		if kill.Weapon != demoinfo.EqAWP || kill.KillerTeam == kill.VictimTeam || !opts.includeFrame(m, kill.Frame) {
+			continue
+		}
+		if i, ok := indices[kill.KillerSteamID64]; ok {
+			awpStats[i].Kills++
+		}
+	}
+
+	for i := range awpStats {
+		id := awpStats[i].SteamID64
+		if repositionCounts[id] > 0 {
+			awpStats[i].MeanReposition = repositionTotals[id] / float32(repositionCounts[id])
+		}
+	}
+
+	sort.SliceStable(awpStats, func(i, j int) bool { return awpStats[i].Shots > awpStats[j].Shots })
+
+	return awpStats
+}
+
+func repositionDistance(shot common.Shot, m *match.Match) (float32, bool) {
+	frame := shot.Frame
+	for frame < m.LastFrame() && durationBetween(shot.Frame, frame, m) < repositionDelay {
+		frame++
+	}
+	if frame > m.LastFrame() {
+		return 0, false
+	}
+	for _, player := range m.StateAtFrame(frame).Players {
+		if player.SteamID64 == shot.ShooterSteamID64 && player.IsAlive {
+			return player.Position.Distance(shot.Position), true
+		}
+	}
+	return 0, false
+}
+
+// WriteAWP writes a human-readable summary of the AWP statistics to w.
+func WriteAWP(w io.Writer, awpStats []AWPStats) error {
+	_, err := fmt.Fprintln(w, "AWP")
+	if err != nil {
+		return err
+	}
+	for _, s := range awpStats {
+		_, err = fmt.Fprintf(w, "%-16s %3d shots, %3d kills, conversion %3.0f%%, repositions %4.0f units after a shot\n",
+			cropString(s.PlayerName, 16), s.Shots, s.Kills, s.Conversion()*100, s.MeanReposition)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// crossfireSetupDelay is the time after the end of the freezetime that the
+// counter-terrorists have to get into their positions before their setup is
+// checked.
+const crossfireSetupDelay = 20 * time.Second
+
+// EntryWatch describes how often the counter-terrorists of a team left an
+// entry path to a site unwatched.
+type EntryWatch struct {
+	// Team is the name of the counter-terrorists, see SideStats.Team.
+	Team       string
+	Chokepoint mapinfo.Chokepoint
+	// Rounds is the number of rounds in which the setup was checked and
+	// UnwatchedRounds the numbers of the rounds in which nobody watched the
+	// chokepoint for most of the time.
+	Rounds          int
+	UnwatchedRounds []int
+}
+
+// UnwatchedShare returns the share of rounds in which the chokepoint was left
+// unwatched, ranging from 0 to 1.
+func (e EntryWatch) UnwatchedShare() float64 {
+	if e.Rounds == 0 {
+		return 0
+	}
+	return float64(len(e.UnwatchedRounds)) / float64(e.Rounds)
+}
+
+// CrossfireGaps checks in every round once a second from crossfireSetupDelay
+// after the end of the freezetime until the first contact, the bomb plant or
+// the end of the round whether an alive counter-terrorist saw the chokepoints
+// that lead to the sites, see mapinfo.Info.Watches. A chokepoint counts as
+// unwatched in a round if nobody saw it in most of these checks. If there is
+// no geometric information about the map, nil is returned.
+func CrossfireGaps(m *match.Match, opts Options) []EntryWatch {
+	info, ok := mapinfo.Lookup(m.MapName)
+	if !ok || len(info.Occluders) == 0 {
+		return nil
+	}
+	chokepoints := make([]mapinfo.Chokepoint, 0)
+	for _, c := range info.Chokepoints {
+		if c.Site != "" {
+			chokepoints = append(chokepoints, c)
+		}
+	}
+
+	watches := make([]EntryWatch, 0)
+	index := make(map[string]int)
+	step := int(math.Max(math.Round(m.FrameRate), 1))
+	for _, round := range m.Rounds {
+		if round.FreezetimeEndFrame < 0 || !opts.includeFrame(m, round.StartFrame) {
+			continue
+		}
+		end := setupEndFrame(m, round.FreezetimeEndFrame, round.EndFrame)
+		setupStart := m.TimeAt(round.FreezetimeEndFrame) + crossfireSetupDelay
+		samples := 0
+		watched := make([]int, len(chokepoints))
+		for frame := round.FreezetimeEndFrame; frame <= end; frame += step {
+			if m.TimeAt(frame) < setupStart {
+				continue
+			}
+			samples++
+			for _, p := range m.StateAtFrame(frame).Players {
+				if p.Team != demoinfo.TeamCounterTerrorists || !p.IsAlive {
+					continue
+				}
+				for i, c := range chokepoints {
+					if info.Watches(p.Position, p.ViewDirectionX, c) {
+						watched[i]++
+					}
+				}
+			}
+		}
+		if samples == 0 {
+			continue
+		}
+
+		ctName, _ := teamNames(round, halfNumber(m, round.StartFrame))
+		for i, c := range chokepoints {
+			key := ctName + "\x00" + c.Name
+			j, ok := index[key]
+			if !ok {
+				j = len(watches)
+				index[key] = j
+				watches = append(watches, EntryWatch{Team: ctName, Chokepoint: c, UnwatchedRounds: make([]int, 0)})
+			}
+			watches[j].Rounds++
+			if 2*watched[i] < samples {
+				watches[j].UnwatchedRounds = append(watches[j].UnwatchedRounds, round.Number)
+			}
+		}
+	}
+	return watches
+}
+
+// setupEndFrame returns the frame of the first contact or the bomb plant after
+// freezetimeEnd, whichever comes first, or the end of the round.
+func setupEndFrame(m *match.Match, freezetimeEnd, roundEnd int) int {
+	end := roundEnd
+	if end < 0 || end > m.LastFrame() {
+		end = m.LastFrame()
+	}
+	if contact, ok := firstContact(m, freezetimeEnd, end); ok {
+		end = contact
+	}
+	for _, plant := range m.BombPlants {
+		if plant.Frame >= freezetimeEnd && plant.Frame < end {
+			end = plant.Frame
+			break
+		}
+	}
+	return end
+}
+
+// WriteCrossfireGaps writes for every team and chokepoint in how many rounds
+// the counter-terrorists left it unwatched to w.
+func WriteCrossfireGaps(w io.Writer, watches []EntryWatch) error {
+	_, err := fmt.Fprintln(w, "Unwatched entries")
+	if err != nil {
+		return err
+	}
+	for _, e := range watches {
+		_, err = fmt.Fprintf(w, "%-20s %s %-14s unwatched in %2d/%2d rounds (%3.0f%%) %v\n",
+			e.Team, e.Chokepoint.Site, e.Chokepoint.Name, len(e.UnwatchedRounds), e.Rounds,
+			e.UnwatchedShare()*100, e.UnwatchedRounds)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,183 @@
+package stats
+
+import (
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Buy is a hypothetical buy of a player in the economy simulator.
+type Buy int
+
+// Possible values for Buy.
+const (
+	// BuyActual is what the player really bought in the freezetime.
+	BuyActual Buy = iota
+	// BuyEco saves all money.
+	BuyEco
+	// BuyForce is a cheap rifle with kevlar, a Galil for the terrorists and
+	// a FAMAS for the counter-terrorists.
+	BuyForce
+	// BuyFull is a rifle, kevlar and helmet and a smoke, a flash and a
+	// molotov, and for the counter-terrorists a defuse kit.
+	BuyFull
+)
+
+func (b Buy) String() string {
+	switch b {
+	case BuyEco:
+		return "eco"
+	case BuyForce:
+		return "force"
+	case BuyFull:
+		return "full"
+	default:
+		return "actual"
+	}
+}
+
+// Next returns the buy that follows b when cycling through the buys.
+func (b Buy) Next() Buy {
+	if b == BuyFull {
+		return BuyActual
+	}
+	return b + 1
+}
+
+// Cost returns the price of the buy for a player of the given team. The
+// price of BuyActual depends on the player, see PlayerBuy.Cost.
+func (b Buy) Cost(team demoinfo.Team) int {
+	switch b {
+	case BuyForce:
+		if team == demoinfo.TeamCounterTerrorists {
+			return 2050 + 650
+		}
+		return 1800 + 650
+	case BuyFull:
+		if team == demoinfo.TeamCounterTerrorists {
+			return 3100 + 1000 + 400 + 300 + 200 + 600
+		}
+		return 2700 + 1000 + 300 + 200 + 400
+	default:
+		return 0
+	}
+}
+
+// PlayerBuy is the money of a player at the start of a round and the buy of
+// the player in the economy simulator.
+type PlayerBuy struct {
+	Name      string
+	SteamID64 uint64
+	// Money is the money of the player at the start of the round and Spent
+	// the money the player really spent in the freezetime.
+	Money int
+	Spent int
+	Buy   Buy
+}
+
+// Cost returns the money the player spends with Buy, at most Money.
+func (p PlayerBuy) Cost(team demoinfo.Team) int {
+	cost := p.Spent
+	if p.Buy != BuyActual {
+		cost = p.Buy.Cost(team)
+	}
+	if cost > p.Money {
+		return p.Money
+	}
+	return cost
+}
+
+// EconomyPlan contains the buys of the players of a team in a round, which
+// can be changed to see how much money the team would have had in the next
+// round.
+type EconomyPlan struct {
+	// Round is the number of the round, starting at 1.
+	Round      int
+	Team       demoinfo.Team
+	LossStreak int
+	Players    []PlayerBuy
+	maxMoney   int
+}
+
+// EconomyProjection is the money of a team at the start of the next round.
+type EconomyProjection struct {
+	// Money contains the money of every player in the order of
+	// EconomyPlan.Players.
+	Money []int
+	Total int
+	// LossStreak is the loss streak of the team in the next round and
+	// LossBonus the money they would receive for losing it.
+	LossStreak int
+	LossBonus  int
+	// FullBuys is the number of players who can afford BuyFull.
+	FullBuys int
+}
+
+// NewEconomyPlan returns the plan of the team in the round with the actual
+// buys of the players. The money of the players is taken from the start of
+// the round and what they spent from the end of the freezetime or, if it did
+// not end yet, from the current frame. Dropped weapons are not taken into
+// account.
+func NewEconomyPlan(m *match.Match, round common.Round, team demoinfo.Team, frame int) EconomyPlan {
+	plan := EconomyPlan{
+		Round:      round.Number,
+		Team:       team,
+		LossStreak: round.Team(team).LossStreak,
+		Players:    make([]PlayerBuy, 0),
+		maxMoney:   m.MaxMoney(),
+	}
+	end := round.FreezetimeEndFrame
+	if end < 0 || end > frame {
+		end = frame
+	}
+	after := m.StateAtFrame(end).Players
+	for _, p := range m.StateAtFrame(round.StartFrame).Players {
+		if p.Team != team {
+			continue
+		}
+		buy := PlayerBuy{Name: p.Name, SteamID64: p.SteamID64, Money: int(p.Money)}
+		if later, ok := findPlayer(after, p.SteamID64); ok && int(later.Money) < buy.Money {
+			buy.Spent = buy.Money - int(later.Money)
+		}
+		plan.Players = append(plan.Players, buy)
+	}
+	return plan
+}
+
+// LossBonus returns the money every player of the team receives if the team
+// loses the round.
+func (p EconomyPlan) LossBonus() int {
+	return match.LossBonus(p.LossStreak)
+}
+
+// Project returns the money of the team at the start of the next round if
+// it wins or loses the round with the buys of the plan. For a loss of the
+// terrorists, bombPlanted adds the bonus for planting the bomb. The rewards
+// for kills are left out, as is the rule that terrorists who survive a loss
+// by time receive no loss bonus.
+func (p EconomyPlan) Project(won, bombPlanted bool) EconomyProjection {
+	reward := match.WinReward
+	if !won {
+		reward = match.LossBonus(p.LossStreak)
+		if p.Team == demoinfo.TeamTerrorists && bombPlanted {
+			reward += match.PlantBonus
+		}
+	}
+	projection := EconomyProjection{
+		Money:      make([]int, len(p.Players)),
+		LossStreak: match.NextLossStreak(p.LossStreak, won),
+	}
+	projection.LossBonus = match.LossBonus(projection.LossStreak)
+	for i, player := range p.Players {
+		money := player.Money - player.Cost(p.Team) + reward
+		if money > p.maxMoney {
+			money = p.maxMoney
+		}
+		projection.Money[i] = money
+		projection.Total += money
+		if money >= BuyFull.Cost(p.Team) {
+			projection.FullBuys++
+		}
+	}
+	return projection
+}
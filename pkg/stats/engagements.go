@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// offAngleThreshold is the minimum angle in degrees between the view
+// direction of the victim and the killer for a kill to count as an
+// off-angle kill.
+const offAngleThreshold float32 = 45
+
+// DistanceBuckets are the upper bounds in world units of the buckets that are
+// used for the engagement distance distribution. The last bucket contains all
+// kills that are further away.
+var DistanceBuckets = []float32{500, 1000, 1500, 2000}
+
+// Engagement contains the distance and the angles of a single kill.
+type Engagement struct {
+	// Round is the number of the round, starting at 1.
+	Round          int
+	Kill           common.Kill
+	Distance       float32
+	VictimAngleOff float32
+	KillerAngleOff float32
+	// KillerPitchOff is the angle by which the crosshair of the killer was
+	// below the head of the victim, see common.Kill.KillerPitchOff.
+	KillerPitchOff float32
+}
+
+// IsOffAngle reports whether the victim was not looking at the killer.
+func (e Engagement) IsOffAngle() bool {
+	return e.VictimAngleOff >= offAngleThreshold
+}
+
+// EngagementSummary contains the engagement statistics of a player with a
+// weapon.
+type EngagementSummary struct {
+	PlayerName string
+	Weapon     demoinfo.EquipmentType
+	Kills      int
+	// Distribution contains the number of kills per distance bucket, see
+	// DistanceBuckets.
+	Distribution        []int
+	MeanDistance        float32
+	MedianDistance      float32
+	MeanVictimAngleOff  float32
+	OffAngleKills       int
+	MeanKillerPitchOff  float32
+	distances           []float32
+	victimAngleOffTotal float32
+	killerPitchOffTotal float32
+}
+
+// Engagements returns the engagement of every kill in the match that was made
+// by a player of the other team.
+func Engagements(m *match.Match, opts Options) []Engagement {
+	engagements := make([]Engagement, 0, len(m.Kills))
+	for _, kill := range m.Kills {
+		if !kill.HasKiller() || kill.KillerTeam == kill.VictimTeam || !opts.includeFrame(m, kill.Frame) {
+			continue
+		}
+		engagements = append(engagements, Engagement{
+			Round:          m.RoundIndex(kill.Frame) + 1,
+			Kill:           kill,
+			Distance:       kill.Distance(),
+			VictimAngleOff: kill.VictimAngleOff(),
+			KillerAngleOff: kill.KillerAngleOff(),
+			KillerPitchOff: kill.KillerPitchOff(),
+		})
+	}
+	return engagements
+}
+
+// engagementKey identifies an EngagementSummary.
+type engagementKey struct {
+	killerID uint64
+	weapon   demoinfo.EquipmentType
+}
+
+// SummarizeEngagements groups the engagements by killer and weapon. Killers
+// are told apart by their ID, so players with the same name are not merged.
+func SummarizeEngagements(engagements []Engagement) []EngagementSummary {
+	summaries := make([]EngagementSummary, 0)
+	indices := make(map[engagementKey]int)
+	for _, e := range engagements {
+		key := engagementKey{e.Kill.KillerID, e.Kill.Weapon}
+		i, ok := indices[key]
+		if !ok {
+			i = len(summaries)
+			indices[key] = i
+			summaries = append(summaries, EngagementSummary{
+				PlayerName:   e.Kill.KillerName,
+				Weapon:       e.Kill.Weapon,
+				Distribution: make([]int, len(DistanceBuckets)+1),
+			})
+		}
+		s := &summaries[i]
+		s.Kills++
+		s.Distribution[distanceBucket(e.Distance)]++
+		s.distances = append(s.distances, e.Distance)
+		s.victimAngleOffTotal += e.VictimAngleOff
+		s.killerPitchOffTotal += e.KillerPitchOff
+		if e.IsOffAngle() {
+			s.OffAngleKills++
+		}
+	}
+
+	for i := range summaries {
+		s := &summaries[i]
+		sort.Slice(s.distances, func(a, b int) bool { return s.distances[a] < s.distances[b] })
+		var total float32
+		for _, d := range s.distances {
+			total += d
+		}
+		s.MeanDistance = total / float32(s.Kills)
+		s.MedianDistance = s.distances[len(s.distances)/2]
+		s.MeanVictimAngleOff = s.victimAngleOffTotal / float32(s.Kills)
+		s.MeanKillerPitchOff = s.killerPitchOffTotal / float32(s.Kills)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].PlayerName != summaries[j].PlayerName {
+			return summaries[i].PlayerName < summaries[j].PlayerName
+		}
+		return summaries[i].Kills > summaries[j].Kills
+	})
+
+	return summaries
+}
+
+func distanceBucket(distance float32) int {
+	for i, bound := range DistanceBuckets {
+		if distance < bound {
+			return i
+		}
+	}
+	return len(DistanceBuckets)
+}
+
+// WriteEngagements writes a human-readable summary of the engagement
+// statistics to w.
+func WriteEngagements(w io.Writer, summaries []EngagementSummary) error {
+	_, err := fmt.Fprintln(w, "Engagements")
+	if err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		_, err = fmt.Fprintf(w, "%-16s %-12s %3d kills, distance mean %5.0f median %5.0f, %v, off-angle %d (mean %3.0f°), crosshair %+4.1f° below head\n",
+			cropString(s.PlayerName, 16), s.Weapon, s.Kills, s.MeanDistance, s.MedianDistance,
+			s.Distribution, s.OffAngleKills, s.MeanVictimAngleOff, s.MeanKillerPitchOff)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func cropString(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+func TestEngagementsKnifeRounds(t *testing.T) {
+	kill := func(frame int, weapon demoinfo.EquipmentType) common.Kill {
+		return common.Kill{
+			Frame:          frame,
+			KillerName:     "alice",
+			KillerID:       1,
+			KillerTeam:     demoinfo.TeamTerrorists,
+			VictimTeam:     demoinfo.TeamCounterTerrorists,
+			VictimPosition: common.Point{X: 300},
+			Weapon:         weapon,
+			Type:           common.KillEnemy,
+		}
+	}
+	m := &match.Match{
+		RoundStarts: []int{0, 100},
+		Rounds:      []common.Round{{Number: 1, IsKnifeRound: true}, {Number: 2}},
+		Kills:       []common.Kill{kill(10, demoinfo.EqKnife), kill(110, demoinfo.EqAK47)},
+	}
+	tests := []struct {
+		opts Options
+		want int
+	}{
+		{Options{}, 1},
+		{Options{IncludeKnifeRounds: true}, 2},
+	}
+	for _, test := range tests {
+		if got := Engagements(m, test.opts); len(got) != test.want {
+			t.Errorf("Engagements(%+v) returned %d engagements, want %d", test.opts, len(got), test.want)
+		}
+	}
+}
+
+func TestSummarizeEngagements(t *testing.T) {
+	engagement := func(name string, id uint64, weapon demoinfo.EquipmentType, distance float32) Engagement {
+		return Engagement{
+			Kill:     common.Kill{KillerName: name, KillerID: id, Weapon: weapon},
+			Distance: distance,
+		}
+	}
+	// two bots with the same name and a player with two weapons
+	summaries := SummarizeEngagements([]Engagement{
+		engagement("BOT Eli", 1<<63|2, demoinfo.EqAK47, 400),
+		engagement("BOT Eli", 1<<63|3, demoinfo.EqAK47, 1200),
+		engagement("alice", 1, demoinfo.EqAWP, 2500),
+		engagement("alice", 1, demoinfo.EqAWP, 900),
+		engagement("alice", 1, demoinfo.EqAWP, 1700),
+		engagement("alice", 1, demoinfo.EqDeagle, 300),
+	})
+
+	want := []struct {
+		name   string
+		weapon demoinfo.EquipmentType
+		kills  int
+		median float32
+	}{
+		{"BOT Eli", demoinfo.EqAK47, 1, 400},
+		{"BOT Eli", demoinfo.EqAK47, 1, 1200},
+		{"alice", demoinfo.EqAWP, 3, 1700},
+		{"alice", demoinfo.EqDeagle, 1, 300},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("SummarizeEngagements() returned %d summaries, want %d: %+v", len(summaries), len(want), summaries)
+	}
+	for i, w := range want {
+		s := summaries[i]
+		if s.PlayerName != w.name || s.Weapon != w.weapon || s.Kills != w.kills || s.MedianDistance != w.median {
+			t.Errorf("summary %d = %v %v %d kills median %v, want %v %v %d kills median %v",
+				i, s.PlayerName, s.Weapon, s.Kills, s.MedianDistance, w.name, w.weapon, w.kills, w.median)
+		}
+	}
+	// buckets below 500, 1000, 1500, 2000 and above
+	if got := summaries[2].Distribution; len(got) != 5 || got[1] != 1 || got[3] != 1 || got[4] != 1 {
+		t.Errorf("Distribution of the AWP = %v, want [0 1 0 1 1]", got)
+	}
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"math"
+	"sort"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// entryCellSize is the size of the cells of the grid the paths of the
+// terrorists are aggregated on, in world units.
+const entryCellSize float32 = 300
+
+// EntryFlow is a step from one cell of a grid over the map to a neighbouring
+// one that terrorists took on their way to a site.
+type EntryFlow struct {
+	// Site is the site the bomb was planted at in the rounds.
+	Site string
+	// From and To are the centers of the cells in world coordinates.
+	From common.Point
+	To   common.Point
+	// Players is the number of terrorists that took the step, every player
+	// is counted once per round.
+	Players int
+}
+
+type entryCell struct {
+	X, Y int
+}
+
+type entryStep struct {
+	site     string
+	player   uint64
+	from, to entryCell
+}
+
+// EntryFlows aggregates the paths of the terrorists from the end of the
+// freezetime until the bomb was planted in all rounds with a plant. The
+// flows are sorted by site and by the number of players, starting with the
+// most common.
+func EntryFlows(m *match.Match, opts Options) []EntryFlow {
+	counts := make(map[entryStep]int)
+	for _, plant := range m.BombPlants {
+		if plant.Site == "" || plant.Frame > m.LastFrame() || !opts.includeFrame(m, plant.Frame) {
+			continue
+		}
+		i := m.RoundIndex(plant.Frame)
+		if i < 0 || m.Rounds[i].FreezetimeEndFrame < 0 || m.Rounds[i].FreezetimeEndFrame > plant.Frame {
+			continue
+		}
+		for step := range entrySteps(m, plant.Site, m.Rounds[i].FreezetimeEndFrame, plant.Frame) {
+			step.player = 0
+			counts[step]++
+		}
+	}
+
+	flows := make([]EntryFlow, 0, len(counts))
+	for step, players := range counts {
+		flows = append(flows, EntryFlow{
+			Site:    step.site,
+			From:    cellCenter(step.from),
+			To:      cellCenter(step.to),
+			Players: players,
+		})
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		a, b := flows[i], flows[j]
+		if a.Site != b.Site {
+			return a.Site < b.Site
+		}
+		if a.Players != b.Players {
+			return a.Players > b.Players
+		}
+		if a.From != b.From {
+			return a.From.X < b.From.X || a.From.X == b.From.X && a.From.Y < b.From.Y
+		}
+		return a.To.X < b.To.X || a.To.X == b.To.X && a.To.Y < b.To.Y
+	})
+	return flows
+}
+
+// entrySteps returns the steps between the cells that every terrorist took
+// between start and end.
+func entrySteps(m *match.Match, site string, start, end int) map[entryStep]bool {
+	steps := make(map[entryStep]bool)
+	cells := make(map[uint64]entryCell)
+	for frame := start; frame <= end; frame++ {
+		for _, p := range m.StateAtFrame(frame).Players {
+			if p.Team != demoinfo.TeamTerrorists || !p.IsAlive {
+				continue
+			}
+			cell := cellAt(p.Position)
+			last, ok := cells[p.SteamID64]
+			cells[p.SteamID64] = cell
+			if !ok || last == cell {
+				continue
+			}
+			// teleports, e.g. at the start of the round, are no steps
+			if abs(cell.X-last.X) > 1 || abs(cell.Y-last.Y) > 1 {
+				continue
+			}
+			steps[entryStep{site: site, player: p.SteamID64, from: last, to: cell}] = true
+		}
+	}
+	return steps
+}
+
+func cellAt(position common.Point) entryCell {
+	return entryCell{
+		X: int(math.Floor(float64(position.X / entryCellSize))),
+		Y: int(math.Floor(float64(position.Y / entryCellSize))),
+	}
+}
+
+func cellCenter(cell entryCell) common.Point {
+	return common.Point{
+		X: (float32(cell.X) + 0.5) * entryCellSize,
+		Y: (float32(cell.Y) + 0.5) * entryCellSize,
+	}
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
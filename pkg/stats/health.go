@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// HealthInterval is the time between two samples of a HealthSeries.
+const HealthInterval = 250 * time.Millisecond
+
+// HealthSeries is the health of a player during a round, sampled every
+// HealthInterval. Dead players and players who left have 0 health.
+type HealthSeries struct {
+	// ID identifies the player like common.Player.ID.
+	ID        uint64
+	Name      string
+	SteamID64 uint64
+	Team      demoinfo.Team
+	Health    []uint8
+}
+
+// RoundHealth contains the health of all players during a round. The sample
+// with the index i is taken at the frame StartFrame + i*FrameStep.
+type RoundHealth struct {
+	// Round is the number of the round, starting at 1.
+	Round      int
+	StartFrame int
+	FrameStep  int
+	// Players are sorted by team, counter-terrorists first, and by SteamID.
+	Players []HealthSeries
+}
+
+// Samples returns the number of samples of every series.
+func (r RoundHealth) Samples() int {
+	if len(r.Players) == 0 {
+		return 0
+	}
+	return len(r.Players[0].Health)
+}
+
+// SampleAt returns the index of the last sample at or before the frame,
+// -1 if the frame is before the round.
+func (r RoundHealth) SampleAt(frame int) int {
+	if frame < r.StartFrame || r.FrameStep <= 0 {
+		return -1
+	}
+	i := (frame - r.StartFrame) / r.FrameStep
+	if i >= r.Samples() {
+		return r.Samples() - 1
+	}
+	return i
+}
+
+// Time returns the time since the start of the round at the sample with the
+// given index.
+func (r RoundHealth) Time(i int) time.Duration {
+	return time.Duration(i) * HealthInterval
+}
+
+// RoundHealthSeries samples the health of every player who was in the game at
+// the start of the round with the given index from the start of the round
+// until it ended or, if it did not end, until the next round starts. The
+// match must have states.
+func RoundHealthSeries(m *match.Match, i int) RoundHealth {
+	round := m.Rounds[i]
+	end := round.EndFrame
+	if end < 0 {
+		end = m.LastFrame()
+		if i+1 < len(m.Rounds) {
+			end = m.Rounds[i+1].StartFrame
+		}
+	}
+	health := RoundHealth{
+		Round:      round.Number,
+		StartFrame: round.StartFrame,
+		FrameStep:  int(math.Max(math.Round(m.FrameRate*HealthInterval.Seconds()), 1)),
+		Players:    make([]HealthSeries, 0, 10),
+	}
+	for _, p := range m.StateAtFrame(round.StartFrame).Players {
+		if p.Team != demoinfo.TeamCounterTerrorists && p.Team != demoinfo.TeamTerrorists {
+			continue
+		}
+		health.Players = append(health.Players, HealthSeries{ID: p.ID, Name: p.Name, SteamID64: p.SteamID64, Team: p.Team})
+	}
+	sort.Slice(health.Players, func(i, j int) bool {
+		a, b := health.Players[i], health.Players[j]
+		if a.Team != b.Team {
+			return a.Team == demoinfo.TeamCounterTerrorists
+		}
+		return a.ID < b.ID
+	})
+
+	for frame := round.StartFrame; frame <= end; frame += health.FrameStep {
+		players := m.StateAtFrame(frame).Players
+		for j := range health.Players {
+			var hp uint8
+			for _, p := range players {
+				if p.ID == health.Players[j].ID && p.IsAlive && p.Health > 0 {
+					hp = uint8(math.Min(float64(p.Health), math.MaxUint8))
+				}
+			}
+			health.Players[j].Health = append(health.Players[j].Health, hp)
+		}
+	}
+	return health
+}
+
+// HealthByRound returns the health series of every round. It is empty for
+// matches without states.
+func HealthByRound(m *match.Match, opts Options) []RoundHealth {
+	rounds := make([]RoundHealth, 0, len(m.Rounds))
+	if m.LastFrame() < 0 {
+		return rounds
+	}
+	for i, round := range m.Rounds {
+		if !opts.includeFrame(m, round.StartFrame) {
+			continue
+		}
+		rounds = append(rounds, RoundHealthSeries(m, i))
+	}
+	return rounds
+}
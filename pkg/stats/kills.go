@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// PlayerKills contains the kills and deaths of a player counted like on the
+// scoreboard of the game: team kills and suicides subtract one kill, every
+// death counts, including fall damage and the bomb. BlindKills, NoScopes and
+// SmokeKills are the kills of enemies while the player was flashed, with a
+// sniper rifle without zooming in and through smokes.
+type PlayerKills struct {
+	Name       string
+	SteamID64  uint64
+	Kills      int
+	Deaths     int
+	TeamKills  int
+	Suicides   int
+	BlindKills int
+	NoScopes   int
+	SmokeKills int
+}
+
+// KillsAndDeaths returns the kills and deaths of all players in the order of
+// their first kill or death.
+func KillsAndDeaths(m *match.Match, opts Options) []PlayerKills {
+	var players []PlayerKills
+	indices := make(map[string]int)
+	player := func(name string, steamID64 uint64) *PlayerKills {
+		// bots have no Steam ID and are told apart by their names
+		key := fmt.Sprintf("%d/%s", steamID64, name)
+		if steamID64 != 0 {
+			key = fmt.Sprintf("%d", steamID64)
+		}
+		i, ok := indices[key]
+		if !ok {
+			i = len(players)
+			indices[key] = i
+			players = append(players, PlayerKills{Name: name, SteamID64: steamID64})
+		}
+		return &players[i]
+	}
+	for _, kill := range m.Kills {
+		if !opts.includeFrame(m, kill.Frame) || kill.VictimTeam == demoinfo.TeamUnassigned {
+			continue
+		}
+		switch kill.Type {
+		case common.KillEnemy:
+			killer := player(kill.KillerName, kill.KillerSteamID64)
+			killer.Kills++
+			if kill.KillerBlind {
+				killer.BlindKills++
+			}
+			if kill.IsNoScope {
+				killer.NoScopes++
+			}
+			if kill.ThroughSmoke {
+				killer.SmokeKills++
+			}
+		case common.KillTeam:
+			killer := player(kill.KillerName, kill.KillerSteamID64)
+			killer.Kills--
+			killer.TeamKills++
+		case common.KillSuicide:
+			killer := player(kill.KillerName, kill.KillerSteamID64)
+			killer.Kills--
+			killer.Suicides++
+		}
+		player(kill.VictimName, kill.VictimSteamID64).Deaths++
+	}
+	return players
+}
+
+// WriteKillsAndDeaths writes the kills, deaths, team kills, suicides, blind
+// kills, no-scopes and kills through smokes of every player to w.
+func WriteKillsAndDeaths(w io.Writer, players []PlayerKills) error {
+	_, err := fmt.Fprintln(w, "Kills and deaths")
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		_, err = fmt.Fprintf(w, "%-20s K %3d  D %3d  team kills %d  suicides %d  blind kills %d  no-scopes %d  smoke kills %d\n",
+			p.Name, p.Kills, p.Deaths, p.TeamKills, p.Suicides, p.BlindKills, p.NoScopes, p.SmokeKills)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// MultiKill is a player that killed three or more enemies in a round.
+type MultiKill struct {
+	// Round is the number of the round, starting at 1.
+	Round int
+	common.MultiKill
+}
+
+// MultiKills returns the multi-kills of all rounds in the order of the
+// rounds.
+func MultiKills(m *match.Match, opts Options) []MultiKill {
+	var multiKills []MultiKill
+	for _, round := range m.Rounds {
+		if round.IsKnifeRound && !opts.IncludeKnifeRounds {
+			continue
+		}
+		for _, k := range round.MultiKills {
+			multiKills = append(multiKills, MultiKill{
+				Round:     round.Number,
+				MultiKill: k,
+			})
+		}
+	}
+	return multiKills
+}
+
+// WriteMultiKills writes the multi-kills of every round to w, followed by the
+// number of 3k, 4k and ace rounds of every player.
+func WriteMultiKills(w io.Writer, multiKills []MultiKill) error {
+	_, err := fmt.Fprintln(w, "Multi-kills")
+	if err != nil {
+		return err
+	}
+	var players []uint64
+	names := make(map[uint64]string)
+	counts := make(map[uint64]*[3]int)
+	for _, k := range multiKills {
+		_, err = fmt.Fprintf(w, "Round %2d: %-20s %s\n", k.Round, k.PlayerName, k)
+		if err != nil {
+			return err
+		}
+		if counts[k.SteamID64] == nil {
+			players = append(players, k.SteamID64)
+			names[k.SteamID64] = k.PlayerName
+			counts[k.SteamID64] = new([3]int)
+		}
+		switch {
+		case k.IsAce():
+			counts[k.SteamID64][2]++
+		case k.Kills == 4:
+			counts[k.SteamID64][1]++
+		default:
+			counts[k.SteamID64][0]++
+		}
+	}
+	for _, id := range players {
+		c := counts[id]
+		_, err = fmt.Fprintf(w, "%-20s 3k %d, 4k %d, ace %d\n", names[id], c[0], c[1], c[2])
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,189 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Rotation is a counter-terrorist moving from one bombsite to another after
+// the first contact of the round.
+type Rotation struct {
+	// Round is the number of the round, starting at 1.
+	Round      int
+	PlayerName string
+	SteamID64  uint64
+	// Team is the name of the counter-terrorists, see SideStats.Team.
+	Team string
+	// From is the site the player was at or last visited before the first
+	// contact, To the site the player rotated to.
+	From string
+	To   string
+	// ContactFrame is the frame of the first kill of the round, StartFrame
+	// the frame the player left From and EndFrame the frame the player
+	// arrived at To.
+	ContactFrame int
+	StartFrame   int
+	EndFrame     int
+	// Start is the time from the end of the freezetime until the player left
+	// From, Reaction the time from the first contact until then and Duration
+	// the time the player needed to get to To.
+	Start    time.Duration
+	Reaction time.Duration
+	Duration time.Duration
+}
+
+// Rotations returns the rotations of the counter-terrorists in all rounds.
+// The first kill after the freezetime counts as first contact. Like the
+// strategies, the sites are located by the bomb plants of the match, so
+// there are no rotations in matches with plants at only one site.
+func Rotations(m *match.Match, opts Options) []Rotation {
+	sites := SiteCenters(m)
+	rotations := make([]Rotation, 0)
+	if len(sites) < 2 {
+		return rotations
+	}
+	for _, round := range m.Rounds {
+		if round.FreezetimeEndFrame < 0 || !opts.includeFrame(m, round.StartFrame) {
+			continue
+		}
+		end := round.EndFrame
+		if end < 0 || end > m.LastFrame() {
+			end = m.LastFrame()
+		}
+		contact, ok := firstContact(m, round.FreezetimeEndFrame, end)
+		if !ok {
+			continue
+		}
+		ctName, _ := teamNames(round, halfNumber(m, round.StartFrame))
+		for _, p := range m.StateAtFrame(contact).Players {
+			if p.Team != demoinfo.TeamCounterTerrorists || !p.IsAlive {
+				continue
+			}
+			rotation, ok := rotation(m, sites, p.SteamID64, round.FreezetimeEndFrame, contact, end)
+			if !ok {
+				continue
+			}
+			rotation.Round = round.Number
+			rotation.PlayerName = p.Name
+			rotation.SteamID64 = p.SteamID64
+			rotation.Team = ctName
+			rotations = append(rotations, rotation)
+		}
+	}
+	return rotations
+}
+
+// firstContact returns the frame of the first kill between start and end.
+func firstContact(m *match.Match, start, end int) (int, bool) {
+	for _, kill := range m.Kills {
+		if kill.Frame >= start && kill.Frame <= end && kill.HasKiller() && kill.KillerTeam != kill.VictimTeam {
+			return kill.Frame, true
+		}
+	}
+	return 0, false
+}
+
+// rotation looks for the player leaving the site they were at or last
+// visited before contact and arriving at another site before end.
+func rotation(m *match.Match, sites map[string]common.Point, steamID uint64, freezetimeEnd, contact, end int) (Rotation, bool) {
+	var from string
+	for frame := contact; frame >= freezetimeEnd && from == ""; frame-- {
+		p, ok := findPlayer(m.StateAtFrame(frame).Players, steamID)
+		if ok && p.IsAlive {
+			from = SiteAt(p.Position, sites)
+		}
+	}
+	if from == "" {
+		return Rotation{}, false
+	}
+
+	start := contact
+	for frame := contact; frame <= end; frame++ {
+		p, ok := findPlayer(m.StateAtFrame(frame).Players, steamID)
+		if !ok || !p.IsAlive {
+			return Rotation{}, false
+		}
+		site := SiteAt(p.Position, sites)
+		if site == from {
+			start = frame
+			continue
+		}
+		if site == "" {
+			continue
+		}
+		return Rotation{
+			From:         from,
+			To:           site,
+			ContactFrame: contact,
+			StartFrame:   start,
+			EndFrame:     frame,
+			Start:        durationBetween(freezetimeEnd, start, m),
+			Reaction:     durationBetween(contact, start, m),
+			Duration:     durationBetween(start, frame, m),
+		}, true
+	}
+	return Rotation{}, false
+}
+
+// SiteAt returns the nearest of the sites, see SiteCenters, that is at most
+// 1000 units away from the position or an empty string.
+func SiteAt(position common.Point, sites map[string]common.Point) string {
+	var nearest string
+	var nearestDistance float32
+	for _, site := range sortedSites(sites) {
+		distance := position.Distance(sites[site])
+		if distance <= strategySiteDistance && (nearest == "" || distance < nearestDistance) {
+			nearest = site
+			nearestDistance = distance
+		}
+	}
+	return nearest
+}
+
+func findPlayer(players []common.Player, steamID uint64) (common.Player, bool) {
+	for _, p := range players {
+		if p.SteamID64 == steamID {
+			return p, true
+		}
+	}
+	return common.Player{}, false
+}
+
+// WriteRotations writes all rotations and the mean duration of the rotations
+// of every team to w.
+func WriteRotations(w io.Writer, rotations []Rotation) error {
+	_, err := fmt.Fprintln(w, "CT rotations")
+	if err != nil {
+		return err
+	}
+	var teams []string
+	sums := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, r := range rotations {
+		_, err = fmt.Fprintf(w, "Round %2d: %-20s %s -> %s, left at %v (%v after contact), took %v\n",
+			r.Round, r.PlayerName, r.From, r.To, r.Start.Round(time.Second), r.Reaction.Round(100*time.Millisecond),
+			r.Duration.Round(100*time.Millisecond))
+		if err != nil {
+			return err
+		}
+		if counts[r.Team] == 0 {
+			teams = append(teams, r.Team)
+		}
+		sums[r.Team] += r.Duration
+		counts[r.Team]++
+	}
+	for _, team := range teams {
+		mean := sums[team] / time.Duration(counts[team])
+		_, err = fmt.Fprintf(w, "%-20s %d rotations, %v on average\n", team, counts[team], mean.Round(100*time.Millisecond))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// WriteRounds writes the winner, the win type and the economy of both teams
+// for every round to w, including the value of the equipment the survivors
+// saved for the next round.
+func WriteRounds(w io.Writer, rounds []common.Round) error {
+	_, err := fmt.Fprintln(w, "Rounds")
+	if err != nil {
+		return err
+	}
+	for _, r := range rounds {
+		if r.IsKnifeRound {
+			_, err = fmt.Fprintf(w, "Round %2d: knife round won by %s\n", r.Number, sideString(r.Winner))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		_, err = fmt.Fprintf(w, "Round %2d: %-2s win by %-11s CT equipment %6d (loss bonus %d, saved %5d), T equipment %6d (loss bonus %d, saved %5d)\n",
+			r.Number, sideString(r.Winner), r.WinType, r.CounterTerrorists.EquipmentValue,
+			r.CounterTerrorists.LossBonus, r.CounterTerrorists.SavedValue, r.Terrorists.EquipmentValue,
+			r.Terrorists.LossBonus, r.Terrorists.SavedValue)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func sideString(team demoinfo.Team) string {
+	switch team {
+	case demoinfo.TeamTerrorists:
+		return "T"
+	case demoinfo.TeamCounterTerrorists:
+		return "CT"
+	default:
+		return "-"
+	}
+}
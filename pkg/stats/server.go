@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// WriteServer writes the server info, the convars, the rounds whose timers
+// do not match the convars and the gaps of the demo to w.
+func WriteServer(w io.Writer, m *match.Match) error {
+	_, err := fmt.Fprintf(w, "Server: %s (network protocol %d, %.0f tick), recorded by %s\n",
+		m.Server.Name, m.Server.NetworkProtocol, m.Server.TickRate, m.Server.ClientName)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range m.ConVarNames() {
+		value, _ := m.ConVar(name)
+		_, err = fmt.Fprintf(w, "%-32s %s\n", name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, warning := range m.TimerWarnings() {
+		_, err = fmt.Fprintln(w, "Warning:", warning)
+		if err != nil {
+			return err
+		}
+	}
+	for _, gap := range m.Gaps {
+		_, err = fmt.Fprintf(w, "Gap: round %d at %v (frame %d), %d ticks (%v) missing\n",
+			m.RoundIndex(gap.StartFrame)+1, m.TimeAt(gap.StartFrame).Round(time.Second), gap.StartFrame,
+			gap.MissingTicks, gap.Duration.Round(time.Millisecond))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+const (
+	// ecoEquipmentValue is the equipment value below which a team is
+	// considered to be on an eco round.
+	ecoEquipmentValue int = 5000
+	// regulationHalves is the number of halves before overtime.
+	regulationHalves int = 2
+)
+
+// SideStats contains the results of a team on one side.
+type SideStats struct {
+	// Team is the clan name of the team or "Team A" for the team that
+	// started as counter-terrorists and "Team B" for the other team if the
+	// demo contains no clan names.
+	Team               string
+	Side               demoinfo.Team
+	RoundsPlayed       int
+	RoundsWon          int
+	PistolRoundsPlayed int
+	PistolRoundsWon    int
+	// AntiEcoRounds are the rounds in which the opponent was on an eco round
+	// but the team was not, AntiEcoLosses are those of them the team lost.
+	AntiEcoRounds int
+	AntiEcoLosses int
+}
+
+// WinRate returns the share of rounds won on the side.
+func (s SideStats) WinRate() float64 {
+	if s.RoundsPlayed == 0 {
+		return 0
+	}
+	return float64(s.RoundsWon) / float64(s.RoundsPlayed)
+}
+
+// SideBreakdown splits the rounds of both teams by the side they played on.
+// Pistol rounds are the first rounds of the two regulation halves.
+func SideBreakdown(m *match.Match, opts Options) []SideStats {
+	byKey := make(map[string]*SideStats)
+	get := func(team string, side demoinfo.Team) *SideStats {
+		key := fmt.Sprintf("%s/%d", team, side)
+		if s, ok := byKey[key]; ok {
+			return s
+		}
+		s := &SideStats{Team: team, Side: side}
+		byKey[key] = s
+		return s
+	}
+
+	pistolHalves := make(map[int]bool)
+	for _, round := range m.Rounds {
+		if round.EndFrame < 0 || round.Winner == demoinfo.TeamUnassigned || !opts.includeFrame(m, round.StartFrame) {
+			continue
+		}
+		half := halfNumber(m, round.StartFrame)
+		isPistol := half <= regulationHalves && !pistolHalves[half]
+		pistolHalves[half] = true
+
+		ctName, tName := teamNames(round, half)
+		for _, side := range []demoinfo.Team{demoinfo.TeamCounterTerrorists, demoinfo.TeamTerrorists} {
+			name, own, opponent := ctName, round.CounterTerrorists, round.Terrorists
+			if side == demoinfo.TeamTerrorists {
+				name, own, opponent = tName, round.Terrorists, round.CounterTerrorists
+			}
+			s := get(name, side)
+			won := round.Winner == side
+			s.RoundsPlayed++
+			if won {
+				s.RoundsWon++
+			}
+			if isPistol {
+				s.PistolRoundsPlayed++
+				if won {
+					s.PistolRoundsWon++
+				}
+			} else if isAntiEco(own, opponent) {
+				s.AntiEcoRounds++
+				if !won {
+					s.AntiEcoLosses++
+				}
+			}
+		}
+	}
+
+	sides := make([]SideStats, 0, len(byKey))
+	for _, s := range byKey {
+		sides = append(sides, *s)
+	}
+	sort.Slice(sides, func(i, j int) bool {
+		if sides[i].Team != sides[j].Team {
+			return sides[i].Team < sides[j].Team
+		}
+		return sides[i].Side == demoinfo.TeamCounterTerrorists
+	})
+	return sides
+}
+
+// halfNumber returns the number of the half the frame belongs to or 1 if the
+// demo contains no halves.
+func halfNumber(m *match.Match, frame int) int {
+	i := m.HalfIndex(frame)
+	if i < 0 {
+		return 1
+	}
+	return m.Halves[i].Number
+}
+
+// teamNames returns the names of the counter-terrorists and the terrorists in
+// the round. Without clan names the teams are told apart by the half: they
+// switch sides at the start of the second half of regulation and of every
+// overtime, but keep their sides when an overtime starts.
+func teamNames(round common.Round, half int) (string, string) {
+	if round.CounterTerrorists.ClanName != "" && round.Terrorists.ClanName != "" {
+		return round.CounterTerrorists.ClanName, round.Terrorists.ClanName
+	}
+	if (half/2)%2 == 0 {
+		return "Team A", "Team B"
+	}
+	return "Team B", "Team A"
+}
+
+func isAntiEco(own, opponent common.RoundTeam) bool {
+	return opponent.EquipmentValue < ecoEquipmentValue && own.EquipmentValue >= ecoEquipmentValue
+}
+
+// WriteSides writes the results of both teams per side to w.
+func WriteSides(w io.Writer, sides []SideStats) error {
+	_, err := fmt.Fprintln(w, "Sides")
+	if err != nil {
+		return err
+	}
+	for _, s := range sides {
+		_, err = fmt.Fprintf(w, "%-20s %-2s %2d/%2d rounds won (%3.0f%%), pistols %d/%d, anti-eco losses %d/%d\n",
+			s.Team, sideString(s.Side), s.RoundsWon, s.RoundsPlayed, 100*s.WinRate(),
+			s.PistolRoundsWon, s.PistolRoundsPlayed, s.AntiEcoLosses, s.AntiEcoRounds)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,243 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// ChokepointCoverage describes how long a chokepoint was blocked by smokes
+// during a round.
+type ChokepointCoverage struct {
+	Chokepoint mapinfo.Chokepoint
+	Smokes     int
+	Duration   time.Duration
+}
+
+// RoundSmokeCoverage contains the chokepoints that were smoked off by the
+// terrorists during a round and how well the smokes of the execute worked
+// together.
+type RoundSmokeCoverage struct {
+	// Round is the number of the round, starting at 1.
+	Round       int
+	Chokepoints []ChokepointCoverage
+	// Site is the bombsite with the most chokepoints covered.
+	Site                   string
+	SiteChokepoints        int
+	SiteChokepointsCovered int
+	// SimultaneousDuration is the time during which all covered chokepoints
+	// of Site were blocked at the same time.
+	SimultaneousDuration time.Duration
+}
+
+// Quality returns the share of chokepoints leading to Site that were covered,
+// ranging from 0 to 1.
+func (c RoundSmokeCoverage) Quality() float64 {
+	if c.SiteChokepoints == 0 {
+		return 0
+	}
+	return float64(c.SiteChokepointsCovered) / float64(c.SiteChokepoints)
+}
+
+type interval struct {
+	start int
+	end   int
+}
+
+// SmokeCoverage computes for every round which chokepoints were covered by
+// smokes of the terrorists and for how long. Rounds without any covered
+// chokepoint are omitted. If there is no geometric information about the map,
+// nil is returned.
+func SmokeCoverage(m *match.Match, opts Options) []RoundSmokeCoverage {
+	info, ok := mapinfo.Lookup(m.MapName)
+	if !ok {
+		return nil
+	}
+
+	smokesByRound := make(map[int][]common.Smoke)
+	for _, smoke := range m.Smokes {
+		if smoke.ThrowerTeam != demoinfo.TeamTerrorists || !opts.includeFrame(m, smoke.StartFrame) {
+			continue
+		}
+		round := m.RoundIndex(smoke.StartFrame)
+		if round < 0 {
+			continue
+		}
+		smokesByRound[round] = append(smokesByRound[round], smoke)
+	}
+
+	rounds := make([]int, 0, len(smokesByRound))
+	for round := range smokesByRound {
+		rounds = append(rounds, round)
+	}
+	sort.Ints(rounds)
+
+	coverages := make([]RoundSmokeCoverage, 0)
+	for _, round := range rounds {
+		coverage := roundSmokeCoverage(smokesByRound[round], info, m)
+		if len(coverage.Chokepoints) == 0 {
+			continue
+		}
+		coverage.Round = round + 1
+		coverages = append(coverages, coverage)
+	}
+
+	return coverages
+}
+
+func roundSmokeCoverage(smokes []common.Smoke, info mapinfo.Info, m *match.Match) RoundSmokeCoverage {
+	var coverage RoundSmokeCoverage
+	intervalsByChokepoint := make(map[string][]interval)
+	coveredBySite := make(map[string]int)
+	durationBySite := make(map[string]time.Duration)
+
+	for _, chokepoint := range info.Chokepoints {
+		intervals := make([]interval, 0)
+		for _, smoke := range smokes {
+			if smoke.Position.DistanceToSegment(chokepoint.From, chokepoint.To) <= match.SmokeRadius {
+				intervals = append(intervals, interval{smoke.StartFrame, smoke.EndFrame})
+			}
+		}
+		if len(intervals) == 0 {
+			continue
+		}
+		smokeCount := len(intervals)
+		intervals = mergeIntervals(intervals)
+		duration := intervalsDuration(intervals, m)
+		coverage.Chokepoints = append(coverage.Chokepoints, ChokepointCoverage{
+			Chokepoint: chokepoint,
+			Smokes:     smokeCount,
+			Duration:   duration,
+		})
+		intervalsByChokepoint[chokepoint.Name] = intervals
+		if chokepoint.Site != "" {
+			coveredBySite[chokepoint.Site]++
+			durationBySite[chokepoint.Site] += duration
+		}
+	}
+
+	// the sites are sorted so that ties in coverage and duration always go
+	// to the same site
+	sites := make([]string, 0, len(coveredBySite))
+	for site := range coveredBySite {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	for _, site := range sites {
+		covered := coveredBySite[site]
+		if covered > coverage.SiteChokepointsCovered ||
+			(covered == coverage.SiteChokepointsCovered && durationBySite[site] > durationBySite[coverage.Site]) {
+			coverage.Site = site
+			coverage.SiteChokepointsCovered = covered
+		}
+	}
+	if coverage.Site == "" {
+		return coverage
+	}
+
+	siteChokepoints := info.ChokepointsForSite(coverage.Site)
+	coverage.SiteChokepoints = len(siteChokepoints)
+	var simultaneous []interval
+	first := true
+	for _, chokepoint := range siteChokepoints {
+		intervals, ok := intervalsByChokepoint[chokepoint.Name]
+		if !ok {
+			continue
+		}
+		if first {
+			simultaneous = intervals
+			first = false
+		} else {
+			simultaneous = intersectIntervals(simultaneous, intervals)
+		}
+	}
+	coverage.SimultaneousDuration = intervalsDuration(simultaneous, m)
+
+	return coverage
+}
+
+func mergeIntervals(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	merged := []interval{intervals[0]}
+	for _, iv := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if iv.start <= last.end {
+			if iv.end > last.end {
+				last.end = iv.end
+			}
+		} else {
+			merged = append(merged, iv)
+		}
+	}
+	return merged
+}
+
+func intersectIntervals(a, b []interval) []interval {
+	result := make([]interval, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start := a[i].start
+		if b[j].start > start {
+			start = b[j].start
+		}
+		end := a[i].end
+		if b[j].end < end {
+			end = b[j].end
+		}
+		if start < end {
+			result = append(result, interval{start, end})
+		}
+		if a[i].end < b[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+func intervalsDuration(intervals []interval, m *match.Match) time.Duration {
+	var duration time.Duration
+	for _, iv := range intervals {
+		duration += durationBetween(iv.start, iv.end, m)
+	}
+	return duration
+}
+
+// WriteSmokeCoverage writes a human-readable summary of the smoke coverage
+// per round to w.
+func WriteSmokeCoverage(w io.Writer, coverages []RoundSmokeCoverage) error {
+	_, err := fmt.Fprintln(w, "Execute smokes")
+	if err != nil {
+		return err
+	}
+	for _, c := range coverages {
+		_, err = fmt.Fprintf(w, "Round %2d: site %s, %d/%d chokepoints (%.0f%%), all blocked for %.1f s\n",
+			c.Round, siteOrNone(c.Site), c.SiteChokepointsCovered, c.SiteChokepoints,
+			c.Quality()*100, c.SimultaneousDuration.Seconds())
+		if err != nil {
+			return err
+		}
+		for _, chokepoint := range c.Chokepoints {
+			_, err = fmt.Fprintf(w, "          %-14s %4.1f s (%d smokes)\n",
+				chokepoint.Chokepoint.Name, chokepoint.Duration.Seconds(), chokepoint.Smokes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func siteOrNone(site string) string {
+	if site == "" {
+		return "-"
+	}
+	return site
+}
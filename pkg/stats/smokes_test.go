@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+func TestMergeAndIntersectIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []interval
+		merged    []interval
+		intersect []interval
+	}{
+		{
+			name:      "disjoint",
+			a:         []interval{{30, 40}, {0, 10}},
+			b:         []interval{{10, 30}},
+			merged:    []interval{{0, 10}, {30, 40}},
+			intersect: []interval{},
+		},
+		{
+			name:      "overlapping",
+			a:         []interval{{0, 10}, {5, 20}, {20, 25}},
+			b:         []interval{{8, 12}, {22, 30}},
+			merged:    []interval{{0, 25}},
+			intersect: []interval{{8, 12}, {22, 25}},
+		},
+		{
+			name:      "contained",
+			a:         []interval{{0, 100}, {10, 20}},
+			b:         []interval{{50, 60}},
+			merged:    []interval{{0, 100}},
+			intersect: []interval{{50, 60}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeIntervals(append([]interval(nil), test.a...))
+			if !reflect.DeepEqual(merged, test.merged) {
+				t.Errorf("mergeIntervals(%v) = %v, want %v", test.a, merged, test.merged)
+			}
+			if got := intersectIntervals(merged, test.b); !reflect.DeepEqual(got, test.intersect) {
+				t.Errorf("intersectIntervals(%v, %v) = %v, want %v", merged, test.b, got, test.intersect)
+			}
+		})
+	}
+}
+
+func TestRoundSmokeCoverageSiteTie(t *testing.T) {
+	info := mapinfo.Info{Chokepoints: []mapinfo.Chokepoint{
+		{Name: "B tunnels", Site: "B", From: common.Point{X: 1000}, To: common.Point{X: 1100}},
+		{Name: "A long", Site: "A", From: common.Point{X: 0}, To: common.Point{X: 100}},
+		{Name: "A short", Site: "A", From: common.Point{X: 0, Y: 500}, To: common.Point{X: 100, Y: 500}},
+	}}
+	smokes := []common.Smoke{
+		{Position: common.Point{X: 50}, StartFrame: 0, EndFrame: 100},
+		{Position: common.Point{X: 1050}, StartFrame: 0, EndFrame: 100},
+	}
+	// the match has no frame times, so both sites are covered equally long
+	m := &match.Match{}
+	// the order of maps is random, a tie must be broken the same way every
+	// time
+	for i := 0; i < 20; i++ {
+		coverage := roundSmokeCoverage(smokes, info, m)
+		if coverage.Site != "A" || coverage.SiteChokepointsCovered != 1 || coverage.SiteChokepoints != 2 {
+			t.Fatalf("roundSmokeCoverage() = site %q with %d of %d chokepoints, want A with 1 of 2",
+				coverage.Site, coverage.SiteChokepointsCovered, coverage.SiteChokepoints)
+		}
+	}
+}
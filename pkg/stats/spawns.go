@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Spawns returns the centers of the spawn areas of the terrorists and the
+// counter-terrorists. For maps without spawn data they are taken from the
+// positions of the players at the end of the first freezetime. ok is false
+// if they are unknown, e.g. for matches without states.
+func Spawns(m *match.Match) (t, ct common.Point, ok bool) {
+	info, known := mapinfo.Lookup(m.MapName)
+	if known {
+		return info.TSpawn, info.CTSpawn, true
+	}
+	if m.LastFrame() < 0 {
+		return common.Point{}, common.Point{}, false
+	}
+	for _, round := range m.Rounds {
+		if round.FreezetimeEndFrame < 0 {
+			continue
+		}
+		players := m.StateAtFrame(round.FreezetimeEndFrame).Players
+		t, tOK := teamCenter(players, demoinfo.TeamTerrorists)
+		ct, ctOK := teamCenter(players, demoinfo.TeamCounterTerrorists)
+		return t, ct, tOK && ctOK
+	}
+	return common.Point{}, common.Point{}, false
+}
+
+// SpawnArrivals returns the earliest times after the end of the freezetime at
+// which the terrorists and the counter-terrorists can be at position when
+// they run in a straight line from the centers of their spawns. Walls are not
+// taken into account, so the actual times are longer.
+func SpawnArrivals(m *match.Match, position common.Point) (t, ct time.Duration, ok bool) {
+	tSpawn, ctSpawn, ok := Spawns(m)
+	if !ok {
+		return 0, 0, false
+	}
+	return mapinfo.TravelTime(tSpawn.Distance(position)), mapinfo.TravelTime(ctSpawn.Distance(position)), true
+}
+
+// teamCenter returns the average position of the living players of the team.
+func teamCenter(players []common.Player, team demoinfo.Team) (common.Point, bool) {
+	var center common.Point
+	var alive int
+	for _, p := range players {
+		if p.Team != team || !p.IsAlive {
+			continue
+		}
+		center.X += p.Position.X
+		center.Y += p.Position.Y
+		alive++
+	}
+	if alive == 0 {
+		return common.Point{}, false
+	}
+	center.X /= float32(alive)
+	center.Y /= float32(alive)
+	return center, true
+}
@@ -0,0 +1,103 @@
+// Package stats contains analyses that are computed from a parsed match and
+// functions to print their results.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// Options controls which events the analyses take into account. The zero
+// value is the default.
+type Options struct {
+	// IncludeKnifeRounds takes the events of knife rounds into account,
+	// which are excluded by default.
+	IncludeKnifeRounds bool
+}
+
+// WriteReport writes the results of all analyses for the match to w.
+func WriteReport(w io.Writer, m *match.Match, opts Options) error {
+	_, err := fmt.Fprintf(w, "Map: %s\n", m.MapName)
+	if err != nil {
+		return err
+	}
+	err = writeSummary(w, m.Summary)
+	if err != nil {
+		return err
+	}
+
+	sections := []func() error{
+		func() error { return WriteServer(w, m) },
+		func() error { return WriteRounds(w, m.Rounds) },
+		func() error { return WriteKillsAndDeaths(w, KillsAndDeaths(m, opts)) },
+		func() error { return WriteMultiKills(w, MultiKills(m, opts)) },
+		func() error { return WriteManAdvantages(w, ManAdvantages(m, opts)) },
+		func() error { return WriteSides(w, SideBreakdown(m, opts)) },
+		func() error { return WriteSmokeCoverage(w, SmokeCoverage(m, opts)) },
+		func() error { return WriteAfterplants(w, Afterplants(m, opts)) },
+		func() error { return WriteStrategies(w, SummarizeStrategies(TStrategies(m, opts))) },
+		func() error { return WriteRotations(w, Rotations(m, opts)) },
+		func() error { return WriteCrossfireGaps(w, CrossfireGaps(m, opts)) },
+		func() error { return WriteEngagements(w, SummarizeEngagements(Engagements(m, opts))) },
+		func() error { return WriteAWP(w, AWP(m, opts)) },
+		func() error { return WriteAccuracy(w, WeaponAccuracy(m, opts)) },
+	}
+	for _, section := range sections {
+		err = section()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeSummary(w io.Writer, summary common.Summary) error {
+	_, err := fmt.Fprintf(w, "%s %d:%d %s after %d rounds\n",
+		clanNameOr(summary.ClanNameCounterTerrorists, "Counter Terrorists"), summary.ScoreCounterTerrorists,
+		summary.ScoreTerrorists, clanNameOr(summary.ClanNameTerrorists, "Terrorists"), summary.RoundsPlayed)
+	if err != nil {
+		return err
+	}
+	if summary.Event != nil {
+		_, err = fmt.Fprintf(w, "%s, %s (%s)\n", summary.Event.Name, summary.Event.Stage, summary.Event.URL)
+		if err != nil {
+			return err
+		}
+	}
+	if len(summary.KnifeRounds) > 0 {
+		_, err = fmt.Fprintf(w, "Knife rounds: %v\n", summary.KnifeRounds)
+		if err != nil {
+			return err
+		}
+	}
+	if summary.IsSurrendered {
+		_, err = fmt.Fprintf(w, "Surrendered by the %s\n", sideString(summary.SurrenderedTeam))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func clanNameOr(clanName, fallback string) string {
+	if clanName == "" {
+		return fallback
+	}
+	return clanName
+}
+
+// includeFrame reports whether events at the given frame are taken into
+// account by the analyses.
+func (opts Options) includeFrame(m *match.Match, frame int) bool {
+	return opts.IncludeKnifeRounds || !m.IsKnifeRound(frame)
+}
+
+func durationBetween(startFrame, endFrame int, m *match.Match) time.Duration {
+	return m.TimeAt(endFrame) - m.TimeAt(startFrame)
+}
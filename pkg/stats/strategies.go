@@ -0,0 +1,358 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+const (
+	// strategyClusters is the maximum number of strategies per team.
+	strategyClusters int = 4
+	// strategyGridSize is the number of rows and columns of the grid the
+	// positions of the terrorists are binned into to compare rounds.
+	strategyGridSize int = 8
+	// strategyIterations limits the iterations of the clustering.
+	strategyIterations int = 50
+	// terrorists closer than this to a bombsite are considered to attack it
+	strategySiteDistance float32 = 1000
+	// strategySitePlayers is the number of terrorists that have to be close
+	// to a site for a rush or an execute
+	strategySitePlayers int = 3
+	strategyDefault         = "Default"
+	overviewSize            = 1024
+)
+
+// strategyTimes are the times after the end of the freezetime at which the
+// positions of the terrorists are compared. Terrorists that are at a site at
+// the first time rushed it, at the second time executed on it.
+var strategyTimes = []time.Duration{30 * time.Second, 45 * time.Second}
+
+// StrategyRound is the strategy the terrorists played in a round.
+type StrategyRound struct {
+	// Round is the number of the round, starting at 1.
+	Round int
+	// Team is the name of the terrorists, see SideStats.Team.
+	Team string
+	// Cluster is the index of the group of rounds with similar positions of
+	// the team, Strategy is the name of the group, e.g. "Default",
+	// "A Execute" or "B Rush".
+	Cluster  int
+	Strategy string
+	TWon     bool
+}
+
+// StrategySummary contains how often a team played a strategy.
+type StrategySummary struct {
+	Team     string
+	Strategy string
+	Rounds   []int
+	TWins    int
+	// Share is the share of the T rounds of the team with the strategy.
+	Share float64
+}
+
+type strategyCandidate struct {
+	StrategyRound
+	features []float64
+	label    string
+}
+
+// TStrategies groups the T rounds of every team by the positions of the
+// terrorists 30 and 45 seconds after the freezetime with k-means and names
+// the groups after the site most of their rounds went to early (rush) or
+// later (execute). The bombsites are located by the bomb plants of the match,
+// so rounds towards a site at which the bomb was never planted count as
+// default.
+func TStrategies(m *match.Match, opts Options) []StrategyRound {
+	sites := SiteCenters(m)
+	byTeam := make(map[string][]strategyCandidate)
+	var teams []string
+	for _, round := range m.Rounds {
+		if round.FreezetimeEndFrame < 0 || round.EndFrame < 0 || round.Winner == demoinfo.TeamUnassigned ||
+			!opts.includeFrame(m, round.StartFrame) || round.EndFrame > m.LastFrame() {
+			continue
+		}
+		_, tName := teamNames(round, halfNumber(m, round.StartFrame))
+		candidate := strategyCandidate{
+			StrategyRound: StrategyRound{Round: round.Number, Team: tName, TWon: round.Winner == demoinfo.TeamTerrorists},
+			label:         strategyDefault,
+		}
+		for i, d := range strategyTimes {
+			players := m.StateAtFrame(frameAfter(m, round.FreezetimeEndFrame, round.EndFrame, d)).Players
+			candidate.features = append(candidate.features, positionGrid(m, players)...)
+			if candidate.label == strategyDefault {
+				if site, ok := attackedSite(players, sites); ok {
+					candidate.label = fmt.Sprintf("%s %s", site, []string{"Rush", "Execute"}[i])
+				}
+			}
+		}
+		if _, ok := byTeam[tName]; !ok {
+			teams = append(teams, tName)
+		}
+		byTeam[tName] = append(byTeam[tName], candidate)
+	}
+
+	strategies := make([]StrategyRound, 0)
+	for _, team := range teams {
+		candidates := byTeam[team]
+		assignments := kMeans(candidates, strategyClusters)
+		names := clusterNames(candidates, assignments)
+		for i, c := range candidates {
+			c.Cluster = assignments[i]
+			c.Strategy = names[assignments[i]]
+			strategies = append(strategies, c.StrategyRound)
+		}
+	}
+	sort.SliceStable(strategies, func(i, j int) bool { return strategies[i].Round < strategies[j].Round })
+	return strategies
+}
+
+// SiteCenters returns the mean position of the bomb plants at every site.
+func SiteCenters(m *match.Match) map[string]common.Point {
+	sums := make(map[string]common.Point)
+	counts := make(map[string]int)
+	for _, plant := range m.BombPlants {
+		if plant.Site == "" {
+			continue
+		}
+		sum := sums[plant.Site]
+		sum.X += plant.Position.X
+		sum.Y += plant.Position.Y
+		sums[plant.Site] = sum
+		counts[plant.Site]++
+	}
+	centers := make(map[string]common.Point, len(sums))
+	for site, sum := range sums {
+		centers[site] = common.Point{X: sum.X / float32(counts[site]), Y: sum.Y / float32(counts[site])}
+	}
+	return centers
+}
+
+// frameAfter returns the first frame d after start, but at most the last
+// frame before end.
+func frameAfter(m *match.Match, start, end int, d time.Duration) int {
+	frame := start
+	for frame < end-1 && durationBetween(start, frame, m) < d {
+		frame++
+	}
+	return frame
+}
+
+// positionGrid returns the share of the alive terrorists in every cell of a
+// grid over the overview.
+func positionGrid(m *match.Match, players []common.Player) []float64 {
+	grid := make([]float64, strategyGridSize*strategyGridSize)
+	var alive int
+	for _, p := range players {
+		if p.Team != demoinfo.TeamTerrorists || !p.IsAlive {
+			continue
+		}
+		alive++
+		x, y := m.TranslateScale(p.Position.X, p.Position.Y)
+		grid[gridIndex(y)*strategyGridSize+gridIndex(x)]++
+	}
+	if alive > 0 {
+		for i := range grid {
+			grid[i] /= float64(alive)
+		}
+	}
+	return grid
+}
+
+func gridIndex(coordinate float32) int {
+	i := int(coordinate) * strategyGridSize / overviewSize
+	if i < 0 {
+		return 0
+	}
+	if i >= strategyGridSize {
+		return strategyGridSize - 1
+	}
+	return i
+}
+
+// attackedSite returns the site that enough alive terrorists are close to.
+func attackedSite(players []common.Player, sites map[string]common.Point) (string, bool) {
+	for _, site := range sortedSites(sites) {
+		var close int
+		for _, p := range players {
+			if p.Team == demoinfo.TeamTerrorists && p.IsAlive && p.Position.Distance(sites[site]) <= strategySiteDistance {
+				close++
+			}
+		}
+		if close >= strategySitePlayers {
+			return site, true
+		}
+	}
+	return "", false
+}
+
+func sortedSites(sites map[string]common.Point) []string {
+	names := make([]string, 0, len(sites))
+	for site := range sites {
+		names = append(names, site)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// kMeans assigns every candidate to one of at most k clusters. The initial
+// centers are chosen by farthest-point sampling starting with the first
+// round, so that the result does not depend on chance.
+func kMeans(candidates []strategyCandidate, k int) []int {
+	assignments := make([]int, len(candidates))
+	if len(candidates) == 0 {
+		return assignments
+	}
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	centers := [][]float64{append([]float64{}, candidates[0].features...)}
+	for len(centers) < k {
+		farthest, farthestDistance := -1, 0.0
+		for i, c := range candidates {
+			_, distance := nearestCenter(c.features, centers)
+			if distance > farthestDistance {
+				farthest, farthestDistance = i, distance
+			}
+		}
+		if farthest < 0 {
+			// all remaining rounds are identical to a center
+			break
+		}
+		centers = append(centers, append([]float64{}, candidates[farthest].features...))
+	}
+
+	for iteration := 0; iteration < strategyIterations; iteration++ {
+		changed := iteration == 0
+		for i, c := range candidates {
+			nearest, _ := nearestCenter(c.features, centers)
+			if assignments[i] != nearest {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		for j := range centers {
+			var members int
+			sum := make([]float64, len(centers[j]))
+			for i, c := range candidates {
+				if assignments[i] != j {
+					continue
+				}
+				members++
+				for f, value := range c.features {
+					sum[f] += value
+				}
+			}
+			if members == 0 {
+				continue
+			}
+			for f := range sum {
+				sum[f] /= float64(members)
+			}
+			centers[j] = sum
+		}
+	}
+	return assignments
+}
+
+func nearestCenter(features []float64, centers [][]float64) (int, float64) {
+	nearest, nearestDistance := 0, math.Inf(1)
+	for j, center := range centers {
+		var distance float64
+		for f, value := range features {
+			diff := value - center[f]
+			distance += diff * diff
+		}
+		if distance < nearestDistance {
+			nearest, nearestDistance = j, distance
+		}
+	}
+	return nearest, nearestDistance
+}
+
+// clusterNames names every cluster after the most common label of its
+// rounds.
+func clusterNames(candidates []strategyCandidate, assignments []int) map[int]string {
+	counts := make(map[int]map[string]int)
+	for i, c := range candidates {
+		if counts[assignments[i]] == nil {
+			counts[assignments[i]] = make(map[string]int)
+		}
+		counts[assignments[i]][c.label]++
+	}
+	names := make(map[int]string, len(counts))
+	for cluster, labels := range counts {
+		best := ""
+		for label, count := range labels {
+			if best == "" || count > labels[best] || count == labels[best] && label < best {
+				best = label
+			}
+		}
+		names[cluster] = best
+	}
+	return names
+}
+
+// SummarizeStrategies groups the rounds by team and strategy.
+func SummarizeStrategies(rounds []StrategyRound) []StrategySummary {
+	summaries := make([]StrategySummary, 0)
+	indices := make(map[string]int)
+	totals := make(map[string]int)
+	for _, r := range rounds {
+		key := r.Team + "/" + r.Strategy
+		i, ok := indices[key]
+		if !ok {
+			i = len(summaries)
+			indices[key] = i
+			summaries = append(summaries, StrategySummary{Team: r.Team, Strategy: r.Strategy})
+		}
+		summaries[i].Rounds = append(summaries[i].Rounds, r.Round)
+		if r.TWon {
+			summaries[i].TWins++
+		}
+		totals[r.Team]++
+	}
+	for i := range summaries {
+		summaries[i].Share = float64(len(summaries[i].Rounds)) / float64(totals[summaries[i].Team])
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Team != summaries[j].Team {
+			return summaries[i].Team < summaries[j].Team
+		}
+		return len(summaries[i].Rounds) > len(summaries[j].Rounds)
+	})
+	return summaries
+}
+
+// WriteStrategies writes how often every team played its T strategies to w,
+// e.g. "B Rush 40%".
+func WriteStrategies(w io.Writer, summaries []StrategySummary) error {
+	_, err := fmt.Fprintln(w, "T strategies")
+	if err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		rounds := make([]string, 0, len(s.Rounds))
+		for _, r := range s.Rounds {
+			rounds = append(rounds, fmt.Sprint(r))
+		}
+		_, err = fmt.Fprintf(w, "%-20s %-12s %3.0f%% (%d/%d won), rounds %s\n",
+			s.Team, s.Strategy, 100*s.Share, s.TWins, len(s.Rounds), strings.Join(rounds, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/network"
+)
+
+// broadcastRequestTimeout is the timeout of a single request for a fragment
+// of a GOTV broadcast.
+const broadcastRequestTimeout = 10 * time.Second
+
+// Sizes of the fields of the demo header.
+const (
+	demoHeaderStampSize  = 8
+	demoHeaderStringSize = 260
+)
+
+// broadcastSync is the response of the sync request of a GOTV broadcast. It
+// tells clients which fragment to start with.
+type broadcastSync struct {
+	Fragment       int     `json:"fragment"`
+	SignupFragment int     `json:"signup_fragment"`
+	TicksPerSecond float64 `json:"tps"`
+	Map            string  `json:"map"`
+}
+
+// broadcastReader reads a GOTV broadcast (tv_broadcast_url) as a demo file.
+// The fragments of a broadcast contain the same commands as a demo file, so
+// a demo header is followed by the start fragment with the signon data, the
+// full fragment that the broadcast is joined at and all delta fragments from
+// there on. Fragments that are not yet available are polled for until
+// nothing new was published for followIdleTimeout.
+type broadcastReader struct {
+	client  *http.Client
+	baseURL string
+	sync    broadcastSync
+	// stage and fragment determine the next fragment that is downloaded.
+	stage    fragmentType
+	fragment int
+	buf      bytes.Reader
+}
+
+// fragmentType is the type of a fragment of a GOTV broadcast.
+type fragmentType int
+
+// Possible values for fragmentType in the order they are read.
+const (
+	fragmentStart fragmentType = iota
+	fragmentFull
+	fragmentDelta
+)
+
+// openBroadcast joins the GOTV broadcast at baseURL, which is the URL that the
+// server posts the broadcast to or a relay of it.
+func openBroadcast(baseURL string) (*broadcastReader, error) {
+	r := &broadcastReader{
+		client:  network.NewClient(broadcastRequestTimeout),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+	data, err := r.get("sync")
+	if err != nil {
+		return nil, fmt.Errorf("trying to sync with broadcast: %v", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("trying to sync with broadcast: no broadcast at %v", baseURL)
+	}
+	err = json.Unmarshal(data, &r.sync)
+	if err != nil {
+		return nil, fmt.Errorf("trying to sync with broadcast: %v", err)
+	}
+	r.buf.Reset(demoHeader(r.sync.Map))
+	r.fragment = r.sync.Fragment
+	return r, nil
+}
+
+// TickRate returns the tick rate that the broadcast was synced with.
+func (r *broadcastReader) TickRate() float64 {
+	return r.sync.TicksPerSecond
+}
+
+func (r *broadcastReader) Read(p []byte) (int, error) {
+	if r.buf.Len() == 0 {
+		err := r.fetchNext()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// fetchNext downloads the next fragment into the buffer. It waits for
+// fragments that have not been published yet.
+func (r *broadcastReader) fetchNext() error {
+	idleSince := time.Now()
+	for {
+		data, err := r.get(r.path())
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			r.buf.Reset(data)
+			switch r.stage {
+			case fragmentStart:
+				r.stage = fragmentFull
+			case fragmentFull:
+				// the delta fragment with the same number follows
+				r.stage = fragmentDelta
+			default:
+				r.fragment++
+			}
+			return nil
+		}
+		if time.Since(idleSince) > followIdleTimeout {
+			return io.EOF
+		}
+		time.Sleep(followPollInterval)
+	}
+}
+
+// path returns the path of the next fragment relative to baseURL.
+func (r *broadcastReader) path() string {
+	switch r.stage {
+	case fragmentStart:
+		return fmt.Sprintf("%d/start", r.sync.SignupFragment)
+	case fragmentFull:
+		return fmt.Sprintf("%d/full", r.fragment)
+	default:
+		return fmt.Sprintf("%d/delta", r.fragment)
+	}
+}
+
+// get downloads the resource at path relative to baseURL. It returns nil
+// without an error if the resource does not exist yet.
+func (r *broadcastReader) get(path string) ([]byte, error) {
+	resp, err := r.client.Get(r.baseURL + "/" + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound, http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("trying to get %v of broadcast: %v", path, resp.Status)
+	}
+}
+
+func (r *broadcastReader) Close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+// demoHeader returns a demo header for a broadcast. It only contains the
+// map, the playback time is unknown like in demos that are still being
+// recorded.
+func demoHeader(mapName string) []byte {
+	var header bytes.Buffer
+	stamp := make([]byte, demoHeaderStampSize)
+	copy(stamp, "HL2DEMO")
+	header.Write(stamp)
+	// protocol and network protocol
+	binary.Write(&header, binary.LittleEndian, [2]int32{})
+	for _, s := range []string{"", "", mapName, "csgo"} {
+		field := make([]byte, demoHeaderStringSize)
+		copy(field, s)
+		header.Write(field)
+	}
+	// playback time, ticks, frames and signon length
+	binary.Write(&header, binary.LittleEndian, [4]int32{})
+	return header.Bytes()
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// MatchCache keeps parsed matches in memory so that repeated requests for a
+// demo do not parse it again. The least recently used matches are evicted
+// when the estimated memory of the matches exceeds the budget. Concurrent
+// requests for a match that is being parsed wait for the same parse.
+type MatchCache struct {
+	mu      sync.Mutex
+	budget  int64
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+	calls   map[string]*cacheCall
+}
+
+type cacheEntry struct {
+	key   string
+	match *match.Match
+	size  int64
+}
+
+// cacheCall is a parse that is in progress, done is closed when it finished.
+type cacheCall struct {
+	done  chan struct{}
+	match *match.Match
+	err   error
+}
+
+// NewMatchCache returns an empty MatchCache that holds matches of at most
+// budget bytes in total, see match.Match.EstimatedSize.
+func NewMatchCache(budget int64) *MatchCache {
+	return &MatchCache{
+		budget:  budget,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		calls:   make(map[string]*cacheCall),
+	}
+}
+
+// Get returns the match with the given key. If it is not cached, it is parsed
+// with parse, unless another call is already parsing it. Matches that failed
+// to parse are not cached.
+func (c *MatchCache) Get(key string, parse func() (*match.Match, error)) (*match.Match, error) {
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*cacheEntry).match, nil
+	}
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.match, call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	c.run(key, call, parse)
+	return call.match, call.err
+}
+
+// run parses the match of call and wakes up the waiting calls. The parser
+// can panic on corrupt demos, the panic is returned as the error of the call
+// so that later requests for the demo do not wait forever.
+func (c *MatchCache) run(key string, call *cacheCall, parse func() (*match.Match, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.match, call.err = nil, fmt.Errorf("parser panicked: %v", r)
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		if call.err == nil {
+			c.add(key, call.match)
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.match, call.err = parse()
+}
+
+// add inserts the match and evicts the least recently used matches until the
+// budget is kept. A match that is larger than the budget is not cached.
+func (c *MatchCache) add(key string, m *match.Match) {
+	size := m.EstimatedSize()
+	if size > c.budget {
+		return
+	}
+	c.size += size
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, match: m, size: size})
+	for c.size > c.budget {
+		element := c.lru.Back()
+		entry := element.Value.(*cacheEntry)
+		c.lru.Remove(element)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+}
+
+// Stats returns the number of cached matches and their estimated size in
+// bytes.
+func (c *MatchCache) Stats() (matches int, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len(), c.size
+}
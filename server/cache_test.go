@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+func TestMatchCacheParsesOnce(t *testing.T) {
+	cache := NewMatchCache(1 << 30)
+	var parses int32
+	release := make(chan struct{})
+	parse := func() (*match.Match, error) {
+		atomic.AddInt32(&parses, 1)
+		<-release
+		return &match.Match{MapName: "de_dust2"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*match.Match, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := cache.Get("final.dem", parse)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = m
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	if parses != 1 {
+		t.Errorf("parsed %d times, want 1", parses)
+	}
+	for _, m := range results {
+		if m != results[0] {
+			t.Fatal("Get() returned different matches for the same key")
+		}
+	}
+	if m, _ := cache.Get("final.dem", parse); m != results[0] || parses != 1 {
+		t.Error("Get() did not return the cached match")
+	}
+	if matches, _ := cache.Stats(); matches != 1 {
+		t.Errorf("Stats() = %d matches, want 1", matches)
+	}
+}
+
+func TestMatchCacheDoesNotKeepFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() (*match.Match, error)
+	}{
+		{"error", func() (*match.Match, error) { return nil, errors.New("corrupt demo") }},
+		{"panic", func() (*match.Match, error) { panic("index out of range") }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := NewMatchCache(1 << 30)
+			if _, err := cache.Get("broken.dem", test.parse); err == nil {
+				t.Fatal("Get() returned no error")
+			}
+			want := &match.Match{}
+			m, err := cache.Get("broken.dem", func() (*match.Match, error) { return want, nil })
+			if m != want || err != nil {
+				t.Errorf("Get() after the failure = %p, %v, want the parsed match", m, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/pkg/export"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+)
+
+// DemoLibrary serves the reports of the recorded demos in a directory, e.g.
+// /demos/2021/final.dem returns the report of 2021/final.dem as JSON. The
+// parsed matches are kept in a MatchCache.
+type DemoLibrary struct {
+	dir               string
+	cache             *MatchCache
+	fallbackFrameRate float64
+	fallbackTickRate  float64
+	metrics           *Metrics
+	// Quantize stores the positions of the parsed matches in a compact form,
+	// so that more of them fit into the cache.
+	Quantize bool
+	// Options selects the events that the analyses of the reports take into
+	// account.
+	Options stats.Options
+}
+
+// NewDemoLibrary returns a DemoLibrary for the demos in dir that keeps parsed
+// matches of at most cacheBudget bytes in memory.
+func NewDemoLibrary(dir string, cacheBudget int64, fallbackFrameRate, fallbackTickRate float64) *DemoLibrary {
+	return &DemoLibrary{
+		dir:               dir,
+		cache:             NewMatchCache(cacheBudget),
+		fallbackFrameRate: fallbackFrameRate,
+		fallbackTickRate:  fallbackTickRate,
+	}
+}
+
+// ServeHTTP parses the requested demo unless it is cached and writes its
+// report.
+func (l *DemoLibrary) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// cleaning the path as an absolute path keeps it inside of dir
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, "/demos/")), "/")
+	fileName := filepath.Join(l.dir, filepath.FromSlash(name))
+	info, err := os.Stat(fileName)
+	if name == "" || err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// a demo that was replaced is parsed again
+	key := fmt.Sprintf("%v@%v", fileName, info.ModTime().UnixNano())
+	m, err := l.cache.Get(key, func() (*match.Match, error) {
+		start := time.Now()
+		m, err := match.NewMatch(fileName, l.fallbackFrameRate, l.fallbackTickRate)
+		if err == nil && l.Quantize {
+			m.Quantize()
+		}
+		if err == nil {
+			// the match is shared by the requests for the demo
+			m.Freeze()
+		}
+		if l.metrics != nil {
+			l.metrics.ObserveParse(time.Since(start), err)
+		}
+		return m, err
+	})
+	if err != nil {
+		log.Printf("trying to parse %v: %v\n", fileName, err)
+		http.Error(w, "the demo could not be parsed", http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(export.NewReport(m, nil, l.Options))
+	if err != nil {
+		log.Println("trying to write report:", err)
+	}
+}
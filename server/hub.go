@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+// clientBufferSize is the number of messages that are queued for a client
+// before it is considered too slow and disconnected.
+const clientBufferSize = 256
+
+// Message types that are sent to clients.
+const (
+	MessageTypeMatch = "match"
+	MessageTypeState = "state"
+	MessageTypeDelta = "delta"
+)
+
+// Message is sent to the clients as JSON or, if they connected with the query
+// parameter format=protobuf, in the binary encoding of message.proto, which is
+// a fraction of the size. A client first receives a match
+// message and a state message containing the complete current state, after
+// that only delta messages that have to be applied to the previous state.
+// Timer is sent with every state message and with delta messages when it
+// changed. The state and the deltas leave out the layers that the client
+// hid.
+type Message struct {
+	Type     string
+	Frame    int           `json:",omitempty"`
+	MapName  string        `json:",omitempty"`
+	MapPZero *common.Point `json:",omitempty"`
+	MapScale float32       `json:",omitempty"`
+	// MapTransform is only sent if the overview image is rotated or
+	// mirrored, the positions have to be transformed like in
+	// match.TranslateScale.
+	MapTransform *common.MapTransform  `json:",omitempty"`
+	MapSections  []common.MapSection   `json:",omitempty"`
+	State        *common.OverviewState `json:",omitempty"`
+	Delta        *common.StateDelta    `json:",omitempty"`
+	Timer        *common.Timer         `json:",omitempty"`
+}
+
+type client struct {
+	conn     *wsConn
+	send     chan []byte
+	filter   common.LayerFilter
+	protobuf bool
+}
+
+// filterKey identifies the filter and the encoding of the client so that
+// messages can be encoded once for all clients with the same filter.
+func (c *client) filterKey() string {
+	key := strings.Join(c.filter.Names(), ",")
+	if c.protobuf {
+		key += ";protobuf"
+	}
+	return key
+}
+
+// encode returns msg in the encoding of the client.
+func (c *client) encode(msg Message) []byte {
+	if c.protobuf {
+		return encodeProtobuf(msg)
+	}
+	return encode(msg)
+}
+
+// Hub distributes the states of a followed match to all connected clients.
+type Hub struct {
+	mu       sync.Mutex
+	clients  map[*client]bool
+	matchMsg *Message
+	// stateMsgs caches the state message of the current state for clients
+	// without a filter, the key is whether it is encoded with protobuf
+	stateMsgs map[bool][]byte
+	state     common.OverviewState
+	timer     common.Timer
+	hasState  bool
+}
+
+// NewHub returns a Hub without clients.
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*client]bool),
+	}
+}
+
+// Broadcast sends the changes between the previous and the given state to all
+// clients.
+func (h *Hub) Broadcast(m *match.Match, frame int, state common.OverviewState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.matchMsg == nil {
+		pZero := m.MapPZero
+		msg := Message{
+			Type:     MessageTypeMatch,
+			MapName:  m.MapName,
+			MapPZero: &pZero,
+			MapScale: m.MapScale,
+			// clients choose the overview image of a position with the
+			// sections and the heights of the players and grenades
+			MapSections: m.MapSections,
+		}
+		if !m.MapTransform.IsIdentity() {
+			transform := m.MapTransform
+			msg.MapTransform = &transform
+		}
+		h.matchMsg = &msg
+	}
+
+	timer := m.TimerAt(frame)
+	var delta common.StateDelta
+	send := false
+	timerChanged := false
+	if h.hasState {
+		delta = common.DiffStates(h.state, state)
+		timerChanged = timer != h.timer
+		send = !delta.IsEmpty() || timerChanged
+	}
+	h.state = state
+	h.timer = timer
+	h.hasState = true
+	h.stateMsgs = nil
+
+	if !send {
+		return
+	}
+	msgs := make(map[string][]byte)
+	for c := range h.clients {
+		key := c.filterKey()
+		msg, ok := msgs[key]
+		if !ok {
+			filtered := c.filter.FilterDelta(delta)
+			deltaMsg := Message{Type: MessageTypeDelta, Frame: frame, Delta: &filtered}
+			if timerChanged {
+				deltaMsg.Timer = &timer
+			}
+			msg = c.encode(deltaMsg)
+			msgs[key] = msg
+		}
+		select {
+		case c.send <- msg:
+		default:
+			// the client cannot keep up, it has to reconnect to get a full state
+			h.removeLocked(c)
+		}
+	}
+}
+
+func (h *Hub) add(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.matchMsg != nil {
+		c.send <- c.encode(*h.matchMsg)
+	}
+	if h.hasState {
+		timer := h.timer
+		if len(c.filter) > 0 {
+			state := c.filter.FilterState(h.state)
+			c.send <- c.encode(Message{Type: MessageTypeState, State: &state, Timer: &timer})
+		} else {
+			msg, ok := h.stateMsgs[c.protobuf]
+			if !ok {
+				state := h.state
+				msg = c.encode(Message{Type: MessageTypeState, State: &state, Timer: &timer})
+				if h.stateMsgs == nil {
+					h.stateMsgs = make(map[bool][]byte)
+				}
+				h.stateMsgs[c.protobuf] = msg
+			}
+			c.send <- msg
+		}
+	}
+	h.clients[c] = true
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+func (h *Hub) removeLocked(c *client) {
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// ClientCount returns the number of connected clients.
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func encode(msg Message) []byte {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Println("trying to encode message:", err)
+	}
+	return data
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+func TestHubSendsStateThenDeltas(t *testing.T) {
+	m := &match.Match{MapName: "de_dust2", MapScale: 4.4}
+	first := common.OverviewState{
+		Frame:    1,
+		Players:  []common.Player{{ID: 1, Name: "alice", Health: 100}},
+		Grenades: []common.GrenadeProjectile{{ID: 7}},
+	}
+	second := first
+	second.Frame = 2
+	second.Players = []common.Player{{ID: 1, Name: "alice", Health: 73}}
+
+	hub := NewHub()
+	hub.Broadcast(m, first.Frame, first)
+	filter, err := common.ParseLayerFilter("grenades")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := &client{send: make(chan []byte, clientBufferSize)}
+	filtered := &client{send: make(chan []byte, clientBufferSize), filter: filter}
+	hub.add(all)
+	hub.add(filtered)
+	hub.Broadcast(m, second.Frame, second)
+	// nothing changed, no delta is sent
+	hub.Broadcast(m, second.Frame, second)
+
+	for _, test := range []struct {
+		name     string
+		c        *client
+		grenades int
+	}{
+		{"all layers", all, 1},
+		{"without grenades", filtered, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			msgs := receive(t, test.c)
+			if len(msgs) != 3 {
+				t.Fatalf("received %d messages, want 3", len(msgs))
+			}
+			if msgs[0].Type != MessageTypeMatch || msgs[0].MapName != m.MapName {
+				t.Errorf("first message = %+v, want the match", msgs[0])
+			}
+			if msgs[1].Type != MessageTypeState || msgs[1].State == nil || msgs[1].Timer == nil {
+				t.Fatalf("second message = %+v, want the state with the timer", msgs[1])
+			}
+			if len(msgs[1].State.Grenades) != test.grenades {
+				t.Errorf("state has %d grenades, want %d", len(msgs[1].State.Grenades), test.grenades)
+			}
+			delta := msgs[2].Delta
+			if msgs[2].Type != MessageTypeDelta || delta == nil || msgs[2].Frame != second.Frame {
+				t.Fatalf("third message = %+v, want the delta of frame %d", msgs[2], second.Frame)
+			}
+			got := delta.Apply(*msgs[1].State)
+			if len(got.Players) != 1 || got.Players[0].Health != 73 {
+				t.Errorf("players after the delta = %+v, want alice with 73 health", got.Players)
+			}
+		})
+	}
+}
+
+func TestHubDropsSlowClients(t *testing.T) {
+	m := &match.Match{}
+	hub := NewHub()
+	hub.Broadcast(m, 0, common.OverviewState{})
+	c := &client{send: make(chan []byte, 2)}
+	hub.add(c)
+	for frame := 1; frame <= 3; frame++ {
+		hub.Broadcast(m, frame, common.OverviewState{Frame: frame, Players: []common.Player{{ID: 1, Health: int16(frame)}}})
+	}
+	if hub.ClientCount() != 0 {
+		t.Errorf("ClientCount() = %d after the buffer overflowed, want 0", hub.ClientCount())
+	}
+	if _, ok := <-c.send; !ok {
+		t.Error("the buffered messages were discarded")
+	}
+}
+
+// receive returns the messages that were queued for the client.
+func receive(t *testing.T, c *client) []Message {
+	t.Helper()
+	var msgs []Message
+	for {
+		select {
+		case data := <-c.send:
+			var msg Message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatal(err)
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Metrics counts the work of the server and serves it in the text format of
+// Prometheus, so that operators can monitor a csgoverview service.
+type Metrics struct {
+	mu            sync.Mutex
+	hub           *Hub
+	cache         *MatchCache
+	demosParsed   int
+	parseFailures int
+	parseSeconds  float64
+	matches       int
+	matchBytes    int64
+}
+
+// NewMetrics returns Metrics that report the clients of hub and the matches
+// in cache. Both may be nil.
+func NewMetrics(hub *Hub, cache *MatchCache) *Metrics {
+	return &Metrics{hub: hub, cache: cache}
+}
+
+// ObserveParse records a demo that was parsed in the given time. err is the
+// error of the parser, failed demos are counted separately.
+func (m *Metrics) ObserveParse(duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.parseFailures++
+		return
+	}
+	m.demosParsed++
+	m.parseSeconds += duration.Seconds()
+}
+
+// SetMatches records the number of matches that are held in memory besides
+// the cache and their estimated size in bytes, see
+// match.Match.EstimatedSize.
+func (m *Metrics) SetMatches(count int, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.matches = count
+	m.matchBytes = bytes
+}
+
+// ServeHTTP writes the metrics, it is meant to be served under /metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var clients int
+	if m.hub != nil {
+		clients = m.hub.ClientCount()
+	}
+	var cachedMatches int
+	var cachedBytes int64
+	if m.cache != nil {
+		cachedMatches, cachedBytes = m.cache.Stats()
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	b := bufio.NewWriter(w)
+	writeMetric(b, "csgoverview_demos_parsed_total", "counter", "Number of demos that were parsed.", float64(m.demosParsed))
+	writeMetric(b, "csgoverview_demo_parse_failures_total", "counter", "Number of demos that could not be parsed.", float64(m.parseFailures))
+	fmt.Fprintln(b, "# HELP csgoverview_demo_parse_duration_seconds Time it took to parse the demos.")
+	fmt.Fprintln(b, "# TYPE csgoverview_demo_parse_duration_seconds summary")
+	fmt.Fprintf(b, "csgoverview_demo_parse_duration_seconds_sum %g\n", m.parseSeconds)
+	fmt.Fprintf(b, "csgoverview_demo_parse_duration_seconds_count %d\n", m.demosParsed)
+	writeMetric(b, "csgoverview_websocket_clients", "gauge", "Number of connected WebSocket clients.", float64(clients))
+	writeMetric(b, "csgoverview_cached_matches", "gauge", "Number of parsed matches held in memory.", float64(m.matches+cachedMatches))
+	writeMetric(b, "csgoverview_cached_matches_bytes", "gauge", "Estimated memory of the parsed matches held in memory.", float64(m.matchBytes+cachedBytes))
+	writeMetric(b, "go_goroutines", "gauge", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	writeMetric(b, "go_memstats_heap_alloc_bytes", "gauge", "Number of heap bytes allocated and still in use.", float64(memStats.HeapAlloc))
+	b.Flush()
+}
+
+func writeMetric(w *bufio.Writer, name, metricType, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+	fmt.Fprintf(w, "%s %g\n", name, value)
+}
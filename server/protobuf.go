@@ -0,0 +1,335 @@
+package server
+
+import (
+	"encoding/binary"
+	"math"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+// Wire types of the Protocol Buffers encoding.
+const (
+	wireVarint  = 0
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protoBuffer encodes messages in the Protocol Buffers wire format, following
+// the schema in message.proto. Like in proto3, scalar fields with the zero
+// value are left out unless they are optional.
+type protoBuffer struct {
+	data []byte
+}
+
+// encodeProtobuf returns msg in the binary encoding of message.proto.
+func encodeProtobuf(msg Message) []byte {
+	var b protoBuffer
+	b.string(1, msg.Type)
+	b.int(2, int64(msg.Frame))
+	b.string(3, msg.MapName)
+	if msg.MapPZero != nil {
+		b.point(4, *msg.MapPZero)
+	}
+	b.float(5, msg.MapScale)
+	if msg.MapTransform != nil {
+		transform := *msg.MapTransform
+		b.message(6, func(b *protoBuffer) {
+			b.int(1, int64(transform.Rotate))
+			b.bool(2, transform.FlipX)
+			b.bool(3, transform.FlipY)
+		})
+	}
+	for _, section := range msg.MapSections {
+		section := section
+		b.message(7, func(b *protoBuffer) {
+			b.string(1, section.Name)
+			b.float(2, section.AltitudeMin)
+			b.float(3, section.AltitudeMax)
+		})
+	}
+	if msg.State != nil {
+		b.message(8, func(b *protoBuffer) { b.overviewState(*msg.State) })
+	}
+	if msg.Delta != nil {
+		b.message(9, func(b *protoBuffer) { b.stateDelta(*msg.Delta) })
+	}
+	if msg.Timer != nil {
+		timer := *msg.Timer
+		b.message(10, func(b *protoBuffer) {
+			b.int(1, int64(timer.TimeRemaining))
+			b.int(2, int64(timer.Duration))
+			b.uint(3, uint64(timer.Phase))
+			b.bool(4, timer.IsPaused)
+			b.bool(5, timer.IsDefusing)
+			b.int(6, int64(timer.DefuseRemaining))
+			b.bool(7, timer.DefuseInTime)
+		})
+	}
+	return b.data
+}
+
+func (b *protoBuffer) overviewState(state common.OverviewState) {
+	b.int(1, int64(state.Frame))
+	b.int(2, int64(state.IngameTick))
+	b.int(3, int64(state.Time))
+	for _, player := range state.Players {
+		player := player
+		b.message(4, func(b *protoBuffer) { b.player(player) })
+	}
+	for _, grenade := range state.Grenades {
+		grenade := grenade
+		b.message(5, func(b *protoBuffer) { b.grenade(grenade) })
+	}
+	for _, inferno := range state.Infernos {
+		inferno := inferno
+		b.message(6, func(b *protoBuffer) { b.inferno(inferno) })
+	}
+	for _, weapon := range state.DroppedWeapons {
+		weapon := weapon
+		b.message(7, func(b *protoBuffer) { b.droppedWeapon(weapon) })
+	}
+	b.message(8, func(b *protoBuffer) { b.bomb(state.Bomb) })
+	b.message(9, func(b *protoBuffer) { b.teamState(state.TeamCounterTerrorists) })
+	b.message(10, func(b *protoBuffer) { b.teamState(state.TeamTerrorists) })
+}
+
+func (b *protoBuffer) stateDelta(delta common.StateDelta) {
+	b.int(1, int64(delta.Frame))
+	b.int(2, int64(delta.IngameTick))
+	b.int(3, int64(delta.Time))
+	for _, player := range delta.Players {
+		player := player
+		b.message(4, func(b *protoBuffer) { b.playerDelta(player) })
+	}
+	if len(delta.RemovedPlayers) > 0 {
+		b.message(5, func(b *protoBuffer) {
+			for _, id := range delta.RemovedPlayers {
+				b.appendUvarint(id)
+			}
+		})
+	}
+	for _, grenade := range delta.Grenades {
+		grenade := grenade
+		b.message(6, func(b *protoBuffer) { b.grenade(grenade) })
+	}
+	if len(delta.RemovedGrenades) > 0 {
+		b.message(7, func(b *protoBuffer) {
+			for _, id := range delta.RemovedGrenades {
+				b.appendUvarint(zigzag(id))
+			}
+		})
+	}
+	for _, inferno := range delta.Infernos {
+		inferno := inferno
+		b.message(8, func(b *protoBuffer) { b.inferno(inferno) })
+	}
+	b.bool(9, delta.InfernosChanged)
+	for _, weapon := range delta.DroppedWeapons {
+		weapon := weapon
+		b.message(10, func(b *protoBuffer) { b.droppedWeapon(weapon) })
+	}
+	b.bool(11, delta.DroppedWeaponsChanged)
+	if delta.Bomb != nil {
+		b.message(12, func(b *protoBuffer) { b.bomb(*delta.Bomb) })
+	}
+	if delta.TeamCounterTerrorists != nil {
+		b.message(13, func(b *protoBuffer) { b.teamState(*delta.TeamCounterTerrorists) })
+	}
+	if delta.TeamTerrorists != nil {
+		b.message(14, func(b *protoBuffer) { b.teamState(*delta.TeamTerrorists) })
+	}
+}
+
+func (b *protoBuffer) playerDelta(delta common.PlayerDelta) {
+	b.uint(1, delta.ID)
+	if delta.Position != nil {
+		b.point(2, *delta.Position)
+	}
+	// the optional fields are sent even if they changed to zero
+	if delta.PositionZ != nil {
+		b.optionalFloat(3, *delta.PositionZ)
+	}
+	if delta.ViewDirectionX != nil {
+		b.optionalFloat(4, *delta.ViewDirectionX)
+	}
+	if delta.ViewDirectionY != nil {
+		b.optionalFloat(5, *delta.ViewDirectionY)
+	}
+	if delta.Health != nil {
+		b.optionalInt(6, int64(*delta.Health))
+	}
+	if delta.Armor != nil {
+		b.optionalInt(7, int64(*delta.Armor))
+	}
+	if delta.Player != nil {
+		b.message(8, func(b *protoBuffer) { b.player(*delta.Player) })
+	}
+}
+
+func (b *protoBuffer) player(player common.Player) {
+	b.uint(1, player.ID)
+	b.string(2, player.Name)
+	b.uint(3, player.SteamID64)
+	b.uint(4, uint64(player.Team))
+	b.point(5, player.Position)
+	b.float(6, player.PositionZ)
+	b.point(7, player.LastAlivePosition)
+	b.float(8, player.ViewDirectionX)
+	b.float(9, player.ViewDirectionY)
+	b.int(10, int64(player.FlashDuration))
+	b.int(11, int64(player.FlashTimeRemaining))
+	for _, item := range player.Inventory {
+		item := item
+		b.message(12, func(b *protoBuffer) { b.inventoryItem(item) })
+	}
+	b.int(13, int64(player.Health))
+	b.int(14, int64(player.Armor))
+	b.int(15, int64(player.Money))
+	b.int(16, int64(player.Kills))
+	b.int(17, int64(player.Deaths))
+	b.int(18, int64(player.Assists))
+	b.bool(19, player.IsAlive)
+	b.bool(20, player.IsDefusing)
+	b.bool(21, player.HasHelmet)
+	b.bool(22, player.HasDefuseKit)
+	b.bool(23, player.HasBomb)
+	b.message(24, func(b *protoBuffer) {
+		hud := player.HUD
+		b.int(1, int64(hud.Health))
+		b.uint(2, uint64(hud.Armor))
+		b.bool(3, hud.HasDefuseKit)
+		b.bool(4, hud.HasBomb)
+		b.int(5, int64(hud.Money))
+		b.uint(6, uint64(hud.Primary))
+		b.uint(7, uint64(hud.Secondary))
+		for _, item := range hud.Grenades {
+			item := item
+			b.message(8, func(b *protoBuffer) { b.inventoryItem(item) })
+		}
+	})
+	b.uint(25, uint64(player.ActiveWeapon))
+	b.int(26, int64(player.ObserverSlot))
+}
+
+func (b *protoBuffer) inventoryItem(item common.InventoryItem) {
+	b.uint(1, uint64(item.Type))
+	b.int(2, int64(item.Count))
+	b.int(3, int64(item.Ammo))
+}
+
+func (b *protoBuffer) grenade(grenade common.GrenadeProjectile) {
+	b.int(1, grenade.ID)
+	b.point(2, grenade.Position)
+	b.float(3, grenade.PositionZ)
+	b.uint(4, uint64(grenade.Type))
+	b.string(5, grenade.ThrowerName)
+	b.uint(6, uint64(grenade.ThrowerTeam))
+}
+
+func (b *protoBuffer) inferno(inferno common.Inferno) {
+	b.int(1, inferno.ID)
+	for _, p := range inferno.ConvexHull2D {
+		b.point(2, p)
+	}
+}
+
+func (b *protoBuffer) droppedWeapon(weapon common.DroppedWeapon) {
+	b.int(1, weapon.ID)
+	b.uint(2, uint64(weapon.Type))
+	b.point(3, weapon.Position)
+}
+
+func (b *protoBuffer) bomb(bomb common.Bomb) {
+	b.point(1, bomb.Position)
+	b.bool(2, bomb.IsBeingCarried)
+}
+
+func (b *protoBuffer) teamState(team common.TeamState) {
+	b.string(1, team.ClanName)
+	b.uint(2, uint64(team.Score))
+}
+
+func (b *protoBuffer) point(field int, p common.Point) {
+	b.message(field, func(b *protoBuffer) {
+		b.float(1, p.X)
+		b.float(2, p.Y)
+	})
+}
+
+// message encodes a nested message, it is written even if it is empty so
+// that clients can tell it apart from a missing one.
+func (b *protoBuffer) message(field int, encode func(b *protoBuffer)) {
+	var nested protoBuffer
+	encode(&nested)
+	b.appendTag(field, wireBytes)
+	b.appendUvarint(uint64(len(nested.data)))
+	b.data = append(b.data, nested.data...)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	b.appendTag(field, wireBytes)
+	b.appendUvarint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.appendTag(field, wireVarint)
+	b.appendUvarint(v)
+}
+
+// int encodes a signed integer as sint32 or sint64, which are the same on the
+// wire.
+func (b *protoBuffer) int(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	b.optionalInt(field, v)
+}
+
+func (b *protoBuffer) optionalInt(field int, v int64) {
+	b.appendTag(field, wireVarint)
+	b.appendUvarint(zigzag(v))
+}
+
+func (b *protoBuffer) bool(field int, v bool) {
+	if v {
+		b.appendTag(field, wireVarint)
+		b.appendUvarint(1)
+	}
+}
+
+func (b *protoBuffer) float(field int, v float32) {
+	if v == 0 {
+		return
+	}
+	b.optionalFloat(field, v)
+}
+
+func (b *protoBuffer) optionalFloat(field int, v float32) {
+	b.appendTag(field, wireFixed32)
+	var bits [4]byte
+	binary.LittleEndian.PutUint32(bits[:], math.Float32bits(v))
+	b.data = append(b.data, bits[:]...)
+}
+
+func (b *protoBuffer) appendTag(field int, wireType uint64) {
+	b.appendUvarint(uint64(field)<<3 | wireType)
+}
+
+func (b *protoBuffer) appendUvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	b.data = append(b.data, buf[:n]...)
+}
+
+// zigzag maps signed integers to unsigned ones so that small negative
+// numbers have short varints, like sint64 in Protocol Buffers.
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
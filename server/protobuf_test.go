@@ -0,0 +1,61 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+)
+
+func TestEncodeProtobuf(t *testing.T) {
+	zero := int16(0)
+	tests := []struct {
+		name string
+		msg  Message
+		want []byte
+	}{
+		{
+			name: "empty",
+			msg:  Message{},
+			want: nil,
+		},
+		{
+			name: "type and negative frame",
+			msg:  Message{Type: "delta", Frame: -1},
+			want: []byte{0x0a, 5, 'd', 'e', 'l', 't', 'a', 0x10, 0x01},
+		},
+		{
+			name: "empty nested message is sent",
+			msg:  Message{MapPZero: &common.Point{}},
+			want: []byte{0x22, 0},
+		},
+		{
+			name: "float",
+			msg:  Message{MapScale: 1},
+			want: []byte{0x2d, 0x00, 0x00, 0x80, 0x3f},
+		},
+		{
+			name: "optional field changed to zero",
+			msg: Message{Delta: &common.StateDelta{
+				Players: []common.PlayerDelta{{ID: 1, Health: &zero}},
+			}},
+			// delta (9) > players (4) > id (1), health (6)
+			want: []byte{0x4a, 6, 0x22, 4, 0x08, 0x01, 0x30, 0x00},
+		},
+		{
+			name: "packed repeated field",
+			msg: Message{Delta: &common.StateDelta{
+				RemovedGrenades: []int64{1, -2, 300},
+			}},
+			// delta (9) > removed_grenades (7) with zigzag encoded IDs
+			want: []byte{0x4a, 6, 0x3a, 4, 0x02, 0x03, 0xd8, 0x04},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := encodeProtobuf(test.msg); !bytes.Equal(got, test.want) {
+				t.Errorf("encodeProtobuf() = % x, want % x", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package server contains a caster mode that follows a match live and sends
+// its states as JSON or Protocol Buffers over WebSockets, e.g. for production
+// overlays. The match is either a GOTV broadcast (tv_broadcast_url) or a demo
+// file that is still being recorded with tv_record.
+package server
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/network"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+const (
+	// followPollInterval is the time to wait for new data when the end of a
+	// demo that is still being recorded is reached.
+	followPollInterval = 250 * time.Millisecond
+	// followIdleTimeout is the time without new data after which a demo is
+	// considered finished.
+	followIdleTimeout = 30 * time.Second
+	// metricsInterval is the time between two updates of the estimated
+	// memory of the followed match, estimating it walks through all states.
+	metricsInterval = time.Second
+)
+
+// ServeRecording follows the demo at source and serves its states as
+// messages to WebSocket clients on addr under the path /ws, see Message.
+// source is either the path to a demo file that is still being recorded (e.g.
+// with tv_record on the server) or an HTTP URL whose response body is such a
+// demo file. Clients can leave out layers with a comma separated
+// list in the query parameter hide, e.g. /ws?hide=grenades,infernos, and
+// choose the binary encoding with format=protobuf.
+// Prometheus metrics are served under /metrics.
+// If webhook is not nil, it is notified of the events of the match. If demos
+// is not nil, the reports of its demos are served under /demos/.
+// Browsers may only connect from pages on the same host as the server or on
+// one of allowedOrigins.
+// ServeRecording returns when the demo ends.
+func ServeRecording(addr, source string, fallbackFrameRate, fallbackTickRate float64, webhook *Webhook, demos *DemoLibrary, allowedOrigins []string) error {
+	reader, err := openSource(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return serve(addr, source, reader, fallbackFrameRate, fallbackTickRate, webhook, demos, allowedOrigins)
+}
+
+// ServeBroadcast is like ServeRecording, but it joins the GOTV broadcast at
+// url instead, which is the tv_broadcast_url of the server or a relay of it.
+// If fallbackTickRate is -1, the tick rate of the broadcast is used.
+// ServeBroadcast returns when no new fragment was published for a while.
+func ServeBroadcast(addr, url string, fallbackFrameRate, fallbackTickRate float64, webhook *Webhook, demos *DemoLibrary, allowedOrigins []string) error {
+	reader, err := openBroadcast(url)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if fallbackTickRate == -1 && reader.TickRate() > 0 {
+		fallbackTickRate = reader.TickRate()
+	}
+	return serve(addr, url, reader, fallbackFrameRate, fallbackTickRate, webhook, demos, allowedOrigins)
+}
+
+func serve(addr, source string, reader io.Reader, fallbackFrameRate, fallbackTickRate float64, webhook *Webhook, demos *DemoLibrary, allowedOrigins []string) error {
+	hub := NewHub()
+	var cache *MatchCache
+	if demos != nil {
+		cache = demos.cache
+	}
+	metrics := NewMetrics(hub, cache)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		serveWebSocket(hub, w, r, allowedOrigins)
+	})
+	mux.Handle("/metrics", metrics)
+	if demos != nil {
+		demos.metrics = metrics
+		mux.Handle("/demos/", demos)
+	}
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		err := httpServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("trying to serve WebSocket clients:", err)
+		}
+	}()
+	defer httpServer.Close()
+
+	log.Printf("serving states of %v on ws://%v/ws\n", source, addr)
+	start := time.Now()
+	var lastMetrics time.Time
+	m, err := match.Stream(reader, fallbackFrameRate, fallbackTickRate,
+		func(m *match.Match, frame int, state common.OverviewState) {
+			hub.Broadcast(m, frame, state)
+			if webhook != nil {
+				webhook.Observe(m, frame, state)
+			}
+			if time.Since(lastMetrics) >= metricsInterval {
+				metrics.SetMatches(1, m.EstimatedSize())
+				lastMetrics = time.Now()
+			}
+		})
+	metrics.ObserveParse(time.Since(start), err)
+	if m != nil {
+		metrics.SetMatches(1, m.EstimatedSize())
+	}
+	if webhook != nil {
+		webhook.Finish(m)
+	}
+	return err
+}
+
+func serveWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request, allowedOrigins []string) {
+	filter, err := common.ParseLayerFilter(r.URL.Query().Get("hide"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var protobuf bool
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+	case "protobuf":
+		protobuf = true
+	default:
+		http.Error(w, "unknown format "+format, http.StatusBadRequest)
+		return
+	}
+	conn, err := upgrade(w, r, allowedOrigins)
+	if err != nil {
+		log.Println("trying to upgrade connection:", err)
+		return
+	}
+	c := &client{
+		conn:     conn,
+		send:     make(chan []byte, clientBufferSize),
+		filter:   filter,
+		protobuf: protobuf,
+	}
+	hub.add(c)
+
+	go func() {
+		defer conn.Close()
+		write := conn.WriteText
+		if protobuf {
+			write = conn.WriteBinary
+		}
+		for msg := range c.send {
+			err := write(msg)
+			if err != nil {
+				hub.remove(c)
+				return
+			}
+		}
+	}()
+
+	conn.readLoop()
+	hub.remove(c)
+	conn.Close()
+}
+
+func openSource(source string) (io.ReadCloser, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := network.NewClient(0).Get(source)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &followReader{file: file}, nil
+}
+
+// followReader reads a file that is still being written to. Instead of
+// returning io.EOF at the current end of the file it waits for more data
+// until nothing was written for followIdleTimeout.
+type followReader struct {
+	file *os.File
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	idleSince := time.Now()
+	for {
+		n, err := f.file.Read(p)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		if time.Since(idleSince) > followIdleTimeout {
+			return 0, io.EOF
+		}
+		time.Sleep(followPollInterval)
+	}
+}
+
+func (f *followReader) Close() error {
+	return f.file.Close()
+}
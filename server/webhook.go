@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/network"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+// Events that a Webhook can post.
+const (
+	WebhookRoundEnd  = "round_end"
+	WebhookBombPlant = "bomb_plant"
+	WebhookMatchEnd  = "match_end"
+)
+
+const (
+	// webhookTimeout is the time after which posting an event is aborted.
+	webhookTimeout = 10 * time.Second
+	// webhookQueueSize is the number of events that wait to be posted
+	// before further events are dropped.
+	webhookQueueSize = 64
+)
+
+// WebhookEvent is posted as JSON to the URL of a Webhook. Content is a short
+// summary of the event, so that the URL of a Discord webhook can be used
+// directly.
+type WebhookEvent struct {
+	Content                   string `json:"content"`
+	Event                     string
+	MapName                   string
+	Frame                     int
+	Round                     int    `json:",omitempty"`
+	Winner                    string `json:",omitempty"`
+	WinType                   string `json:",omitempty"`
+	Site                      string `json:",omitempty"`
+	Planter                   string `json:",omitempty"`
+	ClanNameCounterTerrorists string
+	ClanNameTerrorists        string
+	ScoreCounterTerrorists    int
+	ScoreTerrorists           int
+}
+
+// Webhook posts the events of a followed match to a URL, e.g. for Discord bots
+// or dashboards. The events are posted in the background so that slow
+// receivers do not hold up the match.
+type Webhook struct {
+	url    string
+	events map[string]bool
+	queue  chan WebhookEvent
+	done   chan struct{}
+
+	// plants is the number of bomb plants and rounds the number of ended
+	// rounds that were handled.
+	plants int
+	rounds int
+	// pendingRound is the index of the round that ended but whose end was
+	// not posted yet, because the score is only updated a moment after the
+	// end of the round. It is -1 if there is none.
+	pendingRound int
+	pendingScore int
+	lastState    common.OverviewState
+}
+
+// NewWebhook returns a Webhook that posts the events in the comma separated
+// list events to url. An empty list selects all events.
+func NewWebhook(url, events string) (*Webhook, error) {
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(events, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+		case WebhookRoundEnd, WebhookBombPlant, WebhookMatchEnd:
+			selected[name] = true
+		default:
+			return nil, fmt.Errorf("unknown webhook event %q, expected %v, %v or %v",
+				name, WebhookRoundEnd, WebhookBombPlant, WebhookMatchEnd)
+		}
+	}
+	if len(selected) == 0 {
+		selected = map[string]bool{WebhookRoundEnd: true, WebhookBombPlant: true, WebhookMatchEnd: true}
+	}
+	w := &Webhook{
+		url:          url,
+		events:       selected,
+		queue:        make(chan WebhookEvent, webhookQueueSize),
+		done:         make(chan struct{}),
+		pendingRound: -1,
+	}
+	go w.run()
+	return w, nil
+}
+
+// Observe looks for new events in the match after the given frame was parsed.
+func (w *Webhook) Observe(m *match.Match, frame int, state common.OverviewState) {
+	w.lastState = state
+	for ; w.plants < len(m.BombPlants); w.plants++ {
+		plant := m.BombPlants[w.plants]
+		e := w.newEvent(WebhookBombPlant, m, plant.Frame, state)
+		e.Round = m.RoundIndex(plant.Frame) + 1
+		e.Site = plant.Site
+		e.Planter = plant.PlanterName
+		e.Content = fmt.Sprintf("Round %d: %s planted the bomb on %s", e.Round, plant.PlanterName, plant.Site)
+		w.post(e)
+	}
+
+	score := int(state.TeamCounterTerrorists.Score) + int(state.TeamTerrorists.Score)
+	if w.pendingRound >= 0 && (score != w.pendingScore || len(m.Rounds) > w.pendingRound+1) {
+		w.postRoundEnd(m, state)
+	}
+	if w.pendingRound < 0 && w.rounds < len(m.Rounds) && m.Rounds[w.rounds].EndFrame >= 0 {
+		w.pendingRound = w.rounds
+		w.pendingScore = score
+		w.rounds++
+	}
+}
+
+// Finish posts the end of the match and waits until all events were posted.
+// m is the match returned by match.Stream, it may be nil if the stream
+// failed.
+func (w *Webhook) Finish(m *match.Match) {
+	if m != nil {
+		if w.pendingRound >= 0 {
+			w.postRoundEnd(m, w.lastState)
+		}
+		// the score of the last state is the final score, it is copied
+		// into the event like for the other events
+		e := w.newEvent(WebhookMatchEnd, m, m.TotalFrames(), w.lastState)
+		e.Content = fmt.Sprintf("Match over on %s: %s", m.MapName, scoreString(e))
+		w.post(e)
+	}
+	close(w.queue)
+	<-w.done
+}
+
+func (w *Webhook) postRoundEnd(m *match.Match, state common.OverviewState) {
+	round := m.Rounds[w.pendingRound]
+	w.pendingRound = -1
+	e := w.newEvent(WebhookRoundEnd, m, round.EndFrame, state)
+	e.Round = round.Number
+	e.Winner = teamName(round.Winner)
+	e.WinType = round.WinType.String()
+	e.Content = fmt.Sprintf("Round %d: %s win by %s (%s)", round.Number, e.Winner, e.WinType, scoreString(e))
+	w.post(e)
+}
+
+func (w *Webhook) newEvent(name string, m *match.Match, frame int, state common.OverviewState) WebhookEvent {
+	return WebhookEvent{
+		Event:                     name,
+		MapName:                   m.MapName,
+		Frame:                     frame,
+		ClanNameCounterTerrorists: state.TeamCounterTerrorists.ClanName,
+		ClanNameTerrorists:        state.TeamTerrorists.ClanName,
+		ScoreCounterTerrorists:    int(state.TeamCounterTerrorists.Score),
+		ScoreTerrorists:           int(state.TeamTerrorists.Score),
+	}
+}
+
+// post queues the event if it was selected. Events are dropped if the queue
+// is full.
+func (w *Webhook) post(e WebhookEvent) {
+	if !w.events[e.Event] {
+		return
+	}
+	select {
+	case w.queue <- e:
+	default:
+		log.Println("trying to post webhook event: queue is full, dropping", e.Event)
+	}
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+	client := network.NewClient(webhookTimeout)
+	for e := range w.queue {
+		body, err := json.Marshal(e)
+		if err != nil {
+			log.Println("trying to encode webhook event:", err)
+			continue
+		}
+		resp, err := client.Post(w.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Println("trying to post webhook event:", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("trying to post webhook event: %v returned %v\n", w.url, resp.Status)
+		}
+	}
+}
+
+func scoreString(e WebhookEvent) string {
+	return fmt.Sprintf("%s %d:%d %s",
+		clanNameOr(e.ClanNameCounterTerrorists, "Counter Terrorists"), e.ScoreCounterTerrorists,
+		e.ScoreTerrorists, clanNameOr(e.ClanNameTerrorists, "Terrorists"))
+}
+
+func clanNameOr(clanName, fallback string) string {
+	if clanName == "" {
+		return fallback
+	}
+	return clanName
+}
+
+func teamName(team demoinfo.Team) string {
+	switch team {
+	case demoinfo.TeamCounterTerrorists:
+		return "CT"
+	case demoinfo.TeamTerrorists:
+		return "T"
+	default:
+		return "nobody"
+	}
+}
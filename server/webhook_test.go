@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+)
+
+func TestWebhookPostsEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []WebhookEvent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	webhook, err := NewWebhook(receiver.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &match.Match{MapName: "de_inferno", RoundStarts: []int{0}}
+	state := func(ct, t byte) common.OverviewState {
+		return common.OverviewState{
+			TeamCounterTerrorists: common.TeamState{ClanName: "Blue", Score: ct},
+			TeamTerrorists:        common.TeamState{ClanName: "Red", Score: t},
+		}
+	}
+
+	webhook.Observe(m, 10, state(0, 0))
+	m.BombPlants = []common.BombPlant{{Frame: 20, Site: "B", PlanterName: "alice"}}
+	webhook.Observe(m, 20, state(0, 0))
+	m.Rounds = []common.Round{{Number: 1, EndFrame: 30, Winner: demoinfo.TeamTerrorists, WinType: common.RoundWinTypeBombExploded}}
+	webhook.Observe(m, 30, state(0, 0))
+	// the score is updated a moment after the end of the round
+	webhook.Observe(m, 31, state(0, 1))
+	webhook.Finish(m)
+
+	want := []WebhookEvent{
+		{Event: WebhookBombPlant, Round: 1, Site: "B", Planter: "alice"},
+		{Event: WebhookRoundEnd, Round: 1, Winner: "T", WinType: "explosion", ScoreTerrorists: 1},
+		{Event: WebhookMatchEnd, ScoreTerrorists: 1},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("posted %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Event != w.Event || e.Round != w.Round || e.Site != w.Site || e.Planter != w.Planter ||
+			e.Winner != w.Winner || e.WinType != w.WinType || e.ScoreTerrorists != w.ScoreTerrorists {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+		if e.MapName != m.MapName || e.ClanNameCounterTerrorists != "Blue" || e.ClanNameTerrorists != "Red" {
+			t.Errorf("event %d = %+v, want the map and the clan names", i, e)
+		}
+		if e.Content == "" {
+			t.Errorf("event %d has no content", i)
+		}
+	}
+}
+
+func TestNewWebhookRejectsUnknownEvents(t *testing.T) {
+	if _, err := NewWebhook("http://localhost", "round_end,kill"); err == nil {
+		t.Error("NewWebhook() accepted the unknown event kill")
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"no origin", "", nil, true},
+		{"same host", "http://caster:8080", nil, true},
+		{"same host other case", "http://CASTER:8080", nil, true},
+		{"other port", "http://caster:3000", nil, false},
+		{"other host", "http://evil.example", nil, false},
+		{"null origin", "null", nil, false},
+		{"allowed", "http://overlay:3000", []string{"http://overlay:3000"}, true},
+		{"allowed with space and slash", "http://overlay:3000", []string{"http://other", " http://overlay:3000/"}, true},
+		{"not allowed", "http://evil.example", []string{"http://overlay:3000"}, false},
+		{"all allowed", "http://evil.example", []string{"*"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://caster:8080/ws", nil)
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+			if got := checkOrigin(r, test.allowed); got != test.want {
+				t.Errorf("checkOrigin(%q, %q) = %v, want %v", test.origin, test.allowed, got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeRejectsForeignOrigin(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://caster:8080/ws", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "http://evil.example")
+	w := httptest.NewRecorder()
+	if _, err := upgrade(w, r, nil); err == nil {
+		t.Fatal("upgrade() accepted a foreign origin")
+	}
+	if w.Code != 403 {
+		t.Errorf("upgrade() answered %d, want 403", w.Code)
+	}
+}
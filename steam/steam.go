@@ -0,0 +1,256 @@
+// Package steam enriches the players of a match with their profiles from the
+// Steam Web API. The profiles are cached locally so that a demo can be opened
+// repeatedly without querying the API every time.
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/network"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+const (
+	apiURL = "https://api.steampowered.com"
+	// maxIDsPerRequest is the maximum number of SteamIDs the API accepts in
+	// one request.
+	maxIDsPerRequest = 100
+	// cacheLifetime is the time after which cached profiles are fetched again.
+	cacheLifetime = 24 * time.Hour
+	cacheFileName = "profiles.json"
+	avatarDirName = "avatars"
+)
+
+// Client queries the Steam Web API and caches its results in CacheDir.
+type Client struct {
+	Key        string
+	CacheDir   string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client that uses the given API key. The profiles are
+// cached in the csgoverview directory of the user's cache directory.
+func NewClient(key string) (*Client, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Key:        key,
+		CacheDir:   filepath.Join(cacheDir, "csgoverview", "steam"),
+		HTTPClient: network.NewClient(10 * time.Second),
+	}, nil
+}
+
+type cachedProfile struct {
+	Profile   common.Profile
+	FetchedAt time.Time
+}
+
+// Enrich fetches the profiles of all players of the match and stores them
+// with m.SetProfiles. It modifies the match, so it has to be called before
+// the match is frozen.
+func Enrich(m *match.Match, c *Client) error {
+	var ids []uint64
+	for _, p := range m.Players() {
+		// bots have no profile
+		if p.SteamID64 != 0 {
+			ids = append(ids, p.SteamID64)
+		}
+	}
+	profiles, err := c.Profiles(ids)
+	if err != nil {
+		return err
+	}
+	m.SetProfiles(profiles)
+	return nil
+}
+
+// Profiles returns the profiles of the given SteamIDs. Profiles that were
+// fetched within cacheLifetime are taken from the cache.
+func (c *Client) Profiles(ids []uint64) (map[uint64]common.Profile, error) {
+	cache := c.readCache()
+	profiles := make(map[uint64]common.Profile)
+	var missing []uint64
+	for _, id := range ids {
+		cached, ok := cache[id]
+		if ok && time.Since(cached.FetchedAt) < cacheLifetime {
+			profiles[id] = cached.Profile
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return profiles, nil
+	}
+
+	for start := 0; start < len(missing); start += maxIDsPerRequest {
+		end := start + maxIDsPerRequest
+		if end > len(missing) {
+			end = len(missing)
+		}
+		fetched, err := c.fetchProfiles(missing[start:end])
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for id, profile := range fetched {
+			profiles[id] = profile
+			cache[id] = cachedProfile{Profile: profile, FetchedAt: now}
+		}
+	}
+
+	err := c.writeCache(cache)
+	if err != nil {
+		return nil, fmt.Errorf("trying to write profile cache: %v", err)
+	}
+	return profiles, nil
+}
+
+func (c *Client) fetchProfiles(ids []uint64) (map[uint64]common.Profile, error) {
+	idStrings := make([]string, len(ids))
+	for i, id := range ids {
+		idStrings[i] = strconv.FormatUint(id, 10)
+	}
+	joinedIDs := strings.Join(idStrings, ",")
+
+	var summaries struct {
+		Response struct {
+			Players []struct {
+				SteamID     string `json:"steamid"`
+				PersonaName string `json:"personaname"`
+				ProfileURL  string `json:"profileurl"`
+				AvatarURL   string `json:"avatarmedium"`
+			} `json:"players"`
+		} `json:"response"`
+	}
+	err := c.get("/ISteamUser/GetPlayerSummaries/v0002/", joinedIDs, &summaries)
+	if err != nil {
+		return nil, fmt.Errorf("trying to get player summaries: %v", err)
+	}
+
+	var bans struct {
+		Players []struct {
+			SteamID          string `json:"SteamId"`
+			VACBanned        bool   `json:"VACBanned"`
+			NumberOfVACBans  int    `json:"NumberOfVACBans"`
+			NumberOfGameBans int    `json:"NumberOfGameBans"`
+			DaysSinceLastBan int    `json:"DaysSinceLastBan"`
+		} `json:"players"`
+	}
+	err = c.get("/ISteamUser/GetPlayerBans/v1/", joinedIDs, &bans)
+	if err != nil {
+		return nil, fmt.Errorf("trying to get player bans: %v", err)
+	}
+
+	profiles := make(map[uint64]common.Profile)
+	for _, s := range summaries.Response.Players {
+		id, err := strconv.ParseUint(s.SteamID, 10, 64)
+		if err != nil {
+			continue
+		}
+		profile := profiles[id]
+		profile.SteamID64 = id
+		profile.PersonaName = s.PersonaName
+		profile.ProfileURL = s.ProfileURL
+		profile.AvatarURL = s.AvatarURL
+		profiles[id] = profile
+	}
+	for _, b := range bans.Players {
+		id, err := strconv.ParseUint(b.SteamID, 10, 64)
+		if err != nil {
+			continue
+		}
+		profile := profiles[id]
+		profile.SteamID64 = id
+		profile.VACBanned = b.VACBanned
+		profile.NumberOfVACBans = b.NumberOfVACBans
+		profile.NumberOfGameBans = b.NumberOfGameBans
+		profile.DaysSinceLastBan = b.DaysSinceLastBan
+		profiles[id] = profile
+	}
+	return profiles, nil
+}
+
+func (c *Client) get(path, ids string, v interface{}) error {
+	query := url.Values{}
+	query.Set("key", c.Key)
+	query.Set("steamids", ids)
+	resp, err := c.HTTPClient.Get(apiURL + path + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) readCache() map[uint64]cachedProfile {
+	cache := make(map[uint64]cachedProfile)
+	data, err := ioutil.ReadFile(filepath.Join(c.CacheDir, cacheFileName))
+	if err != nil {
+		return cache
+	}
+	// a broken cache is ignored and overwritten
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+func (c *Client) writeCache(cache map[uint64]cachedProfile) error {
+	err := os.MkdirAll(c.CacheDir, 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(c.CacheDir, cacheFileName), data, 0644)
+}
+
+// Avatar returns the avatar image of the profile. The image is downloaded
+// once and then read from the cache.
+func (c *Client) Avatar(profile common.Profile) ([]byte, error) {
+	if profile.AvatarURL == "" {
+		return nil, fmt.Errorf("profile %v has no avatar", profile.SteamID64)
+	}
+	fileName := filepath.Join(c.CacheDir, avatarDirName, strconv.FormatUint(profile.SteamID64, 10)+filepath.Ext(profile.AvatarURL))
+	data, err := ioutil.ReadFile(fileName)
+	if err == nil {
+		return data, nil
+	}
+
+	resp, err := c.HTTPClient.Get(profile.AvatarURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(fileName, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,916 @@
+package viewer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/linus4/csgoverview/locale"
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/mapinfo"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	radiusPlayer        int32   = 10
+	radiusPlayerFloat   float64 = float64(radiusPlayer)
+	shotLength          float64 = 1000
+	killLineLifetime    int     = 2
+	awpTrailSeconds     int     = 4
+	awpTrailLength      float64 = 400
+	tooltipLineHeight   int32   = 18
+	progressRingOffset  int32   = 5
+	oneWayRingOffset    int32   = 8
+	selectionRingOffset int32   = 3
+	// soundLifetime is the number of seconds in which sound circles fade out.
+	soundLifetime float64 = 1
+	// altitudeRadiusChange is how much larger the highest and smaller the
+	// lowest players are drawn with altitude shading.
+	altitudeRadiusChange int32 = 3
+	// deathMarkerHoverRadius is the distance from a death marker in which it
+	// shows its tooltip.
+	deathMarkerHoverRadius int32   = 8
+	grenadeHoverRadius     int32   = 6
+	tooltipOffset          int32   = 12
+	entryArrowMaxWidth     int32   = 8
+	entryArrowHeadSize     float64 = 8
+	measurementPointRadius int32   = 3
+)
+
+var (
+	colorTerror            = sdl.Color{252, 176, 12, 255}
+	colorCounter           = sdl.Color{89, 206, 200, 255}
+	colorBomb              = sdl.Color{255, 0, 0, 255}
+	colorEqDecoy           = sdl.Color{102, 34, 0, 255}
+	colorEqMolotov         = sdl.Color{255, 153, 0, 255}
+	colorEqIncendiary      = sdl.Color{255, 153, 0, 255}
+	colorInferno           = sdl.Color{255, 153, 0, 100}
+	colorInfernoExtent     = sdl.Color{255, 153, 0, 120}
+	colorOneWay            = sdl.Color{230, 230, 230, 255}
+	colorSelection         = sdl.Color{120, 220, 80, 255}
+	colorDroppedWeapon     = sdl.Color{200, 200, 200, 200}
+	colorEqFlash           = sdl.Color{128, 170, 255, 255}
+	colorEqSmoke           = sdl.Color{153, 153, 153, 255}
+	colorSmoke             = sdl.Color{153, 153, 153, 100}
+	colorEqHE              = sdl.Color{85, 150, 0, 255}
+	colorDarkWhite         = sdl.Color{200, 200, 200, 255}
+	colorFlashEffect       = sdl.Color{200, 200, 200, 180}
+	colorAwpShot           = sdl.Color{255, 50, 0, 255}
+	colorOverlayBackground = sdl.Color{10, 10, 10, 200}
+	colorDefuseInTime      = sdl.Color{0, 200, 0, 255}
+	colorGhost             = sdl.Color{255, 255, 255, 110}
+	colorMeasurement       = sdl.Color{255, 230, 90, 255}
+	colorLineOfSight       = sdl.Color{255, 255, 200, 45}
+	colorCrossfire         = sdl.Color{89, 206, 200, 40}
+	colorCrossfireGap      = sdl.Color{255, 40, 40, 255}
+	colorZone              = sdl.Color{255, 255, 255, 70}
+)
+
+// Draw draws the overview and everything on it at Frame. It does not clear
+// the renderer or present it.
+func (v *Viewer) Draw() {
+	if v.Frame < 0 || v.Frame > v.match.LastFrame() {
+		return
+	}
+	v.followSelection()
+	if overview := v.sectionOverview(); overview != nil {
+		v.renderer.Copy(overview, nil, &sdl.Rect{X: v.X, Y: v.Y, W: OverviewSize, H: OverviewSize})
+	}
+
+	if v.Zones {
+		v.drawZones()
+	}
+
+	if v.HiddenLayers.Shows(common.LayerShots) {
+		shots := v.match.ShotsAt(v.Frame)
+		for _, shot := range shots {
+			if v.IsSelected(shot.ShooterSteamID64) {
+				v.drawShot(&shot)
+			}
+		}
+	}
+
+	if v.HiddenLayers.Shows(common.LayerKillfeed) {
+		kills := v.match.KillfeedAt(v.Frame)
+		for _, kill := range kills {
+			if v.IsSelected(kill.KillerSteamID64) || v.IsSelected(kill.VictimSteamID64) {
+				v.drawKillLine(&kill)
+			}
+		}
+	}
+
+	state := v.HiddenLayers.FilterState(v.match.StateAtFrame(v.Frame))
+
+	for _, inferno := range state.Infernos {
+		if v.InfernoExtents {
+			v.drawInfernoExtent(&inferno)
+		}
+		v.drawInferno(&inferno)
+	}
+
+	for _, weapon := range state.DroppedWeapons {
+		v.drawDroppedWeapon(&weapon)
+	}
+
+	if v.HiddenLayers.Shows(common.LayerGrenadeEffects) {
+		effects := v.match.EffectsAt(v.Frame)
+		for _, effect := range effects {
+			v.drawGrenadeEffect(&effect)
+		}
+	}
+
+	for _, grenade := range state.Grenades {
+		v.drawGrenade(&grenade)
+	}
+
+	if v.HiddenLayers.Shows(common.LayerBomb) {
+		v.drawBomb(&state.Bomb)
+	}
+
+	if v.EntryPaths {
+		v.drawEntryPaths()
+	}
+
+	if v.SmokeKills {
+		v.drawSmokeKills()
+	}
+
+	if v.AWPOverlay {
+		v.drawAWPOverlay()
+	}
+
+	if v.SoundOverlay {
+		for _, sound := range v.match.SoundsAt(v.Frame) {
+			if v.IsSelected(sound.SteamID64) {
+				v.drawSound(&sound)
+			}
+		}
+	}
+
+	if v.LineOfSight {
+		v.drawLinesOfSight(state.Players)
+	}
+
+	if v.Crossfire {
+		v.drawCrossfire(state.Players)
+	}
+
+	var deaths []common.Kill
+	if v.HiddenLayers.Shows(common.LayerDeadPlayers) {
+		deaths = v.match.DeathsAt(v.Frame)
+	}
+	died := make(map[uint64]bool, len(deaths))
+	for _, death := range deaths {
+		v.drawDeathMarker(&death)
+		died[death.VictimSteamID64] = true
+	}
+
+	players := v.match.StateAtFrame(v.Frame).Players
+	for _, player := range players {
+		if !player.IsAlive && died[player.SteamID64] {
+			continue
+		}
+		v.drawPlayer(&player)
+		if v.Selected[player.ID] && player.IsAlive {
+			v.drawSelection(&player)
+		}
+	}
+
+	if v.Ghost != nil {
+		v.drawGhost(*v.Ghost)
+	}
+
+	if len(v.Measurement) > 0 {
+		v.drawMeasurement()
+	}
+
+	if v.SpawnTiming != nil {
+		v.drawSpawnTiming(*v.SpawnTiming)
+	}
+
+	for _, oneWay := range v.match.OneWaysAt(v.Frame) {
+		for _, player := range players {
+			if player.SteamID64 == oneWay.SteamID64 {
+				v.drawOneWay(&player)
+			}
+		}
+	}
+
+	for _, progress := range v.match.ProgressAt(v.Frame) {
+		for _, player := range players {
+			if player.SteamID64 == progress.SteamID64 {
+				v.drawProgress(&player, progress)
+			}
+		}
+	}
+
+	if lines, ok := v.hoveredTooltip(&state, deaths); ok && v.font != nil {
+		v.drawTooltip(lines, v.mouseX, v.mouseY)
+	}
+}
+
+// screen returns the position in renderer coordinates of a position in world
+// coordinates.
+func (v *Viewer) screen(pos common.Point) (int32, int32) {
+	scaledX, scaledY := v.match.TranslateScale(pos.X, pos.Y)
+	return int32(scaledX) + v.X, int32(scaledY) + v.Y
+}
+
+func (v *Viewer) drawPlayer(player *common.Player) {
+	var color sdl.Color
+	if player.Team == demoinfo.TeamTerrorists {
+		color = colorTerror
+	} else {
+		color = colorCounter
+	}
+
+	if player.IsAlive {
+		scaledXInt, scaledYInt := v.screen(player.Position)
+
+		// players on another floor are faded out
+		color.A = v.sectionAlpha(player.PositionZ)
+		radius := v.altitudeRadius(radiusPlayer, player.PositionZ)
+		viewColor := colorDarkWhite
+		viewColor.A = color.A
+
+		gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radius, color)
+		if player.ObserverSlot >= 0 && !player.IsDefusing {
+			gfx.CharacterColor(v.renderer, scaledXInt-3, scaledYInt-3, byte('0'+player.ObserverSlot), color)
+		}
+
+		if v.font != nil {
+			DrawString(v.renderer, cropStringToN(player.Name, 10), color, scaledXInt+10, scaledYInt+10, v.font)
+		}
+
+		viewAngle := int32(v.match.ScreenAngle(player.ViewDirectionX))
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, radius+1, viewAngle-20, viewAngle+20, viewColor)
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, radius+2, viewAngle-10, viewAngle+10, viewColor)
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, radius+3, viewAngle-5, viewAngle+5, viewColor)
+
+		if player.FlashDuration.Seconds() > 0.5 {
+			remaining := player.FlashTimeRemaining
+			colorFlashEffect.A = uint8((remaining.Seconds() * 255) / (2 + 5.5))
+			gfx.FilledCircleColor(v.renderer, scaledXInt, scaledYInt, radius-5, colorFlashEffect)
+		}
+
+		if player.HasBomb {
+			gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radius-1, colorBomb)
+			gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radius-2, colorBomb)
+		}
+
+		if player.IsDefusing {
+			defuseColor := color
+			defuseColor.A = uint8(int(color.A) * 200 / 255)
+			gfx.CharacterColor(v.renderer, scaledXInt-radius/4, scaledYInt-radius/4, 'D', defuseColor)
+		}
+	} else if v.HiddenLayers.Shows(common.LayerDeadPlayers) {
+		// players that died without a kill, e.g. because they disconnected
+		scaledXInt, scaledYInt := v.screen(player.LastAlivePosition)
+
+		color.A = 150
+		gfx.CharacterColor(v.renderer, scaledXInt, scaledYInt, 'X', color)
+		color.A = 255
+	}
+}
+
+// drawSelection draws a ring around a selected player.
+func (v *Viewer) drawSelection(player *common.Player) {
+	scaledXInt, scaledYInt := v.screen(player.Position)
+	gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radiusPlayer+selectionRingOffset, colorSelection)
+}
+
+// drawGhost draws the marker of the reviewer as a translucent player dot
+// with a question mark.
+func (v *Viewer) drawGhost(position common.Point) {
+	x, y := v.screen(position)
+	gfx.FilledCircleColor(v.renderer, x, y, radiusPlayer, colorGhost)
+	gfx.AACircleColor(v.renderer, x, y, radiusPlayer, colorDarkWhite)
+	gfx.CharacterColor(v.renderer, x-3, y-3, '?', colorOverlayBackground)
+}
+
+// drawMeasurement draws the line between the measured positions and labels
+// it with the distance and the time it takes to run it.
+func (v *Viewer) drawMeasurement() {
+	x1, y1 := v.screen(v.Measurement[0])
+	gfx.FilledCircleColor(v.renderer, x1, y1, measurementPointRadius, colorMeasurement)
+	if len(v.Measurement) < 2 {
+		return
+	}
+	x2, y2 := v.screen(v.Measurement[1])
+	gfx.ThickLineColor(v.renderer, x1, y1, x2, y2, 2, colorMeasurement)
+	gfx.FilledCircleColor(v.renderer, x2, y2, measurementPointRadius, colorMeasurement)
+	if v.font == nil {
+		return
+	}
+	distance := v.Measurement[0].Distance(v.Measurement[1])
+	v.drawTooltip([]string{
+		locale.Sprintf("measure.distance", distance, mapinfo.Meters(distance)),
+		locale.Sprintf("measure.travel_time", mapinfo.TravelTime(distance).Seconds()),
+	}, x2, y2)
+}
+
+// drawSpawnTiming marks the position and labels it with the times the teams
+// need to run there from their spawns.
+func (v *Viewer) drawSpawnTiming(position common.Point) {
+	x, y := v.screen(position)
+	gfx.FilledCircleColor(v.renderer, x, y, measurementPointRadius, colorMeasurement)
+	if v.font == nil {
+		return
+	}
+	t, ct, ok := stats.SpawnArrivals(v.match, position)
+	if !ok {
+		v.drawTooltip([]string{locale.T("timing.unknown")}, x, y)
+		return
+	}
+	v.drawTooltip([]string{
+		locale.Sprintf("timing.t", t.Seconds()),
+		locale.Sprintf("timing.ct", ct.Seconds()),
+	}, x, y)
+}
+
+// drawLinesOfSight shades the area that each selected player who is alive
+// can see. Nothing is drawn if the geometry of the map is unknown.
+func (v *Viewer) drawLinesOfSight(players []common.Player) {
+	info, ok := mapinfo.Lookup(v.match.MapName)
+	if !ok || len(info.Occluders) == 0 {
+		return
+	}
+	for _, player := range players {
+		if player.IsAlive && v.Selected[player.ID] {
+			v.drawVisibleArea(info, &player, colorLineOfSight)
+		}
+	}
+}
+
+// drawCrossfire shades the combined area that the selected counter-terrorists
+// can see and marks the chokepoints to the sites that they watch green and
+// the gaps in their setup red. It needs at least two selected
+// counter-terrorists who are alive.
+func (v *Viewer) drawCrossfire(players []common.Player) {
+	info, ok := mapinfo.Lookup(v.match.MapName)
+	if !ok || len(info.Occluders) == 0 {
+		return
+	}
+	defenders := make([]common.Player, 0)
+	for _, player := range players {
+		if player.IsAlive && player.Team == demoinfo.TeamCounterTerrorists && v.Selected[player.ID] {
+			defenders = append(defenders, player)
+		}
+	}
+	if len(defenders) < 2 {
+		return
+	}
+	for _, player := range defenders {
+		v.drawVisibleArea(info, &player, colorCrossfire)
+	}
+	for _, chokepoint := range info.Chokepoints {
+		if chokepoint.Site == "" {
+			continue
+		}
+		color := colorCrossfireGap
+		for _, player := range defenders {
+			if info.Watches(player.Position, player.ViewDirectionX, chokepoint) {
+				color = colorSelection
+				break
+			}
+		}
+		x1, y1 := v.screen(chokepoint.From)
+		x2, y2 := v.screen(chokepoint.To)
+		gfx.ThickLineColor(v.renderer, x1, y1, x2, y2, 3, color)
+	}
+}
+
+// drawZones outlines the bombsites and hostage zones of the map and labels
+// them with their names. Nothing is drawn if the map is unknown.
+func (v *Viewer) drawZones() {
+	info, ok := mapinfo.Lookup(v.match.MapName)
+	if !ok {
+		return
+	}
+	for _, zone := range append(info.Bombsites, info.HostageZones...) {
+		xCoordinates := make([]int16, 0, len(zone.Polygon))
+		yCoordinates := make([]int16, 0, len(zone.Polygon))
+		for _, point := range zone.Polygon {
+			scaledX, scaledY := v.screen(point)
+			xCoordinates = append(xCoordinates, int16(scaledX))
+			yCoordinates = append(yCoordinates, int16(scaledY))
+		}
+		gfx.AAPolygonColor(v.renderer, xCoordinates, yCoordinates, colorZone)
+		if v.font != nil {
+			x, y := v.screen(zone.Center())
+			DrawString(v.renderer, zone.Name, colorZone, x-5, y-10, v.font)
+		}
+	}
+}
+
+// drawVisibleArea fills the area that the player can see.
+func (v *Viewer) drawVisibleArea(info mapinfo.Info, player *common.Player, color sdl.Color) {
+	area := info.VisibleArea(player.Position, player.ViewDirectionX)
+	xCoordinates := make([]int16, 0, len(area))
+	yCoordinates := make([]int16, 0, len(area))
+	for _, point := range area {
+		scaledX, scaledY := v.screen(point)
+		xCoordinates = append(xCoordinates, int16(scaledX))
+		yCoordinates = append(yCoordinates, int16(scaledY))
+	}
+	gfx.FilledPolygonColor(v.renderer, xCoordinates, yCoordinates, color)
+}
+
+// drawDeathMarker draws an X where the victim of the kill died. It stays until
+// the end of the round.
+func (v *Viewer) drawDeathMarker(kill *common.Kill) {
+	color := colorCounter
+	if kill.VictimTeam == demoinfo.TeamTerrorists {
+		color = colorTerror
+	}
+	scaledXInt, scaledYInt := v.screen(kill.VictimPosition)
+
+	color.A = 150
+	gfx.CharacterColor(v.renderer, scaledXInt, scaledYInt, 'X', color)
+}
+
+// isHovering reports whether the mouse is within radius of the point x, y in
+// renderer coordinates.
+func (v *Viewer) isHovering(x, y, radius int32) bool {
+	dx := v.mouseX - x
+	dy := v.mouseY - y
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// hoveredTooltip returns the tooltip of the player, grenade or death marker
+// under the mouse. Players take precedence over grenades and grenades over
+// death markers.
+func (v *Viewer) hoveredTooltip(state *common.OverviewState, deaths []common.Kill) ([]string, bool) {
+	for i := range state.Players {
+		player := &state.Players[i]
+		if !player.IsAlive {
+			continue
+		}
+		x, y := v.screen(player.Position)
+		if v.isHovering(x, y, radiusPlayer) {
+			return playerTooltip(player), true
+		}
+	}
+	for i := range state.Grenades {
+		grenade := &state.Grenades[i]
+		x, y := v.screen(grenade.Position)
+		if v.isHovering(x, y, grenadeHoverRadius) {
+			return grenadeTooltip(grenade), true
+		}
+	}
+	for i := len(deaths) - 1; i >= 0; i-- {
+		kill := &deaths[i]
+		x, y := v.screen(kill.VictimPosition)
+		// the character is drawn with its top left corner at the position
+		if v.isHovering(x+4, y+4, deathMarkerHoverRadius) {
+			return deathTooltip(kill), true
+		}
+	}
+	return nil, false
+}
+
+// playerTooltip returns the lines of the tooltip of a player.
+func playerTooltip(player *common.Player) []string {
+	armor := locale.Sprintf("tooltip.armor", player.Armor)
+	if player.Armor > 0 && player.HasHelmet {
+		armor = locale.Sprintf("tooltip.armor_helmet", player.Armor)
+	}
+	inventory := make([]string, 0, len(player.Inventory))
+	for _, item := range player.Inventory {
+		if item.Count > 1 {
+			inventory = append(inventory, fmt.Sprintf("%v x%d", item.Type, item.Count))
+		} else {
+			inventory = append(inventory, item.Type.String())
+		}
+	}
+	if player.HasDefuseKit {
+		inventory = append(inventory, locale.T("tooltip.defuse_kit"))
+	}
+	return []string{
+		player.Name,
+		locale.Sprintf("tooltip.health", player.Health) + "  " + armor + "  " + fmt.Sprintf("%v $", player.Money),
+		strings.Join(inventory, ", "),
+		locale.Sprintf("tooltip.kda", player.Kills, player.Assists, player.Deaths),
+	}
+}
+
+// grenadeTooltip returns the lines of the tooltip of a flying grenade.
+func grenadeTooltip(grenade *common.GrenadeProjectile) []string {
+	if grenade.ThrowerName == "" {
+		return []string{grenade.Type.String()}
+	}
+	return []string{grenade.Type.String(), locale.Sprintf("tooltip.thrown_by", grenade.ThrowerName)}
+}
+
+// deathTooltip returns the lines of the tooltip of a death marker.
+func deathTooltip(kill *common.Kill) []string {
+	switch {
+	case kill.Type == common.KillSuicide:
+		return []string{kill.VictimName, locale.Sprintf("tooltip.suicide", kill.Weapon)}
+	case kill.Type == common.KillFallDamage:
+		return []string{kill.VictimName, locale.T("tooltip.fall_damage")}
+	case kill.Type == common.KillTeam:
+		return []string{kill.VictimName, locale.Sprintf("tooltip.team_kill", kill.KillerName, kill.Weapon)}
+	case !kill.HasKiller():
+		return []string{kill.VictimName, locale.Sprintf("tooltip.died", kill.Weapon)}
+	}
+	return []string{kill.VictimName, locale.Sprintf("tooltip.killed_by", kill.KillerName, kill.Weapon)}
+}
+
+// drawTooltip draws the lines in a box next to the position x, y. The box is
+// moved to the left of the position if it would leave the renderer.
+func (v *Viewer) drawTooltip(lines []string, x, y int32) {
+	var width int32
+	for _, line := range lines {
+		w, _, err := v.font.SizeUTF8(line)
+		if err == nil && int32(w) > width {
+			width = int32(w)
+		}
+	}
+	height := int32(len(lines)) * tooltipLineHeight
+	x += tooltipOffset
+	y += tooltipOffset
+	rendererWidth, _ := v.renderer.GetLogicalSize()
+	if rendererWidth == 0 {
+		rendererWidth, _, _ = v.renderer.GetOutputSize()
+	}
+	if x+width+10 > rendererWidth {
+		x -= width + 10 + 2*tooltipOffset
+	}
+	gfx.BoxColor(v.renderer, x, y, x+width+10, y+height+10, colorOverlayBackground)
+	for _, line := range lines {
+		DrawString(v.renderer, line, colorDarkWhite, x+5, y+5, v.font)
+		y += tooltipLineHeight
+	}
+}
+
+// drawProgress draws a ring around a player that is planting or defusing the
+// bomb. The ring fills clockwise from the top as the action progresses.
+func (v *Viewer) drawProgress(player *common.Player, progress common.ActionProgress) {
+	if !player.IsAlive {
+		return
+	}
+	scaledXInt, scaledYInt := v.screen(player.Position)
+
+	color := colorBomb
+	if progress.IsDefuse {
+		color = colorDefuseInTime
+	}
+	radius := radiusPlayer + progressRingOffset
+	end := -90 + int32(360*progress.Progress)
+	color.A = 80
+	gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radius, color)
+	color.A = 255
+	if end > -90 {
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, radius, -90, end, color)
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, radius+1, -90, end, color)
+	}
+}
+
+func (v *Viewer) drawGrenade(grenade *common.GrenadeProjectile) {
+	scaledXInt, scaledYInt := v.screen(grenade.Position)
+	var color sdl.Color
+
+	switch grenade.Type {
+	case demoinfo.EqDecoy:
+		color = colorEqDecoy
+	case demoinfo.EqMolotov:
+		color = colorEqMolotov
+	case demoinfo.EqIncendiary:
+		color = colorEqIncendiary
+	case demoinfo.EqFlash:
+		color = colorEqFlash
+	case demoinfo.EqSmoke:
+		color = colorEqSmoke
+	case demoinfo.EqHE:
+		color = colorEqHE
+	}
+
+	color.A = v.sectionAlpha(grenade.PositionZ)
+	size := v.altitudeRadius(2, grenade.PositionZ) - 2
+
+	gfx.BoxColor(v.renderer, scaledXInt-2-size, scaledYInt-3-size, scaledXInt+2+size, scaledYInt+3+size, color)
+}
+
+// altitudeRadius returns the radius for something at the given height. With
+// altitude shading, higher positions are drawn larger than lower ones, e.g.
+// to tell apart players on a boost from players below them.
+func (v *Viewer) altitudeRadius(radius int32, z float32) int32 {
+	if !v.AltitudeShading {
+		return radius
+	}
+	relative := v.match.RelativeAltitude(z)*2 - 1
+	change := int32(math.Round(float64(relative * float32(altitudeRadiusChange))))
+	if radius+change < 1 {
+		return 1
+	}
+	return radius + change
+}
+
+// drawDroppedWeapon draws a small mark for a weapon on the ground. Dropped
+// AWPs stand out because they are often worth picking up or denying.
+func (v *Viewer) drawDroppedWeapon(weapon *common.DroppedWeapon) {
+	scaledXInt, scaledYInt := v.screen(weapon.Position)
+
+	color := colorDroppedWeapon
+	switch {
+	case weapon.Type == demoinfo.EqAWP:
+		color = colorAwpShot
+	case weapon.Type.Class() == demoinfo.EqClassGrenade:
+		color = colorEqSmoke
+	}
+	gfx.RectangleColor(v.renderer, scaledXInt-4, scaledYInt-2, scaledXInt+4, scaledYInt+2, color)
+}
+
+func (v *Viewer) drawGrenadeEffect(effect *common.GrenadeEffect) {
+	scaledXInt, scaledYInt := v.screen(effect.Position)
+
+	switch effect.GrenadeType {
+	case demoinfo.EqFlash:
+		gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, effect.Lifetime, colorEqFlash)
+	case demoinfo.EqHE:
+		gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, effect.Lifetime, colorEqHE)
+	case demoinfo.EqSmoke:
+		radius, opacity := smokeShape(effect.Lifetime, v.match.SmokeEffectLifetime, v.match.FrameRate)
+		scaledRadiusSmoke := int32(radius / v.match.MapScale)
+		color := colorSmoke
+		color.A = uint8(float64(colorSmoke.A) * opacity)
+		gfx.FilledCircleColor(v.renderer, scaledXInt, scaledYInt, scaledRadiusSmoke, color)
+		// only draw the outline if the smoke is not fading
+		if opacity == 1 {
+			gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, scaledRadiusSmoke, colorDarkWhite)
+		}
+		gfx.ArcColor(v.renderer, scaledXInt, scaledYInt, 10, 270+effect.Lifetime*360/v.match.SmokeEffectLifetime, 630, colorDarkWhite)
+	}
+}
+
+// smokeShape returns the radius and opacity of a smoke effect that has been
+// shown for lifetime of its total frames.
+func smokeShape(lifetime, total int32, frameRate float64) (float32, float64) {
+	elapsed := time.Duration(float64(lifetime) / frameRate * float64(time.Second))
+	remaining := time.Duration(float64(total-lifetime) / frameRate * float64(time.Second))
+	return match.SmokeShape(elapsed, remaining)
+}
+
+// drawOneWay marks a player that stands in a potential one-way position.
+func (v *Viewer) drawOneWay(player *common.Player) {
+	scaledXInt, scaledYInt := v.screen(player.Position)
+	gfx.AACircleColor(v.renderer, scaledXInt, scaledYInt, radiusPlayer+oneWayRingOffset, colorOneWay)
+}
+
+func (v *Viewer) drawInferno(inferno *common.Inferno) {
+	hull := inferno.ConvexHull2D
+	xCoordinates := make([]int16, 0)
+	yCoordinates := make([]int16, 0)
+
+	for _, point := range hull {
+		scaledX, scaledY := v.screen(point)
+		xCoordinates = append(xCoordinates, int16(scaledX))
+		yCoordinates = append(yCoordinates, int16(scaledY))
+	}
+
+	gfx.FilledPolygonColor(v.renderer, xCoordinates, yCoordinates, colorInferno)
+	gfx.AAPolygonColor(v.renderer, xCoordinates, yCoordinates, colorInferno)
+}
+
+// drawInfernoExtent draws a faint outline of the area the inferno will have
+// spread to when it burns out.
+func (v *Viewer) drawInfernoExtent(inferno *common.Inferno) {
+	extent := v.match.InfernoExtent(inferno.ID)
+	if len(extent) < 3 {
+		return
+	}
+	xCoordinates := make([]int16, 0, len(extent))
+	yCoordinates := make([]int16, 0, len(extent))
+	for _, point := range extent {
+		scaledX, scaledY := v.screen(point)
+		xCoordinates = append(xCoordinates, int16(scaledX))
+		yCoordinates = append(yCoordinates, int16(scaledY))
+	}
+	gfx.AAPolygonColor(v.renderer, xCoordinates, yCoordinates, colorInfernoExtent)
+}
+
+func (v *Viewer) drawBomb(bomb *common.Bomb) {
+	if bomb.IsBeingCarried {
+		return
+	}
+	scaledXInt, scaledYInt := v.screen(bomb.Position)
+	gfx.BoxColor(v.renderer, scaledXInt-3, scaledYInt-2, scaledXInt+3, scaledYInt+2, colorBomb)
+}
+
+func (v *Viewer) drawShot(shot *common.Shot) {
+	pos := shot.Position
+	viewAngleDegrees := v.match.ScreenAngle(shot.ViewDirectionX)
+	viewAngleRadian := float64(viewAngleDegrees * math.Pi / 180)
+	color := colorDarkWhite
+	if shot.IsAwpShot {
+		color = colorAwpShot
+	}
+
+	scaledX, scaledY := v.match.TranslateScale(pos.X, pos.Y)
+	scaledX += float32(math.Cos(viewAngleRadian) * radiusPlayerFloat)
+	scaledY += float32(math.Sin(viewAngleRadian) * radiusPlayerFloat)
+	var scaledXInt int32 = int32(scaledX) + v.X
+	var scaledYInt int32 = int32(scaledY) + v.Y
+
+	targetX := int32(scaledXInt) + int32(math.Cos(viewAngleRadian)*shotLength/float64(v.match.MapScale))
+	targetY := int32(scaledYInt) + int32(math.Sin(viewAngleRadian)*shotLength/float64(v.match.MapScale))
+
+	gfx.AALineColor(v.renderer, scaledXInt, scaledYInt, targetX, targetY, color)
+}
+
+// drawSound draws the area in which enemies could hear a sound. The circle
+// fades out over the lifetime of the sound.
+func (v *Viewer) drawSound(sound *common.Sound) {
+	var color sdl.Color
+	if sound.Team == demoinfo.TeamTerrorists {
+		color = colorTerror
+	} else {
+		color = colorCounter
+	}
+	age := v.match.TimeAt(v.Frame) - v.match.TimeAt(sound.Frame)
+	color.A = uint8(150 * (1 - math.Min(age.Seconds()/soundLifetime, 1)))
+
+	x, y := v.screen(sound.Position)
+	radius := int32(sound.Type.Radius() / v.match.MapScale)
+	gfx.AACircleColor(v.renderer, x, y, radius, color)
+}
+
+func (v *Viewer) drawKillLine(kill *common.Kill) {
+	lifetime := v.match.FrameRateRounded * killLineLifetime
+	age := v.Frame - kill.Frame
+	if !kill.HasKiller() || age < 0 || age >= lifetime {
+		return
+	}
+
+	var color sdl.Color
+	if kill.KillerTeam == demoinfo.TeamTerrorists {
+		color = colorTerror
+	} else {
+		color = colorCounter
+	}
+	color.A = uint8(255 - 200*age/lifetime)
+
+	killerX, killerY := v.screen(kill.KillerPosition)
+	victimX, victimY := v.screen(kill.VictimPosition)
+	gfx.AALineColor(v.renderer, killerX, killerY, victimX, victimY, color)
+}
+
+// drawSmokeKills draws a line from the killer to the victim of every kill
+// through a smoke of the match, the kills of the current round are drawn
+// stronger.
+func (v *Viewer) drawSmokeKills() {
+	round := v.match.RoundIndex(v.Frame)
+	for _, kill := range v.match.Kills {
+		if !kill.ThroughSmoke || !v.IsSelected(kill.KillerSteamID64) && !v.IsSelected(kill.VictimSteamID64) {
+			continue
+		}
+		var color sdl.Color
+		if kill.KillerTeam == demoinfo.TeamTerrorists {
+			color = colorTerror
+		} else {
+			color = colorCounter
+		}
+		color.A = 90
+		if v.match.RoundIndex(kill.Frame) == round {
+			color.A = 220
+		}
+		killerX, killerY := v.screen(kill.KillerPosition)
+		victimX, victimY := v.screen(kill.VictimPosition)
+		gfx.AALineColor(v.renderer, killerX, killerY, victimX, victimY, color)
+		gfx.FilledCircleColor(v.renderer, victimX, victimY, 3, color)
+	}
+}
+
+func (v *Viewer) drawAWPOverlay() {
+	m := v.match
+	for _, player := range m.StateAtFrame(v.Frame).Players {
+		if !player.IsAlive || !hasAWP(&player) || !v.IsSelected(player.ID) {
+			continue
+		}
+
+		// view-cone residue of the angles the player held recently
+		step := m.FrameRateRounded / 4
+		if step == 0 {
+			step = 1
+		}
+		trailFrames := m.FrameRateRounded * awpTrailSeconds
+		for i := step; i <= trailFrames && v.Frame-i >= 0; i += step {
+			for _, past := range m.StateAtFrame(v.Frame - i).Players {
+				if past.SteamID64 != player.SteamID64 || !past.IsAlive {
+					continue
+				}
+				color := colorAwpShot
+				color.A = uint8(120 - 100*i/trailFrames)
+				v.drawViewLine(past.Position, past.ViewDirectionX, awpTrailLength, color)
+			}
+		}
+
+		// reposition since the latest shot in this round
+		var shots, kills int
+		var lastShot *common.Shot
+		round := m.RoundIndex(v.Frame)
+		for i, shot := range m.FiredShots {
+			if shot.Frame > v.Frame {
+				break
+			}
+			if !shot.IsAwpShot || shot.ShooterSteamID64 != player.SteamID64 {
+				continue
+			}
+			shots++
+			if m.RoundIndex(shot.Frame) == round {
+				lastShot = &m.FiredShots[i]
+			}
+		}
+		if lastShot != nil {
+			shotX, shotY := v.screen(lastShot.Position)
+			playerX, playerY := v.screen(player.Position)
+			gfx.CircleColor(v.renderer, shotX, shotY, 3, colorAwpShot)
+			gfx.LineColor(v.renderer, shotX, shotY, playerX, playerY, colorAwpShot)
+		}
+
+		// kill/shot conversion so far
+		for _, kill := range m.Kills {
+			if kill.Frame > v.Frame {
+				break
+			}
+			if kill.KillerSteamID64 == player.SteamID64 && kill.Weapon == demoinfo.EqAWP {
+				kills++
+			}
+		}
+		if v.font != nil {
+			x, y := v.screen(player.Position)
+			DrawString(v.renderer, fmt.Sprintf("%d/%d", kills, shots), colorAwpShot, x+10, y-20, v.font)
+		}
+	}
+}
+
+func (v *Viewer) drawViewLine(pos common.Point, viewDirectionX float32, length float64, color sdl.Color) {
+	viewAngleRadian := float64(v.match.ScreenAngle(viewDirectionX) * math.Pi / 180)
+	startX, startY := v.screen(pos)
+	targetX := startX + int32(math.Cos(viewAngleRadian)*length/float64(v.match.MapScale))
+	targetY := startY + int32(math.Sin(viewAngleRadian)*length/float64(v.match.MapScale))
+	gfx.AALineColor(v.renderer, startX, startY, targetX, targetY, color)
+}
+
+func hasAWP(player *common.Player) bool {
+	for _, item := range player.Inventory {
+		if item.Type == demoinfo.EqAWP {
+			return true
+		}
+	}
+	return false
+}
+
+// drawEntryPaths draws the flows of the terrorists towards Site or towards
+// all sites. The flows are computed when they are shown first.
+func (v *Viewer) drawEntryPaths() {
+	if !v.entryFlowsLoaded {
+		v.entryFlows = stats.EntryFlows(v.match, stats.Options{})
+		v.entryFlowsLoaded = true
+	}
+	var maxPlayers int
+	for _, flow := range v.entryFlows {
+		if (v.Site == "" || flow.Site == v.Site) && flow.Players > maxPlayers {
+			maxPlayers = flow.Players
+		}
+	}
+	for i := len(v.entryFlows) - 1; i >= 0; i-- {
+		flow := v.entryFlows[i]
+		if v.Site != "" && flow.Site != v.Site {
+			continue
+		}
+		share := float64(flow.Players) / float64(maxPlayers)
+		color := colorTerror
+		color.A = uint8(50 + 180*share)
+		fromX, fromY := v.screen(flow.From)
+		toX, toY := v.screen(flow.To)
+		v.drawArrow(fromX, fromY, toX, toY, 1+int32(share*float64(entryArrowMaxWidth-1)), color)
+	}
+}
+
+// drawArrow draws a line with an arrowhead at its end.
+func (v *Viewer) drawArrow(x1, y1, x2, y2, width int32, color sdl.Color) {
+	angle := math.Atan2(float64(y2-y1), float64(x2-x1))
+	headX := float64(x2) - entryArrowHeadSize*math.Cos(angle)
+	headY := float64(y2) - entryArrowHeadSize*math.Sin(angle)
+	gfx.ThickLineColor(v.renderer, x1, y1, int32(headX), int32(headY), width, color)
+	leftX := headX + entryArrowHeadSize/2*math.Cos(angle+math.Pi/2)
+	leftY := headY + entryArrowHeadSize/2*math.Sin(angle+math.Pi/2)
+	rightX := headX + entryArrowHeadSize/2*math.Cos(angle-math.Pi/2)
+	rightY := headY + entryArrowHeadSize/2*math.Sin(angle-math.Pi/2)
+	gfx.FilledTrigonColor(v.renderer, x2, y2, int32(leftX), int32(leftY), int32(rightX), int32(rightY), color)
+}
+
+func cropStringToN(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
@@ -0,0 +1,56 @@
+package viewer
+
+import (
+	"image"
+	"image/draw"
+	"log"
+
+	"github.com/linus4/csgoverview/pkg/assets"
+	demoinfo "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// iconTextures contains the texture of the icon atlas for every renderer
+// that drew icons. SDL destroys the textures together with their renderer.
+var iconTextures = make(map[*sdl.Renderer]*sdl.Texture)
+
+// DrawIcon draws the icon of the equipment with its top left corner at x, y,
+// tinted with color and scaled by scale. It reports whether the equipment has
+// an icon, so that callers can draw its name instead.
+func DrawIcon(renderer *sdl.Renderer, equipment demoinfo.EquipmentType, color sdl.Color, x, y, scale int32) bool {
+	icon := assets.IconFor(equipment)
+	if icon == assets.IconNone {
+		return false
+	}
+	texture, err := iconTexture(renderer)
+	if err != nil {
+		log.Println("trying to create icon texture:", err)
+		return false
+	}
+	bounds := icon.Bounds()
+	texture.SetColorMod(color.R, color.G, color.B)
+	texture.SetAlphaMod(color.A)
+	source := &sdl.Rect{X: int32(bounds.Min.X), Y: int32(bounds.Min.Y), W: assets.IconWidth, H: assets.IconHeight}
+	renderer.Copy(texture, source, &sdl.Rect{X: x, Y: y, W: assets.IconWidth * scale, H: assets.IconHeight * scale})
+	return true
+}
+
+func iconTexture(renderer *sdl.Renderer) (*sdl.Texture, error) {
+	if texture, ok := iconTextures[renderer]; ok {
+		return texture, nil
+	}
+	atlas := assets.Atlas()
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, int32(atlas.Rect.Dx()), int32(atlas.Rect.Dy()), 32, sdl.PIXELFORMAT_ARGB8888)
+	if err != nil {
+		return nil, err
+	}
+	defer surface.Free()
+	draw.Draw(surface, atlas.Rect, atlas, image.Point{}, draw.Src)
+	texture, err := renderer.CreateTextureFromSurface(surface)
+	if err != nil {
+		return nil, err
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+	iconTextures[renderer] = texture
+	return texture, nil
+}
@@ -0,0 +1,90 @@
+package viewer
+
+import (
+	"log"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// textCacheSize is the number of text textures after which the textures of
+// the texts that were not drawn recently are freed.
+const textCacheSize = 512
+
+type textKey struct {
+	renderer *sdl.Renderer
+	font     *ttf.Font
+	text     string
+	color    sdl.Color
+}
+
+type textTexture struct {
+	texture *sdl.Texture
+	w, h    int32
+}
+
+var (
+	// textCache contains the textures of the texts that were drawn since
+	// the cache was last full and textCacheOld the ones drawn before. Texts
+	// of textCacheOld that are drawn again are moved to textCache, the others
+	// are freed when textCache is full again.
+	textCache    = make(map[textKey]textTexture)
+	textCacheOld = make(map[textKey]textTexture)
+)
+
+// DrawString draws the text with its top left corner at x, y. The texture of
+// the text is cached, so that names and killfeed rows are not rendered again
+// in every frame.
+func DrawString(renderer *sdl.Renderer, text string, color sdl.Color, x, y int32, font *ttf.Font) {
+	t, err := cachedText(renderer, text, color, font)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = renderer.Copy(t.texture, nil, &sdl.Rect{X: x, Y: y, W: t.w, H: t.h})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cachedText(renderer *sdl.Renderer, text string, color sdl.Color, font *ttf.Font) (textTexture, error) {
+	key := textKey{renderer: renderer, font: font, text: text, color: color}
+	if t, ok := textCache[key]; ok {
+		return t, nil
+	}
+	t, ok := textCacheOld[key]
+	if ok {
+		delete(textCacheOld, key)
+	} else {
+		surface, err := font.RenderUTF8Blended(text, color)
+		if err != nil {
+			return textTexture{}, err
+		}
+		defer surface.Free()
+		texture, err := renderer.CreateTextureFromSurface(surface)
+		if err != nil {
+			return textTexture{}, err
+		}
+		t = textTexture{texture: texture, w: surface.W, h: surface.H}
+	}
+	if len(textCache) >= textCacheSize {
+		destroyTextures(textCacheOld)
+		textCacheOld, textCache = textCache, make(map[textKey]textTexture)
+	}
+	textCache[key] = t
+	return t, nil
+}
+
+// ClearTextCache frees the textures of all texts that were drawn. It has to
+// be called before a renderer that drew texts is destroyed.
+func ClearTextCache() {
+	destroyTextures(textCache)
+	destroyTextures(textCacheOld)
+	textCache = make(map[textKey]textTexture)
+	textCacheOld = make(map[textKey]textTexture)
+}
+
+func destroyTextures(cache map[textKey]textTexture) {
+	for _, t := range cache {
+		t.texture.Destroy()
+	}
+}
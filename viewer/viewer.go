@@ -0,0 +1,416 @@
+// Package viewer draws the 2D overview of a match with an SDL renderer. It
+// can be embedded in other Go applications that use go-sdl2, which create the
+// window and the renderer themselves and call Update, Draw and HandleInput in
+// their main loop:
+//
+//	v := viewer.New(m, renderer)
+//	defer v.Destroy()
+//	err := v.LoadOverview(overviewDir)
+//	...
+//	for {
+//		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+//			if !v.HandleInput(event) {
+//				// handle the event in the application
+//			}
+//		}
+//		v.Update(elapsed)
+//		v.Draw()
+//		renderer.Present()
+//	}
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+	"github.com/linus4/csgoverview/pkg/stats"
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	// OverviewSize is the width and height of the overview in renderer
+	// coordinates.
+	OverviewSize int32 = 1024
+	// otherSectionAlpha is the alpha of players and grenades that are in
+	// another vertical section than the one that is shown.
+	otherSectionAlpha uint8 = 70
+	// seekSeconds is how far a and d skip, with shift twice as far.
+	seekSeconds int = 5
+)
+
+// Viewer draws the overview image of a map with the players, grenades, the
+// bomb and the overlays of one frame of a match. The exported fields can be
+// changed at any time, e.g. by the controls of the application.
+type Viewer struct {
+	// X and Y are the position of the top left corner of the overview in
+	// renderer coordinates.
+	X int32
+	Y int32
+	// Frame is the frame of the demo whose state is drawn, see
+	// match.Match.StateAtFrame.
+	Frame int
+	// Paused stops Update from advancing Frame, Speed is the playback speed,
+	// e.g. 2 for twice as fast as in the game.
+	Paused bool
+	Speed  float64
+	// HiddenLayers contains the layers that are not drawn.
+	HiddenLayers common.LayerFilter
+	// Selected contains the IDs (common.Player.ID) of the selected players.
+	// If players are selected, shots, kill lines, sounds and the AWP overlay
+	// only show them.
+	Selected map[uint64]bool
+	// Section is the index of the vertical section of the map that is shown,
+	// see match.Match.MapSections.
+	Section int
+	// AWPOverlay shows the angles AWPers held, their repositions and their
+	// kills per shot.
+	AWPOverlay bool
+	// InfernoExtents outlines the area molotovs and incendiaries will spread
+	// to.
+	InfernoExtents bool
+	// AltitudeShading draws players and grenades larger the higher they are.
+	AltitudeShading bool
+	// SoundOverlay draws circles around the sounds of the players in which
+	// enemies could hear them.
+	SoundOverlay bool
+	// EntryPaths draws the paths the terrorists took to the sites as arrows,
+	// only to Site if it is not empty.
+	EntryPaths bool
+	Site       string
+	// SmokeKills draws the kills through smokes of the whole match.
+	SmokeKills bool
+	// Ghost is a marker that the reviewer placed on the map, e.g. to show
+	// where a player should have been. It is not drawn if it is nil.
+	Ghost *common.Point
+	// Measurement contains up to two positions between which the distance
+	// and the travel time are shown.
+	Measurement []common.Point
+	// SpawnTiming is a position that is labeled with the earliest times the
+	// teams can reach it from their spawns. It is not drawn if it is nil.
+	SpawnTiming *common.Point
+	// LineOfSight shades the area the selected players can see according to
+	// the approximate geometry of the map.
+	LineOfSight bool
+	// Crossfire shades the combined area that two or more selected
+	// counter-terrorists can see and marks the chokepoints to the sites that
+	// none of them watches.
+	Crossfire bool
+	// Zones outlines the bombsites and hostage zones of the map and labels
+	// them, e.g. to orient oneself on an unfamiliar map.
+	Zones bool
+
+	match            *match.Match
+	renderer         *sdl.Renderer
+	font             *ttf.Font
+	overview         *sdl.Texture
+	sectionOverviews []*sdl.Texture
+	// mouseX and mouseY are the position of the mouse in renderer
+	// coordinates, used for tooltips.
+	mouseX int32
+	mouseY int32
+	// elapsed is the playback time that Update did not advance Frame by yet.
+	elapsed          time.Duration
+	entryFlows       []stats.EntryFlow
+	entryFlowsLoaded bool
+}
+
+// New returns a Viewer that draws the match with the renderer, starting at
+// the first frame. The overview image has to be loaded with LoadOverview or
+// SetOverview and a font has to be set with SetFont to draw the names of the
+// players and tooltips.
+func New(m *match.Match, renderer *sdl.Renderer) *Viewer {
+	return &Viewer{
+		Speed:        1,
+		HiddenLayers: make(common.LayerFilter),
+		Selected:     make(map[uint64]bool),
+		match:        m,
+		renderer:     renderer,
+		mouseX:       -1,
+		mouseY:       -1,
+	}
+}
+
+// Match returns the match that is drawn.
+func (v *Viewer) Match() *match.Match {
+	return v.match
+}
+
+// SetFont sets the font of the names of the players, the tooltips and the
+// AWP overlay. The font is not closed by the viewer.
+func (v *Viewer) SetFont(font *ttf.Font) {
+	v.font = font
+}
+
+// SetOverview sets the overview image of the map. The viewer destroys the
+// texture in Destroy.
+func (v *Viewer) SetOverview(texture *sdl.Texture) {
+	if v.overview != nil {
+		v.overview.Destroy()
+	}
+	v.overview = texture
+}
+
+// LoadOverview loads the overview image of the map, e.g. de_dust2.jpg, and of
+// its vertical sections from overviewDir or the working directory.
+func (v *Viewer) LoadOverview(overviewDir string) error {
+	texture, err := v.loadTexture(overviewDir, fmt.Sprintf("%v.jpg", v.match.MapName))
+	if err != nil {
+		return fmt.Errorf("trying to load overview of %v: %v", v.match.MapName, err)
+	}
+	v.SetOverview(texture)
+	v.LoadSectionOverviews(overviewDir)
+	return nil
+}
+
+// LoadSectionOverviews loads the overview images of the vertical sections,
+// e.g. de_nuke_lower.jpg. Sections without an image are drawn on the
+// overview of the map.
+func (v *Viewer) LoadSectionOverviews(overviewDir string) {
+	v.destroySectionOverviews()
+	v.Section = 0
+	for i, section := range v.match.MapSections {
+		if i == 0 {
+			v.sectionOverviews = append(v.sectionOverviews, nil)
+			continue
+		}
+		texture, err := v.loadTexture(overviewDir, fmt.Sprintf("%v_%v.jpg", v.match.MapName, section.Name))
+		if err != nil {
+			texture = nil
+		}
+		v.sectionOverviews = append(v.sectionOverviews, texture)
+	}
+}
+
+func (v *Viewer) loadTexture(overviewDir, fileName string) (*sdl.Texture, error) {
+	surface, err := img.Load(filepath.Join(overviewDir, fileName))
+	if err != nil {
+		surface, err = img.Load(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer surface.Free()
+	return v.renderer.CreateTextureFromSurface(surface)
+}
+
+// Destroy frees the overview images.
+func (v *Viewer) Destroy() {
+	if v.overview != nil {
+		v.overview.Destroy()
+		v.overview = nil
+	}
+	v.destroySectionOverviews()
+}
+
+func (v *Viewer) destroySectionOverviews() {
+	for _, texture := range v.sectionOverviews {
+		if texture != nil {
+			texture.Destroy()
+		}
+	}
+	v.sectionOverviews = nil
+}
+
+// Update advances Frame by the time that elapsed since the last update,
+// taking Paused and Speed into account.
+func (v *Viewer) Update(elapsed time.Duration) {
+	if v.Paused || v.match.FrameRate <= 0 {
+		return
+	}
+	v.elapsed += time.Duration(float64(elapsed) * v.Speed)
+	frameDuration := time.Duration(float64(time.Second) / v.match.FrameRate)
+	for v.elapsed >= frameDuration && v.Frame < v.match.LastFrame() {
+		v.Frame++
+		v.elapsed -= frameDuration
+	}
+	if v.Frame >= v.match.LastFrame() {
+		v.elapsed = 0
+	}
+}
+
+// Seek moves Frame by the given number of seconds, backwards if it is
+// negative.
+func (v *Viewer) Seek(seconds int) {
+	v.Frame += seconds * v.match.FrameRateRounded
+	if v.Frame < 0 {
+		v.Frame = 0
+	}
+	if v.Frame > v.match.LastFrame() {
+		v.Frame = v.match.LastFrame()
+	}
+}
+
+// HandleInput handles the controls of the viewer and reports whether the
+// event was used, so that the application can handle the other events
+// itself: space pauses, a and d skip 5 seconds (with shift 10), the mouse
+// wheel 1 second, c clears the selection, v shows the next section of the
+// map and a click on a player selects them (with shift adds or removes them).
+// Mouse motion is tracked for the tooltips but never reported as used.
+func (v *Viewer) HandleInput(event sdl.Event) bool {
+	switch e := event.(type) {
+	case *sdl.MouseMotionEvent:
+		v.SetMouse(e.X, e.Y)
+		return false
+
+	case *sdl.MouseButtonEvent:
+		if e.Type != sdl.MOUSEBUTTONDOWN || e.Button != sdl.BUTTON_LEFT || !v.contains(e.X, e.Y) {
+			return false
+		}
+		v.SelectAt(e.X, e.Y, sdl.GetModState()&sdl.KMOD_SHIFT != 0)
+		return true
+
+	case *sdl.MouseWheelEvent:
+		switch {
+		case e.Y > 0:
+			v.Seek(-1)
+		case e.Y < 0:
+			v.Seek(1)
+		}
+		return true
+
+	case *sdl.KeyboardEvent:
+		if e.Type != sdl.KEYDOWN {
+			return false
+		}
+		seconds := seekSeconds
+		if e.Keysym.Mod&sdl.KMOD_SHIFT != 0 {
+			seconds *= 2
+		}
+		switch e.Keysym.Sym {
+		case sdl.K_SPACE:
+			v.Paused = !v.Paused
+		case sdl.K_a:
+			v.Seek(-seconds)
+		case sdl.K_d:
+			v.Seek(seconds)
+		case sdl.K_c:
+			v.ClearSelection()
+		case sdl.K_v:
+			v.CycleSection()
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// SetMouse sets the position of the mouse in renderer coordinates. Tooltips
+// are shown for the player, grenade or death marker under the mouse.
+func (v *Viewer) SetMouse(x, y int32) {
+	v.mouseX, v.mouseY = x, y
+}
+
+// WorldAt returns the in-game position at x, y in renderer coordinates and
+// whether x, y is on the overview.
+func (v *Viewer) WorldAt(x, y int32) (common.Point, bool) {
+	if !v.contains(x, y) {
+		return common.Point{}, false
+	}
+	worldX, worldY := v.match.WorldPosition(float32(x-v.X), float32(y-v.Y))
+	return common.Point{X: worldX, Y: worldY}, true
+}
+
+// contains reports whether x, y is on the overview.
+func (v *Viewer) contains(x, y int32) bool {
+	return x >= v.X && x < v.X+OverviewSize && y >= v.Y && y < v.Y+OverviewSize
+}
+
+// IsSelected reports whether the player with the given ID is shown, i.e.
+// whether no player is selected or the player is part of the selection. The
+// ID of a human player is the SteamID, so the SteamIDs of events can be
+// passed. Events of bots, which have no SteamID, are only shown if no player
+// is selected.
+func (v *Viewer) IsSelected(id uint64) bool {
+	return len(v.Selected) == 0 || v.Selected[id]
+}
+
+// PlayerAt returns the living player whose dot is at x, y in renderer
+// coordinates.
+func (v *Viewer) PlayerAt(x, y int32) (common.Player, bool) {
+	for _, player := range v.match.StateAtFrame(v.Frame).Players {
+		if !player.IsAlive {
+			continue
+		}
+		scaledX, scaledY := v.match.TranslateScale(player.Position.X, player.Position.Y)
+		dx := x - (int32(scaledX) + v.X)
+		dy := y - (int32(scaledY) + v.Y)
+		if dx*dx+dy*dy <= radiusPlayer*radiusPlayer {
+			return player, true
+		}
+	}
+	return common.Player{}, false
+}
+
+// SelectAt selects the player at x, y. With shift the player is added to or
+// removed from the selection, otherwise the player replaces it. A click
+// without shift next to all players clears the selection.
+func (v *Viewer) SelectAt(x, y int32, shift bool) {
+	player, ok := v.PlayerAt(x, y)
+	if !ok {
+		if !shift {
+			v.ClearSelection()
+		}
+		return
+	}
+	if shift {
+		if v.Selected[player.ID] {
+			delete(v.Selected, player.ID)
+		} else {
+			v.Selected[player.ID] = true
+		}
+		return
+	}
+	onlySelected := len(v.Selected) == 1 && v.Selected[player.ID]
+	v.ClearSelection()
+	if !onlySelected {
+		v.Selected[player.ID] = true
+	}
+}
+
+// ClearSelection deselects all players.
+func (v *Viewer) ClearSelection() {
+	v.Selected = make(map[uint64]bool)
+}
+
+// CycleSection shows the next vertical section of the map.
+func (v *Viewer) CycleSection() {
+	if len(v.match.MapSections) > 0 {
+		v.Section = (v.Section + 1) % len(v.match.MapSections)
+	}
+}
+
+// followSelection shows the section of the selected player if exactly one
+// player who is alive is selected.
+func (v *Viewer) followSelection() {
+	if len(v.Selected) != 1 {
+		return
+	}
+	for _, player := range v.match.StateAtFrame(v.Frame).Players {
+		if v.Selected[player.ID] && player.IsAlive {
+			v.Section = v.match.SectionAt(player.PositionZ)
+		}
+	}
+}
+
+// sectionOverview returns the overview image of the section that is shown.
+func (v *Viewer) sectionOverview() *sdl.Texture {
+	if v.Section < len(v.sectionOverviews) && v.sectionOverviews[v.Section] != nil {
+		return v.sectionOverviews[v.Section]
+	}
+	return v.overview
+}
+
+// sectionAlpha returns the alpha for something at the given height, which is
+// lower if it is in another section than the one that is shown.
+func (v *Viewer) sectionAlpha(z float32) uint8 {
+	if len(v.match.MapSections) < 2 || v.match.SectionAt(z) == v.Section {
+		return 255
+	}
+	return otherSectionAlpha
+}
@@ -0,0 +1,56 @@
+package viewer
+
+import (
+	"testing"
+
+	common "github.com/linus4/csgoverview/pkg/common"
+	"github.com/linus4/csgoverview/pkg/match"
+)
+
+func TestSelectAt(t *testing.T) {
+	// bots all have the SteamID64 0 and are only told apart by their ID
+	bot1 := common.Player{ID: 1<<63 | 2, Name: "BOT Albert", IsAlive: true, Position: common.Point{X: 100, Y: -100}}
+	bot2 := common.Player{ID: 1<<63 | 3, Name: "BOT Bert", IsAlive: true, Position: common.Point{X: 200, Y: -200}}
+	m := &match.Match{
+		MapScale: 1,
+		States:   []common.OverviewState{{Players: []common.Player{bot1, bot2}}},
+	}
+	v := New(m, nil)
+
+	steps := []struct {
+		name  string
+		x, y  int32
+		shift bool
+		want  []uint64
+	}{
+		{"select", 100, 100, false, []uint64{bot1.ID}},
+		{"add with shift", 200, 200, true, []uint64{bot1.ID, bot2.ID}},
+		{"remove with shift", 100, 100, true, []uint64{bot2.ID}},
+		{"replace", 100, 100, false, []uint64{bot1.ID}},
+		{"deselect the only one", 100, 100, false, nil},
+		{"select again", 200, 200, false, []uint64{bot2.ID}},
+		{"click next to all players", 10, 10, false, nil},
+	}
+	for _, step := range steps {
+		v.SelectAt(step.x, step.y, step.shift)
+		if len(v.Selected) != len(step.want) {
+			t.Fatalf("%v: Selected = %v, want %v", step.name, v.Selected, step.want)
+		}
+		for _, id := range step.want {
+			if !v.Selected[id] {
+				t.Fatalf("%v: Selected = %v, want %v", step.name, v.Selected, step.want)
+			}
+		}
+	}
+}
+
+func TestIsSelected(t *testing.T) {
+	v := New(&match.Match{}, nil)
+	if !v.IsSelected(0) || !v.IsSelected(1) {
+		t.Error("IsSelected() = false without a selection, want true for all players")
+	}
+	v.Selected[1] = true
+	if !v.IsSelected(1) || v.IsSelected(2) || v.IsSelected(0) {
+		t.Error("IsSelected() does not follow the selection")
+	}
+}